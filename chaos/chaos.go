@@ -0,0 +1,173 @@
+package chaos
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// Faults, as counted by the chaos metrics
+const (
+	FaultProcessorError = "processor_error"
+	FaultDecodeFailure  = "decode_failure"
+	FaultMongoLatency   = "mongo_latency"
+	FaultRedisTimeout   = "redis_timeout"
+)
+
+// Config is the rate of each fault between 0 and 1, zero injects none
+type Config struct {
+	ProcessorErrorRate float64
+	DecodeFailureRate  float64
+	MongoLatencyRate   float64
+	MongoLatency       time.Duration
+	RedisTimeoutRate   float64
+}
+
+// Injector injects artificial failures into the pipeline to exercise the
+// DLQ, the retries and the backpressure, never meant for production
+type Injector struct {
+	Config  *Config
+	Metrics *metrics.ChaosMetrics // Optional, counts the injected faults
+}
+
+func NewInjector(conf *Config, chaosMetrics *metrics.ChaosMetrics) *Injector {
+	return &Injector{Config: conf, Metrics: chaosMetrics}
+}
+
+// inject reports whether to inject the fault this time, counting it
+func (i *Injector) inject(fault string, rate float64) bool {
+	if rate <= 0 || rand.Float64() >= rate {
+		return false
+	}
+	i.Metrics.Injected(fault)
+	return true
+}
+
+type TxProcessor interface {
+	ProcessRecords(ctx context.Context, records []models.Record) error
+}
+
+// Processor fails batches and corrupts payloads before the wrapped processor
+type Processor struct {
+	Next     TxProcessor
+	Injector *Injector
+}
+
+// Processor wraps the processor with the processor faults
+func (i *Injector) Processor(next TxProcessor) *Processor {
+	return &Processor{Next: next, Injector: i}
+}
+
+func (p *Processor) ProcessRecords(ctx context.Context, records []models.Record) error {
+	if p.Injector.inject(FaultProcessorError, p.Injector.Config.ProcessorErrorRate) {
+		return errors.E(errors.Internal, "chaos: injected processor error")
+	}
+	if p.Injector.Config.DecodeFailureRate > 0 {
+		corrupted := make([]models.Record, len(records))
+		for idx, record := range records {
+			if p.Injector.inject(FaultDecodeFailure, p.Injector.Config.DecodeFailureRate) {
+				record.Value = []byte("{chaos")
+			}
+			corrupted[idx] = record
+		}
+		records = corrupted
+	}
+	return p.Next.ProcessRecords(ctx, records)
+}
+
+type TxRepository interface {
+	InsertTransactions(ctx context.Context, txs []interface{}) error
+	InsertTransaction(ctx context.Context, tx models.MongoTransaction) error
+}
+
+// Repository delays the mongo writes of the wrapped repository
+type Repository struct {
+	Next     TxRepository
+	Injector *Injector
+}
+
+// Repository wraps the repository with the mongo latency
+func (i *Injector) Repository(next TxRepository) *Repository {
+	return &Repository{Next: next, Injector: i}
+}
+
+func (r *Repository) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	if err := r.delay(ctx); err != nil {
+		return err
+	}
+	return r.Next.InsertTransactions(ctx, txs)
+}
+
+func (r *Repository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	if err := r.delay(ctx); err != nil {
+		return err
+	}
+	return r.Next.InsertTransaction(ctx, tx)
+}
+
+func (r *Repository) delay(ctx context.Context) error {
+	if !r.Injector.inject(FaultMongoLatency, r.Injector.Config.MongoLatencyRate) {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(r.Injector.Config.MongoLatency):
+		return nil
+	}
+}
+
+// RedisHook fails redis commands as timeouts, add it with AddHook
+type RedisHook struct {
+	Injector *Injector
+}
+
+// RedisHook returns the hook injecting the redis timeouts
+func (i *Injector) RedisHook() *RedisHook {
+	return &RedisHook{Injector: i}
+}
+
+func (h *RedisHook) DialHook(next goredis.DialHook) goredis.DialHook {
+	return next
+}
+
+func (h *RedisHook) ProcessHook(next goredis.ProcessHook) goredis.ProcessHook {
+	return func(ctx context.Context, cmd goredis.Cmder) error {
+		if err := h.timeout(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *RedisHook) ProcessPipelineHook(next goredis.ProcessPipelineHook) goredis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []goredis.Cmder) error {
+		if err := h.timeout(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// timeout returns a net timeout error, the kind go-redis returns on a read timeout
+func (h *RedisHook) timeout() error {
+	if !h.Injector.inject(FaultRedisTimeout, h.Injector.Config.RedisTimeoutRate) {
+		return nil
+	}
+	return &net.OpError{Op: "read", Net: "tcp", Err: fmt.Errorf("chaos: injected redis timeout: %w", context.DeadlineExceeded)}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	kafka "tx-stream/kafka"
+	metrics "tx-stream/metrics"
+	mongodb "tx-stream/repositories/mongodb"
+	redis "tx-stream/repositories/redis"
+	aggregation "tx-stream/services/aggregation"
+
+	// External Packages
+	goredis "github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// NewAggregator counts into the windows of the aggregation block and emits
+// them to its target, close releases the producer of a topic target
+func NewAggregator(ctx context.Context, conf config.Config, redisClient goredis.UniversalClient, mongoClient *mongo.Client,
+	logger *zap.Logger, aggregationMetrics *metrics.AggregationMetrics) (*aggregation.Aggregator, func(), error) {
+	agg := conf.Aggregation
+	var sink aggregation.ResultSink
+	closeSink := func() {}
+	switch agg.Emit.Target {
+	case "topic":
+		producer, err := kafka.NewProducer(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create the aggregation producer: %v", err)
+		}
+		sink, closeSink = aggregation.NewTopicSink(producer, agg.Emit.Topic), producer.Close
+	default:
+		results := mongodb.NewWindowResults(mongoClient, agg.Emit.Collection)
+		if err := results.EnsureIndexes(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to create the window result indexes: %v", err)
+		}
+		sink = results
+	}
+
+	windows := make([]aggregation.Window, len(agg.Windows))
+	for idx, window := range agg.Windows {
+		windows[idx] = aggregation.Window{Name: window.Name, Size: window.Size, Slide: window.Slide}
+	}
+	aggregator, err := aggregation.NewAggregator(aggregation.Config{
+		Windows:          windows,
+		GroupBy:          agg.GroupBy,
+		DeclinedStatuses: agg.DeclinedStatuses,
+		Grace:            agg.Grace,
+		Retention:        agg.Retention,
+		Interval:         agg.Interval,
+	}, redis.NewWindowStore(redisClient, agg.Prefix), sink, logger, aggregationMetrics)
+	if err != nil {
+		closeSink()
+		return nil, nil, err
+	}
+	return aggregator, closeSink, nil
+}
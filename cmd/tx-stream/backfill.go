@@ -0,0 +1,68 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+	mongodb "tx-stream/repositories/mongodb"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+var (
+	backfillCmd        = kingpin.Command("backfill", "Publish stored transactions to a topic, to seed new downstream consumers")
+	backfillTopic      = backfillCmd.Arg("topic", "Topic to publish the transactions to").Required().String()
+	backfillFilter     = backfillCmd.Flag("filter", `Mongo filter as extended JSON, e.g. {"status": "completed"}`).Default("{}").String()
+	backfillAfter      = backfillCmd.Flag("after", "Resume after this transaction id, as printed by an interrupted backfill").String()
+	backfillRate       = backfillCmd.Flag("rate", "Transactions published per second").Default("500").Float64()
+	backfillBatch      = backfillCmd.Flag("batch", "Transactions per produce request").Default("100").Int()
+	backfillLimit      = backfillCmd.Flag("limit", "Stop after this many transactions, 0 for all of them").Int64()
+	backfillCollection = backfillCmd.Flag("collection", "Collection to read the transactions from").Default("transactions").String()
+)
+
+func runBackfill() {
+	k, conf := MustLoadConfig()
+	logger := NewLogger(k, conf)
+	if *backfillRate <= 0 || *backfillBatch <= 0 {
+		kingpin.Fatalf("--rate and --batch must be positive")
+	}
+	var filter bson.M
+	err := bson.UnmarshalExtJSON([]byte(*backfillFilter), false, &filter)
+	kingpin.FatalIfError(err, "invalid --filter")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := mongodb.Connect(ctx, conf.Mongo.URI)
+	if err != nil {
+		logger.Fatal("cannot create mongo client", zap.Error(err))
+	}
+	defer func() { _ = mongoClient.Disconnect(context.Background()) }()
+	repo := mongodb.NewTxRepository(mongoClient)
+	repo.Collection = *backfillCollection
+
+	producer, err := kafka.NewProducer(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	if err != nil {
+		logger.Fatal("cannot create kafka producer", zap.Error(err))
+	}
+	defer producer.Close()
+
+	backfiller := txsvc.NewBackfiller(repo, producer, logger, *backfillTopic, *backfillBatch, *backfillRate)
+	backfiller.Limit = *backfillLimit
+	result, err := backfiller.Run(ctx, filter, *backfillAfter)
+	fmt.Printf("published %d transactions to %s\n", result.Published, *backfillTopic)
+	if err != nil && result.LastID != "" {
+		fmt.Printf("resume with --after %s\n", result.LastID)
+	}
+	kingpin.FatalIfError(err, "backfill stopped")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+	mongodb "tx-stream/repositories/mongodb"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"go.uber.org/zap"
+)
+
+var (
+	benchCmd        = kingpin.Command("bench", "Publish synthetic transactions to a test environment and report its throughput and latency")
+	benchConsumer   = benchCmd.Flag("consumer", "Consumer whose topic to publish to, defaults to the only consumer").String()
+	benchRate       = benchCmd.Flag("rate", "Transactions published per second").Default("100").Float64()
+	benchDuration   = benchCmd.Flag("duration", "How long to publish for").Default("1m").Duration()
+	benchMinSize    = benchCmd.Flag("min-size", "Smallest encoded transaction, in bytes").Default("300").Int()
+	benchMaxSize    = benchCmd.Flag("max-size", "Largest encoded transaction, in bytes").Default("1000").Int()
+	benchBatch      = benchCmd.Flag("batch", "Transactions per produce request").Default("10").Int()
+	benchWait       = benchCmd.Flag("wait", "How long to wait for the published transactions to be stored").Default("2m").Duration()
+	benchCollection = benchCmd.Flag("collection", "Collection the consumer stores the transactions in").Default("transactions").String()
+	benchKeep       = benchCmd.Flag("keep", "Keep the synthetic transactions instead of deleting them afterwards").Bool()
+)
+
+func runBench() {
+	k, conf := MustLoadConfig()
+	logger := NewLogger(k, conf)
+	if conf.IsProdMode {
+		kingpin.Fatalf("bench publishes synthetic transactions, refusing to run with is_prod_mode")
+	}
+	if *benchRate <= 0 || *benchBatch <= 0 || *benchDuration <= 0 {
+		kingpin.Fatalf("--rate, --batch and --duration must be positive")
+	}
+	if *benchMinSize > *benchMaxSize {
+		kingpin.Fatalf("--min-size must not exceed --max-size")
+	}
+	consumer := consumerByName(conf, *benchConsumer)
+	if consumer.Processor != "transactions" {
+		kingpin.Fatalf("consumer %s uses the %s processor, only transactions can be benchmarked", consumer.Name, consumer.Processor)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := mongodb.Connect(ctx, conf.Mongo.URI)
+	if err != nil {
+		logger.Fatal("cannot create mongo client", zap.Error(err))
+	}
+	defer func() { _ = mongoClient.Disconnect(context.Background()) }()
+	repo := mongodb.NewTxRepository(mongoClient)
+	repo.Collection = *benchCollection
+
+	producer, err := kafka.NewProducer(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	if err != nil {
+		logger.Fatal("cannot create kafka producer", zap.Error(err))
+	}
+	defer producer.Close()
+
+	bench := txsvc.NewBench(producer, repo, logger, txsvc.BenchConfig{
+		Topic:        consumer.Topic,
+		Rate:         *benchRate,
+		Duration:     *benchDuration,
+		MinSize:      *benchMinSize,
+		MaxSize:      *benchMaxSize,
+		BatchSize:    *benchBatch,
+		Wait:         *benchWait,
+		PollInterval: time.Second,
+	})
+	report, err := bench.Run(ctx)
+	printBenchReport(report)
+
+	if !*benchKeep && report.Produced > 0 {
+		// The signal context may be done already, the cleanup gets its own
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		deleted, cleanupErr := repo.DeleteTransactionsWithPrefix(cleanupCtx, report.Prefix)
+		cancel()
+		if cleanupErr != nil {
+			logger.Warn("cannot delete the bench transactions", zap.String("prefix", report.Prefix), zap.Error(cleanupErr))
+		} else {
+			fmt.Printf("deleted %d bench transactions\n", deleted)
+		}
+	}
+	kingpin.FatalIfError(err, "bench stopped")
+}
+
+func printBenchReport(report txsvc.BenchReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "RUN\t%s\n", report.RunID)
+	fmt.Fprintf(w, "PRODUCED\t%d (%d bytes, %d errors) in %s\n", report.Produced, report.ProducedBytes, report.ProduceErrors, report.ProduceElapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "STORED\t%d (%d missing) in %s\n", report.Stored, report.Missing, report.StoreElapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "THROUGHPUT\t%.1f/s produced, %.1f/s stored\n", report.ProducedPerSec, report.StoredPerSec)
+	fmt.Fprintf(w, "LATENCY\tp50 %s  p90 %s  p99 %s  max %s\n", report.Latency.P50.Round(time.Millisecond),
+		report.Latency.P90.Round(time.Millisecond), report.Latency.P99.Round(time.Millisecond), report.Latency.Max.Round(time.Millisecond))
+	_ = w.Flush()
+}
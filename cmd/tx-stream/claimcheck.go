@@ -0,0 +1,27 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	metrics "tx-stream/metrics"
+	s3 "tx-stream/repositories/s3"
+	claimcheck "tx-stream/services/claimcheck"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// NewClaimResolver fetches the claim-check payloads from the buckets of the claim_check block
+func NewClaimResolver(ctx context.Context, conf config.ClaimCheck, logger *zap.Logger,
+	claimMetrics *metrics.ClaimCheckMetrics) (*claimcheck.Resolver, error) {
+	client, err := s3.Connect(ctx, conf.Region, conf.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to s3: %v", err)
+	}
+	return claimcheck.NewResolver(s3.NewPayloadStore(client), logger, claimMetrics, conf.Buckets, conf.MaxBytes,
+		conf.Timeout, conf.Attempts, conf.Backoff, conf.Concurrency, conf.CacheBytes), nil
+}
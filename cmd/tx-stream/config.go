@@ -0,0 +1,34 @@
+package main
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"os"
+
+	// Local Packages
+	config "tx-stream/config"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+var (
+	configCmd = kingpin.Command("config", "Inspect the configuration")
+
+	configPrintCmd    = configCmd.Command("print", "Print the effective configuration after merging every source, with secrets masked")
+	configPrintFormat = configPrintCmd.Flag("format", "Output format").Default("yaml").Enum("yaml", "flat")
+)
+
+func runConfigPrint() {
+	k, _ := MustLoadConfig()
+	redacted := config.Redacted(k)
+
+	if *configPrintFormat == "flat" {
+		redacted.Print()
+		return
+	}
+	out, err := redacted.Marshal(yaml.Parser())
+	kingpin.FatalIfError(err, "cannot encode config")
+	_, _ = fmt.Fprint(os.Stdout, string(out))
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	// Go Internal Packages
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+	models "tx-stream/models"
+	redis "tx-stream/repositories/redis"
+	dlqsvc "tx-stream/services/dlq"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"go.uber.org/zap"
+)
+
+var (
+	dlqCmd = kingpin.Command("dlq", "Inspect and manage the dead letter queue")
+
+	dlqListCmd    = dlqCmd.Command("list", "List dead-lettered records, newest first")
+	dlqListOffset = dlqListCmd.Flag("offset", "Number of entries to skip").Default("0").Int64()
+	dlqListLimit  = dlqListCmd.Flag("limit", "Maximum number of entries to list").Default("50").Int64()
+
+	dlqShowCmd = dlqCmd.Command("show", "Show a dead-lettered record with its payload")
+	dlqShowID  = dlqShowCmd.Arg("id", "DLQ entry id").Required().String()
+
+	dlqDeleteCmd = dlqCmd.Command("delete", "Delete a dead-lettered record")
+	dlqDeleteID  = dlqDeleteCmd.Arg("id", "DLQ entry id").Required().String()
+
+	dlqRequeueCmd = dlqCmd.Command("requeue", "Publish a dead-lettered record back to its topic")
+	dlqRequeueID  = dlqRequeueCmd.Arg("id", "DLQ entry id").Required().String()
+
+	dlqReplayCmd      = dlqCmd.Command("replay", "Publish dead-lettered records back to their topics, all of them unless filtered")
+	dlqReplayConsumer = dlqReplayCmd.Flag("consumer", "Consumer name within the replay group when replaying everything, defaults to the hostname").String()
+	dlqReplayFrom     = dlqReplayCmd.Flag("from", "Only entries that last failed at or after this RFC3339 time").String()
+	dlqReplayTo       = dlqReplayCmd.Flag("to", "Only entries that last failed before this RFC3339 time").String()
+	dlqReplayClass    = dlqReplayCmd.Flag("class", "Only entries with this error class").String()
+	dlqReplayTopic    = dlqReplayCmd.Flag("source-topic", "Only entries from this topic").String()
+	dlqReplayKey      = dlqReplayCmd.Flag("key", "Only entries whose key matches this glob pattern").String()
+	dlqReplayLimit    = dlqReplayCmd.Flag("limit", "Maximum number of filtered entries to replay").Default("1000").Int()
+
+	dlqExportCmd = dlqCmd.Command("export", "Write every dead-lettered record to an NDJSON file, oldest first")
+	dlqExportOut = dlqExportCmd.Flag("out", "File to write, - for stdout").Required().String()
+
+	dlqImportCmd = dlqCmd.Command("import", "Restore dead-lettered records from an NDJSON export")
+	dlqImportIn  = dlqImportCmd.Flag("in", "File to read, - for stdin").Required().String()
+
+	dlqMigrateCmd = dlqCmd.Command("migrate", "Move entries from the pre-stream list layout into the stream")
+
+	quarantineCmd = dlqCmd.Command("quarantine", "Inspect and acknowledge records that failed again after replay")
+
+	quarantineListCmd    = quarantineCmd.Command("list", "List quarantined records, newest first")
+	quarantineListOffset = quarantineListCmd.Flag("offset", "Number of entries to skip").Default("0").Int64()
+	quarantineListLimit  = quarantineListCmd.Flag("limit", "Maximum number of entries to list").Default("50").Int64()
+
+	quarantineShowCmd = quarantineCmd.Command("show", "Show a quarantined record with its payload")
+	quarantineShowID  = quarantineShowCmd.Arg("id", "Quarantine entry id").Required().String()
+
+	quarantineAckCmd = quarantineCmd.Command("ack", "Acknowledge a quarantined record and move it back to the DLQ")
+	quarantineAckID  = quarantineAckCmd.Arg("id", "Quarantine entry id").Required().String()
+
+	quarantineDeleteCmd = quarantineCmd.Command("delete", "Delete a quarantined record")
+	quarantineDeleteID  = quarantineDeleteCmd.Arg("id", "Quarantine entry id").Required().String()
+)
+
+// newDLQService connects to the dependencies required by the dlq commands
+func newDLQService(ctx context.Context, withProducer bool) (*dlqsvc.DLQService, func()) {
+	k, prodKonf := MustLoadConfig()
+	logger := NewLogger(k, prodKonf)
+
+	backend, err := NewDLQBackend(ctx, prodKonf, logger, nil)
+	if err != nil {
+		logger.Fatal("cannot create dead letter queue", zap.Error(err))
+	}
+	if backend.Inspector == nil {
+		backend.Close()
+		kingpin.Fatalf("dlq backend %q does not support inspection", prodKonf.DLQ.Backend)
+	}
+
+	if !withProducer {
+		return dlqsvc.NewDLQService(logger, backend.Inspector, backend.Quarantine, nil), backend.Close
+	}
+
+	producer, err := kafka.NewProducer(prodKonf.Kafka.BrokerList(), NewKafkaSASL(prodKonf.Kafka))
+	if err != nil {
+		logger.Fatal("cannot create kafka producer", zap.Error(err))
+	}
+	return dlqsvc.NewDLQService(logger, backend.Inspector, backend.Quarantine, producer), func() {
+		producer.Close()
+		backend.Close()
+	}
+}
+
+func runDLQList() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	page, err := service.List(ctx, *dlqListOffset, *dlqListLimit)
+	kingpin.FatalIfError(err, "cannot list dlq entries")
+	printDLQPage(page)
+}
+
+func printDLQPage(page models.DLQPage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTOPIC\tPARTITION\tOFFSET\tATTEMPTS\tLAST FAILED AT\tCLASS\tERROR")
+	for _, entry := range page.Entries {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n", entry.ID, entry.Record.Topic, entry.Record.Partition,
+			entry.Record.Offset, entry.Attempts, entry.LastFailedAt.Format(time.RFC3339), entry.ErrorClass, entry.Error)
+	}
+	_ = w.Flush()
+	fmt.Printf("showing %d of %d entries from offset %d\n", len(page.Entries), page.Total, page.Offset)
+}
+
+func runDLQShow() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	entry, err := service.Show(ctx, *dlqShowID)
+	kingpin.FatalIfError(err, "cannot show dlq entry")
+	printDLQEntry(entry)
+}
+
+func printDLQEntry(entry models.DLQEntry) {
+	// Print the key, headers and payload as text rather than base64 so they can be read
+	headers := make(map[string]string, len(entry.Record.Headers))
+	for _, header := range entry.Record.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	out := struct {
+		ID            string            `json:"id"`
+		Topic         string            `json:"topic"`
+		Partition     int32             `json:"partition"`
+		Offset        int64             `json:"offset"`
+		Timestamp     time.Time         `json:"timestamp"`
+		Key           string            `json:"key"`
+		Headers       map[string]string `json:"headers"`
+		Payload       string            `json:"payload"`
+		ErrorClass    string            `json:"error_class"`
+		Error         string            `json:"error"`
+		Attempts      int               `json:"attempts"`
+		FirstFailedAt time.Time         `json:"first_failed_at"`
+		LastFailedAt  time.Time         `json:"last_failed_at"`
+	}{
+		ID:            entry.ID,
+		Topic:         entry.Record.Topic,
+		Partition:     entry.Record.Partition,
+		Offset:        entry.Record.Offset,
+		Timestamp:     entry.Record.Timestamp,
+		Key:           string(entry.Record.Key),
+		Headers:       headers,
+		Payload:       string(entry.Record.Value),
+		ErrorClass:    entry.ErrorClass,
+		Error:         entry.Error,
+		Attempts:      entry.Attempts,
+		FirstFailedAt: entry.FirstFailedAt,
+		LastFailedAt:  entry.LastFailedAt,
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+}
+
+func runDLQDelete() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	err := service.Delete(ctx, *dlqDeleteID)
+	kingpin.FatalIfError(err, "cannot delete dlq entry")
+	fmt.Printf("deleted %s\n", *dlqDeleteID)
+}
+
+func runDLQRequeue() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, true)
+	defer closeFn()
+
+	err := service.Requeue(ctx, *dlqRequeueID)
+	kingpin.FatalIfError(err, "cannot requeue dlq entry")
+	fmt.Printf("requeued %s\n", *dlqRequeueID)
+}
+
+func runQuarantineList() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	page, err := service.ListQuarantine(ctx, *quarantineListOffset, *quarantineListLimit)
+	kingpin.FatalIfError(err, "cannot list quarantine entries")
+	printDLQPage(page)
+}
+
+func runQuarantineShow() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	entry, err := service.ShowQuarantine(ctx, *quarantineShowID)
+	kingpin.FatalIfError(err, "cannot show quarantine entry")
+	printDLQEntry(entry)
+}
+
+func runQuarantineAck() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	err := service.AckQuarantine(ctx, *quarantineAckID)
+	kingpin.FatalIfError(err, "cannot acknowledge quarantine entry")
+	fmt.Printf("acknowledged %s, it can be requeued from the dlq\n", *quarantineAckID)
+}
+
+func runQuarantineDelete() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	err := service.DeleteQuarantine(ctx, *quarantineDeleteID)
+	kingpin.FatalIfError(err, "cannot delete quarantine entry")
+	fmt.Printf("deleted %s\n", *quarantineDeleteID)
+}
+
+// replayFilter builds the filter from the replay flags, reports false when no filter flag is set
+func replayFilter() (models.DLQFilter, bool) {
+	filter := models.DLQFilter{ErrorClass: *dlqReplayClass, Topic: *dlqReplayTopic, KeyPattern: *dlqReplayKey}
+	var err error
+	if *dlqReplayFrom != "" {
+		filter.From, err = time.Parse(time.RFC3339, *dlqReplayFrom)
+		kingpin.FatalIfError(err, "invalid --from")
+	}
+	if *dlqReplayTo != "" {
+		filter.To, err = time.Parse(time.RFC3339, *dlqReplayTo)
+		kingpin.FatalIfError(err, "invalid --to")
+	}
+	return filter, filter != models.DLQFilter{}
+}
+
+func runDLQReplay() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if filter, ok := replayFilter(); ok || *dryRunFlag {
+		service, closeFn := newDLQService(ctx, true)
+		defer closeFn()
+
+		result, err := service.ReplayMatching(ctx, filter, *dlqReplayLimit, *dryRunFlag)
+		kingpin.FatalIfError(err, "cannot replay dlq entries")
+		if result.DryRun {
+			fmt.Printf("%d entries match, nothing replayed (dry run)\n", result.Matched)
+			return
+		}
+		fmt.Printf("replayed %d of %d matching entries\n", result.Replayed, result.Matched)
+		return
+	}
+
+	k, prodKonf := MustLoadConfig()
+	logger := NewLogger(k, prodKonf)
+
+	backend, err := NewDLQBackend(ctx, prodKonf, logger, nil)
+	if err != nil {
+		logger.Fatal("cannot create dead letter queue", zap.Error(err))
+	}
+	defer backend.Close()
+	if backend.Replay == nil {
+		kingpin.Fatalf("dlq backend %q does not support replay workers", prodKonf.DLQ.Backend)
+	}
+
+	producer, err := kafka.NewProducer(prodKonf.Kafka.BrokerList(), NewKafkaSASL(prodKonf.Kafka))
+	if err != nil {
+		logger.Fatal("cannot create kafka producer", zap.Error(err))
+	}
+	defer producer.Close()
+
+	consumer := *dlqReplayConsumer
+	if consumer == "" {
+		consumer, _ = os.Hostname()
+	}
+	replayer := dlqsvc.NewReplayer(logger, backend.Replay, producer, &dlqsvc.ReplayConfig{
+		Group:     prodKonf.DLQ.Replay.Group,
+		Consumer:  consumer,
+		BatchSize: prodKonf.DLQ.Replay.BatchSize,
+		MinIdle:   prodKonf.DLQ.Replay.MinIdle,
+	})
+
+	replayed, err := replayer.Drain(ctx)
+	fmt.Printf("replayed %d entries\n", replayed)
+	kingpin.FatalIfError(err, "replay stopped")
+}
+
+func runDLQExport() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	out := os.Stdout
+	if *dlqExportOut != "-" {
+		f, err := os.Create(*dlqExportOut)
+		kingpin.FatalIfError(err, "cannot create export file")
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+
+	exported, err := service.Export(ctx, w)
+	kingpin.FatalIfError(err, "cannot export dlq entries")
+	kingpin.FatalIfError(w.Flush(), "cannot write export file")
+	fmt.Fprintf(os.Stderr, "exported %d entries\n", exported)
+}
+
+func runDLQImport() {
+	ctx := context.Background()
+	service, closeFn := newDLQService(ctx, false)
+	defer closeFn()
+
+	in := os.Stdin
+	if *dlqImportIn != "-" {
+		f, err := os.Open(*dlqImportIn)
+		kingpin.FatalIfError(err, "cannot open import file")
+		defer f.Close()
+		in = f
+	}
+
+	imported, err := service.Import(ctx, in)
+	fmt.Printf("imported %d entries\n", imported)
+	kingpin.FatalIfError(err, "import stopped")
+}
+
+func runDLQMigrate() {
+	ctx := context.Background()
+	k, prodKonf := MustLoadConfig()
+	logger := NewLogger(k, prodKonf)
+
+	if prodKonf.DLQ.Backend != "redis" {
+		kingpin.Fatalf("only the redis dlq backend has a list layout to migrate")
+	}
+	redisConf, err := NewRedisConfig(prodKonf)
+	kingpin.FatalIfError(err, "invalid redis tls config")
+	redisClient, err := redis.Connect(ctx, redisConf)
+	kingpin.FatalIfError(err, "cannot create redis client")
+	defer redisClient.Close()
+
+	for _, name := range []string{prodKonf.DLQ.StreamName, prodKonf.DLQ.StreamName + ":quarantine"} {
+		queue := redis.NewDeadLetterQueue(redisClient, logger, &redis.DLQConfig{Name: name}, nil)
+		migrated, err := queue.MigrateFromList(ctx, 500)
+		fmt.Printf("migrated %d entries from %s\n", migrated, name)
+		kingpin.FatalIfError(err, "migration stopped")
+	}
+}
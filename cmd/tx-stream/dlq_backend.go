@@ -0,0 +1,138 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	kafka "tx-stream/kafka"
+	metrics "tx-stream/metrics"
+	notify "tx-stream/notify"
+	file "tx-stream/repositories/file"
+	mongodb "tx-stream/repositories/mongodb"
+	redis "tx-stream/repositories/redis"
+	s3 "tx-stream/repositories/s3"
+	dlqsvc "tx-stream/services/dlq"
+
+	// External Packages
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// DLQBackend is the configured dead letter queue along with the connections it owns
+type DLQBackend struct {
+	Sender     kafka.DeadLetterQueue
+	Inspector  dlqsvc.DeadLetterQueue    // Nil when the backend cannot be inspected
+	Quarantine dlqsvc.DeadLetterQueue    // Nil when quarantine is disabled or the backend cannot be inspected
+	Replay     dlqsvc.ReplayQueue        // Nil when the backend has no replay consumer groups
+	Redis      goredis.UniversalClient   // Nil unless the backend is redis
+	Alerts     *dlqsvc.AlertingSender    // Nil when alerts are disabled
+	Trim       func(ctx context.Context) // Nil unless the backend expires its entries itself, runs until ctx ends
+	Close      func()
+}
+
+// NewDLQConfig maps the dlq config block to the redis dead letter queue config
+func NewDLQConfig(conf config.Config) *redis.DLQConfig {
+	return &redis.DLQConfig{
+		Name:            conf.DLQ.StreamName,
+		EntryTTL:        conf.DLQ.EntryTTL,
+		MaxLength:       conf.DLQ.MaxLength,
+		OverflowPolicy:  conf.DLQ.OverflowPolicy,
+		TrimInterval:    conf.DLQ.TrimInterval,
+		Codec:           conf.DLQ.Compression.Codec,
+		CompressMinSize: conf.DLQ.Compression.MinSize,
+	}
+}
+
+// NewDLQBackend connects to the backend selected by dlq.backend. When quarantine
+// is enabled, a second store of the same backend keeps records that failed again
+// after being replayed. When alerts are enabled, the sender notifies the webhook.
+func NewDLQBackend(ctx context.Context, conf config.Config, logger *zap.Logger, dlqMetrics *metrics.DLQMetrics) (*DLQBackend, error) {
+	backend, err := newDLQBackend(ctx, conf, logger, dlqMetrics)
+	if err != nil {
+		return nil, err
+	}
+
+	if backend.Quarantine != nil && conf.DLQ.Quarantine.Enabled {
+		backend.Sender = dlqsvc.NewQuarantineRouter(logger, backend.Sender, backend.Quarantine, conf.DLQ.Quarantine.MaxReplays)
+	} else {
+		backend.Quarantine = nil
+	}
+
+	if alerts := conf.DLQ.Alerts; alerts.Enabled {
+		var depth dlqsvc.DepthFunc
+		if backend.Inspector != nil {
+			depth = func(ctx context.Context) (int64, error) {
+				page, err := backend.Inspector.List(ctx, 0, 1)
+				return page.Total, err
+			}
+		}
+		webhook := notify.NewWebhook(alerts.WebhookURL, alerts.Timeout)
+		backend.Alerts = dlqsvc.NewAlertingSender(logger, backend.Sender, webhook, depth,
+			alerts.DepthThreshold, alerts.QuietPeriod, conf.Application)
+		backend.Sender = backend.Alerts
+		// The alerts in flight go out before the backend closes
+		alerting, closeBackend := backend.Alerts, backend.Close
+		backend.Close = func() {
+			alerting.Wait()
+			closeBackend()
+		}
+	}
+	return backend, nil
+}
+
+func newDLQBackend(ctx context.Context, conf config.Config, logger *zap.Logger, dlqMetrics *metrics.DLQMetrics) (*DLQBackend, error) {
+	switch conf.DLQ.Backend {
+	case "redis":
+		redisClient, err := ConnectRedis(ctx, conf)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create redis client: %v", err)
+		}
+		queue := redis.NewDeadLetterQueue(redisClient, logger, NewDLQConfig(conf), dlqMetrics)
+		quarantine := redis.NewDeadLetterQueue(redisClient, logger, &redis.DLQConfig{
+			Name:            conf.DLQ.StreamName + ":quarantine", // Kept until acknowledged, so no TTL or cap
+			Codec:           conf.DLQ.Compression.Codec,
+			CompressMinSize: conf.DLQ.Compression.MinSize,
+		}, nil)
+		return &DLQBackend{Sender: queue, Inspector: queue, Quarantine: quarantine, Replay: queue, Redis: redisClient, Trim: queue.Run, Close: func() {
+			_ = redisClient.Close()
+		}}, nil
+
+	case "kafka":
+		producer, err := kafka.NewProducer(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create kafka producer: %v", err)
+		}
+		queue := kafka.NewTopicDeadLetterQueue(producer, conf.DLQ.Topic, logger)
+		return &DLQBackend{Sender: queue, Close: producer.Close}, nil
+
+	case "mongo":
+		mongoClient, err := mongodb.Connect(ctx, conf.Mongo.URI)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create mongo client: %v", err)
+		}
+		queue := mongodb.NewDeadLetterQueue(mongoClient, conf.DLQ.Collection)
+		quarantine := mongodb.NewDeadLetterQueue(mongoClient, conf.DLQ.Collection+"_quarantine")
+		return &DLQBackend{Sender: queue, Inspector: queue, Quarantine: quarantine, Close: func() {
+			_ = mongoClient.Disconnect(context.Background())
+		}}, nil
+
+	case "file":
+		queue := file.NewDeadLetterQueue(conf.DLQ.FilePath, logger)
+		quarantine := file.NewDeadLetterQueue(conf.DLQ.FilePath+".quarantine", logger)
+		return &DLQBackend{Sender: queue, Inspector: queue, Quarantine: quarantine, Close: func() {}}, nil
+
+	case "s3":
+		s3Client, err := s3.Connect(ctx, conf.DLQ.S3.Region, conf.DLQ.S3.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create s3 client: %v", err)
+		}
+		queue := s3.NewDeadLetterQueue(s3Client, conf.DLQ.S3.Bucket, conf.DLQ.S3.Prefix)
+		return &DLQBackend{Sender: queue, Close: func() {}}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown dlq backend %q", conf.DLQ.Backend)
+	}
+}
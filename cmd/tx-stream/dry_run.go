@@ -0,0 +1,43 @@
+package main
+
+import (
+	// Go Internal Packages
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	txsvc "tx-stream/services/transactions"
+)
+
+// printDryRunReport prints what the dry run would have done, the failure
+// reasons by descending count
+func printDryRunReport(report txsvc.DryRunReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "DRY RUN SUMMARY\t%s\n", time.Since(report.StartedAt).Round(time.Second))
+	fmt.Fprintf(w, "records\t%d\n", report.Records)
+	fmt.Fprintf(w, "would write\t%d\n", report.Written)
+	fmt.Fprintf(w, "would fail\t%d\n", report.Failed)
+	if len(report.Failures) > 0 {
+		reasons := make([]string, 0, len(report.Failures))
+		for reason := range report.Failures {
+			reasons = append(reasons, reason)
+		}
+		slices.SortFunc(reasons, func(a, b string) int {
+			if report.Failures[a] != report.Failures[b] {
+				return cmp.Compare(report.Failures[b], report.Failures[a])
+			}
+			return strings.Compare(a, b)
+		})
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "REASON\tRECORDS")
+		for _, reason := range reasons {
+			fmt.Fprintf(w, "%s\t%d\n", reason, report.Failures[reason])
+		}
+	}
+	_ = w.Flush()
+}
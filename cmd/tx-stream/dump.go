@@ -0,0 +1,61 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	// Local Packages
+	handlers "tx-stream/handlers"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// maxStackDump bounds the goroutine stacks of a state dump
+const maxStackDump = 64 << 20
+
+// dumpStateOnSignal logs the goroutine stacks and the runtime stats on
+// SIGUSR1, to diagnose a wedged instance before it is killed
+func dumpStateOnSignal(ctx context.Context, logger *zap.Logger, stats *handlers.StatsHandler, timeout time.Duration) {
+	usr1 := make(chan os.Signal, 1)
+	signal.Notify(usr1, syscall.SIGUSR1)
+	defer signal.Stop(usr1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-usr1:
+			dumpState(logger, stats, timeout)
+		}
+	}
+}
+
+// dumpState logs the stacks first, they need no lock a wedged component may
+// hold, then the stats whose sources are bounded by the timeout
+func dumpState(logger *zap.Logger, stats *handlers.StatsHandler, timeout time.Duration) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackDump {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	logger.Warn("state dump: goroutine stacks", zap.Int("goroutines", runtime.NumGoroutine()), zap.ByteString("stacks", buf))
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	state, err := json.Marshal(stats.Snapshot(ctx))
+	if err != nil {
+		logger.Error("state dump: cannot encode the stats", zap.Error(err))
+		return
+	}
+	logger.Warn("state dump: stats", zap.ByteString("state", state))
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	config "tx-stream/config"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+	fxrates "tx-stream/repositories/fxrates"
+	redis "tx-stream/repositories/redis"
+	fx "tx-stream/services/fx"
+
+	// External Packages
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// NewFXConverter reads the rates of the fx block from its HTTP source through the redis cache
+func NewFXConverter(conf config.FX, redisClient goredis.UniversalClient, logger *zap.Logger,
+	fxMetrics *metrics.FXMetrics) *fx.Converter {
+	source := fxrates.NewHTTPSource(conf.URL, conf.APIKey, conf.APIKeyHeader, conf.Timeout)
+	cache := redis.NewCache[models.FXRates](redisClient, logger, conf.CachePrefix, conf.RefreshInterval, 0)
+	provider := fx.ProviderFunc(func(ctx context.Context, base string) (models.FXRates, error) {
+		return cache.Get(ctx, base, func(ctx context.Context) (models.FXRates, error) {
+			return source.Rates(ctx, base)
+		})
+	})
+	return fx.NewConverter(provider, logger, fxMetrics, conf.BaseCurrency, conf.RefreshInterval, conf.MaxAge, conf.OnStale)
+}
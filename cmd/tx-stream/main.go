@@ -3,137 +3,1304 @@ package main
 import (
 	// Go Internal Packages
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
 	// Local Packages
+	chaos "tx-stream/chaos"
 	config "tx-stream/config"
+	election "tx-stream/election"
+	handlers "tx-stream/handlers"
+	health "tx-stream/health"
 	kafka "tx-stream/kafka"
+	lifecycle "tx-stream/lifecycle"
+	logging "tx-stream/logging"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+	outbox "tx-stream/outbox"
+	txstreamv1 "tx-stream/proto/txstream/v1"
+	region "tx-stream/region"
+	reporting "tx-stream/reporting"
 	mongodb "tx-stream/repositories/mongodb"
 	redis "tx-stream/repositories/redis"
+	rpc "tx-stream/rpc"
+	server "tx-stream/server"
+	aggregation "tx-stream/services/aggregation"
+	audit "tx-stream/services/audit"
+	dlqsvc "tx-stream/services/dlq"
+	features "tx-stream/services/features"
+	notifications "tx-stream/services/notifications"
+	risk "tx-stream/services/risk"
 	txsvc "tx-stream/services/transactions"
+	tracing "tx-stream/tracing"
+	version "tx-stream/version"
 
 	// External Packages
 	"github.com/alecthomas/kingpin/v2"
 	_ "github.com/jsternberg/zap-logfmt"
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
-	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/providers/confmap"
 	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/twmb/franz-go/plugin/kprom"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 )
 
-// LoadSecrets Loads the secret variables and overrides the config
-func LoadSecrets(k config.Config) config.Config {
-	MongoURI := os.Getenv("MONGO_URI")
-	if MongoURI != "" {
-		k.Mongo.URI = MongoURI
-	}
+// metricsNamespace prefixes every exported prometheus metric
+const metricsNamespace = "et"
 
-	RedisURI := os.Getenv("REDIS_URI")
-	if RedisURI != "" {
-		k.Redis.URI = RedisURI
-	}
+// logLevel is shared by every logger so a config reload can change it
+var logLevel = zap.NewAtomicLevel()
 
-	RedisPWD := os.Getenv("REDIS_PWD")
-	if RedisPWD != "" {
-		k.Redis.Password = RedisPWD
-	}
+var (
+	configPath = kingpin.Flag("config", "Path to the application config file").Short('c').Default("config.yml").String()
+	configEnv  = kingpin.Flag("env", "Environment profile, loads e.g. config.prod.yml over config.yml").Envar(config.EnvProfileVar).String()
+	configDirs = kingpin.Flag("config-dir", "Mounted ConfigMap or Secret directory with one file per key, repeatable").Envar(config.EnvPrefix + "CONFIG_DIRS").Strings()
+	runCmd     = kingpin.Command("run", "Consume and process transactions").Default()
 
-	KafkaBrokers := os.Getenv("KAFKA_BROKERS")
-	if KafkaBrokers != "" {
-		k.Kafka.Brokers = KafkaBrokers
-	}
+	// Overrides of the file and environment config, unset flags keep the config value
+	brokersFlag        = kingpin.Flag("brokers", "Comma separated Kafka brokers, overrides kafka.brokers").String()
+	topicFlag          = kingpin.Flag("topic", "Topic to consume, overrides kafka.topic").String()
+	groupFlag          = kingpin.Flag("group", "Consumer group, overrides kafka.consumer_name").String()
+	logLevelFlag       = kingpin.Flag("log-level", "Log level, overrides logger.level").Enum("debug", "info", "warn", "error")
+	recordsPerPollFlag = kingpin.Flag("records-per-poll", "Records fetched per poll, overrides kafka.records_per_poll").Int()
+	dryRunFlag         = kingpin.Flag("dry-run", "Process records without writing them or committing offsets and print a summary on exit, sets dry_run. With dlq replay, only count the matches, with offsets reset and import, only preview the changes").Bool()
+	strictFlag         = kingpin.Flag("strict", "Fail on config keys that no setting reads, sets strict").Bool()
+)
 
-	IsProdMode := os.Getenv("IS_PROD_MODE")
-	k.IsProdMode = IsProdMode == "true"
-	return k
+// flagOverrides returns the config keys set by command line flags
+func flagOverrides() map[string]interface{} {
+	overrides := make(map[string]interface{})
+	if *brokersFlag != "" {
+		overrides["kafka.brokers"] = *brokersFlag
+	}
+	if *topicFlag != "" {
+		overrides["kafka.topic"] = *topicFlag
+	}
+	if *groupFlag != "" {
+		overrides["kafka.consumer_name"] = *groupFlag
+	}
+	if *logLevelFlag != "" {
+		overrides["logger.level"] = *logLevelFlag
+	}
+	if *recordsPerPollFlag != 0 {
+		overrides["kafka.records_per_poll"] = *recordsPerPollFlag
+	}
+	if *dryRunFlag {
+		overrides["dry_run"] = true
+	}
+	if *strictFlag {
+		overrides["strict"] = true
+	}
+	return overrides
 }
 
-// LoadConfig loads the default configuration and overrides it with the config file
-// specified by the path defined in the config flag
-func LoadConfig() *koanf.Koanf {
-	configPathMsg := "Path to the application config file"
-	configPath := kingpin.Flag("config", configPathMsg).Short('c').Default("config.yml").String()
+// ConfigFiles returns the base config file and, with an environment profile,
+// its overlay next to it, e.g. config.yml and config.prod.yml
+func ConfigFiles() []string {
+	if *configPath == "" {
+		return nil
+	}
+	files := []string{*configPath}
+	if *configEnv != "" {
+		ext := filepath.Ext(*configPath)
+		files = append(files, strings.TrimSuffix(*configPath, ext)+"."+*configEnv+ext)
+	}
+	return files
+}
 
-	kingpin.Parse()
+// LoadConfig loads the configuration, each source overriding the previous one:
+//  1. the defaults in config.DefaultConfig
+//  2. the base config file from the config flag, skipped if missing
+//  3. the overlay of the environment profile from the env flag, which must exist
+//  4. the ConfigMap and Secret directories from the config-dir flag, see config.Dir
+//  5. the consul or etcd keys when remote.backend is set
+//  6. environment variables, see config.EnvProvider
+//  7. the vault secret when vault is enabled
+//  8. command line flags, see flagOverrides
+//
+// The ${VAR:-default} references in the values of steps 1 to 5 are expanded
+// before the environment loads, see config.Interpolate. Sops encrypted files
+// are decrypted as they load, enc: values once the environment is loaded, and
+// values referring to AWS Secrets Manager, SSM Parameter Store or GCP Secret
+// Manager are resolved before the flags apply. In strict mode any key that no config field reads
+// fails the load.
+func LoadConfig() (*koanf.Koanf, error) {
 	k := koanf.New(".")
 	_ = k.Load(rawbytes.Provider(config.DefaultConfig), yaml.Parser())
-	if *configPath != "" {
-		_ = k.Load(file.Provider(*configPath), yaml.Parser())
+	for idx, path := range ConfigFiles() {
+		err := loadConfigFile(k, path)
+		if err != nil && (idx > 0 || !os.IsNotExist(err)) {
+			return nil, fmt.Errorf("failed to load config file %s: %v", path, err)
+		}
+	}
+	for _, dir := range *configDirs {
+		if err := k.Load(config.DirProvider(dir), nil); err != nil {
+			return nil, fmt.Errorf("failed to load config dir %s: %v", dir, err)
+		}
+	}
+	if err := loadRemote(k); err != nil {
+		return nil, err
+	}
+	if err := config.Interpolate(k); err != nil {
+		return nil, err
+	}
+	_ = k.Load(config.LegacyEnvProvider(), nil)
+	_ = k.Load(config.EnvProvider(k.Keys()), nil)
+	if err := decryptValues(k); err != nil {
+		return nil, err
+	}
+	if err := loadVault(k); err != nil {
+		return nil, err
+	}
+	if err := resolveAWS(k); err != nil {
+		return nil, err
 	}
-	return k
+	if err := resolveGCP(k); err != nil {
+		return nil, err
+	}
+	_ = k.Load(confmap.Provider(flagOverrides(), "."), nil)
+	if unknown := config.UnknownKeys(k); k.Bool("strict") && len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+	return k, nil
 }
 
-func main() {
-	k := LoadConfig()
+// MustLoadConfig loads, overrides and validates the configuration, exiting on failure
+func MustLoadConfig() (*koanf.Koanf, config.Config) {
+	k, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
 
 	// Unmarshalling config into struct
 	appKonf := config.Config{}
-	err := k.Unmarshal("", &appKonf)
+	err = k.Unmarshal("", &appKonf)
 	if err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
-	// Update and Validate config before starting the server
-	prodKonf := LoadSecrets(appKonf)
-	if err = prodKonf.Validate(); err != nil {
+	// Validate config before starting the server
+	if err = appKonf.Validate(); err != nil {
 		log.Fatalf("Invalid configuration: %v", err)
 	}
+	return k, appKonf
+}
 
-	if !prodKonf.IsProdMode {
-		k.Print()
-	}
-
+// NewLogger builds the application logger
+func NewLogger(k *koanf.Koanf, conf config.Config) *zap.Logger {
 	cfg := zap.NewProductionConfig()
 	cfg.Encoding = "logfmt"
-	_ = cfg.Level.UnmarshalText([]byte(k.String("logger.level")))
+	_ = logLevel.UnmarshalText([]byte(k.String("logger.level")))
+	cfg.Level = logLevel
 	cfg.InitialFields = make(map[string]any)
 	cfg.InitialFields["host"], _ = os.Hostname()
-	cfg.InitialFields["service"] = prodKonf.Application
+	cfg.InitialFields["service"] = conf.Application
+	cfg.InitialFields["version"] = version.Version
 	cfg.OutputPaths = []string{"stdout"}
-	logger, _ := cfg.Build()
-	defer func() {
-		_ = logger.Sync()
-	}()
+	secretValues := config.SecretValues(k)
+	var fileCore zapcore.Core
+	if fileConf := conf.Logger.File; fileConf.Enabled {
+		fileCore = logging.NewFileCore(&logging.FileConfig{
+			Path:       fileConf.Path,
+			MaxSizeMB:  fileConf.MaxSizeMB,
+			MaxAge:     fileConf.MaxAge,
+			MaxBackups: fileConf.MaxBackups,
+			Compress:   fileConf.Compress,
+		}, cfg.EncoderConfig, logLevel)
+		// The initial fields are added to the stdout core before it is wrapped
+		fileCore = fileCore.With([]zap.Field{
+			zap.Any("host", cfg.InitialFields["host"]),
+			zap.Any("service", cfg.InitialFields["service"]),
+			zap.Any("version", cfg.InitialFields["version"]),
+		})
+	}
+	logger, _ := cfg.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		if fileCore != nil {
+			core = zapcore.NewTee(core, fileCore)
+		}
+		return logging.NewRedactingCore(core, secretValues, config.RedactedValue)
+	}))
+	return logger
+}
+
+// ConnectRedis connects to the redis configured by the redis block
+func ConnectRedis(ctx context.Context, conf config.Config) (goredis.UniversalClient, error) {
+	redisConf, err := NewRedisConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis tls config: %v", err)
+	}
+	return redis.Connect(ctx, redisConf)
+}
+
+// NewKafkaSASL maps the sasl block of kafka to the authentication of the kafka clients, nil when disabled
+func NewKafkaSASL(conf config.Kafka) *kafka.SASL {
+	if conf.SASL.Mechanism == "" {
+		return nil
+	}
+	return &kafka.SASL{Mechanism: conf.SASL.Mechanism, Username: conf.SASL.Username, Password: conf.SASL.Password}
+}
+
+// NewFeatureFlags maps the flags of the features block to feature flags
+func NewFeatureFlags(conf config.Features) map[string]models.FeatureFlag {
+	flags := make(map[string]models.FeatureFlag, len(conf.Flags))
+	for name, flag := range conf.Flags {
+		flags[name] = models.FeatureFlag{Enabled: flag.Enabled, Tenants: flag.Tenants, Rollout: flag.Rollout}
+	}
+	return flags
+}
+
+// NewPayloadRules maps the payloads block of the logger to the payload sampling rules
+func NewPayloadRules(conf config.Payloads) logging.PayloadRules {
+	return logging.PayloadRules{
+		Enabled:      conf.Enabled,
+		SampleEvery:  conf.SampleEvery,
+		Match:        conf.Match,
+		RedactFields: conf.RedactFields,
+	}
+}
+
+// NewRiskRules maps the risk block to the rules of the risk engine
+func NewRiskRules(conf config.Risk) []risk.Rule {
+	rules := make([]risk.Rule, len(conf.Rules))
+	for idx, rule := range conf.Rules {
+		rules[idx] = risk.Rule{Name: rule.Name, Score: rule.Score, When: make([]risk.Condition, len(rule.When))}
+		for cdx, condition := range rule.When {
+			rules[idx].When[cdx] = risk.Condition{Field: condition.Field, Op: condition.Op, Value: condition.Value, Values: condition.Values}
+		}
+	}
+	return rules
+}
+
+// NewRedisConfig maps the redis config block to the redis connection config
+func NewRedisConfig(conf config.Config) (*redis.ConnectConfig, error) {
+	tlsConf, err := conf.Redis.TLS.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := []string{conf.Redis.URI}
+	if conf.Redis.Mode == redis.ModeCluster || conf.Redis.Mode == redis.ModeSentinel {
+		addrs = conf.Redis.Addrs
+	}
+	return &redis.ConnectConfig{
+		Mode:             conf.Redis.Mode,
+		Addrs:            addrs,
+		Username:         conf.Redis.Username,
+		Password:         conf.Redis.Password,
+		DB:               conf.Redis.DB,
+		TLS:              tlsConf,
+		MasterName:       conf.Redis.MasterName,
+		SentinelPassword: conf.Redis.SentinelPassword,
+	}, nil
+}
+
+// NewMetricsSinks creates the sinks listed in metrics.sinks, exporting
+// what is gathered from the registry
+func NewMetricsSinks(conf config.Metrics, registry *prometheus.Registry, logger *zap.Logger) ([]metrics.Sink, error) {
+	var sinks []metrics.Sink
+	for _, sink := range conf.Sinks {
+		switch sink {
+		case "prometheus":
+			mux := http.NewServeMux()
+			mux.Handle("GET "+conf.Path, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+			sinks = append(sinks, server.NewServer(conf.Port, mux, logger))
+		case "dogstatsd":
+			dogstatsd, err := metrics.NewDogStatsD(conf.DogStatsD.Address, conf.DogStatsD.Interval,
+				conf.DogStatsD.Tags, registry, logger)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, dogstatsd)
+		default:
+			return nil, fmt.Errorf("unknown metrics sink %q", sink)
+		}
+	}
+	return sinks, nil
+}
+
+// NewElector creates the elector of election.backend, connecting to redis
+// through useRedis only when the lock lives there
+func NewElector(conf config.Election, useRedis func() goredis.UniversalClient, logger *zap.Logger) (*election.Elector, error) {
+	identity := conf.Identity
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+	var lock election.Lock
+	switch conf.Backend {
+	case "kubernetes":
+		kubeLock, err := election.NewKubernetesLock(conf.Namespace, conf.Name, identity, conf.LeaseDuration)
+		if err != nil {
+			return nil, err
+		}
+		lock = kubeLock
+	case "redis":
+		lock = election.NewRedisLock(redis.NewLocker(useRedis(), logger, identity), conf.Name, conf.LeaseDuration)
+	default:
+		return nil, fmt.Errorf("unknown election backend %q", conf.Backend)
+	}
+	return election.NewElector(lock, logger, identity, conf.RetryPeriod, conf.RenewDeadline), nil
+}
+
+func main() {
+	kingpin.Version(version.Get().String())
+	switch kingpin.Parse() {
+	case configPrintCmd.FullCommand():
+		runConfigPrint()
+	case validateCmd.FullCommand():
+		runValidate()
+	case observabilityExportCmd.FullCommand():
+		runObservabilityExport()
+	case dlqListCmd.FullCommand():
+		runDLQList()
+	case dlqShowCmd.FullCommand():
+		runDLQShow()
+	case dlqDeleteCmd.FullCommand():
+		runDLQDelete()
+	case dlqRequeueCmd.FullCommand():
+		runDLQRequeue()
+	case dlqReplayCmd.FullCommand():
+		runDLQReplay()
+	case dlqExportCmd.FullCommand():
+		runDLQExport()
+	case dlqImportCmd.FullCommand():
+		runDLQImport()
+	case dlqMigrateCmd.FullCommand():
+		runDLQMigrate()
+	case quarantineListCmd.FullCommand():
+		runQuarantineList()
+	case quarantineShowCmd.FullCommand():
+		runQuarantineShow()
+	case quarantineAckCmd.FullCommand():
+		runQuarantineAck()
+	case quarantineDeleteCmd.FullCommand():
+		runQuarantineDelete()
+	case offsetsResetCmd.FullCommand():
+		runOffsetsReset()
+	case offsetsExportCmd.FullCommand():
+		runOffsetsExport()
+	case offsetsImportCmd.FullCommand():
+		runOffsetsImport()
+	case topicDescribeCmd.FullCommand():
+		runTopicDescribe()
+	case replayCmd.FullCommand():
+		runReplay()
+	case backfillCmd.FullCommand():
+		runBackfill()
+	case benchCmd.FullCommand():
+		runBench()
+	case reconcileCmd.FullCommand():
+		runReconcile()
+	default:
+		run()
+	}
+}
+
+func run() {
+	k, prodKonf := MustLoadConfig()
+	if !prodKonf.IsProdMode {
+		config.Redacted(k).Print()
+	}
+
+	logger := NewLogger(k, prodKonf)
 
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Subsystems register how they stop, the shutdown runs them phase by phase
+	timeouts := prodKonf.Shutdown.Timeouts
+	shutdown := lifecycle.NewManager(logger, map[lifecycle.Phase]time.Duration{
+		lifecycle.StopFetching: timeouts.StopFetching,
+		lifecycle.DrainWorkers: timeouts.DrainWorkers,
+		lifecycle.Flush:        timeouts.Flush,
+		lifecycle.Commit:       timeouts.Commit,
+		lifecycle.CloseStores:  timeouts.CloseStores,
+		lifecycle.StopServers:  timeouts.StopServers,
+		lifecycle.Telemetry:    timeouts.Telemetry,
+	})
+	shutdown.OnShutdown(lifecycle.Telemetry, "logger", func(context.Context) error {
+		_ = logger.Sync()
+		return nil
+	})
+
+	// Logs go to the collector as well as stdout, redacted the same way
+	if otlpConf := prodKonf.Logger.OTLP; otlpConf.Enabled {
+		otlpCore, shutdownLogs, err := logging.NewOTLPCore(ctx, &logging.OTLPConfig{
+			ServiceName:    prodKonf.Application,
+			Endpoint:       otlpConf.Endpoint,
+			Insecure:       otlpConf.Insecure,
+			QueueSize:      otlpConf.QueueSize,
+			BatchSize:      otlpConf.BatchSize,
+			ExportInterval: otlpConf.ExportInterval,
+		}, logLevel)
+		if err != nil {
+			logger.Fatal("cannot set up log export", zap.Error(err))
+		}
+		secretValues := config.SecretValues(k)
+		logger = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, logging.NewRedactingCore(otlpCore, secretValues, config.RedactedValue))
+		}))
+		shutdown.OnShutdown(lifecycle.Telemetry, "log export", shutdownLogs)
+	}
+
+	// SIGUSR2 toggles debug logging, the admin server can set any level
+	logLevels := logging.NewLevelController(logLevel, logger)
+	go func() {
+		usr2 := make(chan os.Signal, 1)
+		signal.Notify(usr2, syscall.SIGUSR2)
+		defer signal.Stop(usr2)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-usr2:
+				logLevels.Toggle(prodKonf.Logger.ToggleTTL)
+			}
+		}
+	}()
+
+	if vaultSecrets != nil {
+		go vaultSecrets.KeepAlive(ctx, logger)
+	}
+
+	if tracingConf := prodKonf.Tracing; tracingConf.Enabled {
+		shutdownTracing, err := tracing.Setup(ctx, &tracing.Config{
+			ServiceName: prodKonf.Application,
+			Endpoint:    tracingConf.Endpoint,
+			Insecure:    tracingConf.Insecure,
+			SampleRatio: tracingConf.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal("cannot set up tracing", zap.Error(err))
+		}
+		shutdown.OnShutdown(lifecycle.Telemetry, "tracing", shutdownTracing)
+	}
+
+	if sentryConf := prodKonf.Sentry; sentryConf.DSN != "" {
+		flushSentry, err := reporting.Setup(&reporting.Config{
+			DSN:         sentryConf.DSN,
+			Environment: sentryConf.Environment,
+			SampleRate:  sentryConf.SampleRate,
+		})
+		if err != nil {
+			logger.Fatal("cannot set up sentry", zap.Error(err))
+		}
+		shutdown.Close(lifecycle.Telemetry, "sentry", func() { flushSentry(2 * time.Second) })
+		defer reporting.Recover()
+	}
+
 	// Mongo Connection
-	mongoClient, err := mongodb.Connect(ctx, prodKonf.Mongo.URI)
+	mongoClient, err := waitFor(ctx, logger, prodKonf.Startup, "mongo", func(ctx context.Context) (*mongo.Client, error) {
+		return mongodb.Connect(ctx, prodKonf.Mongo.URI)
+	})
 	if err != nil {
 		logger.Fatal("cannot create mongo client", zap.Error(err))
 	}
+	shutdown.OnShutdown(lifecycle.CloseStores, "mongo", mongoClient.Disconnect)
 
-	// Redis Connection
-	redisClient, err := redis.Connect(ctx, prodKonf.Redis.URI, prodKonf.Redis.Password)
+	registry := prometheus.NewRegistry()
+	kafkaMetrics := kprom.NewMetrics(metricsNamespace, kprom.Registry(registry), kprom.GoCollectors())
+	dlqMetrics := metrics.NewDLQMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	metrics.NewBuildInfo(kafkaMetrics.Registry(), metricsNamespace, version.Get())
+
+	// Dead Letter Queue
+	dlqBackend, err := waitFor(ctx, logger, prodKonf.Startup, "dlq backend", func(ctx context.Context) (*DLQBackend, error) {
+		return NewDLQBackend(ctx, prodKonf, logger, dlqMetrics)
+	})
 	if err != nil {
-		logger.Fatal("cannot create redis client", zap.Error(err))
+		logger.Fatal("cannot create dead letter queue", zap.Error(err))
 	}
+	shutdown.Close(lifecycle.CloseStores, "dlq backend", dlqBackend.Close)
 
-	txRepo := mongodb.NewTxRepository(mongoClient)
-	dlQueue := redis.NewDeadLetterQueue(redisClient, logger)
-	txProcessor := txsvc.NewTxProcessor(logger, txRepo)
-
-	metrics := kprom.NewMetrics("et")
-	conf := &kafka.ConsumerConfig{
-		Brokers:        []string{prodKonf.Kafka.Brokers},
-		Name:           prodKonf.Kafka.ConsumerName,
-		Topic:          prodKonf.Kafka.Topic,
-		RecordsPerPoll: prodKonf.Kafka.RecordsPerPoll,
+	var txRepo txsvc.TxRepository = mongodb.NewTxRepository(mongoClient)
+	// Stored events through the outbox, wrapping the mongo writes alone so they share its transaction
+	var outboxStore *outbox.Store
+	if outboxConf := prodKonf.Outbox; outboxConf.Enabled && !prodKonf.DryRun {
+		outboxStore = outbox.NewStore(mongoClient.Database("mybase").Collection(outboxConf.Collection))
+		if err := outboxStore.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("cannot create outbox indexes", zap.Error(err))
+		}
+		txRepo = txsvc.NewOutboxTxRepository(mongoClient, txRepo, outboxStore, outboxConf.Topic)
+	}
+	// Applied records in the same transaction, the outbox joins it
+	var ledger *txsvc.Ledger
+	if idempotencyConf := prodKonf.Idempotency; idempotencyConf.Enabled && !prodKonf.DryRun {
+		appliedRecords := mongodb.NewAppliedRecords(mongoClient, idempotencyConf.Collection)
+		if err := appliedRecords.EnsureIndexes(ctx, idempotencyConf.Retention); err != nil {
+			logger.Fatal("cannot create idempotency ledger indexes", zap.Error(err))
+		}
+		ledger = txsvc.NewLedger(appliedRecords, metrics.NewIdempotencyMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		txRepo = txsvc.NewLedgerTxRepository(mongoClient, txRepo, appliedRecords)
+	}
+	if shadowConf := prodKonf.Shadow; shadowConf.Enabled && !prodKonf.DryRun {
+		shadowClient := mongoClient
+		if shadowConf.URI != "" {
+			shadowClient, err = mongodb.Connect(ctx, shadowConf.URI)
+			if err != nil {
+				logger.Fatal("cannot create shadow mongo client", zap.Error(err))
+			}
+			shutdown.OnShutdown(lifecycle.CloseStores, "shadow mongo", shadowClient.Disconnect)
+		}
+		shadowRepo := mongodb.NewTxRepository(shadowClient)
+		shadowRepo.Database = shadowConf.Database
+		shadowRepo.Collection = shadowConf.Collection
+		shadowRepo.Upsert = true
+		logger.Info("shadow writes enabled", zap.String("database", shadowConf.Database),
+			zap.String("collection", shadowConf.Collection))
+		txRepo = txsvc.NewShadowTxRepository(txRepo, shadowRepo, logger,
+			metrics.NewShadowMetrics(kafkaMetrics.Registry(), metricsNamespace), shadowConf.Timeout)
+	}
+	var dryRunSummary *txsvc.DryRunSummary
+	if prodKonf.DryRun {
+		logger.Warn("dry run, transactions and dead letters are not written and offsets are not committed")
+		dryRunSummary = txsvc.NewDryRunSummary()
+		dryRunRepo := txsvc.NewDryRunTxRepository(logger)
+		dryRunRepo.Summary = dryRunSummary
+		txRepo = dryRunRepo
+		dryRunSender := dlqsvc.NewDryRunSender(logger)
+		dryRunSender.Summary = dryRunSummary
+		dlqBackend.Sender = dryRunSender
+	}
+	stageMetrics := metrics.NewStageMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	errorMetrics := metrics.NewErrorMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	sloMetrics := metrics.NewSLOMetrics(kafkaMetrics.Registry(), metricsNamespace,
+		prodKonf.Metrics.SLO.FreshnessTarget, prodKonf.Metrics.SLO.Objective)
+	// Fault injection for resilience tests, validation refuses it in prod mode
+	var chaosInjector *chaos.Injector
+	if chaosConf := prodKonf.Chaos; chaosConf.Enabled {
+		logger.Warn("chaos mode enabled, failures are injected on purpose")
+		chaosInjector = chaos.NewInjector(&chaos.Config{
+			ProcessorErrorRate: chaosConf.ProcessorErrorRate,
+			DecodeFailureRate:  chaosConf.DecodeFailureRate,
+			MongoLatencyRate:   chaosConf.MongoLatencyRate,
+			MongoLatency:       chaosConf.MongoLatency,
+			RedisTimeoutRate:   chaosConf.RedisTimeoutRate,
+		}, metrics.NewChaosMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		txRepo = chaosInjector.Repository(txRepo)
+		if dlqBackend.Redis != nil {
+			dlqBackend.Redis.AddHook(chaosInjector.RedisHook())
+		}
 	}
 
-	txConsumer, err := kafka.NewTxConsumer(conf, logger, txProcessor, dlQueue, metrics)
+	payloadSampler := logging.NewPayloadSampler(NewPayloadRules(prodKonf.Logger.Payloads))
+	var tailHub *txsvc.TailHub
+	var aggregator *aggregation.Aggregator // Set once redis is connected, see below
+	var notifier *notifications.Notifier
+	txStages, err := NewTxStages(ctx, prodKonf, logger, kafkaMetrics.Registry())
 	if err != nil {
-		logger.Fatal("cannot create transactions consumer", zap.Error(err))
+		logger.Fatal("cannot create processor stages", zap.Error(err))
+	}
+	if tailConf := prodKonf.API.Tail; prodKonf.API.Enabled && tailConf.Enabled {
+		tailHub = txsvc.NewTailHub(tailConf.RedactFields, tailConf.BufferSize, tailConf.MaxSubscribers,
+			metrics.NewTailMetrics(kafkaMetrics.Registry(), metricsNamespace))
+	}
+	heartbeatMetrics := metrics.NewHeartbeatMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	newTxProcessor := func(repo txsvc.TxRepository) *txsvc.TxProcessor {
+		processor := txsvc.NewTxProcessor(logger, repo, stageMetrics, errorMetrics)
+		processor.Payloads = payloadSampler
+		processor.Heartbeats = heartbeatMetrics
+		processor.Summary = dryRunSummary
+		processor.Tail = tailHub
+		processor.Aggregates = aggregator
+		processor.Alerts = notifier
+		txStages.Apply(processor)
+		return processor
+	}
+	txProcessor := newTxProcessor(txRepo)
+	// Every processor whose writes reach txRepo consults the ledger they write
+	txProcessor.Ledger = ledger
+
+	// Redis is shared by the dlq backend, retries and feature flags, connected on first use
+	redisClient := dlqBackend.Redis
+	useRedis := func() goredis.UniversalClient {
+		if redisClient == nil {
+			client, err := waitFor(ctx, logger, prodKonf.Startup, "redis", func(ctx context.Context) (goredis.UniversalClient, error) {
+				return ConnectRedis(ctx, prodKonf)
+			})
+			if err != nil {
+				logger.Fatal("cannot create redis client", zap.Error(err))
+			}
+			if chaosInjector != nil {
+				client.AddHook(chaosInjector.RedisHook())
+			}
+			redisClient = client
+		}
+		return redisClient
+	}
+	shutdown.OnShutdown(lifecycle.CloseStores, "redis", func(context.Context) error {
+		if redisClient != nil && dlqBackend.Redis == nil {
+			return redisClient.Close()
+		}
+		return nil
+	})
+
+	// Queue depths for the runtime stats, by queue
+	queueDepths := make(map[string]dlqsvc.DepthFunc)
+	if dlqBackend.Inspector != nil {
+		queueDepths["dlq"] = func(ctx context.Context) (int64, error) {
+			page, err := dlqBackend.Inspector.List(ctx, 0, 1)
+			return page.Total, err
+		}
+	}
+
+	// Background jobs that must run on one replica only, on this one without election
+	runSingleton := func(_ string, job func(ctx context.Context)) { go job(ctx) }
+	var elector *election.Elector
+	if prodKonf.Election.Enabled {
+		elector, err = NewElector(prodKonf.Election, useRedis, logger)
+		if err != nil {
+			logger.Fatal("cannot create leader elector", zap.Error(err))
+		}
+		runSingleton = elector.Go
+	}
+	if dlqBackend.Trim != nil && !prodKonf.DryRun {
+		runSingleton("dlq-trim", dlqBackend.Trim)
+	}
+
+	// Windowed aggregates of the stored transactions, emitted by a single replica
+	if prodKonf.Aggregation.Enabled && !prodKonf.DryRun {
+		agg, closeAgg, err := NewAggregator(ctx, prodKonf, useRedis(), mongoClient, logger,
+			metrics.NewAggregationMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		if err != nil {
+			logger.Fatal("cannot create aggregator", zap.Error(err))
+		}
+		shutdown.Close(lifecycle.Flush, "aggregation producer", closeAgg)
+		aggregator = agg
+		txProcessor.Aggregates = aggregator
+		runSingleton("aggregation", aggregator.Run)
+	}
+
+	// Alerts on suspicious transactions, sent by every replica for the transactions it stores
+	if prodKonf.Notifications.Enabled && !prodKonf.DryRun {
+		notifier, err = NewNotifier(prodKonf, useRedis(), logger,
+			metrics.NewNotificationMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		if err != nil {
+			logger.Fatal("cannot create notifier", zap.Error(err))
+		}
+		txProcessor.Alerts = notifier
+		go notifier.Run(ctx)
+	}
+
+	// Amounts normalized to the base currency, every replica refreshes its own rates through the cache
+	if prodKonf.FX.Enabled {
+		txStages.StartFX(ctx, prodKonf.FX, useRedis(), logger, kafkaMetrics.Registry())
+		txProcessor.FX = txStages.FX
 	}
 
-	err = txConsumer.Poll(ctx, prodKonf.Kafka.Consume)
+	// Periodic maintenance jobs such as the dlq sweep, run by the leader
+	if prodKonf.Scheduler.Enabled && !prodKonf.DryRun {
+		jobScheduler, err := NewScheduler(prodKonf, mongoClient, dlqBackend, logger,
+			metrics.NewSchedulerMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		if err != nil {
+			logger.Fatal("cannot create scheduler", zap.Error(err))
+		}
+		logger.Info("scheduler enabled", zap.Strings("jobs", jobScheduler.Jobs()))
+		runSingleton("scheduler", jobScheduler.Run)
+	}
+
+	if outboxStore != nil {
+		relayConf := prodKonf.Outbox.Relay
+		outboxProducer, err := kafka.NewProducer(prodKonf.Kafka.BrokerList(), NewKafkaSASL(prodKonf.Kafka))
+		if err != nil {
+			logger.Fatal("cannot create outbox producer", zap.Error(err))
+		}
+		shutdown.Close(lifecycle.Flush, "outbox producer", outboxProducer.Close)
+		relay := outbox.NewRelay(outboxStore, outbox.NewKafkaPublisher(outboxProducer.Client), logger, outbox.RelayConfig{
+			BatchSize:       relayConf.BatchSize,
+			Interval:        relayConf.Interval,
+			Lease:           relayConf.Lease,
+			MaxAttempts:     relayConf.MaxAttempts,
+			BaseDelay:       relayConf.BaseDelay,
+			MaxDelay:        relayConf.MaxDelay,
+			Retention:       relayConf.Retention,
+			CleanupInterval: relayConf.CleanupInterval,
+		}, outbox.NewMetrics(kafkaMetrics.Registry(), metricsNamespace))
+		runSingleton("outbox-relay", relay.Run)
+	}
+
+	// Planned storage outages hold the records on the topic, set from the config or the admin API
+	maintenance := kafka.NewMaintenance()
+	if err := maintenance.Set(prodKonf.Maintenance.Mode, "config"); err != nil {
+		logger.Fatal("cannot set maintenance mode", zap.Error(err))
+	}
+
+	// Active/passive regions, the replicas of a standby region stay in their groups with fetching paused
+	var activeRegion *region.Region
+	if regionConf := prodKonf.Region; regionConf.Name != "" {
+		var store region.Store
+		if regionConf.Backend == "redis" {
+			store = redis.NewRegionStore(useRedis(), regionConf.RedisKey)
+		}
+		activeRegion = region.New(regionConf.Name, regionConf.Active, store, logger,
+			metrics.NewRegionMetrics(kafkaMetrics.Registry(), metricsNamespace), regionConf.Interval, regionConf.FailoverAfter)
+		if store != nil {
+			// Read before the consumers start, so a standby replica never fetches
+			activeRegion.Check(ctx)
+			go activeRegion.Run(ctx)
+		}
+		state := activeRegion.State()
+		logger.Info("region awareness enabled", zap.String("region", state.Region),
+			zap.String("active", state.Active), zap.String("role", state.Role))
+	}
+
+	// Delayed retries before dead-lettering, started once the processors of the consumers are set
+	dlqSender := dlqBackend.Sender
+	var retryScheduler *dlqsvc.RetryScheduler
+	if retryConf := prodKonf.DLQ.Retry; retryConf.Enabled && !prodKonf.DryRun {
+		retryQueue := redis.NewRetryQueue(useRedis(), logger, &redis.RetryConfig{
+			Name:        retryConf.Name,
+			BaseDelay:   retryConf.BaseDelay,
+			MaxDelay:    retryConf.MaxDelay,
+			MaxAttempts: retryConf.MaxAttempts,
+			Lease:       retryConf.Lease,
+		})
+		retryScheduler = dlqsvc.NewRetryScheduler(logger, retryQueue, dlqBackend.Sender, txProcessor, retryConf.BatchSize, retryConf.Interval)
+		retryScheduler.Paused = func() bool { return maintenance.Active() || activeRegion.Standby() }
+		retryScheduler.Processors = make(map[string]dlqsvc.Processor)
+		dlqSender = retryScheduler
+		queueDepths["retry"] = retryQueue.Pending
+	}
+
+	// Feature flags from the config, or refreshed from redis
+	featureMetrics := metrics.NewFeatureMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	var featureStore features.Store
+	if prodKonf.Features.Backend == "redis" {
+		featureStore = redis.NewFeatureFlagRepo(useRedis(), prodKonf.Features.RedisKey)
+	}
+	featureFlags := features.NewFlags(logger, featureStore, featureMetrics, prodKonf.Features.RefreshInterval)
+	if featureStore == nil {
+		featureFlags.Set(NewFeatureFlags(prodKonf.Features))
+	}
+	go featureFlags.Run(ctx)
+
+	// Redis health and pool metrics, whenever redis is in use
+	healthHandler := handlers.NewHealthHandler()
+	healthConf := prodKonf.Health
+	mongoHealth := health.NewPinger("mongo", func(ctx context.Context) error {
+		return mongoClient.Ping(ctx, nil)
+	}, logger, healthConf.Interval, healthConf.Timeout)
+	go mongoHealth.Run(ctx)
+	healthHandler.AddCheck("mongo", mongoHealth.Ready)
+	if redisClient != nil {
+		redisMetrics := metrics.NewRedisMetrics(kafkaMetrics.Registry(), metricsNamespace, redisClient)
+		redisHealth := redis.NewHealthChecker(redisClient, logger, redisMetrics,
+			prodKonf.Redis.HealthInterval, prodKonf.Redis.HealthTimeout)
+		go redisHealth.Run(ctx)
+		healthHandler.AddCheck("redis", redisHealth.Ready)
+	}
+
+	// Metrics of the kafka clients and of our own collectors, to every configured sink
+	if prodKonf.Metrics.Enabled {
+		sinks, err := NewMetricsSinks(prodKonf.Metrics, registry, logger)
+		if err != nil {
+			logger.Fatal("cannot create metrics sinks", zap.Error(err))
+		}
+		for _, sink := range sinks {
+			go func() {
+				if err := sink.Start(); err != nil {
+					logger.Error("metrics sink stopped", zap.Error(err))
+				}
+			}()
+			shutdown.OnShutdown(lifecycle.StopServers, "metrics sink", sink.Shutdown)
+		}
+	}
+
+	statsHandler := handlers.NewStatsHandler(healthConf.Timeout)
+	statsHandler.AddSource("queues", func(ctx context.Context) (any, error) {
+		depths := make(map[string]any, len(queueDepths))
+		for name, depth := range queueDepths {
+			if n, err := depth(ctx); err != nil {
+				depths[name] = map[string]string{"error": err.Error()}
+			} else {
+				depths[name] = n
+			}
+		}
+		return depths, nil
+	})
+
+	brokers, kafkaSASL := prodKonf.Kafka.BrokerList(), NewKafkaSASL(prodKonf.Kafka)
+	var adminMux *http.ServeMux
+	if prodKonf.Admin.Enabled {
+		mux := http.NewServeMux()
+		healthHandler.Register(mux)
+		handlers.NewVersionHandler(version.Get()).Register(mux)
+		var adminHandler http.Handler = mux
+		if prodKonf.Admin.Token != "" {
+			// Every endpoint that reads the state or changes it is behind the token
+			adminMux = mux
+			statsHandler.Register(mux)
+			handlers.NewFeatureHandler(featureFlags).Register(mux)
+			handlers.NewLogHandler(logLevels).Register(mux)
+			if prodKonf.Admin.Debug {
+				logger.Warn("debug endpoints are enabled on the admin server")
+				handlers.NewDebugHandler().Register(mux)
+			}
+			if dlqBackend.Inspector != nil {
+				producer, err := kafka.NewProducer(brokers, kafkaSASL)
+				if err != nil {
+					logger.Fatal("cannot create kafka producer", zap.Error(err))
+				}
+				shutdown.Close(lifecycle.Flush, "dlq admin producer", producer.Close)
+				handlers.NewDLQHandler(dlqsvc.NewDLQService(logger, dlqBackend.Inspector, dlqBackend.Quarantine, producer)).Register(mux)
+			}
+			adminHandler = handlers.RequireToken(prodKonf.Admin.Token, []string{"/healthz", "/readyz", "/version"}, mux)
+		} else {
+			logger.Warn("admin.token is not set, the admin server only serves the probes and the version")
+		}
+		adminServer := server.NewServer(prodKonf.Admin.Port, adminHandler, logger)
+		go func() {
+			if err := adminServer.Start(); err != nil {
+				logger.Error("admin server stopped", zap.Error(err))
+			}
+		}()
+		shutdown.OnShutdown(lifecycle.StopServers, "admin server", adminServer.Shutdown)
+	}
+
+	// The gRPC service and the support API answer the same queries
+	txQueryStore := mongodb.NewTxRepository(mongoClient)
+	sinkDeadLetters := mongodb.NewSinkDeadLetters(mongoClient, prodKonf.Sinks.Fanout.Collection)
+	if prodKonf.GRPC.Enabled || prodKonf.API.Enabled {
+		if err := txQueryStore.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("cannot create transaction indexes", zap.Error(err))
+		}
+		if err := sinkDeadLetters.EnsureIndexes(ctx); err != nil {
+			logger.Fatal("cannot create sink dead letter indexes", zap.Error(err))
+		}
+	}
+
+	if grpcConf := prodKonf.GRPC; grpcConf.Enabled {
+		var opts []grpc.ServerOption
+		if grpcConf.Token != "" {
+			opts = append(opts, grpc.UnaryInterceptor(rpc.RequireToken(grpcConf.Token)))
+		} else {
+			logger.Warn("grpc.token is not set, the transaction queries are unauthenticated, which prod mode refuses")
+		}
+		grpcServer := server.NewGRPCServer(grpcConf.Port, logger, opts...)
+		queries := txsvc.NewQueryService(txQueryStore, sinkDeadLetters, grpcConf.MaxPageSize)
+		txstreamv1.RegisterTransactionQueryServiceServer(grpcServer.GRPC, rpc.NewQueryServer(queries, logger))
+		go func() {
+			if err := grpcServer.Start(); err != nil {
+				logger.Error("grpc server stopped", zap.Error(err))
+			}
+		}()
+		shutdown.OnShutdown(lifecycle.StopServers, "grpc server", grpcServer.Shutdown)
+	}
+
+	if apiConf := prodKonf.API; apiConf.Enabled {
+		mux := http.NewServeMux()
+		handlers.NewAPIHandler(txsvc.NewQueryService(txQueryStore, sinkDeadLetters, apiConf.MaxPageSize),
+			dlqBackend.Inspector, sinkDeadLetters, statsHandler, int64(apiConf.MaxPageSize)).Register(mux)
+		if tailHub != nil {
+			handlers.NewTailHandler(tailHub, apiConf.Tail.Heartbeat, apiConf.Tail.MaxDuration).Register(mux)
+		}
+		var apiHandler http.Handler = mux
+		if apiConf.Token != "" {
+			apiHandler = handlers.RequireToken(apiConf.Token, nil, mux)
+		} else {
+			logger.Warn("api.token is not set, the support api is unauthenticated, which prod mode refuses")
+		}
+		apiServer := server.NewServer(apiConf.Port, apiHandler, logger)
+		go func() {
+			if err := apiServer.Start(); err != nil {
+				logger.Error("api server stopped", zap.Error(err))
+			}
+		}()
+		shutdown.OnShutdown(lifecycle.StopServers, "api server", apiServer.Shutdown)
+		if tailHub != nil {
+			// Registered after the server so the tails end before it waits for its connections
+			shutdown.OnShutdown(lifecycle.StopServers, "live tails", func(context.Context) error {
+				tailHub.Close()
+				return nil
+			})
+		}
+	}
+
+	// Processors that consumers can name in their config
+	processors := map[string]kafka.TxProcessor{
+		"transactions": txProcessor,
+	}
+	// Consumers landing their transactions in another sink, or fanning them
+	// out to several, get a processor of their own. Dry runs write nowhere so
+	// they keep the dry run one.
+	sinkRepos := map[string]txsvc.TxRepository{"mongo": txRepo}
+	connectSink := func(name string) txsvc.TxRepository {
+		if repo, ok := sinkRepos[name]; ok {
+			return repo
+		}
+		sink, err := waitFor(ctx, logger, prodKonf.Startup, name, func(ctx context.Context) (txsvc.Sink, error) {
+			return NewSink(ctx, name, prodKonf.Sinks, logger)
+		})
+		if err != nil {
+			logger.Fatal("cannot create sink", zap.String("sink", name), zap.Error(err))
+		}
+		shutdown.OnShutdown(lifecycle.CloseStores, name, sink.Close)
+		if buffered, ok := sink.(txsvc.BufferedSink); ok {
+			go buffered.Run(ctx)
+			shutdown.OnShutdown(lifecycle.Flush, name, buffered.Flush)
+		}
+		var sinkRepo txsvc.TxRepository = sink
+		if chaosInjector != nil {
+			sinkRepo = chaosInjector.Repository(sinkRepo)
+		}
+		sinkRepos[name] = sinkRepo
+		return sinkRepo
+	}
+	fanoutConf := prodKonf.Sinks.Fanout
+	fanoutMetrics := metrics.NewFanoutMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	consumerProcessors := make(map[string]kafka.TxProcessor)
+	for _, consumerConf := range prodKonf.Kafka.ConsumerList() {
+		if prodKonf.DryRun || consumerConf.Processor != "transactions" || (consumerConf.Sink == "mongo" && len(consumerConf.Fanout) == 0) {
+			continue
+		}
+		repo := connectSink(consumerConf.Sink)
+		if len(consumerConf.Fanout) > 0 {
+			fanout := txsvc.NewFanoutTxRepository(repo, mongodb.NewSinkDeadLetters(mongoClient, fanoutConf.Collection),
+				logger.With(zap.String("consumer", consumerConf.Name)), fanoutMetrics, txsvc.FanoutConfig{
+					MaxRetries: fanoutConf.MaxRetries,
+					Backoff:    fanoutConf.Backoff,
+					MaxBackoff: fanoutConf.MaxBackoff,
+					QueueSize:  fanoutConf.QueueSize,
+				})
+			for _, target := range consumerConf.Fanout {
+				fanout.AddSink(target.Sink, connectSink(target.Sink), target.Commit)
+			}
+			// Registered after the buffered sinks, so the async queues drain into them before they flush
+			shutdown.OnShutdown(lifecycle.Flush, consumerConf.Name+" fan-out", fanout.Close)
+			repo = fanout
+		}
+		processor := newTxProcessor(repo)
+		if consumerConf.Sink == "mongo" {
+			// The primary of the fan-out is txRepo, its writes add ledger entries
+			processor.Ledger = ledger
+		}
+		consumerProcessors[consumerConf.Name] = processor
+	}
+	if chaosInjector != nil {
+		for name, processor := range processors {
+			processors[name] = chaosInjector.Processor(processor)
+		}
+		for name, processor := range consumerProcessors {
+			consumerProcessors[name] = chaosInjector.Processor(processor)
+		}
+	}
+
+	// Trail of the committed offsets, shared by every consumer
+	var auditor kafka.OffsetAuditor
+	if auditConf := prodKonf.Audit; auditConf.Enabled && !prodKonf.DryRun {
+		switch auditConf.Backend {
+		case "mongo":
+			auditRepo := mongodb.NewOffsetAudit(mongoClient, auditConf.Collection)
+			if err := auditRepo.EnsureIndexes(ctx, auditConf.Retention); err != nil {
+				logger.Fatal("cannot create offset audit indexes", zap.Error(err))
+			}
+			auditor = auditRepo
+		case "log":
+			auditor = audit.NewLogAuditor(logger)
+		}
+	}
+
+	var consumers []*kafka.Consumer
+	consumerConfs := prodKonf.Kafka.ConsumerList()
+	hostname, _ := os.Hostname() // Tells the members apart in the group metadata
+	for _, consumerConf := range consumerConfs {
+		processor, ok := processors[consumerConf.Processor]
+		if !ok {
+			logger.Fatal("unknown processor", zap.String("consumer", consumerConf.Name), zap.String("processor", consumerConf.Processor))
+		}
+		if consumerProcessor, ok := consumerProcessors[consumerConf.Name]; ok {
+			processor = consumerProcessor
+		}
+		if retryScheduler != nil {
+			retryScheduler.Processors[consumerConf.Name] = processor
+		}
+		conf := &kafka.ConsumerConfig{
+			Brokers:        brokers,
+			SASL:           kafkaSASL,
+			Consumer:       consumerConf.Name,
+			Name:           consumerConf.Group,
+			Topic:          consumerConf.Topic,
+			RecordsPerPoll: consumerConf.RecordsPerPoll,
+			DryRun:         prodKonf.DryRun,
+			ClientID:       hostname,
+		}
+		consumer, err := kafka.NewTxConsumer(conf, logger.With(zap.String("consumer", consumerConf.Name)), processor, dlqSender, kafkaMetrics, stageMetrics, errorMetrics)
+		if err != nil {
+			logger.Fatal("cannot create consumer", zap.String("consumer", consumerConf.Name), zap.Error(err))
+		}
+		if _, err := waitFor(ctx, logger, prodKonf.Startup, "kafka", func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, consumer.Ping(ctx)
+		}); err != nil {
+			logger.Fatal("cannot reach kafka", zap.String("consumer", consumerConf.Name), zap.Error(err))
+		}
+		consumer.Audit = auditor
+		consumer.SLO = sloMetrics
+		consumer.Heartbeats = heartbeatMetrics
+		sloMetrics.AddFreshness(consumerConf.Name, consumerConf.Topic, consumer.OldestPending)
+		consumers = append(consumers, consumer)
+		if prodKonf.Kafka.Consume {
+			healthHandler.AddLivenessCheck("consumer:"+consumerConf.Name, func() error {
+				return consumer.Alive(healthConf.StallTimeout)
+			})
+		}
+	}
+	if retryScheduler != nil {
+		runSingleton("dlq-retry", retryScheduler.Run)
+	}
+
+	// Per-partition lag of every consumer group, the autoscaler keys on it
+	var lagMetrics *metrics.LagMetrics
+	refreshLag := prodKonf.Kafka.Consume && prodKonf.Metrics.LagInterval > 0
+	if refreshLag {
+		lagMetrics = metrics.NewLagMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	}
+	lagAdmin, err := kafka.NewAdmin(brokers, kafkaSASL)
 	if err != nil {
-		logger.Fatal("cannot poll records from topic", zap.Error(err))
+		logger.Fatal("cannot create kafka admin client", zap.Error(err))
+	}
+	shutdown.Close(lifecycle.Commit, "lag admin", lagAdmin.Close)
+	for idx, consumer := range consumers {
+		consumer.LagMonitor = kafka.NewLagMonitor(lagAdmin.Admin, consumerConfs[idx].Group, consumerConfs[idx].Topic,
+			lagMetrics, logger, prodKonf.Metrics.LagInterval)
+		if refreshLag {
+			go consumer.LagMonitor.Run(ctx)
+		}
+	}
+
+	if adminMux != nil {
+		owners := make(map[string]handlers.OwnershipSource, len(consumers))
+		for idx, consumer := range consumers {
+			owners[consumerConfs[idx].Name] = consumer.LagMonitor
+		}
+		handlers.NewOwnershipHandler(owners).Register(adminMux)
+	}
+
+	throttle := kafka.NewThrottle(prodKonf.Kafka.Throttle, metrics.NewThrottleMetrics(kafkaMetrics.Registry(), metricsNamespace))
+	for _, consumer := range consumers {
+		maintenance.Watch(consumer.SetMaintenance)
+		if activeRegion != nil {
+			activeRegion.Watch(consumer.SetStandby)
+		}
+		consumer.Throttle = throttle
+	}
+
+	// Pause, resume and commit during incidents, adminMux is only set behind the admin token
+	if adminMux != nil {
+		controls := make(map[string]handlers.ConsumerControl, len(consumers))
+		for idx, consumer := range consumers {
+			controls[consumerConfs[idx].Name] = consumer
+		}
+		handlers.NewConsumerHandler(controls).Register(adminMux)
+		handlers.NewMaintenanceHandler(maintenance).Register(adminMux)
+		handlers.NewThrottleHandler(throttle).Register(adminMux)
+	}
+	statsHandler.AddSource("maintenance", func(context.Context) (any, error) {
+		return maintenance.State(), nil
+	})
+	if activeRegion != nil {
+		statsHandler.AddSource("region", func(context.Context) (any, error) {
+			return activeRegion.State(), nil
+		})
+	}
+	statsHandler.AddSource("throttle", func(context.Context) (any, error) {
+		return map[string]float64{"records_per_second": throttle.Rate()}, nil
+	})
+
+	if elector != nil {
+		electorDone := make(chan struct{})
+		go func() {
+			defer close(electorDone)
+			elector.Run(ctx)
+		}()
+		// The lock is released once the jobs stopped, so the next leader takes over right away
+		shutdown.OnShutdown(lifecycle.DrainWorkers, "elector", func(ctx context.Context) error {
+			select {
+			case <-electorDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		statsHandler.AddSource("election", func(context.Context) (any, error) {
+			return map[string]any{"identity": elector.Identity, "leading": elector.Leading()}, nil
+		})
+	}
+
+	statsHandler.AddSource("consumers", func(context.Context) (any, error) {
+		stats := make([]kafka.ConsumerStats, len(consumers))
+		for idx, consumer := range consumers {
+			stats[idx] = consumer.Stats()
+		}
+		return stats, nil
+	})
+	go dumpStateOnSignal(ctx, logger, statsHandler, healthConf.Timeout)
+
+	kafkaHealth := health.NewPinger("kafka", func(ctx context.Context) error {
+		for _, consumer := range consumers {
+			if err := consumer.Ping(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, logger, healthConf.Interval, healthConf.Timeout)
+	go kafkaHealth.Run(ctx)
+	healthHandler.AddCheck("kafka", kafkaHealth.Ready)
+	// Type=notify units only, the watchdog follows the liveness checks
+	go health.NewSystemdNotifier(healthHandler.CheckReady, healthHandler.CheckAlive, logger, time.Second).Run(ctx)
+
+	// Reloadable keys are listed in config.reloadableKeys
+	configuredMaintenance := prodKonf.Maintenance.Mode
+	configuredThrottle := prodKonf.Kafka.Throttle
+	reloader := NewReloader(logger, k, func(conf config.Config) {
+		var level zapcore.Level
+		if err := level.UnmarshalText([]byte(conf.Logger.Level)); err != nil {
+			logger.Warn("invalid log level on reload", zap.String("level", conf.Logger.Level))
+		} else {
+			logLevels.SetBase(level)
+		}
+		// Only consumers without their own records_per_poll follow the default
+		for idx, consumer := range consumers {
+			if len(prodKonf.Kafka.Consumers) == 0 || prodKonf.Kafka.Consumers[idx].RecordsPerPoll == 0 {
+				consumer.SetRecordsPerPoll(conf.Kafka.RecordsPerPoll)
+			}
+		}
+		if dlqBackend.Alerts != nil {
+			dlqBackend.Alerts.SetThreshold(conf.DLQ.Alerts.DepthThreshold)
+		}
+		if featureFlags.Store == nil {
+			featureFlags.Set(NewFeatureFlags(conf.Features))
+		}
+		payloadSampler.Set(NewPayloadRules(conf.Logger.Payloads))
+		if activeRegion != nil {
+			activeRegion.SetConfigured(conf.Region.Active)
+		}
+		if txStages.Risk != nil {
+			if err := txStages.Risk.Set(NewRiskRules(conf.Risk)); err != nil {
+				logger.Warn("invalid risk rules on reload, keeping the previous ones", zap.Error(err))
+			}
+		}
+		// Only changed values apply, so a reload keeps what was set through the admin API
+		if conf.Kafka.Throttle != configuredThrottle {
+			configuredThrottle = conf.Kafka.Throttle
+			throttle.SetRate(conf.Kafka.Throttle)
+		}
+		if conf.Maintenance.Mode != configuredMaintenance {
+			configuredMaintenance = conf.Maintenance.Mode
+			if err := maintenance.Set(conf.Maintenance.Mode, "config"); err != nil {
+				logger.Warn("invalid maintenance mode on reload", zap.Error(err))
+			}
+		}
+	})
+	go reloader.Run(ctx, prodKonf.Reload)
+
+	// Draining hands the partitions over to the other replicas when
+	// configured, on shutdown and on /drain from a preStop hook
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+	drain := func(ctx context.Context) error {
+		drainCtx, cancel := context.WithTimeout(ctx, prodKonf.Shutdown.DrainTimeout)
+		defer cancel()
+		drainGroup, drainGroupCtx := errgroup.WithContext(drainCtx)
+		for idx, consumer := range consumers {
+			name := consumerConfs[idx].Name
+			drainGroup.Go(func() error {
+				if err := consumer.Drain(drainGroupCtx); err != nil {
+					return fmt.Errorf("consumer %s: %v", name, err)
+				}
+				return nil
+			})
+		}
+		return drainGroup.Wait()
+	}
+	if adminMux != nil && prodKonf.Shutdown.Drain {
+		handlers.NewDrainHandler(drain).Register(adminMux)
+	}
+	shutdown.OnShutdown(lifecycle.StopFetching, "consumers", func(ctx context.Context) error {
+		defer stopPolling()
+		if prodKonf.Shutdown.Drain {
+			return drain(ctx)
+		}
+		return nil
+	})
+
+	// A consumer that stops with an error stops the others too
+	restartConf := prodKonf.Kafka.Restart
+	supervisorMetrics := metrics.NewSupervisorMetrics(kafkaMetrics.Registry(), metricsNamespace)
+	group, groupCtx := errgroup.WithContext(pollCtx)
+	for idx, consumer := range consumers {
+		name := consumerConfs[idx].Name
+		supervisor := kafka.NewSupervisor(consumer, &kafka.SupervisorConfig{
+			MaxRestarts:    restartConf.MaxRestarts,
+			Window:         restartConf.Window,
+			InitialBackoff: restartConf.InitialBackoff,
+			MaxBackoff:     restartConf.MaxBackoff,
+		}, logger.With(zap.String("consumer", name)), supervisorMetrics)
+		group.Go(func() error {
+			defer reporting.Recover()
+			// Stopping the poll loops on shutdown is no failure
+			if err := supervisor.Run(groupCtx, prodKonf.Kafka.Consume); err != nil && pollCtx.Err() == nil {
+				return fmt.Errorf("consumer %s: %v", name, err)
+			}
+			return nil
+		})
+	}
+	var pollErr error
+	pollDone := make(chan struct{})
+	go func() {
+		pollErr = group.Wait()
+		close(pollDone)
+	}()
+	shutdown.OnShutdown(lifecycle.DrainWorkers, "consumers", func(ctx context.Context) error {
+		select {
+		case <-pollDone:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("poll loops still running: %v", ctx.Err())
+		}
+	})
+	for idx, consumer := range consumers {
+		name := consumerConfs[idx].Name
+		shutdown.OnShutdown(lifecycle.Commit, "consumer "+name, consumer.Close)
+	}
+
+	// Runs until a signal or a consumer failing for good, both shut down in order
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	case <-pollDone:
+		if pollErr != nil {
+			logger.Error("cannot poll records from topic, shutting down", zap.Error(pollErr))
+		} else {
+			logger.Info("poll loops stopped, shutting down")
+		}
+	}
+	stop()
+	shutdownErr := shutdown.Shutdown()
+	if dryRunSummary != nil {
+		printDryRunReport(dryRunSummary.Report())
+	}
+	select {
+	case <-pollDone:
+		if pollErr != nil {
+			os.Exit(1)
+		}
+	default:
+	}
+	if shutdownErr != nil {
+		fmt.Fprintf(os.Stderr, "shutdown incomplete: %v\n", shutdownErr)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+	notify "tx-stream/notify"
+	redis "tx-stream/repositories/redis"
+	notifications "tx-stream/services/notifications"
+
+	// External Packages
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// NewNotifier evaluates the alert rules of the notifications block and sends
+// the alerts to its channels
+func NewNotifier(conf config.Config, redisClient goredis.UniversalClient, logger *zap.Logger,
+	notificationMetrics *metrics.NotificationMetrics) (*notifications.Notifier, error) {
+	notifConf := conf.Notifications
+	channels := make([]*notifications.Channel, len(notifConf.Channels))
+	for idx, channelConf := range notifConf.Channels {
+		channel := &notifications.Channel{
+			Name:  channelConf.Name,
+			Limit: rate.Limit(channelConf.RateLimit / 60),
+			Burst: channelConf.Burst,
+		}
+		switch channelConf.Type {
+		case "slack":
+			slack := notify.NewWebhook(channelConf.URL, notifConf.Timeout)
+			channel.Send = func(ctx context.Context, alert models.Alert) error {
+				return slack.Notify(ctx, notifications.Text(alert))
+			}
+		case "webhook":
+			webhook := notify.NewJSONWebhook(channelConf.URL, channelConf.Secret, notifConf.Timeout)
+			channel.Send = func(ctx context.Context, alert models.Alert) error {
+				return webhook.Post(ctx, alert)
+			}
+		case "email":
+			email := notify.NewEmail(channelConf.SMTPAddr, channelConf.From, channelConf.To,
+				channelConf.Username, channelConf.Password, notifConf.Timeout)
+			channel.Send = func(ctx context.Context, alert models.Alert) error {
+				return email.Notify(ctx, notifications.Text(alert))
+			}
+		default:
+			return nil, fmt.Errorf("unknown notification channel type %q", channelConf.Type)
+		}
+		channels[idx] = channel
+	}
+
+	rules := make([]notifications.Rule, len(notifConf.Rules))
+	for idx, rule := range notifConf.Rules {
+		severity := rule.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		rules[idx] = notifications.Rule{
+			Name:        rule.Name,
+			Type:        rule.Type,
+			Severity:    severity,
+			MinAmount:   rule.MinAmount,
+			Currencies:  rule.Currencies,
+			Count:       rule.Count,
+			Window:      rule.Window,
+			By:          rule.VelocityField(),
+			Countries:   rule.Countries,
+			DedupBy:     rule.DedupField(),
+			DedupWindow: rule.DedupWindow,
+			Channels:    rule.Channels,
+		}
+	}
+	return notifications.NewNotifier(rules, channels, redis.NewAlertStore(redisClient, notifConf.Prefix), logger,
+		notificationMetrics, conf.Application, notifConf.QueueSize, notifConf.Timeout)
+}
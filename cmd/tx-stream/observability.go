@@ -0,0 +1,82 @@
+package main
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	observability "tx-stream/observability"
+	outbox "tx-stream/outbox"
+	version "tx-stream/version"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/twmb/franz-go/plugin/kprom"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	observabilityCmd = kingpin.Command("observability", "Generate monitoring artifacts")
+
+	observabilityExportCmd = observabilityCmd.Command("export", "Write a Grafana dashboard and Prometheus alert rules for the metrics registered by this binary")
+	observabilityExportDir = observabilityExportCmd.Flag("output-dir", "Directory to write tx-stream-dashboard.json and tx-stream-alerts.yml to").Default(".").ExistingDir()
+	observabilityExportLag = observabilityExportCmd.Flag("lag-threshold", "Consumer lag to alert on, 0 leaves the alert out").Default("10000").Int64()
+)
+
+// describeMetrics registers every metric set run creates, keep it in step with run
+func describeMetrics(reg prometheus.Registerer) {
+	// The kafka client metrics are created when a client is, the nil client is never read while describing
+	kprom.NewMetrics(metricsNamespace, kprom.Registerer(reg)).OnNewClient(nil)
+	metrics.NewDLQMetrics(reg, metricsNamespace)
+	metrics.NewBuildInfo(reg, metricsNamespace, version.Get())
+	metrics.NewStageMetrics(reg, metricsNamespace)
+	metrics.NewErrorMetrics(reg, metricsNamespace)
+	metrics.NewSLOMetrics(reg, metricsNamespace, 0, 0)
+	metrics.NewLagMetrics(reg, metricsNamespace)
+	metrics.NewHeartbeatMetrics(reg, metricsNamespace)
+	metrics.NewShadowMetrics(reg, metricsNamespace)
+	metrics.NewFanoutMetrics(reg, metricsNamespace)
+	metrics.NewTailMetrics(reg, metricsNamespace)
+	metrics.NewAggregationMetrics(reg, metricsNamespace)
+	metrics.NewSchedulerMetrics(reg, metricsNamespace)
+	metrics.NewNotificationMetrics(reg, metricsNamespace)
+	metrics.NewRiskMetrics(reg, metricsNamespace)
+	metrics.NewFXMetrics(reg, metricsNamespace)
+	metrics.NewRegionMetrics(reg, metricsNamespace)
+	metrics.NewClaimCheckMetrics(reg, metricsNamespace)
+	metrics.NewIdempotencyMetrics(reg, metricsNamespace)
+	outbox.NewMetrics(reg, metricsNamespace)
+	metrics.NewThrottleMetrics(reg, metricsNamespace)
+	metrics.NewSupervisorMetrics(reg, metricsNamespace)
+	metrics.NewChaosMetrics(reg, metricsNamespace)
+	metrics.NewFeatureMetrics(reg, metricsNamespace)
+	metrics.NewRedisMetrics(reg, metricsNamespace, nil)
+}
+
+func runObservabilityExport() {
+	_, conf := MustLoadConfig()
+	catalog := observability.NewCatalog()
+	describeMetrics(catalog)
+
+	dashboard, err := json.MarshalIndent(observability.Dashboard(conf.Application, metricsNamespace, catalog), "", "  ")
+	kingpin.FatalIfError(err, "cannot encode dashboard")
+	rules, err := yaml.Marshal(observability.Rules(metricsNamespace, catalog, observability.RuleConfig{
+		FreshnessTarget: conf.Metrics.SLO.FreshnessTarget,
+		Objective:       conf.Metrics.SLO.Objective,
+		DLQDepth:        conf.DLQ.Alerts.DepthThreshold,
+		Lag:             *observabilityExportLag,
+		StallTimeout:    conf.Health.StallTimeout,
+	}))
+	kingpin.FatalIfError(err, "cannot encode alert rules")
+
+	for name, content := range map[string][]byte{"tx-stream-dashboard.json": dashboard, "tx-stream-alerts.yml": rules} {
+		path := filepath.Join(*observabilityExportDir, name)
+		kingpin.FatalIfError(os.WriteFile(path, content, 0o644), "cannot write %s", path)
+		fmt.Println(path)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	config "tx-stream/config"
+	kafka "tx-stream/kafka"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	offsetsCmd = kingpin.Command("offsets", "Inspect and change the committed offsets of a consumer group")
+
+	offsetsResetCmd        = offsetsCmd.Command("reset", "Reset the committed offsets of the group of a consumer, preview with --dry-run")
+	offsetsResetConsumer   = offsetsResetCmd.Flag("consumer", "Consumer whose group and topic to reset, defaults to the only consumer").String()
+	offsetsResetTo         = offsetsResetCmd.Flag("to", "earliest, latest, an offset, or an RFC3339 timestamp").Required().String()
+	offsetsResetPartitions = offsetsResetCmd.Flag("partition", "Partition to reset, repeatable, defaults to every partition").Int32List()
+
+	offsetsExportCmd      = offsetsCmd.Command("export", "Write the committed offsets of the group of a consumer to a JSON snapshot")
+	offsetsExportConsumer = offsetsExportCmd.Flag("consumer", "Consumer whose group and topic to export, defaults to the only consumer").String()
+	offsetsExportOutput   = offsetsExportCmd.Flag("output", "File to write the snapshot to, defaults to stdout").Short('o').String()
+
+	offsetsImportCmd   = offsetsCmd.Command("import", "Commit the offsets of a snapshot for its group, preview with --dry-run")
+	offsetsImportFile  = offsetsImportCmd.Arg("file", "Snapshot written by offsets export").Required().ExistingFile()
+	offsetsImportGroup = offsetsImportCmd.Flag("into-group", "Group to commit the offsets for, defaults to the group of the snapshot").String()
+)
+
+// consumerByName returns the consumer of the config with the name, the only
+// one when the name is empty
+func consumerByName(conf config.Config, name string) config.Consumer {
+	consumers := conf.Kafka.ConsumerList()
+	if name == "" {
+		if len(consumers) > 1 {
+			kingpin.Fatalf("%d consumers are configured, pick one with --consumer", len(consumers))
+		}
+		return consumers[0]
+	}
+	for _, consumer := range consumers {
+		if consumer.Name == name {
+			return consumer
+		}
+	}
+	kingpin.Fatalf("no consumer named %s in the config", name)
+	return config.Consumer{}
+}
+
+// parseResetTarget reads earliest, latest, an offset or an RFC3339 timestamp
+func parseResetTarget(to string) (kafka.ResetTarget, error) {
+	switch to {
+	case kafka.ResetEarliest, kafka.ResetLatest:
+		return kafka.ResetTarget{Mode: to}, nil
+	}
+	if offset, err := strconv.ParseInt(to, 10, 64); err == nil {
+		if offset < 0 {
+			return kafka.ResetTarget{}, fmt.Errorf("offset %d cannot be negative", offset)
+		}
+		return kafka.ResetTarget{Mode: kafka.ResetOffset, Offset: offset}, nil
+	}
+	at, err := time.Parse(time.RFC3339, to)
+	if err != nil {
+		return kafka.ResetTarget{}, fmt.Errorf("%q is not earliest, latest, an offset or an RFC3339 timestamp", to)
+	}
+	return kafka.ResetTarget{Mode: kafka.ResetTimestamp, Timestamp: at}, nil
+}
+
+func runOffsetsReset() {
+	_, conf := MustLoadConfig()
+	consumer := consumerByName(conf, *offsetsResetConsumer)
+	target, err := parseResetTarget(*offsetsResetTo)
+	kingpin.FatalIfError(err, "invalid --to")
+
+	admin, err := kafka.NewAdmin(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	kingpin.FatalIfError(err, "cannot connect to kafka")
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	changes, err := admin.PlanReset(ctx, consumer.Group, consumer.Topic, *offsetsResetPartitions, target)
+	kingpin.FatalIfError(err, "cannot plan the offset reset")
+
+	printOffsetChanges(consumer.Group, consumer.Topic, changes)
+
+	if *dryRunFlag {
+		fmt.Println("dry run, nothing committed")
+		return
+	}
+	kingpin.FatalIfError(admin.ApplyReset(ctx, consumer.Group, consumer.Topic, changes), "cannot reset offsets")
+	fmt.Printf("reset %d partitions\n", len(changes))
+}
+
+// printOffsetChanges prints the current and the target offset of each partition
+func printOffsetChanges(group, topic string, changes []kafka.OffsetChange) {
+	fmt.Printf("group %s, topic %s\n", group, topic)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tCURRENT\tTARGET\tDIFF")
+	for _, change := range changes {
+		current, diff := "-", "-"
+		if change.Current >= 0 {
+			current = strconv.FormatInt(change.Current, 10)
+			diff = fmt.Sprintf("%+d", change.Target-change.Current)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", change.Partition, current, change.Target, diff)
+	}
+	_ = w.Flush()
+}
+
+func runOffsetsExport() {
+	_, conf := MustLoadConfig()
+	consumer := consumerByName(conf, *offsetsExportConsumer)
+
+	admin, err := kafka.NewAdmin(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	kingpin.FatalIfError(err, "cannot connect to kafka")
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	snapshot, err := admin.ExportOffsets(ctx, consumer.Group, consumer.Topic)
+	kingpin.FatalIfError(err, "cannot export offsets")
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	kingpin.FatalIfError(err, "cannot encode the snapshot")
+	data = append(data, '\n')
+	if *offsetsExportOutput == "" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+	kingpin.FatalIfError(os.WriteFile(*offsetsExportOutput, data, 0o644), "cannot write the snapshot")
+	fmt.Printf("exported %d partitions of group %s to %s\n", len(snapshot.Partitions), consumer.Group, *offsetsExportOutput)
+}
+
+func runOffsetsImport() {
+	_, conf := MustLoadConfig()
+	data, err := os.ReadFile(*offsetsImportFile)
+	kingpin.FatalIfError(err, "cannot read the snapshot")
+	var snapshot kafka.OffsetSnapshot
+	kingpin.FatalIfError(json.Unmarshal(data, &snapshot), "cannot decode the snapshot")
+	if snapshot.Topic == "" || len(snapshot.Partitions) == 0 {
+		kingpin.Fatalf("%s has no topic or no partitions", *offsetsImportFile)
+	}
+	group := snapshot.Group
+	if *offsetsImportGroup != "" {
+		group = *offsetsImportGroup
+	}
+
+	admin, err := kafka.NewAdmin(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	kingpin.FatalIfError(err, "cannot connect to kafka")
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	changes, err := admin.PlanImport(ctx, group, snapshot)
+	kingpin.FatalIfError(err, "cannot plan the offset import")
+	fmt.Printf("snapshot of group %s exported at %s\n", snapshot.Group, snapshot.ExportedAt.Format(time.RFC3339))
+	printOffsetChanges(group, snapshot.Topic, changes)
+
+	if *dryRunFlag {
+		fmt.Println("dry run, nothing committed")
+		return
+	}
+	kingpin.FatalIfError(admin.ApplyReset(ctx, group, snapshot.Topic, changes), "cannot import offsets")
+	fmt.Printf("imported %d partitions\n", len(changes))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	metrics "tx-stream/metrics"
+	claimcheck "tx-stream/services/claimcheck"
+	fx "tx-stream/services/fx"
+	risk "tx-stream/services/risk"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// TxStages are the stages of the transactions processor that decide what is
+// stored. The consumers and the replay set the same ones, so a replayed
+// document is stored as the consumers stored it.
+type TxStages struct {
+	Claims *claimcheck.Resolver
+	Risk   *risk.Engine
+	FX     *fx.Converter // Set by StartFX once redis is connected
+}
+
+// NewTxStages creates the stages enabled in the config, their metrics go to reg
+func NewTxStages(ctx context.Context, conf config.Config, logger *zap.Logger, reg prometheus.Registerer) (*TxStages, error) {
+	stages := &TxStages{}
+	if conf.ClaimCheck.Enabled {
+		resolver, err := NewClaimResolver(ctx, conf.ClaimCheck, logger, metrics.NewClaimCheckMetrics(reg, metricsNamespace))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create claim-check resolver: %v", err)
+		}
+		stages.Claims = resolver
+	}
+	if conf.Risk.Enabled {
+		stages.Risk = risk.NewEngine(metrics.NewRiskMetrics(reg, metricsNamespace))
+		if err := stages.Risk.Set(NewRiskRules(conf.Risk)); err != nil {
+			return nil, fmt.Errorf("invalid risk rules: %v", err)
+		}
+		logger.Info("risk rules loaded", zap.Int("rules", len(conf.Risk.Rules)),
+			zap.String("ruleset", stages.Risk.Version()))
+	}
+	return stages, nil
+}
+
+// Apply sets the stages on the processor
+func (s *TxStages) Apply(processor *txsvc.TxProcessor) {
+	processor.Claims = s.Claims
+	processor.Risk = s.Risk
+	processor.FX = s.FX
+}
+
+// StartFX creates the fx converter of the config, fetches the rates and
+// refreshes them until the context is canceled
+func (s *TxStages) StartFX(ctx context.Context, conf config.FX, redisClient goredis.UniversalClient, logger *zap.Logger, reg prometheus.Registerer) {
+	s.FX = NewFXConverter(conf, redisClient, logger, metrics.NewFXMetrics(reg, metricsNamespace))
+	refreshCtx, cancel := context.WithTimeout(ctx, conf.Timeout)
+	if err := s.FX.Refresh(refreshCtx); err != nil {
+		logger.Warn("cannot fetch exchange rates, following on_stale until they are fetched",
+			zap.String("on_stale", conf.OnStale), zap.Error(err))
+	}
+	cancel()
+	go s.FX.Run(ctx)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+	mongodb "tx-stream/repositories/mongodb"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"go.uber.org/zap"
+)
+
+var (
+	reconcileCmd        = kingpin.Command("reconcile", "Compare the records of a topic range with the stored transactions and report missing, duplicate and mismatched documents")
+	reconcileConsumer   = reconcileCmd.Flag("consumer", "Consumer whose topic to reconcile, defaults to the only consumer").String()
+	reconcileFrom       = reconcileCmd.Flag("from", "Reconcile the records produced at or after this RFC3339 time").String()
+	reconcileTo         = reconcileCmd.Flag("to", "Reconcile the records produced before this RFC3339 time, defaults to the end of the topic at start").String()
+	reconcilePartition  = reconcileCmd.Flag("partition", "Reconcile an offset range of this partition instead of a time window").Default("-1").Int32()
+	reconcileStart      = reconcileCmd.Flag("start-offset", "First offset of the --partition range").Int64()
+	reconcileStop       = reconcileCmd.Flag("stop-offset", "Offset after the last one of the --partition range").Int64()
+	reconcileCollection = reconcileCmd.Flag("collection", "Collection the consumer stores the transactions in").Default("transactions").String()
+	reconcileIdle       = reconcileCmd.Flag("idle-timeout", "End the reconciliation when no record arrives for this long").Default("30s").Duration()
+	reconcileShow       = reconcileCmd.Flag("show", "Findings listed per kind, the counts cover all of them").Default("20").Int()
+	reconcileJSON       = reconcileCmd.Flag("json", "Print the full report as JSON").Bool()
+)
+
+func runReconcile() {
+	k, conf := MustLoadConfig()
+	logger := NewLogger(k, conf)
+	consumer := consumerByName(conf, *reconcileConsumer)
+	if consumer.Processor != "transactions" {
+		kingpin.Fatalf("consumer %s uses the %s processor, only transactions can be reconciled", consumer.Name, consumer.Processor)
+	}
+	replayConf := &kafka.ReplayConfig{
+		Brokers:        conf.Kafka.BrokerList(),
+		SASL:           NewKafkaSASL(conf.Kafka),
+		Consumer:       consumer.Name + "-reconcile",
+		Topic:          consumer.Topic,
+		RecordsPerPoll: consumer.RecordsPerPoll,
+		IdleTimeout:    *reconcileIdle,
+	}
+	switch {
+	case *reconcilePartition >= 0:
+		if *reconcileFrom != "" || *reconcileTo != "" {
+			kingpin.Fatalf("--partition and --from/--to are exclusive")
+		}
+		if *reconcileStart < 0 || *reconcileStop <= *reconcileStart {
+			kingpin.Fatalf("--stop-offset must be after --start-offset")
+		}
+		replayConf.Ranges = []kafka.ReplayedPartition{{Partition: *reconcilePartition, Start: *reconcileStart, Stop: *reconcileStop}}
+	case *reconcileFrom != "":
+		from, err := time.Parse(time.RFC3339, *reconcileFrom)
+		kingpin.FatalIfError(err, "invalid --from")
+		replayConf.From = from
+		if *reconcileTo != "" {
+			to, err := time.Parse(time.RFC3339, *reconcileTo)
+			kingpin.FatalIfError(err, "invalid --to")
+			if !to.After(from) {
+				kingpin.Fatalf("--to must be after --from")
+			}
+			replayConf.To = to
+		}
+	default:
+		kingpin.Fatalf("either --from or --partition is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	mongoClient, err := mongodb.Connect(ctx, conf.Mongo.URI)
+	if err != nil {
+		logger.Fatal("cannot create mongo client", zap.Error(err))
+	}
+	defer func() { _ = mongoClient.Disconnect(context.Background()) }()
+	repo := mongodb.NewTxRepository(mongoClient)
+	repo.Collection = *reconcileCollection
+
+	reconciler := txsvc.NewReconciler(repo)
+	partitions, err := kafka.NewReplayer(replayConf, reconciler, logger).Run(ctx)
+	report := reconciler.Report()
+	if *reconcileJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		kingpin.FatalIfError(encoder.Encode(report), "cannot encode the report")
+	} else {
+		printReconcileReport(report, *reconcileShow)
+	}
+	for _, partition := range partitions {
+		if partition.Next < partition.Stop {
+			fmt.Printf("partition %d was only read up to offset %d of %d\n", partition.Partition, partition.Next, partition.Stop)
+		}
+	}
+	kingpin.FatalIfError(err, "reconciliation stopped")
+	if len(report.Missing)+len(report.Mismatched) > 0 {
+		os.Exit(1)
+	}
+}
+
+func printReconcileReport(report txsvc.ReconcileReport, show int) {
+	fmt.Printf("%d records, %d transactions: %d missing, %d duplicated, %d mismatched, %d undecodable\n", report.Records,
+		report.Expected, len(report.Missing), len(report.Duplicates), len(report.Mismatched), len(report.Undecodable))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tTRANSACTION\tPARTITION\tOFFSET\tRECORDS")
+	for _, kind := range []struct {
+		name     string
+		findings []txsvc.ReconcileFinding
+	}{
+		{"missing", report.Missing},
+		{"duplicate", report.Duplicates},
+		{"mismatched", report.Mismatched},
+		{"undecodable", report.Undecodable},
+	} {
+		for idx, finding := range kind.findings {
+			if idx == show {
+				fmt.Fprintf(w, "%s\t... %d more\t\t\t\n", kind.name, len(kind.findings)-show)
+				break
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", kind.name, finding.TxID, finding.Partition, finding.Offset, finding.Records)
+		}
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	// Local Packages
+	config "tx-stream/config"
+
+	// External Packages
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/file"
+	"go.uber.org/zap"
+)
+
+// Reloader re-reads the configuration on SIGHUP, or when the config files or
+// the remote keys change if watching them is enabled, and hands the reloadable keys to Apply.
+// A reload that fails to load or validate keeps the running config.
+type Reloader struct {
+	Logger  *zap.Logger
+	Current *koanf.Koanf
+	Apply   func(conf config.Config)
+}
+
+func NewReloader(logger *zap.Logger, current *koanf.Koanf, apply func(conf config.Config)) *Reloader {
+	return &Reloader{Logger: logger, Current: current, Apply: apply}
+}
+
+// Run reloads on every trigger until the context is canceled
+func (r *Reloader) Run(ctx context.Context, conf config.Reload) {
+	triggers := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case triggers <- struct{}{}:
+		default: // A reload is already pending
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	if conf.WatchRemote && remoteSource != nil {
+		go func() {
+			if err := remoteSource.Watch(ctx, trigger); err != nil {
+				r.Logger.Warn("cannot watch remote config, reload with SIGHUP instead", zap.Error(err))
+			}
+		}()
+	}
+
+	if conf.WatchFile {
+		for _, path := range ConfigFiles() {
+			err := file.Provider(path).Watch(func(_ interface{}, err error) {
+				if err != nil {
+					r.Logger.Warn("config file watch failed", zap.String("path", path), zap.Error(err))
+					return
+				}
+				trigger()
+			})
+			if err != nil {
+				r.Logger.Warn("cannot watch config file, reload with SIGHUP instead", zap.String("path", path), zap.Error(err))
+			}
+		}
+		for _, dir := range *configDirs {
+			err := config.DirProvider(dir).Watch(func(_ interface{}, err error) {
+				if err != nil {
+					r.Logger.Warn("config dir watch failed", zap.String("path", dir), zap.Error(err))
+					return
+				}
+				trigger()
+			})
+			if err != nil {
+				r.Logger.Warn("cannot watch config dir, reload with SIGHUP instead", zap.String("path", dir), zap.Error(err))
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload()
+		case <-triggers:
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	next, err := LoadConfig()
+	if err != nil {
+		r.Logger.Error("config reload failed, keeping the running config", zap.Error(err))
+		return
+	}
+	conf := config.Config{}
+	if err := next.Unmarshal("", &conf); err != nil {
+		r.Logger.Error("config reload failed, keeping the running config", zap.Error(err))
+		return
+	}
+	if err := conf.Validate(); err != nil {
+		r.Logger.Error("reloaded config is invalid, keeping the running config", zap.Error(err))
+		return
+	}
+
+	var applied, pending []string
+	for _, key := range config.ChangedKeys(r.Current, next) {
+		if config.IsReloadable(key) {
+			applied = append(applied, key)
+		} else {
+			pending = append(pending, key)
+		}
+	}
+	if len(pending) > 0 {
+		r.Logger.Warn("changed boot-only config keys need a restart", zap.Strings("keys", pending))
+	}
+	if len(applied) == 0 {
+		r.Logger.Info("config reloaded, nothing to apply")
+		return
+	}
+
+	r.Apply(conf)
+	r.Current = next
+	r.Logger.Info("config reloaded", zap.Strings("applied", applied))
+}
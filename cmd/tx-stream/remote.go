@@ -0,0 +1,54 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	remote "tx-stream/remote"
+
+	// External Packages
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+)
+
+// remoteSource is created by the first config load and reused by reloads and the watch
+var remoteSource remote.Source
+
+// loadRemote overrides the loaded config with the keys of the remote store
+func loadRemote(k *koanf.Koanf) error {
+	var conf config.Remote
+	if err := k.Unmarshal("remote", &conf); err != nil || conf.Backend == "" {
+		return err
+	}
+
+	if remoteSource == nil {
+		source, err := remote.NewSource(&remote.Config{
+			Backend:   conf.Backend,
+			Endpoints: conf.Endpoints,
+			Prefix:    conf.Prefix,
+			Token:     conf.Token,
+			Username:  conf.Username,
+			Password:  conf.Password,
+			Timeout:   conf.Timeout,
+		})
+		if err != nil {
+			return err
+		}
+		remoteSource = source
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.Timeout)
+	defer cancel()
+
+	values, err := remoteSource.Read(ctx)
+	if err != nil {
+		return err
+	}
+	if err := k.Load(confmap.Provider(values, "."), nil); err != nil {
+		return fmt.Errorf("failed to load remote config: %v", err)
+	}
+	return nil
+}
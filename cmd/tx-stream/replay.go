@@ -0,0 +1,107 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+	mongodb "tx-stream/repositories/mongodb"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	replayCmd      = kingpin.Command("replay", "Reprocess the records of a time window without committing group offsets, overwriting what they wrote")
+	replayFrom     = replayCmd.Flag("from", "Replay the records produced at or after this RFC3339 time").Required().String()
+	replayTo       = replayCmd.Flag("to", "Replay the records produced before this RFC3339 time, defaults to the end of the topic at start").String()
+	replayConsumer = replayCmd.Flag("consumer", "Consumer whose topic and processor to replay, defaults to the only consumer").String()
+	replayIdle     = replayCmd.Flag("idle-timeout", "End the replay when no record arrives for this long").Default("30s").Duration()
+)
+
+func runReplay() {
+	k, conf := MustLoadConfig()
+	logger := NewLogger(k, conf)
+	consumer := consumerByName(conf, *replayConsumer)
+	if consumer.Processor != "transactions" {
+		kingpin.Fatalf("consumer %s uses the %s processor, only transactions can be replayed", consumer.Name, consumer.Processor)
+	}
+	from, err := time.Parse(time.RFC3339, *replayFrom)
+	kingpin.FatalIfError(err, "invalid --from")
+	var to time.Time
+	if *replayTo != "" {
+		to, err = time.Parse(time.RFC3339, *replayTo)
+		kingpin.FatalIfError(err, "invalid --to")
+		if !to.After(from) {
+			kingpin.Fatalf("--to must be after --from")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The stages of the consumers, so the replay stores the documents they stored
+	registry := prometheus.NewRegistry()
+	stages, err := NewTxStages(ctx, conf, logger, registry)
+	if err != nil {
+		logger.Fatal("cannot create processor stages", zap.Error(err))
+	}
+	if conf.FX.Enabled {
+		redisClient, err := ConnectRedis(ctx, conf)
+		if err != nil {
+			logger.Fatal("cannot create redis client", zap.Error(err))
+		}
+		defer func() { _ = redisClient.Close() }()
+		stages.StartFX(ctx, conf.FX, redisClient, logger, registry)
+	}
+	var txRepo txsvc.TxRepository
+	if conf.DryRun {
+		logger.Warn("dry run, replayed transactions are not written")
+		txRepo = txsvc.NewDryRunTxRepository(logger)
+	} else {
+		mongoClient, err := mongodb.Connect(ctx, conf.Mongo.URI)
+		if err != nil {
+			logger.Fatal("cannot create mongo client", zap.Error(err))
+		}
+		defer func() { _ = mongoClient.Disconnect(context.Background()) }()
+		repo := mongodb.NewTxRepository(mongoClient)
+		repo.Upsert = true
+		txRepo = repo
+	}
+	processor := txsvc.NewTxProcessor(logger, txRepo, nil, nil)
+	stages.Apply(processor)
+
+	replayer := kafka.NewReplayer(&kafka.ReplayConfig{
+		Brokers:        conf.Kafka.BrokerList(),
+		SASL:           NewKafkaSASL(conf.Kafka),
+		Consumer:       consumer.Name + "-replay",
+		Topic:          consumer.Topic,
+		From:           from,
+		To:             to,
+		RecordsPerPoll: consumer.RecordsPerPoll,
+		IdleTimeout:    *replayIdle,
+	}, processor, logger)
+	partitions, err := replayer.Run(ctx)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PARTITION\tSTART\tSTOP\tREPLAYED\tDONE")
+	var total int64
+	for _, partition := range partitions {
+		replayed := partition.Next - partition.Start
+		total += replayed
+		fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%t\n", partition.Partition, partition.Start, partition.Stop, replayed, partition.Next >= partition.Stop)
+	}
+	_ = w.Flush()
+	fmt.Printf("replayed %d offsets of %s\n", total, consumer.Topic)
+	kingpin.FatalIfError(err, "replay stopped")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	config "tx-stream/config"
+	kafka "tx-stream/kafka"
+	metrics "tx-stream/metrics"
+	mongodb "tx-stream/repositories/mongodb"
+	scheduler "tx-stream/scheduler"
+	dlqsvc "tx-stream/services/dlq"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// NewScheduler registers the enabled jobs of the scheduler block, a job whose
+// backend is missing is left out with a warning
+func NewScheduler(conf config.Config, mongoClient *mongo.Client, dlqBackend *DLQBackend, logger *zap.Logger,
+	schedulerMetrics *metrics.SchedulerMetrics) (*scheduler.Scheduler, error) {
+	loc, err := time.LoadLocation(conf.Scheduler.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	sched := scheduler.NewScheduler(logger, schedulerMetrics)
+	add := func(name, spec string, timeout time.Duration, run func(ctx context.Context) error) error {
+		schedule, err := scheduler.Parse(spec, loc)
+		if err != nil {
+			return fmt.Errorf("invalid schedule of %s: %v", name, err)
+		}
+		sched.Add(scheduler.Job{Name: name, Schedule: schedule, Timeout: timeout, Run: run})
+		return nil
+	}
+	jobs := conf.Scheduler.Jobs
+
+	if sweep := jobs.DLQSweep; sweep.Enabled {
+		if dlqBackend.Inspector == nil {
+			logger.Warn("the dlq backend cannot be listed, the dlq sweep is off", zap.String("backend", conf.DLQ.Backend))
+		} else {
+			dlqService := dlqsvc.NewDLQService(logger, dlqBackend.Inspector, dlqBackend.Quarantine, nil)
+			if err := add("dlq-sweep", sweep.Schedule, sweep.Timeout, func(ctx context.Context) error {
+				_, err := dlqService.Sweep(ctx, time.Now().Add(-sweep.MaxAge), sweep.MaxDeletes)
+				return err
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if archive := jobs.Archive; archive.Enabled {
+		source := mongodb.NewTxRepository(mongoClient)
+		if err := add("archive", archive.Schedule, archive.Timeout, func(ctx context.Context) error {
+			// A sink per run, closing it uploads what the run archived
+			sink, err := NewSink(ctx, "s3", conf.Sinks, logger)
+			if err != nil {
+				return err
+			}
+			from, to := lastPeriod(time.Now(), archive.Period)
+			_, err = txsvc.NewArchiver(source, sink, logger, archive.BatchSize).Run(ctx, from, to)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if reconcile := jobs.Reconcile; reconcile.Enabled {
+		var consumers []config.Consumer
+		for _, consumer := range conf.Kafka.ConsumerList() {
+			if consumer.Processor == "transactions" && consumer.Sink == "mongo" {
+				consumers = append(consumers, consumer)
+			}
+		}
+		if len(consumers) == 0 {
+			logger.Warn("no consumer stores transactions in mongo, the reconcile job is off")
+		} else if err := add("reconcile", reconcile.Schedule, reconcile.Timeout, func(ctx context.Context) error {
+			from, to := lastPeriod(time.Now().Add(-reconcile.Delay), reconcile.Period)
+			return reconcilePeriod(ctx, conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka), consumers, mongodb.NewTxRepository(mongoClient),
+				reconcile.IdleTimeout, from, to, logger)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if rollup := jobs.Rollup; rollup.Enabled {
+		results := mongodb.NewWindowResults(mongoClient, conf.Aggregation.Emit.Collection)
+		if err := add("rollup", rollup.Schedule, rollup.Timeout, func(ctx context.Context) error {
+			from, to := lastPeriod(time.Now().Add(-conf.Aggregation.Grace), rollup.Period)
+			if err := results.Rollup(ctx, rollup.Window, from, to, rollup.Collection); err != nil {
+				return fmt.Errorf("failed to roll up the %s window: %v", rollup.Window, err)
+			}
+			logger.Info("rolled up window aggregates", zap.String("window", rollup.Window), zap.Time("from", from), zap.Time("to", to))
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return sched, nil
+}
+
+// lastPeriod is the last whole period ended by now, aligned on UTC
+func lastPeriod(now time.Time, period time.Duration) (time.Time, time.Time) {
+	to := now.UTC().Truncate(period)
+	return to.Add(-period), to
+}
+
+// reconcilePeriod reconciles the records the consumers' topics got within the
+// period and fails when a transaction is missing or mismatched
+func reconcilePeriod(ctx context.Context, brokers []string, sasl *kafka.SASL, consumers []config.Consumer, repo *mongodb.TxRepository,
+	idleTimeout time.Duration, from, to time.Time, logger *zap.Logger) error {
+	var missing, mismatched int
+	for _, consumer := range consumers {
+		reconciler := txsvc.NewReconciler(repo)
+		_, err := kafka.NewReplayer(&kafka.ReplayConfig{
+			Brokers:        brokers,
+			SASL:           sasl,
+			Consumer:       consumer.Name + "-reconcile",
+			Topic:          consumer.Topic,
+			From:           from,
+			To:             to,
+			RecordsPerPoll: consumer.RecordsPerPoll,
+			IdleTimeout:    idleTimeout,
+		}, reconciler, logger).Run(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile %s: %v", consumer.Name, err)
+		}
+		report := reconciler.Report()
+		logger.Info("reconciled transactions", zap.String("consumer", consumer.Name), zap.Time("from", from), zap.Time("to", to),
+			zap.Int64("records", report.Records), zap.Int64("transactions", report.Expected),
+			zap.Int("missing", len(report.Missing)), zap.Int("duplicates", len(report.Duplicates)),
+			zap.Int("mismatched", len(report.Mismatched)), zap.Int("undecodable", len(report.Undecodable)))
+		missing += len(report.Missing)
+		mismatched += len(report.Mismatched)
+	}
+	if missing+mismatched > 0 {
+		return fmt.Errorf("%d transactions are missing and %d mismatched, see the reconcile command", missing, mismatched)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	// Local Packages
+	config "tx-stream/config"
+	secrets "tx-stream/secrets"
+
+	// External Packages
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/rawbytes"
+)
+
+// vaultSecrets, awsSecrets, gcpSecrets and ageSecrets are created by the first config load that needs them and reused by reloads
+var (
+	vaultSecrets *secrets.Vault
+	awsSecrets   *secrets.AWSResolver
+	gcpSecrets   *secrets.GCPResolver
+	ageSecrets   *secrets.AgeDecrypter
+)
+
+// loadConfigFile loads a yaml config file, decrypting it first when sops encrypted it
+func loadConfigFile(k *koanf.Koanf, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if secrets.IsSOPSFile(data) {
+		if data, err = secrets.DecryptSOPSFile(path); err != nil {
+			return err
+		}
+	}
+	return k.Load(rawbytes.Provider(data), yaml.Parser())
+}
+
+// decryptValues replaces every enc: config value with its age decrypted
+// plaintext, which is masked like a secret field from then on
+func decryptValues(k *koanf.Koanf) error {
+	encrypted := make(map[string]string)
+	for key, value := range k.All() {
+		if str, ok := value.(string); ok && secrets.IsAgeValue(str) {
+			encrypted[key] = str
+		}
+	}
+	if len(encrypted) == 0 {
+		return nil
+	}
+
+	if ageSecrets == nil {
+		decrypter, err := secrets.NewAgeDecrypter(k.String("encryption.age_key_file"))
+		if err != nil {
+			return err
+		}
+		ageSecrets = decrypter
+	}
+
+	for key, value := range encrypted {
+		plaintext, err := ageSecrets.Decrypt(value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %v", key, err)
+		}
+		if err := k.Set(key, plaintext); err != nil {
+			return err
+		}
+		config.MarkSecret(key)
+	}
+	return nil
+}
+
+// loadVault overrides the loaded config with the keys of the vault secret,
+// which are masked like secret fields from then on
+func loadVault(k *koanf.Koanf) error {
+	var conf config.Vault
+	if err := k.Unmarshal("vault", &conf); err != nil || !conf.Enabled {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if vaultSecrets == nil {
+		v, err := secrets.NewVault(ctx, &secrets.VaultConfig{
+			Address:      conf.Address,
+			AuthMethod:   conf.AuthMethod,
+			AuthMount:    conf.AuthMount,
+			Token:        conf.Token,
+			RoleID:       conf.RoleID,
+			SecretIDFile: conf.SecretIDFile,
+			Role:         conf.Role,
+			JWTFile:      conf.JWTFile,
+			Mount:        conf.Mount,
+			Path:         conf.Path,
+		})
+		if err != nil {
+			return err
+		}
+		vaultSecrets = v
+	}
+
+	values, err := vaultSecrets.Read(ctx)
+	if err != nil {
+		return err
+	}
+	if err := k.Load(confmap.Provider(values, "."), nil); err != nil {
+		return fmt.Errorf("failed to load vault secrets: %v", err)
+	}
+	flat, _ := maps.Flatten(values, nil, ".")
+	for key := range flat {
+		config.MarkSecret(key)
+	}
+	return nil
+}
+
+// resolveAWS replaces every aws-sm:// and aws-ssm:// config value with the
+// secret or parameter it refers to, which is masked like a secret field from then on
+func resolveAWS(k *koanf.Koanf) error {
+	refs := collectRefs(k, secrets.IsAWSRef)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if awsSecrets == nil {
+		resolver, err := secrets.NewAWSResolver(ctx, k.String("aws.region"))
+		if err != nil {
+			return err
+		}
+		awsSecrets = resolver
+	}
+
+	for key, ref := range refs {
+		value, err := awsSecrets.Resolve(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %v", key, err)
+		}
+		if err := k.Set(key, value); err != nil {
+			return err
+		}
+		config.MarkSecret(key)
+	}
+	return nil
+}
+
+// resolveGCP replaces every gcp-sm:// config value with the secret it refers
+// to, which is masked like a secret field from then on
+func resolveGCP(k *koanf.Koanf) error {
+	refs := collectRefs(k, secrets.IsGCPRef)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if gcpSecrets == nil {
+		resolver, err := secrets.NewGCPResolver(ctx, k.String("gcp.project"))
+		if err != nil {
+			return err
+		}
+		gcpSecrets = resolver
+	}
+
+	for key, ref := range refs {
+		value, err := gcpSecrets.Resolve(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %v", key, err)
+		}
+		if err := k.Set(key, value); err != nil {
+			return err
+		}
+		config.MarkSecret(key)
+	}
+	return nil
+}
+
+// collectRefs returns the config values the predicate matches, by key
+func collectRefs(k *koanf.Koanf, isRef func(value string) bool) map[string]string {
+	refs := make(map[string]string)
+	for key, value := range k.All() {
+		if ref, ok := value.(string); ok && isRef(ref) {
+			refs[key] = ref
+		}
+	}
+	return refs
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	config "tx-stream/config"
+	clickhouse "tx-stream/repositories/clickhouse"
+	elasticsearch "tx-stream/repositories/elasticsearch"
+	postgres "tx-stream/repositories/postgres"
+	s3 "tx-stream/repositories/s3"
+	webhook "tx-stream/repositories/webhook"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// NewSink connects to the sink a consumer names, mongo is not one of them as
+// it is the primary repository
+func NewSink(ctx context.Context, name string, conf config.Sinks, logger *zap.Logger) (txsvc.Sink, error) {
+	switch name {
+	case "postgres":
+		pool, err := postgres.Connect(ctx, conf.Postgres.URI, conf.Postgres.MaxConns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to postgres: %v", err)
+		}
+		repo := postgres.NewTxRepository(pool, conf.Postgres.Table, conf.Postgres.BatchSize)
+		if err := repo.EnsureSchema(ctx); err != nil {
+			pool.Close()
+			return nil, err
+		}
+		logger.Info("postgres sink connected", zap.String("table", conf.Postgres.Table))
+		return repo, nil
+	case "elasticsearch":
+		es := conf.Elasticsearch
+		repo := elasticsearch.NewTxRepository(&elasticsearch.Config{
+			Addresses:    es.Addresses,
+			Username:     es.Username,
+			Password:     es.Password,
+			APIKey:       es.APIKey,
+			Index:        es.Index,
+			Template:     es.Template,
+			MaxRetries:   es.MaxRetries,
+			RetryBackoff: es.RetryBackoff,
+			Timeout:      es.Timeout,
+		})
+		if err := repo.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to elasticsearch: %v", err)
+		}
+		if err := repo.EnsureTemplate(ctx); err != nil {
+			return nil, err
+		}
+		logger.Info("elasticsearch sink connected", zap.String("index", es.Index))
+		return repo, nil
+	case "s3":
+		archive := conf.S3
+		client, err := s3.Connect(ctx, archive.Region, archive.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create s3 client: %v", err)
+		}
+		logger.Info("s3 archive sink ready", zap.String("bucket", archive.Bucket), zap.String("format", archive.Format))
+		return s3.NewTxArchive(client, &s3.ArchiveConfig{
+			Bucket:        archive.Bucket,
+			Prefix:        archive.Prefix,
+			Format:        archive.Format,
+			Partition:     archive.Partition,
+			MaxRecords:    archive.MaxRecords,
+			MaxBytes:      archive.MaxBytes,
+			FlushInterval: archive.FlushInterval,
+		}, logger), nil
+	case "webhook":
+		var endpoints []webhook.Endpoint
+		for _, endpoint := range conf.Webhook.EndpointList() {
+			endpoints = append(endpoints, webhook.Endpoint{
+				Name:       endpoint.Name,
+				URL:        endpoint.URL,
+				Secret:     endpoint.Secret,
+				MaxRetries: *endpoint.MaxRetries,
+				Backoff:    endpoint.Backoff,
+				MaxBackoff: endpoint.MaxBackoff,
+			})
+		}
+		logger.Info("webhook sink ready", zap.Int("endpoints", len(endpoints)))
+		return webhook.NewTxSink(endpoints, conf.Webhook.BatchSize, conf.Webhook.DeadLetterPath, conf.Webhook.Timeout, logger), nil
+	case "clickhouse":
+		ch := conf.ClickHouse
+		repo := clickhouse.NewTxRepository(&clickhouse.Config{
+			URL:           ch.URL,
+			Database:      ch.Database,
+			Table:         ch.Table,
+			Username:      ch.Username,
+			Password:      ch.Password,
+			BatchSize:     ch.BatchSize,
+			FlushInterval: ch.FlushInterval,
+			Timeout:       ch.Timeout,
+		}, logger)
+		if err := repo.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("failed to connect to clickhouse: %v", err)
+		}
+		if err := repo.EnsureSchema(ctx); err != nil {
+			return nil, err
+		}
+		logger.Info("clickhouse sink connected", zap.String("table", ch.Database+"."+ch.Table))
+		return repo, nil
+	default:
+		return nil, fmt.Errorf("unknown sink %q", name)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	// Local Packages
+	config "tx-stream/config"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// waitFor calls connect until it succeeds, retrying with a jittered
+// exponential backoff until the startup wait runs out or ctx is done
+func waitFor[T any](ctx context.Context, logger *zap.Logger, conf config.Startup, dependency string, connect func(ctx context.Context) (T, error)) (T, error) {
+	deadline := time.Now().Add(conf.MaxWait)
+	backoff := conf.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		value, err := connect(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("dependency is up", zap.String("dependency", dependency), zap.Int("attempts", attempt))
+			}
+			return value, nil
+		}
+		delay := backoff/2 + rand.N(backoff/2+1)
+		if time.Now().Add(delay).After(deadline) {
+			return value, fmt.Errorf("%s not ready after %d attempts: %v", dependency, attempt, err)
+		}
+		logger.Warn("dependency not ready, retrying", zap.String("dependency", dependency),
+			zap.Int("attempt", attempt), zap.Duration("retry_in", delay), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return value, fmt.Errorf("%s not ready: %v", dependency, ctx.Err())
+		case <-time.After(delay):
+		}
+		backoff = min(2*backoff, conf.MaxBackoff)
+	}
+}
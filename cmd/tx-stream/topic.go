@@ -0,0 +1,88 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	topicCmd = kingpin.Command("topic", "Diagnose the consumed topics with the configured brokers")
+
+	topicDescribeCmd  = topicCmd.Command("describe", "Print the partitions, leaders and watermarks of a topic and the offsets of the configured groups")
+	topicDescribeName = topicDescribeCmd.Arg("topic", "Topic to describe, defaults to the topic of the only consumer").String()
+)
+
+func runTopicDescribe() {
+	_, conf := MustLoadConfig()
+	topic := *topicDescribeName
+	if topic == "" {
+		topic = consumerByName(conf, "").Topic
+	}
+	var groups []string
+	for _, consumer := range conf.Kafka.ConsumerList() {
+		if consumer.Topic == topic {
+			groups = append(groups, consumer.Group)
+		}
+	}
+
+	admin, err := kafka.NewAdmin(conf.Kafka.BrokerList(), NewKafkaSASL(conf.Kafka))
+	kingpin.FatalIfError(err, "cannot connect to kafka")
+	defer admin.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	description, err := admin.DescribeTopic(ctx, topic, groups)
+	kingpin.FatalIfError(err, "cannot describe topic %s", topic)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BROKER\tHOST")
+	for _, broker := range description.Brokers {
+		fmt.Fprintf(w, "%d\t%s:%d\n", broker.NodeID, broker.Host, broker.Port)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "TOPIC %s\n", description.Topic)
+	fmt.Fprintln(w, "PARTITION\tLEADER\tREPLICAS\tISR\tLOW\tHIGH\tERROR")
+	for _, partition := range description.Partitions {
+		partitionErr := ""
+		if partition.Err != nil {
+			partitionErr = partition.Err.Error()
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\t%s\n", partition.Partition, partition.Leader,
+			joinInt32s(partition.Replicas), joinInt32s(partition.ISR), partition.Low, partition.High, partitionErr)
+	}
+	for _, group := range groups {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "GROUP %s\n", group)
+		fmt.Fprintln(w, "PARTITION\tCOMMITTED\tEND\tLAG")
+		var total int64
+		for _, lag := range description.Groups[group] {
+			committed := "-"
+			if lag.Committed >= 0 {
+				committed = fmt.Sprint(lag.Committed)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%d\n", lag.Partition, committed, lag.End, lag.Lag)
+			total += lag.Lag
+		}
+		fmt.Fprintf(w, "total\t\t\t%d\n", total)
+	}
+	_ = w.Flush()
+}
+
+func joinInt32s(values []int32) string {
+	parts := make([]string, len(values))
+	for idx, value := range values {
+		parts[idx] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, ",")
+}
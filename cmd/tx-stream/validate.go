@@ -0,0 +1,96 @@
+package main
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+
+	// Local Packages
+	config "tx-stream/config"
+	mongodb "tx-stream/repositories/mongodb"
+
+	// External Packages
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var (
+	validateCmd     = kingpin.Command("validate", "Load and validate the configuration, exiting non-zero when it is invalid")
+	validateFile    = validateCmd.Arg("file", "Config file to validate, defaults to the config flag").String()
+	validateConnect = validateCmd.Flag("connect", "Also check that Kafka, Mongo and Redis are reachable").Bool()
+	validateTimeout = validateCmd.Flag("timeout", "Timeout of each connectivity check").Default("10s").Duration()
+)
+
+func runValidate() {
+	if *validateFile != "" {
+		*configPath = *validateFile
+		if _, err := os.Stat(*validateFile); err != nil {
+			kingpin.Fatalf("cannot read config file: %v", err)
+		}
+	}
+
+	k, err := LoadConfig()
+	kingpin.FatalIfError(err, "cannot load config")
+	conf := config.Config{}
+	kingpin.FatalIfError(k.Unmarshal("", &conf), "cannot decode config")
+	if err := conf.Validate(); err != nil {
+		kingpin.Fatalf("invalid config: %v", err)
+	}
+	fmt.Println("config is valid")
+
+	if !*validateConnect {
+		return
+	}
+	failed := false
+	for _, check := range connectivityChecks(conf) {
+		ctx, cancel := context.WithTimeout(context.Background(), *validateTimeout)
+		err := check.run(ctx)
+		cancel()
+		if err != nil {
+			failed = true
+			fmt.Printf("%s: %v\n", check.name, err)
+			continue
+		}
+		fmt.Printf("%s: ok\n", check.name)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+type connectivityCheck struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// connectivityChecks returns a check for every dependency the config uses
+func connectivityChecks(conf config.Config) []connectivityCheck {
+	checks := []connectivityCheck{
+		{name: "kafka", run: func(ctx context.Context) error {
+			client, err := kgo.NewClient(append(NewKafkaSASL(conf.Kafka).Opts(), kgo.SeedBrokers(conf.Kafka.BrokerList()...))...)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.Ping(ctx)
+		}},
+		{name: "mongo", run: func(ctx context.Context) error {
+			client, err := mongodb.Connect(ctx, conf.Mongo.URI)
+			if err != nil {
+				return err
+			}
+			return client.Disconnect(context.Background())
+		}},
+	}
+	if conf.DLQ.Backend == "redis" || conf.DLQ.Retry.Enabled || conf.Features.Backend == "redis" {
+		checks = append(checks, connectivityCheck{name: "redis", run: func(ctx context.Context) error {
+			client, err := ConnectRedis(ctx, conf)
+			if err != nil {
+				return err
+			}
+			return client.Close()
+		}})
+	}
+	return checks
+}
@@ -1,8 +1,9 @@
 package config
 
 import (
-	// Local Packages
-	errors "tx-stream/errors"
+	// Go Internal Packages
+	"strings"
+	"time"
 )
 
 var DefaultConfig = []byte(`
@@ -10,73 +11,1270 @@ application: "tx-consumer"
 
 logger:
   level: "info"
+  toggle_ttl: "15m"
+  otlp:
+    enabled: false
+    endpoint: ""
+    insecure: true
+    queue_size: 2048
+    batch_size: 512
+    export_interval: 1s
+  file:
+    enabled: false
+    path: "/var/log/tx-stream/tx-stream.log"
+    max_size_mb: 100
+    max_age: 168h
+    max_backups: 10
+    compress: true
+  payloads:
+    enabled: false
+    sample_every: 1000
+    match: {}
+    redact_fields: ["card_number", "ip_address", "bank_name"]
 
 is_prod_mode: false
 
+dry_run: false
+
+strict: false
+
 mongo:
   uri: "mongodb://localhost:27017"
 
+sinks:
+  postgres:
+    uri: ""
+    table: "transactions"
+    batch_size: 500
+    max_conns: 4
+  elasticsearch:
+    addresses: ["http://localhost:9200"]
+    username: ""
+    password: ""
+    api_key: ""
+    index: "transactions"
+    template: "transactions"
+    max_retries: 5
+    retry_backoff: 500ms
+    timeout: 10s
+  s3:
+    bucket: ""
+    prefix: "archive"
+    region: "us-east-1"
+    endpoint: ""
+    format: "parquet"
+    partition: "hour"
+    max_records: 100000
+    max_bytes: 67108864
+    flush_interval: 5m
+  webhook:
+    endpoints: []
+    batch_size: 100
+    timeout: 10s
+    max_retries: 5
+    backoff: 1s
+    max_backoff: 30s
+    dead_letter_path: "webhook-dlq.ndjson"
+  clickhouse:
+    url: "http://localhost:8123"
+    database: "default"
+    table: "transactions"
+    username: "default"
+    password: ""
+    batch_size: 10000
+    flush_interval: 1s
+    timeout: 10s
+  fanout:
+    max_retries: 3
+    backoff: 500ms
+    max_backoff: 10s
+    queue_size: 100
+    collection: "failed_sink_writes"
+
+shadow:
+  enabled: false
+  uri: ""
+  database: "mybase"
+  collection: "transactions_shadow"
+  timeout: 5s
+
 redis:
+  mode: "standalone"
   uri: "localhost:6379"
+  addrs: []
+  username: ""
   password: ""
+  db: 0
+  master_name: ""
+  sentinel_password: ""
+  tls:
+    enabled: false
+    reload_interval: "1m"
+  health_interval: "10s"
+  health_timeout: "2s"
+
+dlq:
+  backend: "redis"
+  stream_name: "failed-transactions"
+  entry_ttl: "168h"
+  max_length: 100000
+  overflow_policy: "drop-oldest"
+  trim_interval: "1m"
+  compression:
+    codec: "zstd"
+    min_size: 16384
+  topic: "transactions-dlq"
+  collection: "failed_transactions"
+  file_path: "dlq.ndjson"
+  s3:
+    bucket: ""
+    prefix: "dlq"
+    region: "us-east-1"
+    endpoint: ""
+  quarantine:
+    enabled: true
+    max_replays: 1
+  replay:
+    group: "dlq-replayers"
+    batch_size: 100
+    min_idle: "1m"
+  retry:
+    enabled: false
+    name: "tx-retries"
+    base_delay: "30s"
+    max_delay: "30m"
+    max_attempts: 5
+    batch_size: 50
+    interval: "5s"
+    lease: "5m"
+  alerts:
+    enabled: false
+    webhook_url: ""
+    depth_threshold: 1000
+    quiet_period: "30m"
+    timeout: "5s"
 
 kafka:
   brokers: "localhost:9092"
   consume: true
   topic: "transactions"
   records_per_poll: 50
+  throttle: 0
+  sink: "mongo"
+  fanout: []
   consumer_name: "tx-consumer"
+  consumers: []
+  sasl:
+    mechanism: ""
+    username: ""
+    password: ""
+  restart:
+    max_restarts: 5
+    window: 10m
+    initial_backoff: 1s
+    max_backoff: 30s
+
+admin:
+  enabled: true
+  port: 8081
+  debug: false
+  token: ""
+
+grpc:
+  enabled: false
+  port: 50051
+  token: ""
+  max_page_size: 500
+
+api:
+  enabled: false
+  port: 8082
+  token: ""
+  max_page_size: 500
+  tail:
+    enabled: true
+    max_subscribers: 10
+    buffer_size: 256
+    heartbeat: 15s
+    max_duration: 1h
+    redact_fields: ["card_number", "ip_address", "bank_name"]
+
+tracing:
+  enabled: false
+  endpoint: ""
+  insecure: true
+  sample_ratio: 0.1
+
+chaos:
+  enabled: false
+  processor_error_rate: 0
+  decode_failure_rate: 0
+  mongo_latency_rate: 0
+  mongo_latency: 2s
+  redis_timeout_rate: 0
+
+startup:
+  max_wait: 2m
+  initial_backoff: 1s
+  max_backoff: 15s
+
+maintenance:
+  mode: "off"
+
+shutdown:
+  drain: false
+  drain_timeout: 30s
+  timeouts:
+    stop_fetching: 35s
+    drain_workers: 30s
+    flush: 10s
+    commit: 10s
+    close_stores: 10s
+    stop_servers: 5s
+    telemetry: 5s
+
+aggregation:
+  enabled: false
+  prefix: "agg"
+  group_by: ["merchant_name", "currency"]
+  declined_statuses: ["declined", "failed"]
+  grace: 30s
+  retention: 1h
+  interval: 5s
+  windows:
+    - name: "1m"
+      size: 1m
+  emit:
+    target: "collection"
+    collection: "window_aggregates"
+    topic: "tx-window-aggregates"
+
+scheduler:
+  enabled: false
+  timezone: "UTC"
+  jobs:
+    dlq_sweep:
+      enabled: true
+      schedule: "0 * * * *"
+      timeout: 10m
+      max_age: 720h
+      max_deletes: 10000
+    archive:
+      enabled: false
+      schedule: "15 0 * * *"
+      timeout: 1h
+      period: 24h
+      batch_size: 1000
+    reconcile:
+      enabled: false
+      schedule: "*/30 * * * *"
+      timeout: 20m
+      period: 30m
+      delay: 5m
+      idle_timeout: 30s
+    rollup:
+      enabled: false
+      schedule: "5 0 * * *"
+      timeout: 10m
+      window: "1m"
+      period: 24h
+      collection: "window_rollups"
+
+outbox:
+  enabled: false
+  collection: "outbox"
+  topic: "tx-stored-events"
+  relay:
+    batch_size: 100
+    interval: 1s
+    lease: 30s
+    max_attempts: 20
+    base_delay: 1s
+    max_delay: 5m
+    retention: 72h
+    cleanup_interval: 10m
+
+idempotency:
+  enabled: false
+  collection: "applied_records"
+  retention: 336h
+
+notifications:
+  enabled: false
+  prefix: "notify"
+  queue_size: 1000
+  timeout: 5s
+  channels: []
+  rules: []
+
+risk:
+  enabled: false
+  rules: []
+
+claim_check:
+  enabled: false
+  region: "us-east-1"
+  endpoint: ""
+  buckets: []
+  max_bytes: 16777216
+  timeout: 10s
+  attempts: 3
+  backoff: 200ms
+  concurrency: 8
+  cache_bytes: 67108864
+
+fx:
+  enabled: false
+  base_currency: "USD"
+  url: ""
+  api_key: ""
+  api_key_header: "Authorization"
+  timeout: 5s
+  refresh_interval: 15m
+  max_age: 24h
+  on_stale: "skip"
+  cache_prefix: "fx"
+
+election:
+  enabled: false
+  backend: "kubernetes"
+  name: "tx-stream"
+  namespace: ""
+  identity: ""
+  lease_duration: 15s
+  renew_deadline: 10s
+  retry_period: 2s
+
+region:
+  name: ""
+  active: ""
+  backend: "config"
+  redis_key: "tx-stream:region"
+  interval: 5s
+  failover_after: 0s
+
+audit:
+  enabled: false
+  backend: "mongo"
+  collection: "offset_audit"
+  retention: 720h
+
+sentry:
+  dsn: ""
+  environment: ""
+  sample_rate: 1.0
+
+health:
+  interval: "10s"
+  timeout: "2s"
+  stall_timeout: "5m"
+
+metrics:
+  enabled: true
+  sinks: ["prometheus"]
+  port: 9090
+  path: "/metrics"
+  lag_interval: 30s
+  dogstatsd:
+    address: "127.0.0.1:8125"
+    interval: 10s
+    tags: []
+  slo:
+    freshness_target: 30s
+    objective: 0.999
+
+reload:
+  watch_file: false
+  watch_remote: false
+
+remote:
+  backend: ""
+  endpoints: []
+  prefix: "tx-stream/"
+  token: ""
+  username: ""
+  password: ""
+  timeout: "5s"
+
+aws:
+  region: ""
+
+gcp:
+  project: ""
+
+encryption:
+  age_key_file: ""
+
+features:
+  backend: "config"
+  redis_key: "tx-stream:features"
+  refresh_interval: "30s"
+  flags: {}
+
+vault:
+  enabled: false
+  address: ""
+  auth_method: "token"
+  auth_mount: ""
+  token: ""
+  role_id: ""
+  secret_id_file: ""
+  role: ""
+  jwt_file: "/var/run/secrets/kubernetes.io/serviceaccount/token"
+  mount: "secret"
+  path: "tx-stream"
 `)
 
 type Config struct {
-	Application string `koanf:"application"`
-	Logger      Logger `koanf:"logger"`
-	IsProdMode  bool   `koanf:"is_prod_mode"`
-	Mongo       Mongo  `koanf:"mongo"`
-	Redis       Redis  `koanf:"redis"`
-	Kafka       Kafka  `koanf:"kafka"`
+	Application   string        `koanf:"application"`
+	Logger        Logger        `koanf:"logger"`
+	IsProdMode    bool          `koanf:"is_prod_mode"`
+	DryRun        bool          `koanf:"dry_run"` // Skips every write and offset commit
+	Strict        bool          `koanf:"strict"`  // Fails loading on keys no field reads
+	Mongo         Mongo         `koanf:"mongo"`
+	Shadow        Shadow        `koanf:"shadow"`
+	Sinks         Sinks         `koanf:"sinks"`
+	Redis         Redis         `koanf:"redis"`
+	DLQ           DLQ           `koanf:"dlq"`
+	Kafka         Kafka         `koanf:"kafka"`
+	Admin         Admin         `koanf:"admin"`
+	GRPC          GRPC          `koanf:"grpc"`
+	API           API           `koanf:"api"`
+	Metrics       Metrics       `koanf:"metrics"`
+	Health        Health        `koanf:"health"`
+	Tracing       Tracing       `koanf:"tracing"`
+	Sentry        Sentry        `koanf:"sentry"`
+	Audit         Audit         `koanf:"audit"`
+	Aggregation   Aggregation   `koanf:"aggregation"`
+	Scheduler     Scheduler     `koanf:"scheduler"`
+	Outbox        Outbox        `koanf:"outbox"`
+	Idempotency   Idempotency   `koanf:"idempotency"`
+	Notifications Notifications `koanf:"notifications"`
+	Risk          Risk          `koanf:"risk"`
+	ClaimCheck    ClaimCheck    `koanf:"claim_check"`
+	FX            FX            `koanf:"fx"`
+	Election      Election      `koanf:"election"`
+	Region        Region        `koanf:"region"`
+	Startup       Startup       `koanf:"startup"`
+	Chaos         Chaos         `koanf:"chaos"`
+	Shutdown      Shutdown      `koanf:"shutdown"`
+	Maintenance   Maintenance   `koanf:"maintenance"`
+	Reload        Reload        `koanf:"reload"`
+	Remote        Remote        `koanf:"remote"`
+	Vault         Vault         `koanf:"vault"`
+	AWS           AWS           `koanf:"aws"`
+	GCP           GCP           `koanf:"gcp"`
+	Encryption    Encryption    `koanf:"encryption"`
+	Features      Features      `koanf:"features"`
 }
 
 type Logger struct {
-	Level string `koanf:"level"`
+	Level     string        `koanf:"level"`
+	ToggleTTL time.Duration `koanf:"toggle_ttl"` // How long SIGUSR2 turns on debug for, zero until the next SIGUSR2
+	OTLP      LogExport     `koanf:"otlp"`
+	File      LogFile       `koanf:"file"`
+	Payloads  Payloads      `koanf:"payloads"`
+}
+
+// LogFile writes the logs to a rotated file next to stdout, for hosts without a log collector
+type LogFile struct {
+	Enabled    bool          `koanf:"enabled"`
+	Path       string        `koanf:"path"`
+	MaxSizeMB  int           `koanf:"max_size_mb"` // Size at which the file is rotated
+	MaxAge     time.Duration `koanf:"max_age"`     // Rotated files older than this are removed, in whole days, 0 keeps them
+	MaxBackups int           `koanf:"max_backups"` // Rotated files kept, 0 keeps them all
+	Compress   bool          `koanf:"compress"`
+}
+
+// Payloads logs the redacted payload of 1 in SampleEvery records and of every
+// record whose top-level fields have the Match values, reloadable as a whole
+type Payloads struct {
+	Enabled      bool              `koanf:"enabled"`
+	SampleEvery  int               `koanf:"sample_every"` // 0 logs only the matching records
+	Match        map[string]string `koanf:"match"`
+	RedactFields []string          `koanf:"redact_fields"`
+}
+
+// LogExport ships the logs to an OTLP collector next to stdout
+type LogExport struct {
+	Enabled        bool          `koanf:"enabled"`
+	Endpoint       string        `koanf:"endpoint"` // host:port, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure       bool          `koanf:"insecure"`
+	QueueSize      int           `koanf:"queue_size"` // Records held while the collector is slow, the oldest are dropped past it
+	BatchSize      int           `koanf:"batch_size"`
+	ExportInterval time.Duration `koanf:"export_interval"`
 }
 
 type Mongo struct {
 	URI string `koanf:"uri"`
 }
 
+// Sinks are the destinations besides the mongo collection that consumers
+// can land their transactions in, a sink connects only when a consumer names it
+type Sinks struct {
+	Postgres      PostgresSink      `koanf:"postgres"`
+	Elasticsearch ElasticsearchSink `koanf:"elasticsearch"`
+	S3            ArchiveSink       `koanf:"s3"`
+	Webhook       WebhookSink       `koanf:"webhook"`
+	ClickHouse    ClickHouseSink    `koanf:"clickhouse"`
+	Fanout        Fanout            `koanf:"fanout"`
+}
+
+// Fanout is the retry policy of the sinks consumers fan out to, each sink
+// retries on its own and then dead-letters to the collection
+type Fanout struct {
+	MaxRetries int           `koanf:"max_retries"`
+	Backoff    time.Duration `koanf:"backoff"`
+	MaxBackoff time.Duration `koanf:"max_backoff"`
+	QueueSize  int           `koanf:"queue_size"` // Batches an async sink holds before dead-lettering
+	Collection string        `koanf:"collection"`
+}
+
+// FanoutSink is a sink a consumer writes to besides its own, commit tells
+// whether the offsets wait for it: wait or async
+type FanoutSink struct {
+	Sink   string `koanf:"sink"`
+	Commit string `koanf:"commit"`
+}
+
+// PostgresSink upserts the transactions into a table, created when missing
+type PostgresSink struct {
+	URI       string `koanf:"uri" secret:"true"`
+	Table     string `koanf:"table"`
+	BatchSize int    `koanf:"batch_size"` // Rows per insert statement
+	MaxConns  int32  `koanf:"max_conns"`
+}
+
+// ElasticsearchSink indexes the transactions into Elasticsearch or OpenSearch
+// for full text search, the index template is put at startup
+type ElasticsearchSink struct {
+	Addresses    []string      `koanf:"addresses"`
+	Username     string        `koanf:"username"`
+	Password     string        `koanf:"password" secret:"true"`
+	APIKey       string        `koanf:"api_key" secret:"true"` // Used instead of username and password when set
+	Index        string        `koanf:"index"`
+	Template     string        `koanf:"template"`
+	MaxRetries   int           `koanf:"max_retries"` // Resends of the documents rejected with 429
+	RetryBackoff time.Duration `koanf:"retry_backoff"`
+	Timeout      time.Duration `koanf:"timeout"`
+}
+
+// ArchiveSink writes the transactions to a bucket as hive partitioned files
+// for the data team, see s3.TxArchive
+type ArchiveSink struct {
+	Bucket        string        `koanf:"bucket"`
+	Prefix        string        `koanf:"prefix"`
+	Region        string        `koanf:"region"`
+	Endpoint      string        `koanf:"endpoint"`  // S3 compatible store, https://storage.googleapis.com for GCS
+	Format        string        `koanf:"format"`    // parquet or ndjson
+	Partition     string        `koanf:"partition"` // hour or day
+	MaxRecords    int           `koanf:"max_records"`
+	MaxBytes      int           `koanf:"max_bytes"` // Uncompressed size a file is uploaded at
+	FlushInterval time.Duration `koanf:"flush_interval"`
+}
+
+// WebhookSink posts the transactions to partner endpoints, the retry policy
+// applies to the endpoints that don't set their own
+type WebhookSink struct {
+	Endpoints      []WebhookEndpoint `koanf:"endpoints"`
+	BatchSize      int               `koanf:"batch_size"` // Transactions per request
+	Timeout        time.Duration     `koanf:"timeout"`
+	MaxRetries     int               `koanf:"max_retries"`
+	Backoff        time.Duration     `koanf:"backoff"`
+	MaxBackoff     time.Duration     `koanf:"max_backoff"`
+	DeadLetterPath string            `koanf:"dead_letter_path"` // NDJSON file of the deliveries an endpoint refused
+}
+
+type WebhookEndpoint struct {
+	Name       string        `koanf:"name"`
+	URL        string        `koanf:"url"`
+	Secret     string        `koanf:"secret" secret:"true"` // HMAC-SHA256 key of the X-Signature header
+	MaxRetries *int          `koanf:"max_retries"`
+	Backoff    time.Duration `koanf:"backoff"`
+	MaxBackoff time.Duration `koanf:"max_backoff"`
+}
+
+// ClickHouseSink inserts the transactions into a table for the analytics
+// dashboards, created when missing
+type ClickHouseSink struct {
+	URL           string        `koanf:"url"` // HTTP interface
+	Database      string        `koanf:"database"`
+	Table         string        `koanf:"table"`
+	Username      string        `koanf:"username"`
+	Password      string        `koanf:"password" secret:"true"`
+	BatchSize     int           `koanf:"batch_size"`     // Rows buffered before an insert
+	FlushInterval time.Duration `koanf:"flush_interval"` // Longest a row is buffered
+	Timeout       time.Duration `koanf:"timeout"`
+}
+
+// EndpointList returns the endpoints with the sink's retry policy applied
+func (w WebhookSink) EndpointList() []WebhookEndpoint {
+	endpoints := make([]WebhookEndpoint, len(w.Endpoints))
+	for idx, endpoint := range w.Endpoints {
+		if endpoint.MaxRetries == nil {
+			endpoint.MaxRetries = &w.MaxRetries
+		}
+		if endpoint.Backoff == 0 {
+			endpoint.Backoff = w.Backoff
+		}
+		if endpoint.MaxBackoff == 0 {
+			endpoint.MaxBackoff = w.MaxBackoff
+		}
+		endpoints[idx] = endpoint
+	}
+	return endpoints
+}
+
+// Shadow also writes the transactions to a second target during a storage
+// migration, the divergence metrics tell when the target can take over
+type Shadow struct {
+	Enabled    bool          `koanf:"enabled"`
+	URI        string        `koanf:"uri" secret:"true"` // Empty writes through the mongo.uri client
+	Database   string        `koanf:"database"`
+	Collection string        `koanf:"collection"`
+	Timeout    time.Duration `koanf:"timeout"`
+}
+
 type Redis struct {
-	URI      string `koanf:"uri"`
-	Password string `koanf:"password"`
+	Mode             string        `koanf:"mode"`
+	URI              string        `koanf:"uri"`
+	Addrs            []string      `koanf:"addrs"`
+	Username         string        `koanf:"username"`
+	Password         string        `koanf:"password" secret:"true"`
+	DB               int           `koanf:"db"`
+	MasterName       string        `koanf:"master_name"`
+	SentinelPassword string        `koanf:"sentinel_password" secret:"true"`
+	TLS              TLS           `koanf:"tls"`
+	HealthInterval   time.Duration `koanf:"health_interval"`
+	HealthTimeout    time.Duration `koanf:"health_timeout"`
 }
 
+// DLQ selects the dead letter queue backend, each backend only reads its own keys.
+type DLQ struct {
+	Backend string `koanf:"backend"`
+
+	// Redis backend
+	StreamName     string        `koanf:"stream_name"`
+	ListName       string        `koanf:"list_name"` // Renamed to stream_name, only read to refuse it
+	EntryTTL       time.Duration `koanf:"entry_ttl"`
+	MaxLength      int64         `koanf:"max_length"`
+	OverflowPolicy string        `koanf:"overflow_policy"`
+	TrimInterval   time.Duration `koanf:"trim_interval"` // Expires and evicts entries between the writes
+	Compression    Compression   `koanf:"compression"`
+
+	// Kafka backend
+	Topic string `koanf:"topic"`
+
+	// Mongo backend
+	Collection string `koanf:"collection"`
+
+	// File backend
+	FilePath string `koanf:"file_path"`
+
+	// S3 backend
+	S3 S3 `koanf:"s3"`
+
+	// Redis, Mongo and File backends
+	Quarantine Quarantine `koanf:"quarantine"`
+
+	// Redis backend replay workers
+	Replay Replay `koanf:"replay"`
+
+	// Delayed retries in Redis before records reach the backend
+	Retry Retry `koanf:"retry"`
+
+	// Webhook alerts, the depth alert needs an inspectable backend
+	Alerts Alerts `koanf:"alerts"`
+}
+
+type Retry struct {
+	Enabled     bool          `koanf:"enabled"`
+	Name        string        `koanf:"name"`
+	BaseDelay   time.Duration `koanf:"base_delay"`
+	MaxDelay    time.Duration `koanf:"max_delay"`
+	MaxAttempts int           `koanf:"max_attempts"`
+	BatchSize   int64         `koanf:"batch_size"`
+	Interval    time.Duration `koanf:"interval"`
+	Lease       time.Duration `koanf:"lease"`
+}
+
+type Compression struct {
+	Codec   string `koanf:"codec"`    // One of none, gzip, zstd
+	MinSize int    `koanf:"min_size"` // Entries smaller than this many bytes are stored as is
+}
+
+type Alerts struct {
+	Enabled        bool          `koanf:"enabled"`
+	WebhookURL     string        `koanf:"webhook_url" secret:"true"` // Slack webhook urls embed their token
+	DepthThreshold int64         `koanf:"depth_threshold"`           // Zero disables the depth alert
+	QuietPeriod    time.Duration `koanf:"quiet_period"`              // Alert on the first dead letter after this long without one
+	Timeout        time.Duration `koanf:"timeout"`
+}
+
+type Replay struct {
+	Group     string        `koanf:"group"`
+	BatchSize int64         `koanf:"batch_size"`
+	MinIdle   time.Duration `koanf:"min_idle"`
+}
+
+type Quarantine struct {
+	Enabled    bool `koanf:"enabled"`
+	MaxReplays int  `koanf:"max_replays"` // Failures after this many requeues are quarantined
+}
+
+type S3 struct {
+	Bucket   string `koanf:"bucket"`
+	Prefix   string `koanf:"prefix"`
+	Region   string `koanf:"region"`
+	Endpoint string `koanf:"endpoint"`
+}
+
+// Kafka runs the consumers listed in Consumers, or without a list a single
+// transactions consumer described by Topic and ConsumerName
 type Kafka struct {
-	Brokers        string `koanf:"brokers"`
-	Consume        bool   `koanf:"consume"`
-	Topic          string `koanf:"topic"`
-	RecordsPerPoll int    `koanf:"records_per_poll"`
-	ConsumerName   string `koanf:"consumer_name"`
+	Brokers        string       `koanf:"brokers"` // Comma separated host:port seed brokers
+	Consume        bool         `koanf:"consume"`
+	Topic          string       `koanf:"topic"`
+	RecordsPerPoll int          `koanf:"records_per_poll"` // Default of consumers that don't set their own
+	Throttle       float64      `koanf:"throttle"`         // Records per second of all consumers together, 0 for no cap
+	Sink           string       `koanf:"sink"`             // Default of consumers that don't set their own, see Sinks
+	Fanout         []FanoutSink `koanf:"fanout"`           // Default of consumers that don't set their own
+	ConsumerName   string       `koanf:"consumer_name"`
+	Consumers      []Consumer   `koanf:"consumers"`
+	SASL           SASL         `koanf:"sasl"` // Of every kafka client, the consumers, producers and admin clients alike
+	Restart        Restart      `koanf:"restart"`
 }
 
-// Validate validates the configuration
-func (c *Config) Validate() error {
-	ve := errors.ValidationErrs()
+// SASL authenticates the kafka clients with the brokers
+type SASL struct {
+	Mechanism string `koanf:"mechanism"` // PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, empty disables SASL
+	Username  string `koanf:"username"`
+	Password  string `koanf:"password" secret:"true"`
+}
+
+// Restart recreates the kafka client of a consumer whose poll loop failed,
+// instead of exiting and losing the warm caches of the process
+type Restart struct {
+	MaxRestarts    int           `koanf:"max_restarts"` // Within the window before exiting, 0 exits on the first failure
+	Window         time.Duration `koanf:"window"`
+	InitialBackoff time.Duration `koanf:"initial_backoff"`
+	MaxBackoff     time.Duration `koanf:"max_backoff"`
+}
 
-	if c.Application == "" {
-		ve.Add("application", "cannot be empty")
+type Consumer struct {
+	Name           string       `koanf:"name"`
+	Topic          string       `koanf:"topic"`
+	Group          string       `koanf:"group"`     // Defaults to the name
+	Processor      string       `koanf:"processor"` // Defaults to transactions
+	RecordsPerPoll int          `koanf:"records_per_poll"`
+	Sink           string       `koanf:"sink"`   // Where the transactions land, defaults to kafka.sink
+	Fanout         []FanoutSink `koanf:"fanout"` // Written to besides the sink, defaults to kafka.fanout
+}
+
+// ConsumerList returns the consumers to run with their defaults applied
+func (k Kafka) ConsumerList() []Consumer {
+	if len(k.Consumers) == 0 {
+		return []Consumer{{Name: k.ConsumerName, Topic: k.Topic, Group: k.ConsumerName, Processor: "transactions", RecordsPerPoll: k.RecordsPerPoll, Sink: k.Sink, Fanout: k.Fanout}}
 	}
-	if c.Logger.Level == "" {
-		ve.Add("logger.level", "cannot be empty")
+	consumers := make([]Consumer, len(k.Consumers))
+	for idx, consumer := range k.Consumers {
+		if consumer.Group == "" {
+			consumer.Group = consumer.Name
+		}
+		if consumer.Processor == "" {
+			consumer.Processor = "transactions"
+		}
+		if consumer.RecordsPerPoll == 0 {
+			consumer.RecordsPerPoll = k.RecordsPerPoll
+		}
+		if consumer.Sink == "" {
+			consumer.Sink = k.Sink
+		}
+		if consumer.Fanout == nil {
+			consumer.Fanout = k.Fanout
+		}
+		consumers[idx] = consumer
 	}
-	if c.Mongo.URI == "" {
-		ve.Add("mongo.uri", "cannot be empty")
+	return consumers
+}
+
+// BrokerList splits the comma separated brokers
+func (k Kafka) BrokerList() []string {
+	var brokers []string
+	for _, broker := range strings.Split(k.Brokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
 	}
-	if c.Redis.URI == "" {
-		ve.Add("redis.uri", "cannot be empty")
+	return brokers
+}
+
+// Reload controls config reloads, SIGHUP always triggers one.
+// The reloadable keys are listed in reload.go.
+type Reload struct {
+	WatchFile   bool `koanf:"watch_file"` // Also watches the config dirs
+	WatchRemote bool `koanf:"watch_remote"`
+}
+
+// Remote loads shared config keys from consul or etcd, stored as
+// <prefix>kafka/brokers for kafka.brokers. They override the config
+// files and are overridden by the environment.
+type Remote struct {
+	Backend   string        `koanf:"backend"`   // One of consul, etcd, empty disables
+	Endpoints []string      `koanf:"endpoints"` // Consul uses the first, empty uses CONSUL_HTTP_ADDR
+	Prefix    string        `koanf:"prefix"`
+	Token     string        `koanf:"token" secret:"true"` // Consul ACL token
+	Username  string        `koanf:"username"`            // Etcd auth
+	Password  string        `koanf:"password" secret:"true"`
+	Timeout   time.Duration `koanf:"timeout"`
+}
+
+// AWS is used to resolve aws-sm:// and aws-ssm:// config values
+type AWS struct {
+	Region string `koanf:"region"` // Empty uses AWS_REGION or the profile region
+}
+
+// Features holds the feature flags, or with the redis backend where to refresh them from.
+// Flags in the config are reloadable, flags in redis are refreshed every RefreshInterval.
+type Features struct {
+	Backend         string                 `koanf:"backend"` // One of config, redis
+	RedisKey        string                 `koanf:"redis_key"`
+	RefreshInterval time.Duration          `koanf:"refresh_interval"`
+	Flags           map[string]FeatureFlag `koanf:"flags"`
+}
+
+type FeatureFlag struct {
+	Enabled bool     `koanf:"enabled"`
+	Tenants []string `koanf:"tenants"`
+	Rollout int      `koanf:"rollout"` // Percentage of the unlisted tenants
+}
+
+// GCP is used to resolve gcp-sm:// config values
+type GCP struct {
+	Project string `koanf:"project"` // Project of references that don't name one
+}
+
+// Encryption decrypts enc: config values, sops encrypted files need the sops binary
+type Encryption struct {
+	AgeKeyFile string `koanf:"age_key_file"` // Empty uses SOPS_AGE_KEY_FILE or SOPS_AGE_KEY
+}
+
+// Vault overrides config keys with the values of a KV v2 secret, e.g. mongo.uri
+type Vault struct {
+	Enabled      bool   `koanf:"enabled"`
+	Address      string `koanf:"address"`             // Empty uses VAULT_ADDR
+	AuthMethod   string `koanf:"auth_method"`         // One of token, approle, kubernetes
+	AuthMount    string `koanf:"auth_mount"`          // Defaults to the auth method name
+	Token        string `koanf:"token" secret:"true"` // Empty uses VAULT_TOKEN
+	RoleID       string `koanf:"role_id"`
+	SecretIDFile string `koanf:"secret_id_file"`
+	Role         string `koanf:"role"`
+	JWTFile      string `koanf:"jwt_file"`
+	Mount        string `koanf:"mount"`
+	Path         string `koanf:"path"`
+}
+
+type Admin struct {
+	Enabled bool   `koanf:"enabled"`
+	Port    int    `koanf:"port"`
+	Debug   bool   `koanf:"debug"`               // Serves pprof and runtime stats under /debug
+	Token   string `koanf:"token" secret:"true"` // Bearer token of every endpoint but the probes, empty only serves the probes and the version
+}
+
+// GRPC serves the read-only transaction queries of proto/txstream/v1 to
+// internal services
+type GRPC struct {
+	Enabled     bool   `koanf:"enabled"`
+	Port        int    `koanf:"port"`
+	Token       string `koanf:"token" secret:"true"` // Bearer token of every call, empty leaves the service unauthenticated
+	MaxPageSize int    `koanf:"max_page_size"`       // Caps the listings and the ids of a status query
+}
+
+// API serves the read-only HTTP API of the support tooling: transaction
+// lookups, recent failures and pipeline stats
+type API struct {
+	Enabled     bool   `koanf:"enabled"`
+	Port        int    `koanf:"port"`
+	Token       string `koanf:"token" secret:"true"` // Bearer token of every request, empty leaves the API unauthenticated
+	MaxPageSize int    `koanf:"max_page_size"`       // Caps every listing
+	Tail        Tail   `koanf:"tail"`
+}
+
+// Tail streams the processed transactions matching a filter to the support
+// API as Server-Sent Events
+type Tail struct {
+	Enabled        bool          `koanf:"enabled"`
+	MaxSubscribers int           `koanf:"max_subscribers"`
+	BufferSize     int           `koanf:"buffer_size"` // Events held per tail, a slower client drops the next ones
+	Heartbeat      time.Duration `koanf:"heartbeat"`
+	MaxDuration    time.Duration `koanf:"max_duration"`
+	RedactFields   []string      `koanf:"redact_fields"` // Top-level payload fields masked and not filterable
+}
+
+// Metrics exports the metrics to each of the sinks, prometheus is served on
+// its own port apart from the admin endpoints
+type Metrics struct {
+	Enabled     bool          `koanf:"enabled"`
+	Sinks       []string      `koanf:"sinks"` // prometheus, dogstatsd or both
+	Port        int           `koanf:"port"`
+	Path        string        `koanf:"path"`
+	LagInterval time.Duration `koanf:"lag_interval"` // How often the per-partition consumer lag is refreshed, 0 disables it
+	DogStatsD   DogStatsD     `koanf:"dogstatsd"`
+	SLO         SLO           `koanf:"slo"`
+}
+
+// DogStatsD pushes the metrics to a datadog agent, for environments without prometheus
+type DogStatsD struct {
+	Address  string        `koanf:"address"`
+	Interval time.Duration `koanf:"interval"`
+	Tags     []string      `koanf:"tags"` // Added to every metric, e.g. env:prod
+}
+
+// SLO is the freshness objective: Objective of the records committed within FreshnessTarget of their timestamp
+type SLO struct {
+	FreshnessTarget time.Duration `koanf:"freshness_target"`
+	Objective       float64       `koanf:"objective"`
+}
+
+// Health tunes the /healthz and /readyz checks of the admin server, redis has its own in the redis block
+type Health struct {
+	Interval     time.Duration `koanf:"interval"`      // Between kafka and mongo pings
+	Timeout      time.Duration `koanf:"timeout"`       // Of each ping
+	StallTimeout time.Duration `koanf:"stall_timeout"` // A batch in processing for longer fails liveness
+}
+
+// Maintenance holds the records on the topic during a planned storage outage,
+// persist keeps fetching and holds the batch, fetch stops fetching
+type Maintenance struct {
+	Mode string `koanf:"mode"` // off, persist or fetch, the admin API can switch it too
+}
+
+// Chaos injects failures at the given rates, between 0 and 1, to test the
+// DLQ, retries and backpressure, refused in prod mode
+type Chaos struct {
+	Enabled            bool          `koanf:"enabled"`
+	ProcessorErrorRate float64       `koanf:"processor_error_rate"` // Of the batches
+	DecodeFailureRate  float64       `koanf:"decode_failure_rate"`  // Of the records
+	MongoLatencyRate   float64       `koanf:"mongo_latency_rate"`   // Of the writes
+	MongoLatency       time.Duration `koanf:"mongo_latency"`
+	RedisTimeoutRate   float64       `koanf:"redis_timeout_rate"` // Of the commands
+}
+
+// Startup bounds the wait for kafka, mongo and redis at boot, so a cold
+// start of the cluster does not crash loop the instance
+type Startup struct {
+	MaxWait        time.Duration `koanf:"max_wait"` // 0 gives up on the first failure
+	InitialBackoff time.Duration `koanf:"initial_backoff"`
+	MaxBackoff     time.Duration `koanf:"max_backoff"`
+}
+
+// Shutdown tunes how the instance stops, drain hands the partitions over
+// before exiting so rolling restarts reprocess as little as possible
+type Shutdown struct {
+	Drain        bool             `koanf:"drain"`         // On SIGTERM, and serves /drain behind admin.token
+	DrainTimeout time.Duration    `koanf:"drain_timeout"` // Keep below the termination grace period
+	Timeouts     ShutdownTimeouts `koanf:"timeouts"`
+}
+
+// ShutdownTimeouts bound each phase of the shutdown, see lifecycle.Phases,
+// their sum is the longest a shutdown takes
+type ShutdownTimeouts struct {
+	StopFetching time.Duration `koanf:"stop_fetching"` // Covers the drain when enabled
+	DrainWorkers time.Duration `koanf:"drain_workers"`
+	Flush        time.Duration `koanf:"flush"`
+	Commit       time.Duration `koanf:"commit"`
+	CloseStores  time.Duration `koanf:"close_stores"`
+	StopServers  time.Duration `koanf:"stop_servers"`
+	Telemetry    time.Duration `koanf:"telemetry"`
+}
+
+// Aggregation maintains windowed aggregates of the stored transactions per
+// group in redis and emits each window once it closes, to a collection or a topic
+type Aggregation struct {
+	Enabled          bool                `koanf:"enabled"`
+	Prefix           string              `koanf:"prefix"`            // Of the redis keys
+	GroupBy          []string            `koanf:"group_by"`          // Transaction fields, one of AggregationFields
+	DeclinedStatuses []string            `koanf:"declined_statuses"` // Statuses counted in the decline rate
+	Grace            time.Duration       `koanf:"grace"`             // Wait after the end of a window before emitting it
+	Retention        time.Duration       `koanf:"retention"`         // Downtime of the emitter that can be caught up
+	Interval         time.Duration       `koanf:"interval"`
+	Windows          []AggregationWindow `koanf:"windows"`
+	Emit             AggregationEmit     `koanf:"emit"`
+}
+
+// AggregationFields are the transaction fields a window can group by
+var AggregationFields = []string{"user_id", "currency", "transaction_type", "status", "payment_method",
+	"bank_name", "merchant_name", "location", "category"}
+
+// AggregationWindow is tumbling without a slide, sliding by slide otherwise
+type AggregationWindow struct {
+	Name  string        `koanf:"name"`
+	Size  time.Duration `koanf:"size"`
+	Slide time.Duration `koanf:"slide"` // Must divide the size
+}
+
+type AggregationEmit struct {
+	Target     string `koanf:"target"` // collection or topic
+	Collection string `koanf:"collection"`
+	Topic      string `koanf:"topic"` // Keyed by result id, so it can be compacted
+}
+
+// Scheduler runs periodic maintenance jobs on the leader, each on a cron
+// schedule (see scheduler.Parse) and never twice at the same time. The
+// periods the jobs cover are aligned on UTC and end before the run, so a
+// retried run covers the same period.
+type Scheduler struct {
+	Enabled  bool          `koanf:"enabled"`
+	Timezone string        `koanf:"timezone"` // Of the schedules, an IANA name
+	Jobs     SchedulerJobs `koanf:"jobs"`
+}
+
+type SchedulerJobs struct {
+	DLQSweep  DLQSweepJob  `koanf:"dlq_sweep"`
+	Archive   ArchiveJob   `koanf:"archive"`
+	Reconcile ReconcileJob `koanf:"reconcile"`
+	Rollup    RollupJob    `koanf:"rollup"`
+}
+
+// DLQSweepJob deletes the dead letters that last failed longer ago than max_age
+type DLQSweepJob struct {
+	Enabled    bool          `koanf:"enabled"`
+	Schedule   string        `koanf:"schedule"`
+	Timeout    time.Duration `koanf:"timeout"`
+	MaxAge     time.Duration `koanf:"max_age"`
+	MaxDeletes int           `koanf:"max_deletes"` // Per run, 0 for no limit
+}
+
+// ArchiveJob copies the transactions processed in the last period to the
+// sinks.s3 archive
+type ArchiveJob struct {
+	Enabled   bool          `koanf:"enabled"`
+	Schedule  string        `koanf:"schedule"`
+	Timeout   time.Duration `koanf:"timeout"`
+	Period    time.Duration `koanf:"period"`
+	BatchSize int           `koanf:"batch_size"`
+}
+
+// ReconcileJob compares the last period of the topic of every mongo consumer
+// with the stored transactions, a run with missing or mismatched documents fails
+type ReconcileJob struct {
+	Enabled     bool          `koanf:"enabled"`
+	Schedule    string        `koanf:"schedule"`
+	Timeout     time.Duration `koanf:"timeout"`
+	Period      time.Duration `koanf:"period"`
+	Delay       time.Duration `koanf:"delay"` // Left to the consumers before a period is reconciled
+	IdleTimeout time.Duration `koanf:"idle_timeout"`
+}
+
+// RollupJob sums the results of a tumbling aggregation window over the last
+// period into a collection, one document per group and period
+type RollupJob struct {
+	Enabled    bool          `koanf:"enabled"`
+	Schedule   string        `koanf:"schedule"`
+	Timeout    time.Duration `koanf:"timeout"`
+	Window     string        `koanf:"window"` // Name of an aggregation window without slide
+	Period     time.Duration `koanf:"period"` // A multiple of the window size
+	Collection string        `koanf:"collection"`
+}
+
+// Outbox writes a stored event per transaction to an outbox collection in
+// the mongo transaction that stores it, and relays the events to a topic.
+// It covers the consumers storing in mongo and needs a replica set.
+type Outbox struct {
+	Enabled    bool        `koanf:"enabled"`
+	Collection string      `koanf:"collection"`
+	Topic      string      `koanf:"topic"`
+	Relay      OutboxRelay `koanf:"relay"`
+}
+
+// OutboxRelay tunes the relay, see outbox.RelayConfig
+type OutboxRelay struct {
+	BatchSize       int           `koanf:"batch_size"`
+	Interval        time.Duration `koanf:"interval"`
+	Lease           time.Duration `koanf:"lease"`
+	MaxAttempts     int           `koanf:"max_attempts"` // 0 retries forever
+	BaseDelay       time.Duration `koanf:"base_delay"`
+	MaxDelay        time.Duration `koanf:"max_delay"`
+	Retention       time.Duration `koanf:"retention"` // Of the published events, 0 keeps them
+	CleanupInterval time.Duration `koanf:"cleanup_interval"`
+}
+
+// Idempotency records every applied record in Collection in the mongo
+// transaction of its write and skips the records found there, so a record
+// redelivered after a rebalance or a crash is applied once. Retention should
+// outlast the retention of the topics, an older record is applied again.
+type Idempotency struct {
+	Enabled    bool          `koanf:"enabled"`
+	Collection string        `koanf:"collection"`
+	Retention  time.Duration `koanf:"retention"`
+}
+
+// Notifications alert on the stored transactions matching the rules, to
+// Slack, email or webhook channels. The velocity windows and the dedup keys
+// live in redis, prefixed with Prefix.
+type Notifications struct {
+	Enabled   bool                  `koanf:"enabled"`
+	Prefix    string                `koanf:"prefix"`
+	QueueSize int                   `koanf:"queue_size"` // Alerts waiting per channel, more are dropped
+	Timeout   time.Duration         `koanf:"timeout"`    // Of a notification
+	Channels  []NotificationChannel `koanf:"channels"`
+	Rules     []AlertRule           `koanf:"rules"`
+}
+
+// NotificationChannel is a destination of the alerts
+type NotificationChannel struct {
+	Name      string   `koanf:"name"`
+	Type      string   `koanf:"type"` // slack, webhook or email
+	URL       string   `koanf:"url" secret:"true"`
+	Secret    string   `koanf:"secret" secret:"true"` // Webhook only, signs the payloads like the webhook sink
+	RateLimit float64  `koanf:"rate_limit"`           // Notifications per minute, 0 for no limit
+	Burst     int      `koanf:"burst"`
+	SMTPAddr  string   `koanf:"smtp_addr"` // Email only, host:port
+	From      string   `koanf:"from"`
+	To        []string `koanf:"to"`
+	Username  string   `koanf:"username"`
+	Password  string   `koanf:"password" secret:"true"`
+}
+
+// AlertRule is an amount threshold, a velocity or a list of blocked countries
+type AlertRule struct {
+	Name        string        `koanf:"name"`
+	Type        string        `koanf:"type"`     // amount, velocity or country
+	Severity    string        `koanf:"severity"` // Defaults to warning
+	MinAmount   float64       `koanf:"min_amount"`
+	Currencies  []string      `koanf:"currencies"` // Amount only, empty for every currency
+	Count       int           `koanf:"count"`      // Velocity only, transactions within the window
+	Window      time.Duration `koanf:"window"`
+	By          string        `koanf:"by"` // Velocity only, one of AggregationFields, defaults to user_id
+	Countries   []string      `koanf:"countries"`
+	DedupBy     string        `koanf:"dedup_by"`     // transaction_id or one of AggregationFields, defaults to by for velocities
+	DedupWindow time.Duration `koanf:"dedup_window"` // An alert is sent once per rule and dedup_by value within it
+	Channels    []string      `koanf:"channels"`     // Empty notifies every channel
+}
+
+// DedupField returns the field the alerts of the rule are deduped by
+func (r AlertRule) DedupField() string {
+	switch {
+	case r.DedupBy != "":
+		return r.DedupBy
+	case r.Type == "velocity":
+		return r.VelocityField()
+	default:
+		return "transaction_id"
 	}
-	if c.Kafka.Brokers == "" {
-		ve.Add("kafka.brokers", "cannot be empty")
+}
+
+// VelocityField returns the field a velocity is counted by
+func (r AlertRule) VelocityField() string {
+	if r.By == "" {
+		return "user_id"
 	}
+	return r.By
+}
+
+// Risk scores the transactions with the rules before they are stored, the
+// flags and the score land in the risk field of the documents. The rules are
+// reloadable, enabling or disabling them needs a restart.
+type Risk struct {
+	Enabled bool       `koanf:"enabled"`
+	Rules   []RiskRule `koanf:"rules"`
+}
+
+// RiskRule flags the transactions meeting every condition with its name and
+// adds its score, negative to lower the risk, to theirs
+type RiskRule struct {
+	Name  string          `koanf:"name"`
+	Score float64         `koanf:"score"`
+	When  []RiskCondition `koanf:"when"`
+}
+
+// RiskCondition compares a transaction field, by JSON name, with Value or
+// with Values for in and not_in. The operators are eq, ne, in, not_in, the
+// gt, gte, lt, lte comparisons of numbers and the contains, prefix, suffix,
+// regex matches of text.
+type RiskCondition struct {
+	Field  string   `koanf:"field"`
+	Op     string   `koanf:"op"`
+	Value  string   `koanf:"value"`
+	Values []string `koanf:"values"`
+}
+
+// ClaimCheck resolves the records whose payload the producer parked in S3,
+// sending a {"claim_check": {"uri": "s3://bucket/key"}} envelope instead
+type ClaimCheck struct {
+	Enabled     bool          `koanf:"enabled"`
+	Region      string        `koanf:"region"`
+	Endpoint    string        `koanf:"endpoint"` // S3 compatible store
+	Buckets     []string      `koanf:"buckets"`  // The only buckets payloads are read from
+	MaxBytes    int64         `koanf:"max_bytes"`
+	Timeout     time.Duration `koanf:"timeout"` // Of a fetch attempt
+	Attempts    int           `koanf:"attempts"`
+	Backoff     time.Duration `koanf:"backoff"`     // Doubles per attempt
+	Concurrency int           `koanf:"concurrency"` // Fetches in flight per batch
+	CacheBytes  int64         `koanf:"cache_bytes"` // Of the recently fetched payloads, 0 disables the cache
+}
+
+// FX normalizes the amounts of the transactions to BaseCurrency with the rates
+// of an HTTP source. The rates are cached in redis under CachePrefix for
+// RefreshInterval, so the replicas share a fetch.
+type FX struct {
+	Enabled         bool          `koanf:"enabled"`
+	BaseCurrency    string        `koanf:"base_currency"`
+	URL             string        `koanf:"url"` // {base} is replaced by the base currency
+	APIKey          string        `koanf:"api_key" secret:"true"`
+	APIKeyHeader    string        `koanf:"api_key_header"`
+	Timeout         time.Duration `koanf:"timeout"`
+	RefreshInterval time.Duration `koanf:"refresh_interval"`
+	MaxAge          time.Duration `koanf:"max_age"`  // Rates older than this as of their publication are stale, daily sources skip weekends
+	OnStale         string        `koanf:"on_stale"` // use, skip or fail, see fx.StaleUse
+	CachePrefix     string        `koanf:"cache_prefix"`
+}
+
+// Election runs the background jobs such as the dlq retries on one replica only
+type Election struct {
+	Enabled       bool          `koanf:"enabled"`
+	Backend       string        `koanf:"backend"`   // kubernetes, a Lease object, or redis, a lock
+	Name          string        `koanf:"name"`      // Of the Lease or the lock
+	Namespace     string        `koanf:"namespace"` // Kubernetes only, empty uses the pod's namespace
+	Identity      string        `koanf:"identity"`  // Defaults to the hostname, the pod name in kubernetes
+	LeaseDuration time.Duration `koanf:"lease_duration"`
+	RenewDeadline time.Duration `koanf:"renew_deadline"` // A leader not renewed for longer stops its jobs
+	RetryPeriod   time.Duration `koanf:"retry_period"`
+}
+
+// Region runs active/passive across regions: the replicas of the standby
+// regions join their consumer groups with fetching paused and resume once
+// their region becomes the active one. Each region consumes its own cluster,
+// e.g. a mirror, a paused member of the active group would hold its partitions.
+type Region struct {
+	Name          string        `koanf:"name"`    // Of this replica's region, empty disables region awareness
+	Active        string        `koanf:"active"`  // The active region, with redis the default while its record is unset, reloadable
+	Backend       string        `koanf:"backend"` // config, or redis for the record <redis_key>:active
+	RedisKey      string        `koanf:"redis_key"`
+	Interval      time.Duration `koanf:"interval"`       // Between reads of the redis record
+	FailoverAfter time.Duration `koanf:"failover_after"` // Redis only, a standby takes over once the active region missed its heartbeats for this long, 0 never
+}
+
+// Audit keeps a trail of every committed offset range, for incident reviews
+type Audit struct {
+	Enabled    bool          `koanf:"enabled"`
+	Backend    string        `koanf:"backend"`    // mongo or log
+	Collection string        `koanf:"collection"` // Mongo backend only
+	Retention  time.Duration `koanf:"retention"`  // Mongo backend only, 0 keeps the ranges forever
+}
+
+// Sentry reports panics and the batches that fail past their retries, it is
+// off while the dsn is empty
+type Sentry struct {
+	DSN         string  `koanf:"dsn" secret:"true"`
+	Environment string  `koanf:"environment"`
+	SampleRate  float64 `koanf:"sample_rate"` // Share of the error events that are sent
+}
 
-	return ve.Err()
+// Tracing exports OpenTelemetry spans of every pipeline stage over OTLP gRPC
+type Tracing struct {
+	Enabled     bool    `koanf:"enabled"`
+	Endpoint    string  `koanf:"endpoint"` // host:port, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    `koanf:"insecure"`
+	SampleRatio float64 `koanf:"sample_ratio"` // Share of the traces started here that are kept
 }
@@ -0,0 +1,111 @@
+package config
+
+import (
+	// Go Internal Packages
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// External Packages
+	"github.com/fsnotify/fsnotify"
+	"github.com/knadh/koanf/maps"
+	"github.com/knadh/koanf/parsers/yaml"
+)
+
+// Dir reads config from a mounted ConfigMap or Secret directory. A file holds
+// the value of the key it is named after, e.g. kafka.brokers, except .yml and
+// .yaml files which hold config documents like the base config file.
+type Dir struct {
+	Path string
+}
+
+func DirProvider(path string) *Dir {
+	return &Dir{Path: path}
+}
+
+// ReadBytes is not supported, the directory is read as a map
+func (d *Dir) ReadBytes() ([]byte, error) {
+	return nil, errors.New("config dir provider does not support this method")
+}
+
+// Read returns the keys of every file in the directory. Entries starting with a
+// dot are skipped, including the ..data links of projected volumes.
+func (d *Dir) Read() (map[string]interface{}, error) {
+	entries, err := os.ReadDir(d.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(d.Path, name)
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		// Mounted keys are symlinks into ..data, follow them
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %v", path, err)
+		}
+
+		switch filepath.Ext(name) {
+		case ".yml", ".yaml":
+			doc, err := yaml.Parser().Unmarshal(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+			}
+			flat, _ := maps.Flatten(doc, nil, ".")
+			for key, value := range flat {
+				values[key] = value
+			}
+		default:
+			values[name] = strings.TrimRight(string(data), "\r\n")
+		}
+	}
+	return maps.Unflatten(values, "."), nil
+}
+
+// Watch calls cb after the directory changes, such as the kubelet swapping the
+// ..data link of an updated ConfigMap. Events are batched for a second.
+func (d *Dir) Watch(cb func(event interface{}, err error)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(d.Path); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		var pending <-chan time.Time
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op != fsnotify.Chmod {
+					pending = time.After(time.Second)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				cb(nil, err)
+			case <-pending:
+				pending = nil
+				cb(nil, nil)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	// Go Internal Packages
+	"reflect"
+	"strings"
+
+	// External Packages
+	"github.com/knadh/koanf/providers/env"
+)
+
+// EnvPrefix prefixes the environment variables that override config keys
+const EnvPrefix = "TXSTREAM_"
+
+// EnvProfileVar selects the environment profile, it is not a config key
+const EnvProfileVar = EnvPrefix + "ENV"
+
+// legacyEnv maps the environment variables read before EnvPrefix existed
+var legacyEnv = map[string]string{
+	"MONGO_URI":     "mongo.uri",
+	"REDIS_URI":     "redis.uri",
+	"REDIS_ADDRS":   "redis.addrs",
+	"REDIS_USER":    "redis.username",
+	"REDIS_PWD":     "redis.password",
+	"KAFKA_BROKERS": "kafka.brokers",
+	"IS_PROD_MODE":  "is_prod_mode",
+}
+
+// EnvProvider overrides any config key from TXSTREAM_ prefixed variables, with
+// dots and underscores of the key both written as underscores, for instance
+// TXSTREAM_KAFKA_RECORDS_PER_POLL sets kafka.records_per_poll. Variables are
+// matched against the keys of the Config fields and the known keys, such as
+// the entries of maps, anything else maps underscores to dots. List values
+// are comma separated and empty variables are ignored.
+func EnvProvider(knownKeys []string) *env.Env {
+	names := make(map[string]string, len(configKeys)+len(knownKeys))
+	for _, key := range append(append([]string{}, configKeys...), knownKeys...) {
+		names[envName(key)] = key
+	}
+
+	return env.ProviderWithValue(EnvPrefix, ".", func(name, value string) (string, interface{}) {
+		if value == "" || name == EnvProfileVar {
+			return "", nil
+		}
+		if key, ok := names[name]; ok {
+			return key, value
+		}
+		return strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(name, EnvPrefix), "_", ".")), value
+	})
+}
+
+// LegacyEnvProvider reads the unprefixed variables supported for existing deployments
+func LegacyEnvProvider() *env.Env {
+	return env.ProviderWithValue("", ".", func(name, value string) (string, interface{}) {
+		key, ok := legacyEnv[name]
+		if !ok || value == "" {
+			return "", nil
+		}
+		return key, value
+	})
+}
+
+// configKeys are the keys of the Config fields, with or without a default
+var configKeys = collectConfigKeys(reflect.TypeOf(Config{}), "")
+
+func collectConfigKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		name := field.Tag.Get("koanf")
+		if name == "" {
+			continue
+		}
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, collectConfigKeys(field.Type, key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// envName returns the environment variable that overrides the config key
+func envName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	// External Packages
+	"github.com/knadh/koanf"
+)
+
+// reference matches $$ and ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?message} and ${VAR?message}
+var reference = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?[-?])([^}]*))?\}`)
+
+// Interpolate expands the environment variable references in every string
+// value, and in string list values, of the loaded config. The references
+// behave as in the shell: :- uses the default when the variable is unset or
+// empty, :? fails the load with the message, without the colon only an unset
+// variable counts and $$ is a literal dollar sign.
+func Interpolate(k *koanf.Koanf) error {
+	for key, value := range k.All() {
+		switch value := value.(type) {
+		case string:
+			expanded, err := expand(value, os.LookupEnv)
+			if err != nil {
+				return fmt.Errorf("failed to interpolate %s: %v", key, err)
+			}
+			if expanded != value {
+				_ = k.Set(key, expanded)
+			}
+		case []interface{}:
+			items := make([]interface{}, len(value))
+			changed := false
+			for idx, item := range value {
+				items[idx] = item
+				str, ok := item.(string)
+				if !ok {
+					continue
+				}
+				expanded, err := expand(str, os.LookupEnv)
+				if err != nil {
+					return fmt.Errorf("failed to interpolate %s[%d]: %v", key, idx, err)
+				}
+				items[idx], changed = expanded, changed || expanded != str
+			}
+			if changed {
+				_ = k.Set(key, items)
+			}
+		}
+	}
+	return nil
+}
+
+func expand(value string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+	var err error
+	expanded := reference.ReplaceAllStringFunc(value, func(ref string) string {
+		if ref == "$$" {
+			return "$"
+		}
+		match := reference.FindStringSubmatch(ref)
+		name, op, arg := match[1], match[2], match[3]
+		env, set := lookup(name)
+		missing := !set || (strings.HasPrefix(op, ":") && env == "")
+		switch {
+		case !missing:
+			return env
+		case strings.HasSuffix(op, "-"):
+			return arg
+		case strings.HasSuffix(op, "?"):
+			if arg == "" {
+				arg = "is not set"
+			}
+			if err == nil {
+				err = fmt.Errorf("%s %s", name, arg)
+			}
+		}
+		return ""
+	})
+	return expanded, err
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	// Go Internal Packages
+	"reflect"
+	"sort"
+	"strings"
+
+	// External Packages
+	"github.com/knadh/koanf"
+)
+
+// reloadableKeys are applied to the running process on reload. Every other key
+// is boot-only, a changed boot-only key is reported and waits for a restart.
+var reloadableKeys = map[string]bool{
+	"logger.level":               true,
+	"kafka.records_per_poll":     true,
+	"kafka.throttle":             true,
+	"dlq.alerts.depth_threshold": true,
+	"maintenance.mode":           true,
+	"region.active":              true,
+}
+
+// reloadableTrees are reloadable along with every key below them
+var reloadableTrees = []string{
+	"features.flags",
+	"logger.payloads",
+	"risk.rules",
+}
+
+// IsReloadable reports whether the key can change without a restart
+func IsReloadable(key string) bool {
+	for _, tree := range reloadableTrees {
+		if key == tree || strings.HasPrefix(key, tree+".") {
+			return true
+		}
+	}
+	return reloadableKeys[key]
+}
+
+// ChangedKeys returns the keys whose values differ between two loaded configs
+func ChangedKeys(prev, next *koanf.Koanf) []string {
+	prevAll, nextAll := prev.All(), next.All()
+	var changed []string
+	for key, value := range nextAll {
+		if !reflect.DeepEqual(prevAll[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range prevAll {
+		if _, ok := nextAll[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
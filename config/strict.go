@@ -0,0 +1,74 @@
+package config
+
+import (
+	// Go Internal Packages
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	// External Packages
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/maps"
+)
+
+// UnknownKeys returns the loaded keys that no Config field reads, such as a
+// misspelled recordPerPoll, which would otherwise silently keep its default.
+// The items of lists like kafka.consumers are checked too.
+func UnknownKeys(k *koanf.Koanf) []string {
+	found := make(map[string]bool)
+	for key, value := range k.All() {
+		collectUnknownKeys(reflect.TypeOf(Config{}), "", key, value, found)
+	}
+	unknown := make([]string, 0, len(found))
+	for key := range found {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+func collectUnknownKeys(t reflect.Type, prefix, key string, value interface{}, found map[string]bool) {
+	parts := strings.Split(key, ".")
+	for idx, part := range parts {
+		switch t.Kind() {
+		case reflect.Struct:
+			field, ok := fieldByKey(t, part)
+			if !ok {
+				found[prefix+strings.Join(parts[:idx+1], ".")] = true
+				return
+			}
+			t = field.Type
+		case reflect.Map:
+			t = t.Elem()
+		default:
+			found[prefix+strings.Join(parts[:idx+1], ".")] = true
+			return
+		}
+	}
+
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Struct {
+		return
+	}
+	items, _ := value.([]interface{})
+	for idx, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		flat, _ := maps.Flatten(fields, nil, ".")
+		for itemKey, itemValue := range flat {
+			collectUnknownKeys(t.Elem(), prefix+key+"["+strconv.Itoa(idx)+"].", itemKey, itemValue, found)
+		}
+	}
+}
+
+// fieldByKey returns the struct field with the koanf tag
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		if field := t.Field(idx); field.Tag.Get("koanf") == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	// Go Internal Packages
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type TLS struct {
+	Enabled            bool   `koanf:"enabled"`
+	CAFile             string `koanf:"ca_file"`
+	CertFile           string `koanf:"cert_file"`
+	KeyFile            string `koanf:"key_file"`
+	ServerName         string `koanf:"server_name"`
+	InsecureSkipVerify bool   `koanf:"insecure_skip_verify"`
+
+	// How often the client certificate files are checked for a rotated
+	// certificate, such as a renewal by cert-manager, zero loads them once.
+	// The CA file is always loaded once.
+	ReloadInterval time.Duration `koanf:"reload_interval"`
+}
+
+// Load builds the tls config from the configured files, returns nil when TLS is disabled
+func (t TLS) Load() (*tls.Config, error) {
+	if !t.Enabled {
+		return nil, nil
+	}
+
+	conf := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		ca, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", t.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.ReloadInterval > 0 {
+			reloader, err := newCertReloader(t.CertFile, t.KeyFile, t.ReloadInterval)
+			if err != nil {
+				return nil, err
+			}
+			conf.GetClientCertificate = reloader.clientCertificate
+			return conf, nil
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+// certReloader hands out the client certificate for new connections, re-reading
+// the files when they changed and the check interval has passed. A rotated pair
+// that fails to load keeps the previous certificate, existing connections keep
+// the certificate they were opened with.
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		if modTime, err := r.latestModTime(); err == nil && !modTime.Equal(r.modTime) {
+			_ = r.reloadLocked()
+		}
+	}
+	return r.cert, nil
+}
+
+func (r *certReloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkedAt = time.Now()
+	return r.reloadLocked()
+}
+
+func (r *certReloader) reloadLocked() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return fmt.Errorf("failed to stat client certificate: %v", err)
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %v", err)
+	}
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+// latestModTime returns the newest modification time of the pair, following
+// the symlinks of mounted secrets to the files they point at
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// validate adds the tls violations under the given field prefix
+func (t TLS) validate(prefix string, add func(field, err string)) {
+	if !t.Enabled {
+		return
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		add(prefix+".cert_file", "cert_file and key_file must be set together")
+	}
+	if t.ReloadInterval < 0 {
+		add(prefix+".reload_interval", "cannot be negative")
+	}
+}
@@ -0,0 +1,1305 @@
+package config
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	scheduler "tx-stream/scheduler"
+	risk "tx-stream/services/risk"
+)
+
+// Bounds of tunables, values outside them are almost certainly typos
+const (
+	maxRecordsPerPoll = 10000
+	maxAlertTimeout   = time.Minute
+)
+
+// Validate checks every config block and returns all violations at once, each
+// reported with the path of its field
+func (c *Config) Validate() error {
+	ve := errors.ValidationErrs()
+
+	if c.Application == "" {
+		ve.Add("application", "cannot be empty")
+	}
+	c.Logger.validate(ve.Add)
+	c.Mongo.validate(ve.Add)
+	c.Shadow.validate(c.Mongo, ve.Add)
+	c.Sinks.validate(c.Kafka.ConsumerList(), c.Scheduler.Enabled && c.Scheduler.Jobs.Archive.Enabled, ve.Add)
+	c.Redis.validate(ve.Add)
+	c.Kafka.validate(ve.Add)
+	c.DLQ.validate(ve.Add)
+	c.Vault.validate(ve.Add)
+	c.Remote.validate(ve.Add)
+	c.Features.validate(ve.Add)
+	c.Admin.validate(ve.Add)
+	c.GRPC.validate(ve.Add)
+	c.API.validate(ve.Add)
+	c.Metrics.validate(ve.Add)
+	c.Health.validate(ve.Add)
+	c.Tracing.validate(ve.Add)
+	c.Sentry.validate(ve.Add)
+	c.Audit.validate(ve.Add)
+	c.Aggregation.validate(ve.Add)
+	c.Scheduler.validate(c.Aggregation, ve.Add)
+	c.Outbox.validate(ve.Add)
+	c.Idempotency.validate(ve.Add)
+	c.Notifications.validate(ve.Add)
+	c.Risk.validate(ve.Add)
+	c.ClaimCheck.validate(ve.Add)
+	c.FX.validate(ve.Add)
+	c.Election.validate(ve.Add)
+	c.Region.validate(ve.Add)
+	switch c.Maintenance.Mode {
+	case "off", "persist", "fetch":
+	default:
+		ve.Add("maintenance.mode", "must be one of off, persist, fetch")
+	}
+	c.Startup.validate(ve.Add)
+	c.Chaos.validate(ve.Add)
+	if c.Chaos.Enabled && c.IsProdMode {
+		ve.Add("chaos.enabled", "cannot be enabled in prod mode")
+	}
+	if c.GRPC.Enabled && c.GRPC.Token == "" && c.IsProdMode {
+		ve.Add("grpc.token", "cannot be empty in prod mode")
+	}
+	if c.API.Enabled && c.API.Token == "" && c.IsProdMode {
+		ve.Add("api.token", "cannot be empty in prod mode")
+	}
+	c.Shutdown.validate(ve.Add)
+	if c.Admin.Enabled && c.Metrics.Enabled && slices.Contains(c.Metrics.Sinks, "prometheus") && c.Admin.Port == c.Metrics.Port {
+		ve.Add("metrics.port", "cannot be the admin port")
+	}
+	if c.GRPC.Enabled && ((c.Admin.Enabled && c.GRPC.Port == c.Admin.Port) || (c.Metrics.Enabled && c.GRPC.Port == c.Metrics.Port)) {
+		ve.Add("grpc.port", "cannot be the admin or the metrics port")
+	}
+	if c.API.Enabled && ((c.Admin.Enabled && c.API.Port == c.Admin.Port) || (c.Metrics.Enabled && c.API.Port == c.Metrics.Port) ||
+		(c.GRPC.Enabled && c.API.Port == c.GRPC.Port)) {
+		ve.Add("api.port", "cannot be the admin, the metrics or the grpc port")
+	}
+
+	return ve.Err()
+}
+
+func (l Logger) validate(add func(field, err string)) {
+	switch l.Level {
+	case "debug", "info", "warn", "error", "dpanic", "panic", "fatal":
+	default:
+		add("logger.level", "must be one of debug, info, warn, error, dpanic, panic, fatal")
+	}
+	if l.ToggleTTL < 0 {
+		add("logger.toggle_ttl", "cannot be negative")
+	}
+	l.OTLP.validate(add)
+	l.File.validate(add)
+	if l.Payloads.SampleEvery < 0 {
+		add("logger.payloads.sample_every", "cannot be negative")
+	}
+}
+
+func (e LogExport) validate(add func(field, err string)) {
+	if !e.Enabled {
+		return
+	}
+	if e.Endpoint != "" && !isHostPort(e.Endpoint) {
+		add("logger.otlp.endpoint", "must be a host:port address")
+	}
+	if e.QueueSize <= 0 {
+		add("logger.otlp.queue_size", "must be positive")
+	}
+	if e.BatchSize <= 0 || e.BatchSize > e.QueueSize {
+		add("logger.otlp.batch_size", "must be positive and at most the queue size")
+	}
+	if e.ExportInterval <= 0 {
+		add("logger.otlp.export_interval", "must be positive")
+	}
+}
+
+func (f LogFile) validate(add func(field, err string)) {
+	if !f.Enabled {
+		return
+	}
+	if f.Path == "" {
+		add("logger.file.path", "cannot be empty")
+	}
+	if f.MaxSizeMB <= 0 {
+		add("logger.file.max_size_mb", "must be positive")
+	}
+	if f.MaxAge < 0 {
+		add("logger.file.max_age", "cannot be negative")
+	}
+	if f.MaxBackups < 0 {
+		add("logger.file.max_backups", "cannot be negative")
+	}
+}
+
+func (m Mongo) validate(add func(field, err string)) {
+	if m.URI == "" {
+		add("mongo.uri", "cannot be empty")
+		return
+	}
+	u, err := url.Parse(m.URI)
+	if err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") || u.Host == "" {
+		add("mongo.uri", "must be a mongodb:// or mongodb+srv:// uri with a host")
+	}
+}
+
+func (c Chaos) validate(add func(field, err string)) {
+	rates := map[string]float64{
+		"chaos.processor_error_rate": c.ProcessorErrorRate,
+		"chaos.decode_failure_rate":  c.DecodeFailureRate,
+		"chaos.mongo_latency_rate":   c.MongoLatencyRate,
+		"chaos.redis_timeout_rate":   c.RedisTimeoutRate,
+	}
+	for field, rate := range rates {
+		if rate < 0 || rate > 1 {
+			add(field, "must be between 0 and 1")
+		}
+	}
+	if c.MongoLatency < 0 {
+		add("chaos.mongo_latency", "cannot be negative")
+	}
+}
+
+func (s Startup) validate(add func(field, err string)) {
+	if s.MaxWait < 0 {
+		add("startup.max_wait", "cannot be negative")
+	}
+	if s.InitialBackoff <= 0 {
+		add("startup.initial_backoff", "must be positive")
+	}
+	if s.MaxBackoff < s.InitialBackoff {
+		add("startup.max_backoff", "cannot be below startup.initial_backoff")
+	}
+}
+
+// SinkNames are the sinks consumers can name
+var SinkNames = []string{"mongo", "postgres", "elasticsearch", "s3", "webhook", "clickhouse"}
+
+// validate checks the sinks some consumer lands its transactions in
+func (s Sinks) validate(consumers []Consumer, archived bool, add func(field, err string)) {
+	used := map[string]bool{"s3": archived} // The archive job writes to the s3 sink
+	fanout := false
+	for _, consumer := range consumers {
+		used[consumer.Sink] = true
+		for _, target := range consumer.Fanout {
+			used[target.Sink] = true
+			fanout = true
+		}
+	}
+	if fanout {
+		s.Fanout.validate(add)
+	}
+	if used["postgres"] {
+		u, err := url.Parse(s.Postgres.URI)
+		if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Host == "" {
+			add("sinks.postgres.uri", "must be a postgres:// uri with a host")
+		}
+		if s.Postgres.Table == "" {
+			add("sinks.postgres.table", "cannot be empty")
+		}
+		if s.Postgres.BatchSize <= 0 {
+			add("sinks.postgres.batch_size", "must be positive")
+		}
+		if s.Postgres.MaxConns < 0 {
+			add("sinks.postgres.max_conns", "cannot be negative")
+		}
+	}
+	if used["elasticsearch"] {
+		es := s.Elasticsearch
+		if len(es.Addresses) == 0 {
+			add("sinks.elasticsearch.addresses", "cannot be empty")
+		}
+		for idx, address := range es.Addresses {
+			if u, err := url.Parse(address); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add(fmt.Sprintf("sinks.elasticsearch.addresses[%d]", idx), "must be an http(s):// url with a host")
+			}
+		}
+		if es.Index == "" || es.Index != strings.ToLower(es.Index) {
+			add("sinks.elasticsearch.index", "must be a non empty lowercase name")
+		}
+		if es.Template == "" {
+			add("sinks.elasticsearch.template", "cannot be empty")
+		}
+		if es.Password != "" && es.Username == "" {
+			add("sinks.elasticsearch.username", "is required with a password")
+		}
+		if es.MaxRetries < 0 {
+			add("sinks.elasticsearch.max_retries", "cannot be negative")
+		}
+		if es.RetryBackoff <= 0 {
+			add("sinks.elasticsearch.retry_backoff", "must be positive")
+		}
+		if es.Timeout <= 0 {
+			add("sinks.elasticsearch.timeout", "must be positive")
+		}
+	}
+	if used["s3"] {
+		archive := s.S3
+		if archive.Bucket == "" {
+			add("sinks.s3.bucket", "cannot be empty")
+		}
+		if archive.Region == "" {
+			add("sinks.s3.region", "cannot be empty")
+		}
+		if archive.Format != "parquet" && archive.Format != "ndjson" {
+			add("sinks.s3.format", "must be one of parquet, ndjson")
+		}
+		if archive.Partition != "hour" && archive.Partition != "day" {
+			add("sinks.s3.partition", "must be one of hour, day")
+		}
+		if archive.MaxRecords <= 0 {
+			add("sinks.s3.max_records", "must be positive")
+		}
+		if archive.MaxBytes <= 0 {
+			add("sinks.s3.max_bytes", "must be positive")
+		}
+		if archive.FlushInterval <= 0 {
+			add("sinks.s3.flush_interval", "must be positive")
+		}
+	}
+	if used["webhook"] {
+		s.Webhook.validate(add)
+	}
+	if used["clickhouse"] {
+		ch := s.ClickHouse
+		if u, err := url.Parse(ch.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("sinks.clickhouse.url", "must be an http(s):// url with a host")
+		}
+		if ch.Database == "" {
+			add("sinks.clickhouse.database", "cannot be empty")
+		}
+		if ch.Table == "" {
+			add("sinks.clickhouse.table", "cannot be empty")
+		}
+		if ch.BatchSize <= 0 {
+			add("sinks.clickhouse.batch_size", "must be positive")
+		}
+		if ch.FlushInterval <= 0 {
+			add("sinks.clickhouse.flush_interval", "must be positive")
+		}
+		if ch.Timeout <= 0 {
+			add("sinks.clickhouse.timeout", "must be positive")
+		}
+	}
+}
+
+func (w WebhookSink) validate(add func(field, err string)) {
+	if len(w.Endpoints) == 0 {
+		add("sinks.webhook.endpoints", "cannot be empty")
+	}
+	if w.BatchSize <= 0 {
+		add("sinks.webhook.batch_size", "must be positive")
+	}
+	if w.Timeout <= 0 {
+		add("sinks.webhook.timeout", "must be positive")
+	}
+	if w.DeadLetterPath == "" {
+		add("sinks.webhook.dead_letter_path", "cannot be empty")
+	}
+	names := make(map[string]bool)
+	for idx, endpoint := range w.EndpointList() {
+		prefix := fmt.Sprintf("sinks.webhook.endpoints[%d]", idx)
+		if endpoint.Name == "" || names[endpoint.Name] {
+			add(prefix+".name", "must be set and unique")
+		}
+		names[endpoint.Name] = true
+		if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(prefix+".url", "must be an http(s):// url with a host")
+		}
+		if *endpoint.MaxRetries < 0 {
+			add(prefix+".max_retries", "cannot be negative")
+		}
+		if endpoint.Backoff <= 0 {
+			add(prefix+".backoff", "must be positive")
+		}
+		if endpoint.MaxBackoff < endpoint.Backoff {
+			add(prefix+".max_backoff", "cannot be below the backoff")
+		}
+	}
+}
+
+func (f Fanout) validate(add func(field, err string)) {
+	if f.MaxRetries < 0 {
+		add("sinks.fanout.max_retries", "cannot be negative")
+	}
+	if f.Backoff <= 0 {
+		add("sinks.fanout.backoff", "must be positive")
+	}
+	if f.MaxBackoff < f.Backoff {
+		add("sinks.fanout.max_backoff", "cannot be below the backoff")
+	}
+	if f.QueueSize <= 0 {
+		add("sinks.fanout.queue_size", "must be positive")
+	}
+	if f.Collection == "" {
+		add("sinks.fanout.collection", "cannot be empty")
+	}
+}
+
+// validateFanout checks the sinks a consumer fans out to besides its own
+func validateFanout(prefix string, consumer Consumer, add func(field, err string)) {
+	seen := map[string]bool{consumer.Sink: true}
+	for idx, target := range consumer.Fanout {
+		field := prefix + "[" + strconv.Itoa(idx) + "]"
+		switch {
+		case !slices.Contains(SinkNames, target.Sink):
+			add(field+".sink", "must be one of "+strings.Join(SinkNames, ", "))
+		case seen[target.Sink]:
+			add(field+".sink", strconv.Quote(target.Sink)+" is already written to by consumer "+consumer.Name)
+		}
+		seen[target.Sink] = true
+		if target.Commit != "wait" && target.Commit != "async" {
+			add(field+".commit", "must be one of wait, async")
+		}
+	}
+}
+
+func (s Shadow) validate(primary Mongo, add func(field, err string)) {
+	if !s.Enabled {
+		return
+	}
+	if s.URI != "" {
+		u, err := url.Parse(s.URI)
+		if err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") || u.Host == "" {
+			add("shadow.uri", "must be a mongodb:// or mongodb+srv:// uri with a host")
+		}
+	}
+	if s.Database == "" {
+		add("shadow.database", "cannot be empty")
+	}
+	if s.Collection == "" {
+		add("shadow.collection", "cannot be empty")
+	}
+	if (s.URI == "" || s.URI == primary.URI) && s.Database == "mybase" && s.Collection == "transactions" {
+		add("shadow.collection", "cannot be the primary transactions collection")
+	}
+	if s.Timeout <= 0 {
+		add("shadow.timeout", "must be positive")
+	}
+}
+
+func (r Redis) validate(add func(field, err string)) {
+	switch r.Mode {
+	case "standalone":
+		if r.URI == "" {
+			add("redis.uri", "cannot be empty")
+		} else if !isHostPort(r.URI) {
+			add("redis.uri", "must be a host:port address")
+		}
+	case "cluster", "sentinel":
+		if len(r.Addrs) == 0 {
+			add("redis.addrs", "cannot be empty in "+r.Mode+" mode")
+		}
+		for idx, addr := range r.Addrs {
+			if !isHostPort(addr) {
+				add("redis.addrs["+strconv.Itoa(idx)+"]", "must be a host:port address")
+			}
+		}
+		if r.Mode == "cluster" && r.DB != 0 {
+			add("redis.db", "must be 0 in cluster mode")
+		}
+		if r.Mode == "sentinel" && r.MasterName == "" {
+			add("redis.master_name", "cannot be empty in sentinel mode")
+		}
+	default:
+		add("redis.mode", "must be one of standalone, cluster, sentinel")
+	}
+	if r.DB < 0 || r.DB > 15 {
+		add("redis.db", "must be between 0 and 15")
+	}
+	r.TLS.validate("redis.tls", add)
+	if r.HealthInterval <= 0 {
+		add("redis.health_interval", "must be positive")
+	}
+	if r.HealthTimeout <= 0 {
+		add("redis.health_timeout", "must be positive")
+	} else if r.HealthTimeout >= r.HealthInterval {
+		add("redis.health_timeout", "must be shorter than health_interval")
+	}
+}
+
+func (k Kafka) validate(add func(field, err string)) {
+	brokers := k.BrokerList()
+	if len(brokers) == 0 {
+		add("kafka.brokers", "cannot be empty")
+	}
+	for _, broker := range brokers {
+		if !isHostPort(broker) {
+			add("kafka.brokers", "must be comma separated host:port addresses, got "+strconv.Quote(broker))
+		}
+	}
+	if k.RecordsPerPoll < 1 || k.RecordsPerPoll > maxRecordsPerPoll {
+		add("kafka.records_per_poll", "must be between 1 and "+strconv.Itoa(maxRecordsPerPoll))
+	}
+	if !slices.Contains(SinkNames, k.Sink) {
+		add("kafka.sink", "must be one of "+strings.Join(SinkNames, ", "))
+	}
+	if k.Throttle < 0 {
+		add("kafka.throttle", "cannot be negative")
+	}
+	switch k.SASL.Mechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if k.SASL.Username == "" {
+			add("kafka.sasl.username", "cannot be empty")
+		}
+		if k.SASL.Password == "" {
+			add("kafka.sasl.password", "cannot be empty")
+		}
+	default:
+		add("kafka.sasl.mechanism", "must be one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512")
+	}
+	if k.Restart.MaxRestarts < 0 {
+		add("kafka.restart.max_restarts", "cannot be negative")
+	}
+	if k.Restart.MaxRestarts > 0 {
+		if k.Restart.Window <= 0 {
+			add("kafka.restart.window", "must be positive")
+		}
+		if k.Restart.InitialBackoff <= 0 {
+			add("kafka.restart.initial_backoff", "must be positive")
+		}
+		if k.Restart.MaxBackoff < k.Restart.InitialBackoff {
+			add("kafka.restart.max_backoff", "cannot be below kafka.restart.initial_backoff")
+		}
+	}
+	for idx, consumer := range k.ConsumerList() {
+		prefix := "kafka.fanout"
+		if len(k.Consumers) > 0 && k.Consumers[idx].Fanout != nil {
+			prefix = "kafka.consumers[" + strconv.Itoa(idx) + "].fanout"
+		}
+		validateFanout(prefix, consumer, add)
+	}
+	if len(k.Consumers) == 0 {
+		if k.Topic == "" {
+			add("kafka.topic", "cannot be empty")
+		}
+		if k.ConsumerName == "" {
+			add("kafka.consumer_name", "cannot be empty")
+		}
+		return
+	}
+
+	names := make(map[string]bool, len(k.Consumers))
+	for idx, consumer := range k.Consumers {
+		prefix := "kafka.consumers[" + strconv.Itoa(idx) + "]"
+		if consumer.Name == "" {
+			add(prefix+".name", "cannot be empty")
+		} else if names[consumer.Name] {
+			add(prefix+".name", "must be unique, "+strconv.Quote(consumer.Name)+" is repeated")
+		}
+		names[consumer.Name] = true
+		if consumer.Topic == "" {
+			add(prefix+".topic", "cannot be empty")
+		}
+		if consumer.RecordsPerPoll < 0 || consumer.RecordsPerPoll > maxRecordsPerPoll {
+			add(prefix+".records_per_poll", "must be between 1 and "+strconv.Itoa(maxRecordsPerPoll)+", or 0 for the default")
+		}
+		if consumer.Sink != "" && !slices.Contains(SinkNames, consumer.Sink) {
+			add(prefix+".sink", "must be one of "+strings.Join(SinkNames, ", ")+", or empty for the default")
+		}
+	}
+}
+
+func (d DLQ) validate(add func(field, err string)) {
+	if d.ListName != "" {
+		// Ignoring it would fall back to the default stream
+		add("dlq.list_name", "was renamed to dlq.stream_name, set that instead")
+	}
+	switch d.Backend {
+	case "redis":
+		if d.StreamName == "" {
+			add("dlq.stream_name", "cannot be empty")
+		}
+		if d.Replay.Group == "" {
+			add("dlq.replay.group", "cannot be empty")
+		}
+		if d.Replay.BatchSize <= 0 {
+			add("dlq.replay.batch_size", "must be positive")
+		}
+		if d.Replay.MinIdle < time.Second {
+			add("dlq.replay.min_idle", "must be at least 1s")
+		}
+		if d.EntryTTL < 0 {
+			add("dlq.entry_ttl", "cannot be negative")
+		}
+		if d.MaxLength < 0 {
+			add("dlq.max_length", "cannot be negative")
+		}
+		if d.OverflowPolicy != "drop-oldest" && d.OverflowPolicy != "drop-new-with-alert" {
+			add("dlq.overflow_policy", "must be one of drop-oldest, drop-new-with-alert")
+		}
+		if d.TrimInterval < time.Second {
+			add("dlq.trim_interval", "must be at least 1s")
+		}
+		switch d.Compression.Codec {
+		case "none", "gzip", "zstd":
+		default:
+			add("dlq.compression.codec", "must be one of none, gzip, zstd")
+		}
+		if d.Compression.MinSize < 0 {
+			add("dlq.compression.min_size", "cannot be negative")
+		}
+	case "kafka":
+		if d.Topic == "" {
+			add("dlq.topic", "cannot be empty")
+		}
+	case "mongo":
+		if d.Collection == "" {
+			add("dlq.collection", "cannot be empty")
+		}
+	case "file":
+		if d.FilePath == "" {
+			add("dlq.file_path", "cannot be empty")
+		}
+	case "s3":
+		if d.S3.Bucket == "" {
+			add("dlq.s3.bucket", "cannot be empty")
+		}
+		if d.S3.Region == "" {
+			add("dlq.s3.region", "cannot be empty")
+		}
+		if d.S3.Endpoint != "" && !isHTTPURL(d.S3.Endpoint) {
+			add("dlq.s3.endpoint", "must be a valid http(s) url")
+		}
+	default:
+		add("dlq.backend", "must be one of redis, kafka, mongo, file, s3")
+	}
+	if d.Quarantine.Enabled && d.Quarantine.MaxReplays < 1 {
+		add("dlq.quarantine.max_replays", "must be at least 1")
+	}
+	if d.Retry.Enabled {
+		d.Retry.validate(add)
+	}
+	if d.Alerts.Enabled {
+		d.Alerts.validate(add)
+	}
+}
+
+func (r Retry) validate(add func(field, err string)) {
+	if r.Name == "" {
+		add("dlq.retry.name", "cannot be empty")
+	}
+	if r.BaseDelay <= 0 {
+		add("dlq.retry.base_delay", "must be positive")
+	}
+	if r.MaxDelay < r.BaseDelay {
+		add("dlq.retry.max_delay", "cannot be less than base_delay")
+	}
+	if r.MaxAttempts < 1 {
+		add("dlq.retry.max_attempts", "must be at least 1")
+	}
+	if r.BatchSize <= 0 {
+		add("dlq.retry.batch_size", "must be positive")
+	}
+	if r.Interval <= 0 {
+		add("dlq.retry.interval", "must be positive")
+	}
+	if r.Lease <= 0 {
+		add("dlq.retry.lease", "must be positive")
+	}
+}
+
+func (a Alerts) validate(add func(field, err string)) {
+	if !isHTTPURL(a.WebhookURL) {
+		add("dlq.alerts.webhook_url", "must be a valid http(s) url")
+	}
+	if a.DepthThreshold < 0 {
+		add("dlq.alerts.depth_threshold", "cannot be negative")
+	}
+	if a.QuietPeriod <= 0 {
+		add("dlq.alerts.quiet_period", "must be positive")
+	}
+	if a.Timeout <= 0 || a.Timeout > maxAlertTimeout {
+		add("dlq.alerts.timeout", "must be positive and at most "+maxAlertTimeout.String())
+	}
+}
+
+func (f Features) validate(add func(field, err string)) {
+	switch f.Backend {
+	case "config":
+	case "redis":
+		if f.RedisKey == "" {
+			add("features.redis_key", "cannot be empty for the redis backend")
+		}
+		if f.RefreshInterval <= 0 {
+			add("features.refresh_interval", "must be positive")
+		}
+	default:
+		add("features.backend", "must be one of config, redis")
+	}
+	for name, flag := range f.Flags {
+		if flag.Rollout < 0 || flag.Rollout > 100 {
+			add("features.flags."+name+".rollout", "must be between 0 and 100")
+		}
+	}
+}
+
+func (r Remote) validate(add func(field, err string)) {
+	switch r.Backend {
+	case "":
+		return
+	case "consul":
+	case "etcd":
+		if len(r.Endpoints) == 0 {
+			add("remote.endpoints", "cannot be empty for etcd")
+		}
+	default:
+		add("remote.backend", "must be one of consul, etcd")
+	}
+	if r.Timeout <= 0 {
+		add("remote.timeout", "must be positive")
+	}
+}
+
+func (v Vault) validate(add func(field, err string)) {
+	if !v.Enabled {
+		return
+	}
+	switch v.AuthMethod {
+	case "token":
+	case "approle":
+		if v.RoleID == "" {
+			add("vault.role_id", "cannot be empty for approle auth")
+		}
+		if v.SecretIDFile == "" {
+			add("vault.secret_id_file", "cannot be empty for approle auth")
+		}
+	case "kubernetes":
+		if v.Role == "" {
+			add("vault.role", "cannot be empty for kubernetes auth")
+		}
+		if v.JWTFile == "" {
+			add("vault.jwt_file", "cannot be empty for kubernetes auth")
+		}
+	default:
+		add("vault.auth_method", "must be one of token, approle, kubernetes")
+	}
+	if v.Address != "" && !isHTTPURL(v.Address) {
+		add("vault.address", "must be a valid http(s) url")
+	}
+	if v.Mount == "" {
+		add("vault.mount", "cannot be empty")
+	}
+	if v.Path == "" {
+		add("vault.path", "cannot be empty")
+	}
+}
+
+func (a Admin) validate(add func(field, err string)) {
+	if a.Enabled && (a.Port <= 0 || a.Port > 65535) {
+		add("admin.port", "must be a valid port")
+	}
+	if a.Token != "" && len(a.Token) < 16 {
+		add("admin.token", "must be at least 16 characters")
+	}
+}
+
+func (g GRPC) validate(add func(field, err string)) {
+	if !g.Enabled {
+		return
+	}
+	if g.Port <= 0 || g.Port > 65535 {
+		add("grpc.port", "must be a valid port")
+	}
+	if g.Token != "" && len(g.Token) < 16 {
+		add("grpc.token", "must be at least 16 characters")
+	}
+	if g.MaxPageSize <= 0 {
+		add("grpc.max_page_size", "must be positive")
+	}
+}
+
+func (a API) validate(add func(field, err string)) {
+	if !a.Enabled {
+		return
+	}
+	if a.Port <= 0 || a.Port > 65535 {
+		add("api.port", "must be a valid port")
+	}
+	if a.Token != "" && len(a.Token) < 16 {
+		add("api.token", "must be at least 16 characters")
+	}
+	if a.MaxPageSize <= 0 {
+		add("api.max_page_size", "must be positive")
+	}
+	a.Tail.validate(add)
+}
+
+func (t Tail) validate(add func(field, err string)) {
+	if !t.Enabled {
+		return
+	}
+	if t.MaxSubscribers <= 0 {
+		add("api.tail.max_subscribers", "must be positive")
+	}
+	if t.BufferSize <= 0 {
+		add("api.tail.buffer_size", "must be positive")
+	}
+	if t.Heartbeat <= 0 {
+		add("api.tail.heartbeat", "must be positive")
+	}
+	if t.MaxDuration <= 0 {
+		add("api.tail.max_duration", "must be positive")
+	}
+}
+
+func (t Tracing) validate(add func(field, err string)) {
+	if !t.Enabled {
+		return
+	}
+	if t.Endpoint != "" && !isHostPort(t.Endpoint) {
+		add("tracing.endpoint", "must be a host:port address")
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		add("tracing.sample_ratio", "must be between 0 and 1")
+	}
+}
+
+func (a Audit) validate(add func(field, err string)) {
+	if !a.Enabled {
+		return
+	}
+	switch a.Backend {
+	case "mongo":
+		if a.Collection == "" {
+			add("audit.collection", "cannot be empty")
+		}
+		if a.Retention < 0 {
+			add("audit.retention", "cannot be negative")
+		}
+	case "log":
+	default:
+		add("audit.backend", "must be one of mongo, log")
+	}
+}
+
+func (a Aggregation) validate(add func(field, err string)) {
+	if !a.Enabled {
+		return
+	}
+	if a.Prefix == "" {
+		add("aggregation.prefix", "cannot be empty")
+	}
+	if len(a.GroupBy) == 0 {
+		add("aggregation.group_by", "cannot be empty")
+	}
+	for idx, field := range a.GroupBy {
+		if !slices.Contains(AggregationFields, field) {
+			add(fmt.Sprintf("aggregation.group_by[%d]", idx), "must be one of "+strings.Join(AggregationFields, ", "))
+		}
+	}
+	if a.Grace < 0 {
+		add("aggregation.grace", "cannot be negative")
+	}
+	if a.Retention <= 0 {
+		add("aggregation.retention", "must be positive")
+	}
+	if a.Interval <= 0 {
+		add("aggregation.interval", "must be positive")
+	}
+	if len(a.Windows) == 0 {
+		add("aggregation.windows", "cannot be empty")
+	}
+	names := make(map[string]bool)
+	for idx, window := range a.Windows {
+		prefix := fmt.Sprintf("aggregation.windows[%d]", idx)
+		if window.Name == "" || names[window.Name] {
+			add(prefix+".name", "must be set and unique")
+		}
+		names[window.Name] = true
+		if window.Size <= 0 {
+			add(prefix+".size", "must be positive")
+		}
+		if window.Slide < 0 || (window.Slide > 0 && window.Size%window.Slide != 0) {
+			add(prefix+".slide", "must divide the size")
+		}
+	}
+	switch a.Emit.Target {
+	case "collection":
+		if a.Emit.Collection == "" {
+			add("aggregation.emit.collection", "cannot be empty")
+		}
+	case "topic":
+		if a.Emit.Topic == "" {
+			add("aggregation.emit.topic", "cannot be empty")
+		}
+	default:
+		add("aggregation.emit.target", "must be one of collection, topic")
+	}
+}
+
+func (s Scheduler) validate(agg Aggregation, add func(field, err string)) {
+	if !s.Enabled {
+		return
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		add("scheduler.timezone", "must be an IANA time zone")
+	}
+	validateJob := func(name string, enabled bool, schedule string, timeout time.Duration) bool {
+		if !enabled {
+			return false
+		}
+		if _, err := scheduler.Parse(schedule, loc); err != nil {
+			add("scheduler.jobs."+name+".schedule", err.Error())
+		}
+		if timeout < 0 {
+			add("scheduler.jobs."+name+".timeout", "cannot be negative")
+		}
+		return true
+	}
+
+	jobs := s.Jobs
+	if sweep := jobs.DLQSweep; validateJob("dlq_sweep", sweep.Enabled, sweep.Schedule, sweep.Timeout) {
+		if sweep.MaxAge <= 0 {
+			add("scheduler.jobs.dlq_sweep.max_age", "must be positive")
+		}
+		if sweep.MaxDeletes < 0 {
+			add("scheduler.jobs.dlq_sweep.max_deletes", "cannot be negative")
+		}
+	}
+	if archive := jobs.Archive; validateJob("archive", archive.Enabled, archive.Schedule, archive.Timeout) {
+		if archive.Period <= 0 {
+			add("scheduler.jobs.archive.period", "must be positive")
+		}
+		if archive.BatchSize <= 0 {
+			add("scheduler.jobs.archive.batch_size", "must be positive")
+		}
+	}
+	if reconcile := jobs.Reconcile; validateJob("reconcile", reconcile.Enabled, reconcile.Schedule, reconcile.Timeout) {
+		if reconcile.Period <= 0 {
+			add("scheduler.jobs.reconcile.period", "must be positive")
+		}
+		if reconcile.Delay < 0 {
+			add("scheduler.jobs.reconcile.delay", "cannot be negative")
+		}
+		if reconcile.IdleTimeout <= 0 {
+			add("scheduler.jobs.reconcile.idle_timeout", "must be positive")
+		}
+	}
+	if rollup := jobs.Rollup; validateJob("rollup", rollup.Enabled, rollup.Schedule, rollup.Timeout) {
+		if !agg.Enabled || agg.Emit.Target != "collection" {
+			add("scheduler.jobs.rollup.enabled", "needs the aggregation emitted to a collection")
+		}
+		idx := slices.IndexFunc(agg.Windows, func(w AggregationWindow) bool { return w.Name == rollup.Window })
+		switch {
+		case idx < 0:
+			add("scheduler.jobs.rollup.window", "must name an aggregation window")
+		case agg.Windows[idx].Slide > 0:
+			add("scheduler.jobs.rollup.window", "cannot be a sliding window, its results overlap")
+		case rollup.Period <= 0 || agg.Windows[idx].Size <= 0 || rollup.Period%agg.Windows[idx].Size != 0:
+			add("scheduler.jobs.rollup.period", "must be a multiple of the window size")
+		}
+		if rollup.Collection == "" || rollup.Collection == agg.Emit.Collection {
+			add("scheduler.jobs.rollup.collection", "must be set and differ from aggregation.emit.collection")
+		}
+	}
+}
+
+func (o Outbox) validate(add func(field, err string)) {
+	if !o.Enabled {
+		return
+	}
+	if o.Collection == "" {
+		add("outbox.collection", "cannot be empty")
+	}
+	if o.Topic == "" {
+		add("outbox.topic", "cannot be empty")
+	}
+	relay := o.Relay
+	if relay.BatchSize <= 0 {
+		add("outbox.relay.batch_size", "must be positive")
+	}
+	if relay.Interval <= 0 {
+		add("outbox.relay.interval", "must be positive")
+	}
+	if relay.Lease <= 0 {
+		add("outbox.relay.lease", "must be positive")
+	}
+	if relay.MaxAttempts < 0 {
+		add("outbox.relay.max_attempts", "cannot be negative")
+	}
+	if relay.BaseDelay <= 0 {
+		add("outbox.relay.base_delay", "must be positive")
+	}
+	if relay.MaxDelay < relay.BaseDelay {
+		add("outbox.relay.max_delay", "cannot be less than the base delay")
+	}
+	if relay.Retention < 0 {
+		add("outbox.relay.retention", "cannot be negative")
+	}
+	if relay.CleanupInterval <= 0 {
+		add("outbox.relay.cleanup_interval", "must be positive")
+	}
+}
+
+func (n Notifications) validate(add func(field, err string)) {
+	if !n.Enabled {
+		return
+	}
+	if n.Prefix == "" {
+		add("notifications.prefix", "cannot be empty")
+	}
+	if n.QueueSize <= 0 {
+		add("notifications.queue_size", "must be positive")
+	}
+	if n.Timeout <= 0 {
+		add("notifications.timeout", "must be positive")
+	}
+	if len(n.Channels) == 0 {
+		add("notifications.channels", "cannot be empty")
+	}
+	channels := make(map[string]bool)
+	for idx, channel := range n.Channels {
+		prefix := fmt.Sprintf("notifications.channels[%d]", idx)
+		if channel.Name == "" || channels[channel.Name] {
+			add(prefix+".name", "must be set and unique")
+		}
+		channels[channel.Name] = true
+		switch channel.Type {
+		case "slack", "webhook":
+			if u, err := url.Parse(channel.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				add(prefix+".url", "must be an http(s) url")
+			}
+		case "email":
+			if _, _, err := net.SplitHostPort(channel.SMTPAddr); err != nil {
+				add(prefix+".smtp_addr", "must be host:port")
+			}
+			if channel.From == "" {
+				add(prefix+".from", "cannot be empty")
+			}
+			if len(channel.To) == 0 {
+				add(prefix+".to", "cannot be empty")
+			}
+		default:
+			add(prefix+".type", "must be one of slack, webhook, email")
+		}
+		if channel.RateLimit < 0 {
+			add(prefix+".rate_limit", "cannot be negative")
+		}
+		if channel.Burst < 0 {
+			add(prefix+".burst", "cannot be negative")
+		}
+	}
+
+	if len(n.Rules) == 0 {
+		add("notifications.rules", "cannot be empty")
+	}
+	rules := make(map[string]bool)
+	for idx, rule := range n.Rules {
+		prefix := fmt.Sprintf("notifications.rules[%d]", idx)
+		if rule.Name == "" || rules[rule.Name] || strings.ContainsAny(rule.Name, ":{}") {
+			add(prefix+".name", "must be set, unique and free of :{}")
+		}
+		rules[rule.Name] = true
+		switch rule.Type {
+		case "amount":
+			if rule.MinAmount <= 0 {
+				add(prefix+".min_amount", "must be positive")
+			}
+		case "velocity":
+			if rule.Count <= 1 {
+				add(prefix+".count", "must be more than 1")
+			}
+			if rule.Window <= 0 {
+				add(prefix+".window", "must be positive")
+			}
+			if !slices.Contains(AggregationFields, rule.VelocityField()) {
+				add(prefix+".by", "must be one of "+strings.Join(AggregationFields, ", "))
+			}
+		case "country":
+			if len(rule.Countries) == 0 {
+				add(prefix+".countries", "cannot be empty")
+			}
+		default:
+			add(prefix+".type", "must be one of amount, velocity, country")
+		}
+		if field := rule.DedupField(); field != "transaction_id" && !slices.Contains(AggregationFields, field) {
+			add(prefix+".dedup_by", "must be transaction_id or one of "+strings.Join(AggregationFields, ", "))
+		}
+		if rule.DedupWindow <= 0 {
+			add(prefix+".dedup_window", "must be positive")
+		}
+		for cdx, channel := range rule.Channels {
+			if !channels[channel] {
+				add(fmt.Sprintf("%s.channels[%d]", prefix, cdx), "must name a notification channel")
+			}
+		}
+	}
+}
+
+func (r Risk) validate(add func(field, err string)) {
+	if !r.Enabled {
+		return
+	}
+	names := make(map[string]bool)
+	for idx, rule := range r.Rules {
+		prefix := fmt.Sprintf("risk.rules[%d]", idx)
+		if rule.Name == "" || names[rule.Name] {
+			add(prefix+".name", "must be set and unique")
+		}
+		names[rule.Name] = true
+		if len(rule.When) == 0 {
+			add(prefix+".when", "cannot be empty")
+		}
+		for cdx, condition := range rule.When {
+			err := risk.Validate(risk.Condition{Field: condition.Field, Op: condition.Op, Value: condition.Value, Values: condition.Values})
+			if err != nil {
+				add(fmt.Sprintf("%s.when[%d]", prefix, cdx), err.Error())
+			}
+		}
+	}
+}
+
+func (i Idempotency) validate(add func(field, err string)) {
+	if !i.Enabled {
+		return
+	}
+	if i.Collection == "" {
+		add("idempotency.collection", "cannot be empty")
+	}
+	if i.Retention <= 0 {
+		add("idempotency.retention", "must be positive")
+	}
+}
+
+func (c ClaimCheck) validate(add func(field, err string)) {
+	if !c.Enabled {
+		return
+	}
+	if c.Region == "" {
+		add("claim_check.region", "cannot be empty")
+	}
+	if len(c.Buckets) == 0 {
+		add("claim_check.buckets", "cannot be empty")
+	}
+	if c.MaxBytes <= 0 {
+		add("claim_check.max_bytes", "must be positive")
+	}
+	if c.Timeout <= 0 {
+		add("claim_check.timeout", "must be positive")
+	}
+	if c.Attempts < 1 {
+		add("claim_check.attempts", "must be at least 1")
+	}
+	if c.Backoff < 0 {
+		add("claim_check.backoff", "cannot be negative")
+	}
+	if c.Concurrency < 1 {
+		add("claim_check.concurrency", "must be at least 1")
+	}
+	if c.CacheBytes < 0 {
+		add("claim_check.cache_bytes", "cannot be negative")
+	}
+}
+
+func (f FX) validate(add func(field, err string)) {
+	if !f.Enabled {
+		return
+	}
+	if len(f.BaseCurrency) != 3 {
+		add("fx.base_currency", "must be an ISO 4217 code such as USD")
+	}
+	if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("fx.url", "must be an http(s) url")
+	}
+	if f.APIKey != "" && f.APIKeyHeader == "" {
+		add("fx.api_key_header", "cannot be empty with an api_key")
+	}
+	if f.Timeout <= 0 {
+		add("fx.timeout", "must be positive")
+	}
+	if f.RefreshInterval <= 0 {
+		add("fx.refresh_interval", "must be positive")
+	}
+	if f.MaxAge <= f.RefreshInterval {
+		add("fx.max_age", "must be longer than refresh_interval")
+	}
+	switch f.OnStale {
+	case "use", "skip", "fail":
+	default:
+		add("fx.on_stale", "must be one of use, skip, fail")
+	}
+	if f.CachePrefix == "" {
+		add("fx.cache_prefix", "cannot be empty")
+	}
+}
+
+func (e Election) validate(add func(field, err string)) {
+	if !e.Enabled {
+		return
+	}
+	switch e.Backend {
+	case "kubernetes", "redis":
+	default:
+		add("election.backend", "must be one of kubernetes, redis")
+	}
+	if e.Name == "" {
+		add("election.name", "cannot be empty")
+	}
+	if e.RetryPeriod <= 0 {
+		add("election.retry_period", "must be positive")
+	}
+	// A leader must notice it lost the lock before another can take it
+	if e.RenewDeadline <= e.RetryPeriod || e.RenewDeadline >= e.LeaseDuration {
+		add("election.renew_deadline", "must be longer than retry_period and shorter than lease_duration")
+	}
+	if e.Backend == "redis" && e.RetryPeriod > e.LeaseDuration/3 {
+		add("election.retry_period", "must be at most a third of lease_duration with redis")
+	}
+}
+
+func (r Region) validate(add func(field, err string)) {
+	if r.Name == "" {
+		return
+	}
+	if r.Active == "" {
+		add("region.active", "cannot be empty with a region name")
+	}
+	switch r.Backend {
+	case "config":
+		if r.FailoverAfter > 0 {
+			add("region.failover_after", "needs the redis backend")
+		}
+	case "redis":
+		if r.RedisKey == "" {
+			add("region.redis_key", "cannot be empty")
+		}
+		if r.Interval <= 0 {
+			add("region.interval", "must be positive")
+		}
+		// A few missed reads must not fail over
+		if r.FailoverAfter > 0 && r.FailoverAfter < 3*r.Interval {
+			add("region.failover_after", "must be at least 3 intervals")
+		}
+	default:
+		add("region.backend", "must be one of config, redis")
+	}
+	if r.FailoverAfter < 0 {
+		add("region.failover_after", "cannot be negative")
+	}
+}
+
+func (s Sentry) validate(add func(field, err string)) {
+	if s.DSN == "" {
+		return
+	}
+	if u, err := url.Parse(s.DSN); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User == nil {
+		add("sentry.dsn", "must be an http(s)://key@host/project dsn")
+	}
+	if s.SampleRate <= 0 || s.SampleRate > 1 {
+		add("sentry.sample_rate", "must be above 0 and at most 1")
+	}
+}
+
+func (h Health) validate(add func(field, err string)) {
+	if h.Interval <= 0 {
+		add("health.interval", "must be positive")
+	}
+	if h.Timeout <= 0 {
+		add("health.timeout", "must be positive")
+	} else if h.Timeout >= h.Interval {
+		add("health.timeout", "must be shorter than interval")
+	}
+	if h.StallTimeout <= 0 {
+		add("health.stall_timeout", "must be positive")
+	}
+}
+
+func (m Metrics) validate(add func(field, err string)) {
+	if !m.Enabled {
+		return
+	}
+	if len(m.Sinks) == 0 {
+		add("metrics.sinks", "cannot be empty")
+	}
+	for idx, sink := range m.Sinks {
+		if slices.Contains(m.Sinks[:idx], sink) {
+			add("metrics.sinks", fmt.Sprintf("%s is listed twice", sink))
+			continue
+		}
+		switch sink {
+		case "prometheus":
+			if m.Port <= 0 || m.Port > 65535 {
+				add("metrics.port", "must be a valid port")
+			}
+			if !strings.HasPrefix(m.Path, "/") {
+				add("metrics.path", "must start with /")
+			}
+		case "dogstatsd":
+			if !isHostPort(m.DogStatsD.Address) {
+				add("metrics.dogstatsd.address", "must be host:port")
+			}
+			if m.DogStatsD.Interval <= 0 {
+				add("metrics.dogstatsd.interval", "must be positive")
+			}
+			for _, tag := range m.DogStatsD.Tags {
+				if tag == "" || strings.ContainsAny(tag, ",|#") {
+					add("metrics.dogstatsd.tags", fmt.Sprintf("%q must be non-empty without , | or #", tag))
+				}
+			}
+		default:
+			add("metrics.sinks", fmt.Sprintf("%s must be one of prometheus, dogstatsd", sink))
+		}
+	}
+	if m.LagInterval < 0 {
+		add("metrics.lag_interval", "must not be negative")
+	}
+	if m.SLO.FreshnessTarget <= 0 {
+		add("metrics.slo.freshness_target", "must be positive")
+	}
+	if m.SLO.Objective <= 0 || m.SLO.Objective >= 1 {
+		add("metrics.slo.objective", "must be between 0 and 1, exclusive")
+	}
+}
+
+// isHostPort reports whether addr is host:port with a numeric port
+func isHostPort(addr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func (s Shutdown) validate(add func(field, err string)) {
+	if s.DrainTimeout <= 0 {
+		add("shutdown.drain_timeout", "must be positive")
+	}
+	timeouts := []struct {
+		key     string
+		timeout time.Duration
+	}{
+		{"stop_fetching", s.Timeouts.StopFetching},
+		{"drain_workers", s.Timeouts.DrainWorkers},
+		{"flush", s.Timeouts.Flush},
+		{"commit", s.Timeouts.Commit},
+		{"close_stores", s.Timeouts.CloseStores},
+		{"stop_servers", s.Timeouts.StopServers},
+		{"telemetry", s.Timeouts.Telemetry},
+	}
+	for _, phase := range timeouts {
+		if phase.timeout <= 0 {
+			add("shutdown.timeouts."+phase.key, "must be positive")
+		}
+	}
+	if s.Drain && s.Timeouts.StopFetching < s.DrainTimeout {
+		add("shutdown.timeouts.stop_fetching", "must cover shutdown.drain_timeout when draining")
+	}
+}
@@ -0,0 +1,131 @@
+package election
+
+import (
+	// Go Internal Packages
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Lock is the leadership record shared by the replicas
+type Lock interface {
+	// TryAcquire takes the lock if it is free or expired and renews it if
+	// this instance holds it, false means another instance holds it
+	TryAcquire(ctx context.Context) (bool, error)
+	// Release frees the lock if this instance still holds it
+	Release(ctx context.Context) error
+}
+
+// Elector runs the registered jobs only while this instance holds the lock.
+// A leader that cannot renew within RenewDeadline stops its jobs before the
+// lock expires, so two instances never run them at the same time.
+type Elector struct {
+	Lock          Lock
+	Logger        *zap.Logger
+	Identity      string
+	RetryPeriod   time.Duration // Between attempts to acquire or renew
+	RenewDeadline time.Duration // Shorter than the lock duration
+
+	leading atomic.Bool
+	mu      sync.Mutex
+	jobs    map[string]func(ctx context.Context)
+}
+
+func NewElector(lock Lock, logger *zap.Logger, identity string, retryPeriod, renewDeadline time.Duration) *Elector {
+	return &Elector{
+		Lock:          lock,
+		Logger:        logger,
+		Identity:      identity,
+		RetryPeriod:   retryPeriod,
+		RenewDeadline: renewDeadline,
+		jobs:          make(map[string]func(ctx context.Context)),
+	}
+}
+
+// Go registers a job started on every election and stopped, by canceling
+// its context, on every loss of the leadership
+// (PS: Must be called before Run)
+func (e *Elector) Go(name string, job func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.jobs[name] = job
+}
+
+// Leading reports whether this instance runs the jobs
+func (e *Elector) Leading() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns until the context is canceled, then stops the jobs and
+// releases the lock so another instance takes over right away
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.RetryPeriod)
+	defer ticker.Stop()
+
+	var stop func()
+	var renewed time.Time
+	for {
+		acquired, err := e.tryAcquire(ctx)
+		switch {
+		case acquired:
+			renewed = time.Now()
+			if stop == nil {
+				e.Logger.Info("elected leader, starting the singleton jobs", zap.String("identity", e.Identity))
+				stop = e.start(ctx)
+			}
+		case stop != nil && (err == nil || time.Since(renewed) > e.RenewDeadline):
+			e.Logger.Warn("lost the leadership, stopping the singleton jobs", zap.String("identity", e.Identity), zap.Error(err))
+			stop()
+			stop = nil
+		case err != nil && ctx.Err() == nil:
+			e.Logger.Warn("failed to campaign for leadership", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stop()
+				releaseCtx, cancel := context.WithTimeout(context.Background(), e.RetryPeriod)
+				if err := e.Lock.Release(releaseCtx); err != nil {
+					e.Logger.Warn("failed to release the leadership", zap.Error(err))
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tryAcquire(ctx context.Context) (bool, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, e.RetryPeriod)
+	defer cancel()
+	return e.Lock.TryAcquire(attemptCtx)
+}
+
+// start runs every job and returns the func stopping them and waiting for them to return
+func (e *Elector) start(ctx context.Context) func() {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	e.mu.Lock()
+	for name, job := range e.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Logger.Info("starting singleton job", zap.String("job", name))
+			job(leaderCtx)
+		}()
+	}
+	e.mu.Unlock()
+	e.leading.Store(true)
+
+	return func() {
+		e.leading.Store(false)
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,245 @@
+package election
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Files of the service account mounted into every pod
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	leaseTimeFormat   = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// lease is the part of a coordination.k8s.io/v1 Lease the election uses
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions"`
+}
+
+// KubernetesLock keeps the leadership in a Lease object, updated with the
+// resource version so two replicas never both win a race for it. It talks
+// to the API server with the pod's service account, which needs get,
+// create and update on leases in the namespace.
+type KubernetesLock struct {
+	Client    *http.Client
+	Host      string // https://host:port of the API server
+	TokenFile string
+	Namespace string
+	Name      string
+	Identity  string
+	Duration  time.Duration
+
+	mu      sync.Mutex
+	current *lease // As last read or written
+}
+
+// NewKubernetesLock configures the lock from the in-cluster environment, an
+// empty namespace is read from the service account
+func NewKubernetesLock(namespace, name, identity string, duration time.Duration) (*KubernetesLock, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in kubernetes, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account ca: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate in the service account ca")
+	}
+	if namespace == "" {
+		raw, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the service account namespace: %v", err)
+		}
+		namespace = strings.TrimSpace(string(raw))
+	}
+	return &KubernetesLock{
+		Client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+		},
+		Host:      "https://" + net.JoinHostPort(host, port),
+		TokenFile: serviceAccountDir + "/token",
+		Namespace: namespace,
+		Name:      name,
+		Identity:  identity,
+		Duration:  duration,
+	}, nil
+}
+
+func (l *KubernetesLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current, found, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if !found {
+		return l.write(ctx, http.MethodPost, l.newLease(now))
+	}
+
+	spec := current.Spec
+	if spec.HolderIdentity != l.Identity && spec.HolderIdentity != "" {
+		renewed, err := time.Parse(leaseTimeFormat, spec.RenewTime)
+		expires := renewed.Add(time.Duration(spec.LeaseDurationSeconds) * time.Second)
+		if err == nil && now.Before(expires) {
+			return false, nil
+		}
+	}
+
+	updated := *current
+	if spec.HolderIdentity != l.Identity {
+		updated.Spec.HolderIdentity = l.Identity
+		updated.Spec.AcquireTime = now.UTC().Format(leaseTimeFormat)
+		updated.Spec.LeaseTransitions++
+	}
+	updated.Spec.LeaseDurationSeconds = l.durationSeconds()
+	updated.Spec.RenewTime = now.UTC().Format(leaseTimeFormat)
+	return l.write(ctx, http.MethodPut, &updated)
+}
+
+// Release hands the lease over by clearing the holder, others take it on their next attempt
+func (l *KubernetesLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil || l.current.Spec.HolderIdentity != l.Identity {
+		return nil
+	}
+	released := *l.current
+	released.Spec.HolderIdentity = ""
+	released.Spec.LeaseDurationSeconds = 1
+	_, err := l.write(ctx, http.MethodPut, &released)
+	return err
+}
+
+func (l *KubernetesLock) newLease(now time.Time) *lease {
+	at := now.UTC().Format(leaseTimeFormat)
+	return &lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata:   leaseMetadata{Name: l.Name, Namespace: l.Namespace},
+		Spec: leaseSpec{
+			HolderIdentity:       l.Identity,
+			LeaseDurationSeconds: l.durationSeconds(),
+			AcquireTime:          at,
+			RenewTime:            at,
+		},
+	}
+}
+
+func (l *KubernetesLock) durationSeconds() int {
+	return int((l.Duration + time.Second - 1) / time.Second)
+}
+
+func (l *KubernetesLock) url(withName bool) string {
+	url := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", l.Host, l.Namespace)
+	if withName {
+		url += "/" + l.Name
+	}
+	return url
+}
+
+func (l *KubernetesLock) get(ctx context.Context) (*lease, bool, error) {
+	resp, err := l.do(ctx, http.MethodGet, l.url(true), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var current lease
+		if err := json.NewDecoder(resp.Body).Decode(&current); err != nil {
+			return nil, false, fmt.Errorf("failed to decode lease: %v", err)
+		}
+		l.current = &current
+		return &current, true, nil
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, responseError("get", resp)
+	}
+}
+
+// write creates or updates the lease, a conflict means another replica wrote it first
+func (l *KubernetesLock) write(ctx context.Context, method string, body *lease) (bool, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return false, err
+	}
+	resp, err := l.do(ctx, method, l.url(method == http.MethodPut), raw)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var written lease
+		if err := json.NewDecoder(resp.Body).Decode(&written); err != nil {
+			return false, fmt.Errorf("failed to decode lease: %v", err)
+		}
+		l.current = &written
+		return written.Spec.HolderIdentity == l.Identity, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, responseError(strings.ToLower(method), resp)
+	}
+}
+
+func (l *KubernetesLock) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	// The token is read on every request as kubelet rotates it
+	token, err := os.ReadFile(l.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account token: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s lease: %v", strings.ToLower(method), err)
+	}
+	return resp, nil
+}
+
+func responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("failed to %s lease: %s: %s", action, resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,63 @@
+package election
+
+import (
+	// Go Internal Packages
+	"context"
+	"sync"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	redis "tx-stream/repositories/redis"
+)
+
+// RedisLock keeps the leadership in a redis lock, for deployments outside
+// kubernetes. The lease renews itself in the background.
+type RedisLock struct {
+	Locker *redis.Locker
+	Name   string
+	TTL    time.Duration
+
+	mu    sync.Mutex
+	lease *redis.Lease
+}
+
+func NewRedisLock(locker *redis.Locker, name string, ttl time.Duration) *RedisLock {
+	return &RedisLock{Locker: locker, Name: name, TTL: ttl}
+}
+
+func (l *RedisLock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lease != nil {
+		select {
+		case <-l.lease.Lost():
+			l.lease = nil
+			return false, nil
+		default:
+			return true, nil
+		}
+	}
+
+	lease, err := l.Locker.Acquire(ctx, "election:"+l.Name, l.TTL)
+	var appErr *errors.Error
+	if errors.As(err, &appErr) && appErr.Kind == errors.Conflict {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	l.lease = lease
+	return true, nil
+}
+
+func (l *RedisLock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lease == nil {
+		return nil
+	}
+	lease := l.lease
+	l.lease = nil
+	return lease.Release(ctx)
+}
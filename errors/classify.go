@@ -0,0 +1,71 @@
+package errors
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"errors"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Error classes counted by the error metrics, so alerts can tell bad input
+// from an unavailable dependency
+const (
+	ClassDecode         = "decode"
+	ClassValidation     = "validation"
+	ClassMongoTransient = "mongo_transient"
+	ClassMongoPermanent = "mongo_permanent"
+	ClassRedis          = "redis"
+	ClassFXRates        = "fx_rates"
+	ClassClaimCheck     = "claim_check"
+	ClassUnknown        = "unknown"
+)
+
+// ErrorClass returns the class of an error as it left the decoder or the
+// driver, the driver types do not survive being formatted with %v
+func ErrorClass(err error) string {
+	var (
+		appErr         *Error
+		validationErrs ValidationErrors
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		redisErr       redis.Error
+	)
+	switch {
+	case err == nil:
+		return ClassUnknown
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ClassDecode
+	case errors.As(err, &validationErrs), errors.As(err, &appErr) && appErr.Kind == Invalid:
+		return ClassValidation
+	case errors.As(err, &redisErr), errors.Is(err, redis.ErrClosed):
+		return ClassRedis
+	}
+	return mongoClass(err)
+}
+
+// mongoClass tells the mongo errors worth retrying from the ones that fail
+// the same way every time, e.g. a duplicate key
+func mongoClass(err error) string {
+	var (
+		serverErr  mongo.ServerError
+		marshalErr mongo.MarshalError
+	)
+	switch {
+	case mongo.IsDuplicateKeyError(err):
+		return ClassMongoPermanent
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return ClassMongoTransient
+	case errors.As(err, &serverErr):
+		if serverErr.HasErrorLabel("RetryableWriteError") || serverErr.HasErrorLabel("TransientTransactionError") {
+			return ClassMongoTransient
+		}
+		return ClassMongoPermanent
+	case errors.As(err, &marshalErr), errors.Is(err, mongo.ErrEmptySlice), errors.Is(err, mongo.ErrNilDocument):
+		return ClassMongoPermanent
+	default:
+		return ClassUnknown
+	}
+}
@@ -3,8 +3,10 @@ package errors
 import (
 	// Go Internal Packages
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 )
 
 // Error defines a standard application error.
@@ -56,10 +58,16 @@ func (k Kind) String() string {
 		return "unclassified error"
 	case Internal:
 		return "internal error"
+	case Conflict:
+		return "entity already exists"
 	case Invalid:
 		return "invalid input"
 	case NotFound:
 		return "entity not found"
+	case Unauthorized:
+		return "unauthorized access"
+	case Forbidden:
+		return "forbidden access"
 	default:
 		return "unknown error kind"
 	}
@@ -86,7 +94,26 @@ func E(args ...interface{}) error {
 	return e
 }
 
+// Class returns a short, low-cardinality classification of the error,
+// suitable for metric labels and triage
+func Class(err error) string {
+	var appErr *Error
+	switch {
+	case err == nil:
+		return "unknown"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &appErr):
+		return strings.ReplaceAll(appErr.Kind.String(), " ", "_")
+	default:
+		return "processing_failed"
+	}
+}
+
 var (
-	As = errors.As
-	Is = errors.Is
+	As   = errors.As
+	Is   = errors.Is
+	Join = errors.Join
 )
@@ -1,5 +1,10 @@
 package errors
 
+import (
+	// Go Internal Packages
+	"strings"
+)
+
 type FieldError struct {
 	Field string `json:"field"`
 	Error string `json:"error"`
@@ -11,8 +16,19 @@ type FieldError struct {
 // Which is error prone, so use ValidationErrorBuilder instead.
 type ValidationErrors []FieldError
 
+// Error lists every field error, e.g. "validation failed: kafka.topic: cannot be empty"
 func (v ValidationErrors) Error() string {
-	return "validation failed"
+	var b strings.Builder
+	b.WriteString("validation failed")
+	for idx, fe := range v {
+		if idx == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(fe.Field + ": " + fe.Error)
+	}
+	return b.String()
 }
 
 func ValidationErrs() *ValidationErrorBuilder {
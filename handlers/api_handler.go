@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"net/http"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+	txsvc "tx-stream/services/transactions"
+)
+
+type TxQueries interface {
+	Get(ctx context.Context, id string) (models.MongoTransaction, error)
+	List(ctx context.Context, filter models.TxFilter, pageSize int, pageToken string) (txsvc.TxPage, error)
+	Status(ctx context.Context, ids []string) ([]txsvc.TxStatus, error)
+}
+
+type DLQLister interface {
+	List(ctx context.Context, offset, limit int64) (models.DLQPage, error)
+}
+
+type SinkFailureLister interface {
+	List(ctx context.Context, offset, limit int64) (models.SinkDeadLetterPage, error)
+}
+
+// APIHandler serves the read-only support API, apart from the admin server
+// so the support tooling never holds the consumer controls. DLQ and
+// SinkFailures are optional, their endpoints are left out when nil.
+type APIHandler struct {
+	Queries      TxQueries
+	DLQ          DLQLister
+	SinkFailures SinkFailureLister
+	Stats        *StatsHandler
+	MaxPageSize  int64 // Caps the limit of the failure listings
+}
+
+func NewAPIHandler(queries TxQueries, dlq DLQLister, sinkFailures SinkFailureLister, stats *StatsHandler, maxPageSize int64) *APIHandler {
+	return &APIHandler{Queries: queries, DLQ: dlq, SinkFailures: sinkFailures, Stats: stats, MaxPageSize: maxPageSize}
+}
+
+// Register mounts the API endpoints on the mux
+func (h *APIHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/transactions", h.ListTransactions)
+	mux.HandleFunc("GET /v1/transactions/{id}", h.GetTransaction)
+	mux.HandleFunc("GET /v1/transactions/{id}/status", h.TransactionStatus)
+	mux.HandleFunc("GET /v1/accounts/{user_id}/transactions", h.AccountTransactions)
+	if h.DLQ != nil {
+		mux.HandleFunc("GET /v1/failures", h.Failures)
+	}
+	if h.SinkFailures != nil {
+		mux.HandleFunc("GET /v1/failures/sinks", h.SinkFailureList)
+	}
+	mux.HandleFunc("GET /v1/pipeline/stats", h.Stats.Stats)
+}
+
+// GetTransaction returns a single processed transaction
+func (h *APIHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	tx, err := h.Queries.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, tx)
+}
+
+// TransactionStatus tells whether a transaction was processed and which
+// fan-out sinks refused it
+func (h *APIHandler) TransactionStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.Queries.Status(r.Context(), []string{r.PathValue("id")})
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, statuses[0])
+}
+
+// ListTransactions pages through the transactions matching the filter query
+// params, paged with page_size and the page_token of the previous page
+func (h *APIHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	h.listTransactions(w, r, r.URL.Query().Get("user_id"))
+}
+
+// AccountTransactions pages through the transactions of an account, filtered
+// and paged like ListTransactions
+func (h *APIHandler) AccountTransactions(w http.ResponseWriter, r *http.Request) {
+	h.listTransactions(w, r, r.PathValue("user_id"))
+}
+
+func (h *APIHandler) listTransactions(w http.ResponseWriter, r *http.Request, userID string) {
+	query := r.URL.Query()
+	filter := models.TxFilter{
+		UserID:          userID,
+		Status:          query.Get("status"),
+		Currency:        query.Get("currency"),
+		TransactionType: query.Get("transaction_type"),
+	}
+	var err error
+	if filter.ProcessedAfter, err = queryTime(r, "processed_after"); err != nil {
+		WriteError(w, errors.E(errors.Invalid, "processed_after must be an RFC 3339 time"))
+		return
+	}
+	if filter.ProcessedBefore, err = queryTime(r, "processed_before"); err != nil {
+		WriteError(w, errors.E(errors.Invalid, "processed_before must be an RFC 3339 time"))
+		return
+	}
+	pageSize, err := queryInt(r, "page_size", 0)
+	if err != nil {
+		WriteError(w, errors.E(errors.Invalid, "page_size must be an integer"))
+		return
+	}
+
+	page, err := h.Queries.List(r.Context(), filter, int(pageSize), query.Get("page_token"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+// Failures returns a page of the dead-lettered records, most recently failed
+// first, paged with the offset and limit query params
+func (h *APIHandler) Failures(w http.ResponseWriter, r *http.Request) {
+	offset, limit, ok := h.offsetLimit(w, r)
+	if !ok {
+		return
+	}
+	page, err := h.DLQ.List(r.Context(), offset, limit)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+// SinkFailureList returns a page of the transactions the fan-out sinks
+// refused, most recently failed first, paged like Failures
+func (h *APIHandler) SinkFailureList(w http.ResponseWriter, r *http.Request) {
+	offset, limit, ok := h.offsetLimit(w, r)
+	if !ok {
+		return
+	}
+	page, err := h.SinkFailures.List(r.Context(), offset, limit)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+func (h *APIHandler) offsetLimit(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		WriteError(w, errors.E(errors.Invalid, "offset must be a non-negative integer"))
+		return 0, 0, false
+	}
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil || limit <= 0 {
+		WriteError(w, errors.E(errors.Invalid, "limit must be a positive integer"))
+		return 0, 0, false
+	}
+	return offset, min(limit, h.MaxPageSize), true
+}
+
+func queryTime(r *http.Request, key string) (time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
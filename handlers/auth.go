@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	// Local Packages
+	errors "tx-stream/errors"
+)
+
+// RequireToken rejects the requests without the bearer token, the open
+// paths such as the kubelet probes are served without it
+func RequireToken(token string, open []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slices.Contains(open, r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tx-stream"`)
+			WriteError(w, errors.E(errors.Unauthorized, "missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	kafka "tx-stream/kafka"
+)
+
+type ConsumerControl interface {
+	Status(ctx context.Context) kafka.ConsumerStatus
+	Pause()
+	Resume()
+	Commit(ctx context.Context) (map[string]map[int32]int64, error)
+	Skip(ctx context.Context, partition int32, offset int64, reason string) (kafka.SkippedRecord, error)
+}
+
+// SkipRequest names the stuck record to abandon and why
+type SkipRequest struct {
+	Partition *int32 `json:"partition"`
+	Offset    *int64 `json:"offset"`
+	Reason    string `json:"reason"`
+}
+
+// ConsumerHandler lets operators intervene on the consumers during an
+// incident, the consumers are named as in the config
+type ConsumerHandler struct {
+	Consumers map[string]ConsumerControl
+}
+
+func NewConsumerHandler(consumers map[string]ConsumerControl) *ConsumerHandler {
+	return &ConsumerHandler{Consumers: consumers}
+}
+
+// Register mounts the consumer endpoints on the mux
+func (h *ConsumerHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /consumers", h.List)
+	mux.HandleFunc("GET /consumers/{name}", h.Show)
+	mux.HandleFunc("POST /consumers/{name}/pause", h.Pause)
+	mux.HandleFunc("POST /consumers/{name}/resume", h.Resume)
+	mux.HandleFunc("POST /consumers/{name}/commit", h.Commit)
+	mux.HandleFunc("POST /consumers/{name}/skip", h.Skip)
+}
+
+// List returns the status of every consumer, sorted by name
+func (h *ConsumerHandler) List(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(h.Consumers))
+	for name := range h.Consumers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	statuses := make([]kafka.ConsumerStatus, len(names))
+	for idx, name := range names {
+		statuses[idx] = h.Consumers[name].Status(r.Context())
+	}
+	WriteJSON(w, http.StatusOK, statuses)
+}
+
+// Show returns the assignments and the lag of a consumer
+func (h *ConsumerHandler) Show(w http.ResponseWriter, r *http.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+	WriteJSON(w, http.StatusOK, consumer.Status(r.Context()))
+}
+
+// Pause stops fetching, the batch in processing is still committed
+func (h *ConsumerHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+	consumer.Pause()
+	WriteJSON(w, http.StatusOK, consumer.Status(r.Context()))
+}
+
+// Resume fetches again after a pause
+func (h *ConsumerHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+	consumer.Resume()
+	WriteJSON(w, http.StatusOK, consumer.Status(r.Context()))
+}
+
+// Commit retries the failed commits of processed records and returns the
+// committed offsets, empty when every processed record was committed
+func (h *ConsumerHandler) Commit(w http.ResponseWriter, r *http.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+	committed, err := consumer.Commit(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"committed": committed})
+}
+
+// Skip abandons a poison record at the committed offset of a partition the
+// instance owns, copying it to the DLQ first
+func (h *ConsumerHandler) Skip(w http.ResponseWriter, r *http.Request) {
+	consumer, ok := h.consumer(w, r)
+	if !ok {
+		return
+	}
+	var req SkipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, errors.InvalidBodyErr(err))
+		return
+	}
+	if req.Partition == nil || req.Offset == nil || req.Reason == "" {
+		WriteError(w, errors.E(errors.Invalid, "partition, offset and reason are required"))
+		return
+	}
+	skipped, err := consumer.Skip(r.Context(), *req.Partition, *req.Offset, req.Reason)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, skipped)
+}
+
+func (h *ConsumerHandler) consumer(w http.ResponseWriter, r *http.Request) (ConsumerControl, bool) {
+	name := r.PathValue("name")
+	consumer, ok := h.Consumers[name]
+	if !ok {
+		WriteError(w, errors.E(errors.NotFound, "consumer "+name+" not found"))
+	}
+	return consumer, ok
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+)
+
+// DebugHandler serves the pprof profiles and a runtime summary, meant to
+// stay off unless someone is diagnosing the process
+type DebugHandler struct{}
+
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{}
+}
+
+// Register mounts the debug endpoints on the mux
+func (h *DebugHandler) Register(mux *http.ServeMux) {
+	// Index also serves the named profiles, e.g. /debug/pprof/heap and /debug/pprof/goroutine
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/runtime", h.Runtime)
+}
+
+// Runtime returns the goroutine count, memory and GC stats and the build info
+func (h *DebugHandler) Runtime(w http.ResponseWriter, _ *http.Request) {
+	body := map[string]any{
+		"goroutines": runtime.NumGoroutine(),
+		"go_version": runtime.Version(),
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+		"memory":     memoryStats(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		body["module"] = info.Main.Path
+		body["module_version"] = info.Main.Version
+	}
+	WriteJSON(w, http.StatusOK, body)
+}
+
+func memoryStats() map[string]uint64 {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return map[string]uint64{
+		"heap_alloc_bytes":   mem.HeapAlloc,
+		"heap_inuse_bytes":   mem.HeapInuse,
+		"heap_objects":       mem.HeapObjects,
+		"stack_inuse_bytes":  mem.StackInuse,
+		"sys_bytes":          mem.Sys,
+		"total_alloc_bytes":  mem.TotalAlloc,
+		"gc_cycles":          uint64(mem.NumGC),
+		"gc_pause_total_ns":  mem.PauseTotalNs,
+		"next_gc_heap_bytes": mem.NextGC,
+	}
+}
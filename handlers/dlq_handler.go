@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+)
+
+type DLQService interface {
+	List(ctx context.Context, offset, limit int64) (models.DLQPage, error)
+	Show(ctx context.Context, id string) (models.DLQEntry, error)
+	Delete(ctx context.Context, id string) error
+	Requeue(ctx context.Context, id string) error
+	ReplayMatching(ctx context.Context, filter models.DLQFilter, limit int, dryRun bool) (models.DLQReplayResult, error)
+	ListQuarantine(ctx context.Context, offset, limit int64) (models.DLQPage, error)
+	ShowQuarantine(ctx context.Context, id string) (models.DLQEntry, error)
+	DeleteQuarantine(ctx context.Context, id string) error
+	AckQuarantine(ctx context.Context, id string) error
+}
+
+type DLQHandler struct {
+	Service DLQService
+}
+
+func NewDLQHandler(service DLQService) *DLQHandler {
+	return &DLQHandler{Service: service}
+}
+
+// Register mounts the DLQ endpoints on the mux
+func (h *DLQHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /dlq", h.List)
+	mux.HandleFunc("POST /dlq/replay", h.Replay)
+	mux.HandleFunc("GET /dlq/{id}", h.Show)
+	mux.HandleFunc("DELETE /dlq/{id}", h.Delete)
+	mux.HandleFunc("POST /dlq/{id}/requeue", h.Requeue)
+	mux.HandleFunc("GET /quarantine", h.ListQuarantine)
+	mux.HandleFunc("GET /quarantine/{id}", h.ShowQuarantine)
+	mux.HandleFunc("DELETE /quarantine/{id}", h.DeleteQuarantine)
+	mux.HandleFunc("POST /quarantine/{id}/ack", h.AckQuarantine)
+}
+
+// List returns a page of entries, paged with the offset and limit query params
+func (h *DLQHandler) List(w http.ResponseWriter, r *http.Request) {
+	h.listPage(w, r, h.Service.List)
+}
+
+// ListQuarantine returns a page of quarantined entries, paged like List
+func (h *DLQHandler) ListQuarantine(w http.ResponseWriter, r *http.Request) {
+	h.listPage(w, r, h.Service.ListQuarantine)
+}
+
+func (h *DLQHandler) listPage(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, offset, limit int64) (models.DLQPage, error)) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		WriteError(w, errors.InvalidParamsErr(err))
+		return
+	}
+	limit, err := queryInt(r, "limit", 50)
+	if err != nil || limit <= 0 {
+		WriteError(w, errors.E(errors.Invalid, "limit must be a positive integer"))
+		return
+	}
+
+	page, err := list(r.Context(), offset, limit)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, page)
+}
+
+// Show returns a single entry
+func (h *DLQHandler) Show(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.Service.Show(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, entry)
+}
+
+// Delete drops a single entry
+func (h *DLQHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.Delete(r.Context(), r.PathValue("id")); err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Requeue publishes a single entry back to its original topic
+func (h *DLQHandler) Requeue(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.Requeue(r.Context(), r.PathValue("id")); err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+type replayRequest struct {
+	models.DLQFilter
+	Limit  int  `json:"limit"`
+	DryRun bool `json:"dry_run"`
+}
+
+// Replay requeues the entries selected by the filter in the request body
+func (h *DLQHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	req := replayRequest{Limit: 1000}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		WriteError(w, errors.InvalidBodyErr(err))
+		return
+	}
+	if req.Limit <= 0 {
+		WriteError(w, errors.E(errors.Invalid, "limit must be a positive integer"))
+		return
+	}
+
+	result, err := h.Service.ReplayMatching(r.Context(), req.DLQFilter, req.Limit, req.DryRun)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, result)
+}
+
+// ShowQuarantine returns a single quarantined entry
+func (h *DLQHandler) ShowQuarantine(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.Service.ShowQuarantine(r.Context(), r.PathValue("id"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, entry)
+}
+
+// DeleteQuarantine drops a single quarantined entry
+func (h *DLQHandler) DeleteQuarantine(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.DeleteQuarantine(r.Context(), r.PathValue("id")); err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AckQuarantine acknowledges a quarantined entry and moves it back to the DLQ
+func (h *DLQHandler) AckQuarantine(w http.ResponseWriter, r *http.Request) {
+	if err := h.Service.AckQuarantine(r.Context(), r.PathValue("id")); err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func queryInt(r *http.Request, key string, fallback int64) (int64, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"net/http"
+)
+
+// DrainHandler hands the partitions over to the other replicas ahead of a
+// shutdown, a preStop hook calls it so the pod stops after the handover. It is
+// served behind the admin token like the other controls.
+type DrainHandler struct {
+	Drain func(ctx context.Context) error
+}
+
+func NewDrainHandler(drain func(ctx context.Context) error) *DrainHandler {
+	return &DrainHandler{Drain: drain}
+}
+
+// Register mounts the drain endpoint on the mux, GET as well for preStop
+// httpGet hooks, which send the token in their httpHeaders
+func (h *DrainHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /drain", h.Run)
+	mux.HandleFunc("GET /drain", h.Run)
+}
+
+// Run drains the consumers and answers once they are drained, repeated calls
+// wait for the same drain
+func (h *DrainHandler) Run(w http.ResponseWriter, r *http.Request) {
+	if err := h.Drain(r.Context()); err != nil {
+		WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "incomplete", "error": err.Error()})
+		return
+	}
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "drained"})
+}
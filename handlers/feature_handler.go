@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"net/http"
+
+	// Local Packages
+	models "tx-stream/models"
+)
+
+type FeatureFlags interface {
+	List() map[string]models.FeatureFlag
+}
+
+type FeatureHandler struct {
+	Flags FeatureFlags
+}
+
+func NewFeatureHandler(flags FeatureFlags) *FeatureHandler {
+	return &FeatureHandler{Flags: flags}
+}
+
+// Register mounts the feature flag endpoints on the mux
+func (h *FeatureHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /features", h.List)
+}
+
+// List returns the current flags, with the tenant query param it returns
+// whether each flag is on for that tenant instead
+func (h *FeatureHandler) List(w http.ResponseWriter, r *http.Request) {
+	flags := h.Flags.List()
+	if !r.URL.Query().Has("tenant") {
+		WriteJSON(w, http.StatusOK, flags)
+		return
+	}
+
+	tenant := r.URL.Query().Get("tenant")
+	results := make(map[string]bool, len(flags))
+	for name, flag := range flags {
+		results[name] = flag.EnabledFor(name, tenant)
+	}
+	WriteJSON(w, http.StatusOK, map[string]any{"tenant": tenant, "flags": results})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ReadinessCheck returns nil while the dependency it checks is usable
+type ReadinessCheck func() error
+
+// LivenessCheck returns nil while the part of the process it checks makes progress
+type LivenessCheck func() error
+
+// HealthHandler reports liveness and readiness per check, so a failing redis
+// can be told apart from a failing kafka
+type HealthHandler struct {
+	mu       sync.RWMutex
+	checks   map[string]ReadinessCheck
+	liveness map[string]LivenessCheck
+}
+
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{checks: make(map[string]ReadinessCheck), liveness: make(map[string]LivenessCheck)}
+}
+
+// AddCheck registers a named readiness check
+func (h *HealthHandler) AddCheck(name string, check ReadinessCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// AddLivenessCheck registers a named liveness check
+func (h *HealthHandler) AddLivenessCheck(name string, check LivenessCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness[name] = check
+}
+
+// Register mounts the health endpoints on the mux
+func (h *HealthHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.Alive)
+	mux.HandleFunc("GET /readyz", h.Ready)
+}
+
+// CheckAlive runs the liveness checks, the error names the failing ones
+func (h *HealthHandler) CheckAlive() error {
+	h.mu.RLock()
+	checks := make(map[string]func() error, len(h.liveness))
+	for name, check := range h.liveness {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+	return failedChecks(checks)
+}
+
+// CheckReady runs the readiness checks, the error names the failing ones
+func (h *HealthHandler) CheckReady() error {
+	h.mu.RLock()
+	checks := make(map[string]func() error, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+	return failedChecks(checks)
+}
+
+// Alive responds 200 when every liveness check passes and 503 otherwise, with the status of each check
+func (h *HealthHandler) Alive(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	checks := make(map[string]func() error, len(h.liveness))
+	for name, check := range h.liveness {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	status, results := runChecks(checks)
+	WriteJSON(w, status, map[string]any{"alive": status == http.StatusOK, "checks": results})
+}
+
+// Ready responds 200 when every check passes and 503 otherwise, with the status of each check
+func (h *HealthHandler) Ready(w http.ResponseWriter, _ *http.Request) {
+	h.mu.RLock()
+	checks := make(map[string]func() error, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	status, results := runChecks(checks)
+	WriteJSON(w, status, map[string]any{"ready": status == http.StatusOK, "checks": results})
+}
+
+func runChecks(checks map[string]func() error) (int, map[string]string) {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := http.StatusOK
+	results := make(map[string]string, len(names))
+	for _, name := range names {
+		if err := checks[name](); err != nil {
+			status = http.StatusServiceUnavailable
+			results[name] = err.Error()
+			continue
+		}
+		results[name] = "ok"
+	}
+	return status, results
+}
+
+func failedChecks(checks map[string]func() error) error {
+	status, results := runChecks(checks)
+	if status == http.StatusOK {
+		return nil
+	}
+	var failed []string
+	for name, result := range results {
+		if result != "ok" {
+			failed = append(failed, name+": "+result)
+		}
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("%s", strings.Join(failed, "; "))
+}
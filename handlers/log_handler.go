@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"net/http"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	logging "tx-stream/logging"
+
+	// External Packages
+	"go.uber.org/zap/zapcore"
+)
+
+type LogLevels interface {
+	State() logging.LevelState
+	Override(level zapcore.Level, ttl time.Duration)
+	Reset()
+}
+
+type LogHandler struct {
+	Levels LogLevels
+}
+
+func NewLogHandler(levels LogLevels) *LogHandler {
+	return &LogHandler{Levels: levels}
+}
+
+// Register mounts the log level endpoints on the mux
+func (h *LogHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /log/level", h.Get)
+	mux.HandleFunc("PUT /log/level", h.Set)
+	mux.HandleFunc("DELETE /log/level", h.Reset)
+}
+
+type levelRequest struct {
+	Level string `json:"level"`
+	TTL   string `json:"ttl"` // Go duration, e.g. 15m, empty keeps the level until reset
+}
+
+// Get returns the active and the configured level
+func (h *LogHandler) Get(w http.ResponseWriter, _ *http.Request) {
+	WriteJSON(w, http.StatusOK, h.Levels.State())
+}
+
+// Set overrides the level, e.g. {"level": "debug", "ttl": "15m"}
+func (h *LogHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, errors.InvalidBodyErr(err))
+		return
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		WriteError(w, errors.InvalidParamsErr(err))
+		return
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed < 0 {
+			WriteError(w, errors.E(errors.Invalid, "ttl must be a positive duration such as 15m"))
+			return
+		}
+		ttl = parsed
+	}
+	h.Levels.Override(level, ttl)
+	WriteJSON(w, http.StatusOK, h.Levels.State())
+}
+
+// Reset drops the override and returns to the configured level
+func (h *LogHandler) Reset(w http.ResponseWriter, _ *http.Request) {
+	h.Levels.Reset()
+	WriteJSON(w, http.StatusOK, h.Levels.State())
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"net/http"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	kafka "tx-stream/kafka"
+)
+
+type MaintenanceControl interface {
+	State() kafka.MaintenanceState
+	Set(mode, reason string) error
+}
+
+// MaintenanceHandler switches the consumers in and out of maintenance
+type MaintenanceHandler struct {
+	Maintenance MaintenanceControl
+}
+
+func NewMaintenanceHandler(maintenance MaintenanceControl) *MaintenanceHandler {
+	return &MaintenanceHandler{Maintenance: maintenance}
+}
+
+// Register mounts the maintenance endpoints on the mux
+func (h *MaintenanceHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /maintenance", h.Get)
+	mux.HandleFunc("PUT /maintenance", h.Set)
+}
+
+type maintenanceRequest struct {
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"`
+}
+
+// Get returns the active mode
+func (h *MaintenanceHandler) Get(w http.ResponseWriter, _ *http.Request) {
+	WriteJSON(w, http.StatusOK, h.Maintenance.State())
+}
+
+// Set switches the mode, e.g. {"mode": "persist", "reason": "mongo upgrade"}
+func (h *MaintenanceHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, errors.InvalidBodyErr(err))
+		return
+	}
+	if !kafka.IsMaintenanceMode(req.Mode) {
+		WriteError(w, errors.E(errors.Invalid, "mode must be one of off, persist, fetch"))
+		return
+	}
+	if err := h.Maintenance.Set(req.Mode, req.Reason); err != nil {
+		WriteError(w, err)
+		return
+	}
+	WriteJSON(w, http.StatusOK, h.Maintenance.State())
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"net/http"
+	"slices"
+
+	// Local Packages
+	kafka "tx-stream/kafka"
+)
+
+type OwnershipSource interface {
+	Ownership(ctx context.Context) (kafka.GroupOwnership, error)
+}
+
+// OwnershipHandler reports which instance owns which partitions of every
+// consumer, so a lagging partition leads straight to its pod
+type OwnershipHandler struct {
+	Consumers map[string]OwnershipSource
+}
+
+func NewOwnershipHandler(consumers map[string]OwnershipSource) *OwnershipHandler {
+	return &OwnershipHandler{Consumers: consumers}
+}
+
+// ConsumerOwnership is the ownership of a consumer, Error is set instead
+// when the group could not be described
+type ConsumerOwnership struct {
+	Consumer string `json:"consumer"`
+	*kafka.GroupOwnership
+	Error string `json:"error,omitempty"`
+}
+
+// Register mounts the ownership endpoint on the mux
+func (h *OwnershipHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /ownership", h.List)
+}
+
+// List describes the group of every consumer, sorted by consumer name
+func (h *OwnershipHandler) List(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(h.Consumers))
+	for name := range h.Consumers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	report := make([]ConsumerOwnership, len(names))
+	for idx, name := range names {
+		report[idx].Consumer = name
+		ownership, err := h.Consumers[name].Ownership(r.Context())
+		if err != nil {
+			report[idx].Error = err.Error()
+			continue
+		}
+		report[idx].GroupOwnership = &ownership
+	}
+	WriteJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"net/http"
+
+	// Local Packages
+	errors "tx-stream/errors"
+)
+
+// WriteJSON writes the value as a JSON response with the given status code
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// WriteError writes the error as a JSON response, using the error kind for the status code
+func WriteError(w http.ResponseWriter, err error) {
+	var appErr *errors.Error
+	if !errors.As(err, &appErr) {
+		appErr = &errors.Error{Kind: errors.Internal, Message: err.Error()}
+	}
+	WriteJSON(w, StatusCode(appErr.Kind), appErr)
+}
+
+// StatusCode maps an error kind to its http status code
+func StatusCode(kind errors.Kind) int {
+	switch kind {
+	case errors.Invalid:
+		return http.StatusBadRequest
+	case errors.NotFound:
+		return http.StatusNotFound
+	case errors.Conflict:
+		return http.StatusConflict
+	case errors.Unauthorized:
+		return http.StatusUnauthorized
+	case errors.Forbidden:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// StatsSource returns a section of the runtime stats
+type StatsSource func(ctx context.Context) (any, error)
+
+// StatsHandler reports what a live instance is doing, each section comes from
+// the component owning it
+type StatsHandler struct {
+	mu      sync.RWMutex
+	sources map[string]StatsSource
+	Timeout time.Duration
+}
+
+func NewStatsHandler(timeout time.Duration) *StatsHandler {
+	return &StatsHandler{sources: make(map[string]StatsSource), Timeout: timeout}
+}
+
+// AddSource registers a named section of the stats
+func (h *StatsHandler) AddSource(name string, source StatsSource) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sources[name] = source
+}
+
+// Register mounts the stats endpoint on the mux
+func (h *StatsHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /stats", h.Stats)
+}
+
+// Stats returns the snapshot of every section
+func (h *StatsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.Timeout)
+	defer cancel()
+	WriteJSON(w, http.StatusOK, h.Snapshot(ctx))
+}
+
+// Snapshot collects every section along with the goroutine count and memory
+// stats, a failing section reports its error in place of its value
+func (h *StatsHandler) Snapshot(ctx context.Context) map[string]any {
+	h.mu.RLock()
+	sources := make(map[string]StatsSource, len(h.sources))
+	for name, source := range h.sources {
+		sources[name] = source
+	}
+	h.mu.RUnlock()
+
+	body := map[string]any{
+		"time":       time.Now().UTC(),
+		"goroutines": runtime.NumGoroutine(),
+		"memory":     memoryStats(),
+	}
+	for name, source := range sources {
+		value, err := source(ctx)
+		if err != nil {
+			body[name] = map[string]string{"error": err.Error()}
+			continue
+		}
+		body[name] = value
+	}
+	return body
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	txsvc "tx-stream/services/transactions"
+)
+
+// TailHandler streams the processed transactions matching a filter as
+// Server-Sent Events, for support to follow some traffic during an incident
+type TailHandler struct {
+	Hub         *txsvc.TailHub
+	Heartbeat   time.Duration // Keeps idle proxies from closing a quiet stream
+	MaxDuration time.Duration // A forgotten tab does not stream forever
+}
+
+func NewTailHandler(hub *txsvc.TailHub, heartbeat, maxDuration time.Duration) *TailHandler {
+	return &TailHandler{Hub: hub, Heartbeat: heartbeat, MaxDuration: maxDuration}
+}
+
+// Register mounts the tail endpoint on the mux
+func (h *TailHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /v1/tail", h.Tail)
+}
+
+// Tail streams the transactions whose payload fields have the values of the
+// query params, e.g. ?merchant_name=Acme&status=failed. Each transaction is
+// a "transaction" event, a "dropped" event tells how many the client was too
+// slow for, and the stream ends with an "end" event at the max duration.
+func (h *TailHandler) Tail(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, errors.E(errors.Internal, "streaming is not supported"))
+		return
+	}
+	filter := make(map[string]string)
+	for field, values := range r.URL.Query() {
+		filter[field] = values[0]
+	}
+	sub, err := h.Hub.Subscribe(filter)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	defer h.Hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+	deadline := time.NewTimer(h.MaxDuration)
+	defer deadline.Stop()
+	var reported uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-deadline.C:
+			writeEvent(w, "end", "", map[string]string{"reason": "max duration reached"})
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			if dropped := sub.Dropped(); dropped != reported {
+				writeEvent(w, "dropped", "", map[string]uint64{"dropped": dropped})
+				reported = dropped
+			} else {
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				writeEvent(w, "end", "", map[string]string{"reason": "server shutting down"})
+				flusher.Flush()
+				return
+			}
+			writeEvent(w, "transaction", fmt.Sprintf("%s:%d:%d", event.Topic, event.Partition, event.Offset), event)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, name, id string, v any) {
+	data, _ := json.Marshal(v)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"net/http"
+
+	// Local Packages
+	errors "tx-stream/errors"
+)
+
+type ThrottleControl interface {
+	Rate() float64
+	SetRate(perSecond float64)
+}
+
+// ThrottleHandler slows the consumers down during downstream incidents
+type ThrottleHandler struct {
+	Throttle ThrottleControl
+}
+
+func NewThrottleHandler(throttle ThrottleControl) *ThrottleHandler {
+	return &ThrottleHandler{Throttle: throttle}
+}
+
+// Register mounts the throttle endpoints on the mux
+func (h *ThrottleHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /throttle", h.Get)
+	mux.HandleFunc("PUT /throttle", h.Set)
+}
+
+type throttleState struct {
+	RecordsPerSecond float64 `json:"records_per_second"` // 0 when not throttled
+}
+
+// Get returns the active cap
+func (h *ThrottleHandler) Get(w http.ResponseWriter, _ *http.Request) {
+	WriteJSON(w, http.StatusOK, throttleState{RecordsPerSecond: h.Throttle.Rate()})
+}
+
+// Set changes the cap, e.g. {"records_per_second": 200}, 0 removes it
+func (h *ThrottleHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req throttleState
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, errors.InvalidBodyErr(err))
+		return
+	}
+	if req.RecordsPerSecond < 0 {
+		WriteError(w, errors.E(errors.Invalid, "records_per_second cannot be negative"))
+		return
+	}
+	h.Throttle.SetRate(req.RecordsPerSecond)
+	WriteJSON(w, http.StatusOK, throttleState{RecordsPerSecond: h.Throttle.Rate()})
+}
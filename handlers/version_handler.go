@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	// Go Internal Packages
+	"net/http"
+
+	// Local Packages
+	version "tx-stream/version"
+)
+
+// VersionHandler reports the build of the running binary
+type VersionHandler struct {
+	Info version.Info
+}
+
+func NewVersionHandler(info version.Info) *VersionHandler {
+	return &VersionHandler{Info: info}
+}
+
+// Register mounts the version endpoint on the mux
+func (h *VersionHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /version", h.Version)
+}
+
+// Version returns the version, the commit and the build time
+func (h *VersionHandler) Version(w http.ResponseWriter, _ *http.Request) {
+	WriteJSON(w, http.StatusOK, h.Info)
+}
@@ -0,0 +1,73 @@
+package health
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// errNotChecked is the state before the first ping finished
+var errNotChecked = errors.New("not checked yet")
+
+// Pinger pings a dependency every Interval and remembers the outcome, so
+// probes answer from the last result instead of waiting on the dependency
+type Pinger struct {
+	Name     string
+	Ping     func(ctx context.Context) error
+	Logger   *zap.Logger
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu      sync.RWMutex
+	lastErr error
+}
+
+func NewPinger(name string, ping func(ctx context.Context) error, logger *zap.Logger, interval, timeout time.Duration) *Pinger {
+	return &Pinger{Name: name, Ping: ping, Logger: logger, Interval: interval, Timeout: timeout, lastErr: errNotChecked}
+}
+
+// Run pings every Interval until the context is canceled
+func (p *Pinger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pinger) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+	err := p.Ping(pingCtx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil && p.lastErr == nil {
+		p.Logger.Warn("health check failed", zap.String("check", p.Name), zap.Error(err))
+	} else if err == nil && p.lastErr != nil && p.lastErr != errNotChecked {
+		p.Logger.Info("health check recovered", zap.String("check", p.Name))
+	}
+	p.lastErr = err
+}
+
+// Ready returns the error of the last ping, nil while the dependency is healthy
+func (p *Pinger) Ready() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.lastErr != nil {
+		return fmt.Errorf("%s is unhealthy: %v", p.Name, p.lastErr)
+	}
+	return nil
+}
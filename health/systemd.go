@@ -0,0 +1,125 @@
+package health
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// States sent to the service manager, see sd_notify(3)
+const (
+	NotifyReady    = "READY=1"
+	NotifyStopping = "STOPPING=1"
+	NotifyWatchdog = "WATCHDOG=1"
+)
+
+// Notify sends the state to systemd through $NOTIFY_SOCKET, false means the
+// process does not run in a Type=notify unit and nothing was sent
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:] // Abstract namespace
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to the notify socket: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to notify systemd: %v", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the WatchdogSec of the unit, false when the
+// watchdog is off or meant for another process
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// SystemdNotifier signals readiness to systemd once the readiness checks
+// pass, then pets the watchdog for as long as the liveness checks pass, so a
+// consumer stuck on a batch is restarted by systemd like by a kubelet
+type SystemdNotifier struct {
+	Ready    func() error
+	Alive    func() error
+	Logger   *zap.Logger
+	Interval time.Duration // How often readiness is checked before READY=1
+}
+
+func NewSystemdNotifier(ready, alive func() error, logger *zap.Logger, interval time.Duration) *SystemdNotifier {
+	return &SystemdNotifier{Ready: ready, Alive: alive, Logger: logger, Interval: interval}
+}
+
+// Run returns right away outside a Type=notify unit, otherwise it notifies
+// until the context is canceled and then sends STOPPING=1
+func (n *SystemdNotifier) Run(ctx context.Context) {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+	defer func() {
+		if _, err := Notify(NotifyStopping); err != nil {
+			n.Logger.Warn("cannot notify systemd of the shutdown", zap.Error(err))
+		}
+	}()
+	if !n.awaitReady(ctx) {
+		return
+	}
+	if _, err := Notify(NotifyReady); err != nil {
+		n.Logger.Warn("cannot notify systemd of readiness", zap.Error(err))
+	}
+	n.Logger.Info("notified systemd of readiness")
+
+	interval, ok := WatchdogInterval()
+	if !ok {
+		<-ctx.Done()
+		return
+	}
+	// Half the timeout, as sd_watchdog_enabled(3) recommends
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := n.Alive(); err != nil {
+			n.Logger.Warn("not petting the systemd watchdog", zap.Error(err))
+			continue
+		}
+		if _, err := Notify(NotifyWatchdog); err != nil {
+			n.Logger.Warn("cannot pet the systemd watchdog", zap.Error(err))
+		}
+	}
+}
+
+func (n *SystemdNotifier) awaitReady(ctx context.Context) bool {
+	ticker := time.NewTicker(n.Interval)
+	defer ticker.Stop()
+	for n.Ready() != nil {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
@@ -0,0 +1,315 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Targets of an offset reset
+const (
+	ResetEarliest  = "earliest"
+	ResetLatest    = "latest"
+	ResetOffset    = "offset"
+	ResetTimestamp = "timestamp"
+)
+
+// ResetTarget is where an offset reset moves the group to, Offset and
+// Timestamp are only read by their own mode
+type ResetTarget struct {
+	Mode      string
+	Offset    int64
+	Timestamp time.Time
+}
+
+// OffsetChange is the move of the committed offset of one partition,
+// Current is -1 when the group has no commit for the partition yet
+type OffsetChange struct {
+	Partition int32
+	Current   int64
+	Target    int64
+}
+
+// Admin inspects topics and changes the committed offsets of consumer groups
+type Admin struct {
+	Admin *kadm.Client
+}
+
+// NewAdmin connects an admin client to the given brokers
+// (PS: Must call Close once done)
+func NewAdmin(brokers []string, sasl *SASL) (*Admin, error) {
+	admin, err := kadm.NewOptClient(append(sasl.Opts(), kgo.SeedBrokers(brokers...))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka admin client: %v", err)
+	}
+	return &Admin{Admin: admin}, nil
+}
+
+func (a *Admin) Close() {
+	a.Admin.Close()
+}
+
+// PlanReset returns the offset changes a reset of the group would commit on
+// the partitions, every partition of the topic when none is given. Offsets
+// outside of what the partitions retain are clamped to the retained range.
+func (a *Admin) PlanReset(ctx context.Context, group, topic string, partitions []int32, target ResetTarget) ([]OffsetChange, error) {
+	committed, err := a.Admin.FetchOffsetsForTopics(ctx, group, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %v", err)
+	}
+	starts, err := a.Admin.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets: %v", err)
+	}
+	ends, err := a.Admin.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %v", err)
+	}
+	if err := ends.Error(); err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %v", err)
+	}
+	var after kadm.ListedOffsets
+	if target.Mode == ResetTimestamp {
+		after, err = a.Admin.ListOffsetsAfterMilli(ctx, target.Timestamp.UnixMilli(), topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list offsets after %s: %v", target.Timestamp.Format(time.RFC3339), err)
+		}
+	}
+
+	var changes []OffsetChange
+	var planErr error
+	ends.Each(func(end kadm.ListedOffset) {
+		if len(partitions) > 0 && !slices.Contains(partitions, end.Partition) {
+			return
+		}
+		start, ok := starts.Lookup(topic, end.Partition)
+		if !ok || start.Err != nil {
+			planErr = fmt.Errorf("failed to list start offset of partition %d", end.Partition)
+			return
+		}
+		change := OffsetChange{Partition: end.Partition, Current: -1}
+		if commit, ok := committed.Lookup(topic, end.Partition); ok && commit.Err == nil {
+			change.Current = commit.At
+		}
+		switch target.Mode {
+		case ResetEarliest:
+			change.Target = start.Offset
+		case ResetLatest:
+			change.Target = end.Offset
+		case ResetOffset:
+			change.Target = target.Offset
+		case ResetTimestamp:
+			// No record at or after the time lists the end offset
+			listed, ok := after.Lookup(topic, end.Partition)
+			if !ok || listed.Err != nil {
+				planErr = fmt.Errorf("failed to list offset after the timestamp of partition %d", end.Partition)
+				return
+			}
+			change.Target = listed.Offset
+		default:
+			planErr = fmt.Errorf("unknown reset target %q", target.Mode)
+			return
+		}
+		change.Target = min(max(change.Target, start.Offset), end.Offset)
+		changes = append(changes, change)
+	})
+	if planErr != nil {
+		return nil, planErr
+	}
+	for _, partition := range partitions {
+		if !slices.ContainsFunc(changes, func(change OffsetChange) bool { return change.Partition == partition }) {
+			return nil, fmt.Errorf("topic %s has no partition %d", topic, partition)
+		}
+	}
+	slices.SortFunc(changes, func(a, b OffsetChange) int { return cmp.Compare(a.Partition, b.Partition) })
+	return changes, nil
+}
+
+// ApplyReset commits the offset changes for the group, which must have no
+// active members as the brokers only accept commits of an empty group
+func (a *Admin) ApplyReset(ctx context.Context, group, topic string, changes []OffsetChange) error {
+	described, err := a.Admin.DescribeGroups(ctx, group)
+	if err != nil {
+		return fmt.Errorf("failed to describe group: %v", err)
+	}
+	if described, ok := described[group]; ok && described.Err == nil && len(described.Members) > 0 {
+		return fmt.Errorf("group %s has %d active members, stop its consumers first", group, len(described.Members))
+	}
+
+	offsets := make(kadm.Offsets)
+	for _, change := range changes {
+		offsets.AddOffset(topic, change.Partition, change.Target, -1)
+	}
+	responses, err := a.Admin.CommitOffsets(ctx, group, offsets)
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets: %v", err)
+	}
+	if err := responses.Error(); err != nil {
+		return fmt.Errorf("failed to commit offsets: %v", err)
+	}
+	return nil
+}
+
+// OffsetSnapshot is the committed offsets of a group in a topic, exported
+// to resume the group elsewhere at the exact same offsets
+type OffsetSnapshot struct {
+	Group      string           `json:"group"`
+	Topic      string           `json:"topic"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Partitions []SnapshotOffset `json:"partitions"`
+}
+
+// SnapshotOffset is the commit of one partition, the leader epoch is only
+// informative as it means nothing on another cluster
+type SnapshotOffset struct {
+	Partition   int32  `json:"partition"`
+	Offset      int64  `json:"offset"`
+	LeaderEpoch int32  `json:"leader_epoch"`
+	Metadata    string `json:"metadata,omitempty"`
+}
+
+// ExportOffsets returns the offsets the group committed in the topic,
+// partitions without a commit are left out
+func (a *Admin) ExportOffsets(ctx context.Context, group, topic string) (OffsetSnapshot, error) {
+	committed, err := a.Admin.FetchOffsetsForTopics(ctx, group, topic)
+	if err != nil {
+		return OffsetSnapshot{}, fmt.Errorf("failed to fetch committed offsets: %v", err)
+	}
+	snapshot := OffsetSnapshot{Group: group, Topic: topic, ExportedAt: time.Now().UTC()}
+	committed.Each(func(commit kadm.OffsetResponse) {
+		if commit.Topic != topic || commit.Err != nil || commit.At < 0 {
+			return
+		}
+		snapshot.Partitions = append(snapshot.Partitions, SnapshotOffset{
+			Partition:   commit.Partition,
+			Offset:      commit.At,
+			LeaderEpoch: commit.LeaderEpoch,
+			Metadata:    commit.Metadata,
+		})
+	})
+	if len(snapshot.Partitions) == 0 {
+		return OffsetSnapshot{}, fmt.Errorf("group %s has no committed offset in %s", group, topic)
+	}
+	slices.SortFunc(snapshot.Partitions, func(a, b SnapshotOffset) int { return cmp.Compare(a.Partition, b.Partition) })
+	return snapshot, nil
+}
+
+// PlanImport returns the offset changes importing the snapshot into the
+// group would commit. Unlike a reset nothing is clamped, an offset the
+// partition does not retain fails the plan as the resume point would be lost.
+func (a *Admin) PlanImport(ctx context.Context, group string, snapshot OffsetSnapshot) ([]OffsetChange, error) {
+	committed, err := a.Admin.FetchOffsetsForTopics(ctx, group, snapshot.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %v", err)
+	}
+	starts, err := a.Admin.ListStartOffsets(ctx, snapshot.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets: %v", err)
+	}
+	ends, err := a.Admin.ListEndOffsets(ctx, snapshot.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %v", err)
+	}
+
+	changes := make([]OffsetChange, 0, len(snapshot.Partitions))
+	for _, offset := range snapshot.Partitions {
+		start, startOk := starts.Lookup(snapshot.Topic, offset.Partition)
+		end, endOk := ends.Lookup(snapshot.Topic, offset.Partition)
+		if !startOk || !endOk {
+			return nil, fmt.Errorf("topic %s has no partition %d", snapshot.Topic, offset.Partition)
+		}
+		if start.Err != nil || end.Err != nil {
+			return nil, fmt.Errorf("failed to list the offsets of partition %d", offset.Partition)
+		}
+		if offset.Offset < start.Offset || offset.Offset > end.Offset {
+			return nil, fmt.Errorf("offset %d of partition %d is outside of the retained range %d-%d",
+				offset.Offset, offset.Partition, start.Offset, end.Offset)
+		}
+		change := OffsetChange{Partition: offset.Partition, Current: -1, Target: offset.Offset}
+		if commit, ok := committed.Lookup(snapshot.Topic, offset.Partition); ok && commit.Err == nil {
+			change.Current = commit.At
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// TopicDescription is where the partitions of a topic live and how far the
+// groups got in them
+type TopicDescription struct {
+	Topic      string
+	Brokers    []kgo.BrokerMetadata
+	Partitions []PartitionDescription
+	Groups     map[string][]PartitionLag // Committed offsets and lag of each described group
+}
+
+// PartitionDescription is the placement and the watermarks of a partition,
+// Leader is -1 while the partition has no leader
+type PartitionDescription struct {
+	Partition int32
+	Leader    int32
+	Replicas  []int32
+	ISR       []int32
+	Low       int64
+	High      int64
+	Err       error
+}
+
+// DescribeTopic returns the partitions of the topic along with the offsets
+// the groups committed in it
+func (a *Admin) DescribeTopic(ctx context.Context, topic string, groups []string) (TopicDescription, error) {
+	metadata, err := a.Admin.Metadata(ctx, topic)
+	if err != nil {
+		return TopicDescription{}, fmt.Errorf("failed to fetch metadata: %v", err)
+	}
+	detail, ok := metadata.Topics[topic]
+	if !ok {
+		return TopicDescription{}, fmt.Errorf("topic %s not found", topic)
+	}
+	if detail.Err != nil {
+		return TopicDescription{}, fmt.Errorf("failed to describe topic %s: %v", topic, detail.Err)
+	}
+	starts, err := a.Admin.ListStartOffsets(ctx, topic)
+	if err != nil {
+		return TopicDescription{}, fmt.Errorf("failed to list start offsets: %v", err)
+	}
+	ends, err := a.Admin.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return TopicDescription{}, fmt.Errorf("failed to list end offsets: %v", err)
+	}
+
+	description := TopicDescription{Topic: topic, Brokers: metadata.Brokers, Groups: make(map[string][]PartitionLag)}
+	for _, partition := range detail.Partitions.Sorted() {
+		described := PartitionDescription{
+			Partition: partition.Partition,
+			Leader:    partition.Leader,
+			Replicas:  partition.Replicas,
+			ISR:       partition.ISR,
+			Low:       -1,
+			High:      -1,
+			Err:       partition.Err,
+		}
+		if start, ok := starts.Lookup(topic, partition.Partition); ok && start.Err == nil {
+			described.Low = start.Offset
+		}
+		if end, ok := ends.Lookup(topic, partition.Partition); ok && end.Err == nil {
+			described.High = end.Offset
+		}
+		description.Partitions = append(description.Partitions, described)
+	}
+	for _, group := range groups {
+		lags, err := (&LagMonitor{Admin: a.Admin, Group: group, Topic: topic}).Lags(ctx)
+		if err != nil {
+			return TopicDescription{}, fmt.Errorf("failed to compute the lag of group %s: %v", group, err)
+		}
+		description.Groups[group] = lags
+	}
+	return description, nil
+}
@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// TopicDeadLetterQueue publishes failed records as JSON envelopes to a Kafka topic.
+// It is write-only, entries are inspected and replayed with regular Kafka tooling.
+type TopicDeadLetterQueue struct {
+	Producer *Producer
+	Topic    string
+	Logger   *zap.Logger
+}
+
+func NewTopicDeadLetterQueue(producer *Producer, topic string, logger *zap.Logger) *TopicDeadLetterQueue {
+	return &TopicDeadLetterQueue{Producer: producer, Topic: topic, Logger: logger}
+}
+
+// Send publishes all failed records to the DLQ topic, keyed by their original key
+func (q *TopicDeadLetterQueue) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	out := make([]models.Record, 0, len(records))
+	for _, entry := range models.NewDLQEntries(records, cause, attempts) {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			q.Logger.Error("failed to marshal transaction", zap.Error(err))
+			continue
+		}
+		out = append(out, models.Record{
+			Key:     entry.Record.Key,
+			Value:   data,
+			Headers: entry.Record.Headers,
+			Topic:   q.Topic,
+		})
+	}
+
+	return q.Producer.Produce(ctx, out...)
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kadm"
+	"go.uber.org/zap"
+)
+
+// LagMonitor periodically compares the committed offsets of a group with
+// the high watermarks of its topic and exports the lag per partition
+type LagMonitor struct {
+	Admin    *kadm.Client
+	Group    string
+	Topic    string
+	Metrics  *metrics.LagMetrics
+	Logger   *zap.Logger
+	Interval time.Duration
+}
+
+// NewLagMonitor creates a monitor on an admin client of its own, so it
+// outlives the restarts of the consumer client
+func NewLagMonitor(admin *kadm.Client, group, topic string, metrics *metrics.LagMetrics, logger *zap.Logger, interval time.Duration) *LagMonitor {
+	return &LagMonitor{
+		Admin:    admin,
+		Group:    group,
+		Topic:    topic,
+		Metrics:  metrics,
+		Logger:   logger,
+		Interval: interval,
+	}
+}
+
+// Run refreshes the lag right away and then on every interval until the context is canceled
+func (m *LagMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Refresh(ctx); err != nil && ctx.Err() == nil {
+			m.Metrics.RefreshFailed(m.Group)
+			m.Logger.Warn("failed to refresh consumer lag", zap.String("group", m.Group), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PartitionLag is the lag of one partition of the topic, Committed is -1
+// when the group has no commit for the partition yet
+type PartitionLag struct {
+	Partition int32 `json:"partition"`
+	Committed int64 `json:"committed"`
+	End       int64 `json:"end"`
+	Lag       int64 `json:"lag"`
+}
+
+// Refresh exports the lag of every partition of the topic
+func (m *LagMonitor) Refresh(ctx context.Context) error {
+	lags, err := m.Lags(ctx)
+	for _, lag := range lags {
+		m.Metrics.SetLag(m.Group, m.Topic, lag.Partition, lag.Lag)
+	}
+	return err
+}
+
+// Lags computes the lag of every partition of the topic, a partition
+// without a committed offset lags by everything still retained in it
+func (m *LagMonitor) Lags(ctx context.Context) ([]PartitionLag, error) {
+	committed, err := m.Admin.FetchOffsetsForTopics(ctx, m.Group, m.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %v", err)
+	}
+	ends, err := m.Admin.ListEndOffsets(ctx, m.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list end offsets: %v", err)
+	}
+	starts, err := m.Admin.ListStartOffsets(ctx, m.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list start offsets: %v", err)
+	}
+
+	var lags []PartitionLag
+	var lagErr error
+	ends.Each(func(end kadm.ListedOffset) {
+		if end.Err != nil {
+			lagErr = fmt.Errorf("failed to list end offset of partition %d: %v", end.Partition, end.Err)
+			return
+		}
+		partitionLag := PartitionLag{Partition: end.Partition, Committed: -1, End: end.Offset}
+		at := int64(-1)
+		if commit, ok := committed.Lookup(end.Topic, end.Partition); ok && commit.Err == nil {
+			at = commit.At
+			partitionLag.Committed = commit.At
+		}
+		if at < 0 {
+			if start, ok := starts.Lookup(end.Topic, end.Partition); ok && start.Err == nil {
+				at = start.Offset
+			}
+		}
+		partitionLag.Lag = end.Offset - at
+		if at < 0 || partitionLag.Lag < 0 {
+			partitionLag.Lag = 0
+		}
+		lags = append(lags, partitionLag)
+	})
+	slices.SortFunc(lags, func(a, b PartitionLag) int { return cmp.Compare(a.Partition, b.Partition) })
+	return lags, lagErr
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Maintenance modes, off consumes as usual
+const (
+	MaintenanceOff     = "off"
+	MaintenancePersist = "persist" // Keeps fetching and the group membership, holds the batch before persisting
+	MaintenanceFetch   = "fetch"   // Stops fetching, the partitions stay assigned
+)
+
+// MaintenanceState is the active maintenance mode and since when
+type MaintenanceState struct {
+	Mode   string     `json:"mode"`
+	Reason string     `json:"reason,omitempty"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+// Maintenance is the toggle the consumers follow during a planned outage of
+// the storage, so the records wait on the topic instead of flooding the DLQ
+type Maintenance struct {
+	mu       sync.Mutex
+	state    MaintenanceState
+	watchers []func(mode string)
+}
+
+func NewMaintenance() *Maintenance {
+	return &Maintenance{state: MaintenanceState{Mode: MaintenanceOff}}
+}
+
+// IsMaintenanceMode reports whether the mode is a known one
+func IsMaintenanceMode(mode string) bool {
+	return mode == MaintenanceOff || mode == MaintenancePersist || mode == MaintenanceFetch
+}
+
+// State returns the active mode
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Active reports whether any maintenance mode is on
+func (m *Maintenance) Active() bool {
+	return m != nil && m.State().Mode != MaintenanceOff
+}
+
+// Set switches the mode and notifies the watchers, setting the active mode
+// again only updates the reason
+func (m *Maintenance) Set(mode, reason string) error {
+	if !IsMaintenanceMode(mode) {
+		return fmt.Errorf("unknown maintenance mode %q", mode)
+	}
+	m.mu.Lock()
+	changed := m.state.Mode != mode
+	m.state.Reason = reason
+	if changed {
+		m.state.Mode = mode
+		m.state.Since = nil
+		if mode != MaintenanceOff {
+			now := time.Now()
+			m.state.Since = &now
+		}
+	}
+	watchers := m.watchers
+	m.mu.Unlock()
+
+	if changed {
+		for _, watch := range watchers {
+			watch(mode)
+		}
+	}
+	return nil
+}
+
+// Watch calls fn on every change, and right away while a maintenance is on
+func (m *Maintenance) Watch(fn func(mode string)) {
+	m.mu.Lock()
+	m.watchers = append(m.watchers, fn)
+	mode := m.state.Mode
+	m.mu.Unlock()
+	if mode != MaintenanceOff {
+		fn(mode)
+	}
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// GroupOwnership is which member of the group owns which partitions of the
+// topic, from the group metadata the brokers keep
+type GroupOwnership struct {
+	Group      string            `json:"group"`
+	Topic      string            `json:"topic"`
+	State      string            `json:"state"`
+	Members    []MemberOwnership `json:"members"`
+	Unassigned []int32           `json:"unassigned,omitempty"`
+}
+
+// MemberOwnership is a member of the group, ClientID names the instance it runs in
+type MemberOwnership struct {
+	MemberID   string         `json:"member_id"`
+	InstanceID string         `json:"instance_id,omitempty"`
+	ClientID   string         `json:"client_id"`
+	Host       string         `json:"host"`
+	Partitions []PartitionLag `json:"partitions"`
+	Lag        int64          `json:"lag"`
+}
+
+// Ownership describes the group and joins the assignment of every member
+// with the lag of its partitions, members are sorted by lag so the one to
+// look at comes first
+func (m *LagMonitor) Ownership(ctx context.Context) (GroupOwnership, error) {
+	described, err := m.Admin.DescribeGroups(ctx, m.Group)
+	if err != nil {
+		return GroupOwnership{}, fmt.Errorf("failed to describe group: %v", err)
+	}
+	group, ok := described[m.Group]
+	if !ok {
+		return GroupOwnership{}, fmt.Errorf("group %s not found", m.Group)
+	}
+	if group.Err != nil {
+		return GroupOwnership{}, fmt.Errorf("failed to describe group %s: %v", m.Group, group.Err)
+	}
+	lags, err := m.Lags(ctx)
+	if err != nil {
+		return GroupOwnership{}, err
+	}
+	byPartition := make(map[int32]PartitionLag, len(lags))
+	for _, lag := range lags {
+		byPartition[lag.Partition] = lag
+	}
+
+	ownership := GroupOwnership{Group: m.Group, Topic: m.Topic, State: group.State}
+	owned := make(map[int32]bool)
+	for _, member := range group.Members {
+		owner := MemberOwnership{MemberID: member.MemberID, ClientID: member.ClientID, Host: member.ClientHost, Partitions: []PartitionLag{}}
+		if member.InstanceID != nil {
+			owner.InstanceID = *member.InstanceID
+		}
+		if assigned, ok := member.Assigned.AsConsumer(); ok {
+			for _, topic := range assigned.Topics {
+				if topic.Topic != m.Topic {
+					continue
+				}
+				for _, partition := range topic.Partitions {
+					lag, ok := byPartition[partition]
+					if !ok {
+						lag = PartitionLag{Partition: partition, Committed: -1, End: -1}
+					}
+					owner.Partitions = append(owner.Partitions, lag)
+					owner.Lag += lag.Lag
+					owned[partition] = true
+				}
+			}
+		}
+		slices.SortFunc(owner.Partitions, func(a, b PartitionLag) int { return cmp.Compare(a.Partition, b.Partition) })
+		ownership.Members = append(ownership.Members, owner)
+	}
+	slices.SortFunc(ownership.Members, func(a, b MemberOwnership) int {
+		return cmp.Or(cmp.Compare(b.Lag, a.Lag), cmp.Compare(a.ClientID, b.ClientID))
+	})
+	for _, lag := range lags {
+		if !owned[lag.Partition] {
+			ownership.Unassigned = append(ownership.Unassigned, lag.Partition)
+		}
+	}
+	return ownership, nil
+}
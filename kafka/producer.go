@@ -0,0 +1,45 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type Producer struct {
+	Client *kgo.Client
+}
+
+// NewProducer creates a new producer to publish records to the given brokers
+func NewProducer(brokers []string, sasl *SASL) (*Producer, error) {
+	client, err := kgo.NewClient(append(sasl.Opts(), kgo.SeedBrokers(brokers...))...)
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{Client: client}, nil
+}
+
+// Produce synchronously publishes the records to their topics, carrying the trace context of ctx
+func (p *Producer) Produce(ctx context.Context, records ...models.Record) error {
+	krs := make([]*kgo.Record, len(records))
+	for idx, record := range records {
+		tracing.Inject(ctx, &record)
+		headers := make([]kgo.RecordHeader, len(record.Headers))
+		for hdx, header := range record.Headers {
+			headers[hdx] = kgo.RecordHeader{Key: header.Key, Value: header.Value}
+		}
+		krs[idx] = &kgo.Record{Key: record.Key, Value: record.Value, Headers: headers, Topic: record.Topic}
+	}
+	return p.Client.ProduceSync(ctx, krs...).FirstErr()
+}
+
+// Close flushes pending records and closes the producer
+func (p *Producer) Close() {
+	p.Client.Close()
+}
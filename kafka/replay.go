@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+type ReplayConfig struct {
+	Brokers        []string
+	SASL           *SASL  // Nil connects without authentication
+	Consumer       string // Name stamped on the replayed records
+	Topic          string
+	From           time.Time
+	To             time.Time           // Zero replays up to the end offsets at start
+	Ranges         []ReplayedPartition // Offset ranges of the partitions to replay instead of the From/To window
+	RecordsPerPoll int
+	IdleTimeout    time.Duration // A poll without records for longer ends the replay early
+}
+
+// ReplayedPartition is the offset range replayed from a partition, Next
+// falls short of Stop when the replay ended early
+type ReplayedPartition struct {
+	Partition int32
+	Start     int64
+	Stop      int64
+	Next      int64
+}
+
+// Replayer reprocesses the records of a time window without a consumer
+// group, so nothing is committed and the running consumers are unaffected
+type Replayer struct {
+	Config    *ReplayConfig
+	Processor TxProcessor
+	Logger    *zap.Logger
+}
+
+func NewReplayer(conf *ReplayConfig, processor TxProcessor, logger *zap.Logger) *Replayer {
+	return &Replayer{Config: conf, Processor: processor, Logger: logger}
+}
+
+// Run processes the window batch by batch, a batch that still fails after
+// retries stops the replay so it can be resumed from the failed offset
+func (r *Replayer) Run(ctx context.Context) ([]ReplayedPartition, error) {
+	partitions, err := r.window(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[int32]kgo.Offset)
+	remaining := 0
+	for idx, partition := range partitions {
+		if partition.Start < partition.Stop {
+			offsets[partition.Partition] = kgo.NewOffset().At(partition.Start)
+			remaining++
+		}
+		partitions[idx].Next = partition.Start
+	}
+	if remaining == 0 {
+		return partitions, nil
+	}
+
+	client, err := kgo.NewClient(append(r.Config.SASL.Opts(),
+		kgo.SeedBrokers(r.Config.Brokers...),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{r.Config.Topic: offsets}),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %v", err)
+	}
+	defer client.Close()
+
+	byPartition := make(map[int32]*ReplayedPartition, len(partitions))
+	for idx := range partitions {
+		byPartition[partitions[idx].Partition] = &partitions[idx]
+	}
+	for remaining > 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, r.Config.IdleTimeout)
+		fetches := client.PollRecords(pollCtx, r.Config.RecordsPerPoll)
+		cancel()
+		if ctx.Err() != nil {
+			return partitions, ctx.Err()
+		}
+		if err := fetches.Err0(); errors.Is(err, context.DeadlineExceeded) {
+			r.Logger.Warn("no records within the idle timeout, ending the replay early", zap.Int("partitions_left", remaining))
+			return partitions, nil
+		}
+		fetches.EachError(func(topic string, partition int32, err error) {
+			r.Logger.Warn("fetch failed", zap.String("topic", topic), zap.Int32("partition", partition), zap.Error(err))
+		})
+
+		var records []models.Record
+		var finished []int32
+		fetches.EachRecord(func(record *kgo.Record) {
+			partition := byPartition[record.Partition]
+			if partition == nil || record.Offset >= partition.Stop || partition.Next >= partition.Stop {
+				return
+			}
+			records = append(records, newRecord(record, r.Config.Consumer))
+			partition.Next = record.Offset + 1
+			if partition.Next >= partition.Stop {
+				finished = append(finished, record.Partition)
+			}
+		})
+		if err := r.process(ctx, records); err != nil {
+			return partitions, err
+		}
+		if len(finished) > 0 {
+			client.PauseFetchPartitions(map[string][]int32{r.Config.Topic: finished})
+			remaining -= len(finished)
+		}
+	}
+	return partitions, nil
+}
+
+// window lists the first offset at or after From and the one at or after To
+// of every partition, ListOffsetsAfterMilli gives the end offset when the
+// partition has no record that late. Configured ranges are used as is.
+func (r *Replayer) window(ctx context.Context) ([]ReplayedPartition, error) {
+	if len(r.Config.Ranges) > 0 {
+		return append([]ReplayedPartition{}, r.Config.Ranges...), nil
+	}
+	admin, err := kadm.NewOptClient(append(r.Config.SASL.Opts(), kgo.SeedBrokers(r.Config.Brokers...))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka admin client: %v", err)
+	}
+	defer admin.Close()
+
+	starts, err := admin.ListOffsetsAfterMilli(ctx, r.Config.From.UnixMilli(), r.Config.Topic)
+	if err == nil {
+		err = starts.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets at the start of the window: %v", err)
+	}
+	var stops kadm.ListedOffsets
+	if r.Config.To.IsZero() {
+		stops, err = admin.ListEndOffsets(ctx, r.Config.Topic)
+	} else {
+		stops, err = admin.ListOffsetsAfterMilli(ctx, r.Config.To.UnixMilli(), r.Config.Topic)
+	}
+	if err == nil {
+		err = stops.Error()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the offsets at the end of the window: %v", err)
+	}
+
+	var partitions []ReplayedPartition
+	for _, start := range starts.Offsets().Sorted() {
+		stop, _ := stops.Lookup(r.Config.Topic, start.Partition)
+		partitions = append(partitions, ReplayedPartition{Partition: start.Partition, Start: start.At, Stop: stop.Offset})
+	}
+	return partitions, nil
+}
+
+func (r *Replayer) process(ctx context.Context, records []models.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var err error
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err = r.Processor.ProcessRecords(ctx, records); err == nil {
+			return nil
+		}
+		r.Logger.Warn("replayed batch failed, retrying...", zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+	first := records[0]
+	return fmt.Errorf("failed to process the batch from partition %d offset %d: %v", first.Partition, first.Offset, err)
+}
@@ -0,0 +1,38 @@
+package kafka
+
+import (
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// SASL mechanisms the clients authenticate with
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// SASL authenticates the clients with the brokers, a nil one connects without
+type SASL struct {
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// Opts returns the client options that authenticate with the mechanism
+func (s *SASL) Opts() []kgo.Opt {
+	if s == nil {
+		return nil
+	}
+	switch s.Mechanism {
+	case SASLPlain:
+		return []kgo.Opt{kgo.SASL(plain.Auth{User: s.Username, Pass: s.Password}.AsMechanism())}
+	case SASLScramSHA256:
+		return []kgo.Opt{kgo.SASL(scram.Auth{User: s.Username, Pass: s.Password}.AsSha256Mechanism())}
+	case SASLScramSHA512:
+		return []kgo.Opt{kgo.SASL(scram.Auth{User: s.Username, Pass: s.Password}.AsSha512Mechanism())}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	// Local Packages
+	errs "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go.uber.org/zap"
+)
+
+// skipFetchTimeout bounds the fetch of the record to skip
+const skipFetchTimeout = 10 * time.Second
+
+// SkippedRecord is a record abandoned by Skip, Committed is the offset the
+// group resumes from
+type SkippedRecord struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Committed int64  `json:"committed"`
+	Reason    string `json:"reason"`
+}
+
+// Skip abandons the record at offset of a partition this instance owns, for
+// a poison record that keeps the partition from progressing. Only the record
+// at the committed offset can be skipped, so nothing else goes unprocessed.
+// The record is copied to the DLQ before the commit moves past it, so a DLQ
+// failure leaves the offsets untouched, and the skip is audited.
+func (c *Consumer) Skip(ctx context.Context, partition int32, offset int64, reason string) (SkippedRecord, error) {
+	skipped := SkippedRecord{Topic: c.Config.Topic, Partition: partition, Offset: offset, Committed: offset + 1, Reason: reason}
+	if c.Config.DryRun {
+		return skipped, errs.E(errs.Conflict, "offsets are not committed in dry run")
+	}
+	c.assignedMu.Lock()
+	owned := slices.Contains(c.assigned[c.Config.Topic], partition)
+	c.assignedMu.Unlock()
+	if !owned {
+		return skipped, errs.E(errs.Conflict, fmt.Sprintf("partition %d is not assigned to this instance", partition))
+	}
+
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+	committed, err := kadm.NewClient(c.Client()).FetchOffsetsForTopics(ctx, c.Config.Name, c.Config.Topic)
+	if err != nil {
+		return skipped, fmt.Errorf("failed to fetch the committed offsets: %v", err)
+	}
+	current, ok := committed.Lookup(c.Config.Topic, partition)
+	if !ok || current.Err != nil || current.At < 0 {
+		return skipped, errs.E(errs.Conflict, fmt.Sprintf("partition %d has no committed offset, reset the offsets instead", partition))
+	}
+	if current.At != offset {
+		return skipped, errs.E(errs.Conflict, fmt.Sprintf("partition %d is committed at offset %d, only that record can be skipped", partition, current.At))
+	}
+
+	fetched, err := c.fetchRecord(ctx, partition, offset)
+	if err != nil {
+		return skipped, err
+	}
+	record := newRecord(fetched, c.Config.Consumer)
+	if err := c.DeadLetterQueue.Send(ctx, []models.Record{record}, fmt.Errorf("skipped by an operator: %s", reason), 0); err != nil {
+		return skipped, fmt.Errorf("failed to copy the record to the DLQ: %v", err)
+	}
+
+	if err := c.Client().CommitRecords(ctx, fetched); err != nil {
+		return skipped, fmt.Errorf("failed to commit past offset %d: %v", offset, err)
+	}
+	c.Client().SetOffsets(map[string]map[int32]kgo.EpochOffset{
+		c.Config.Topic: {partition: {Epoch: fetched.LeaderEpoch, Offset: offset + 1}},
+	})
+
+	key := partitionKey(c.Config.Topic, partition)
+	c.uncommittedMu.Lock()
+	c.skipped[key] = offset + 1
+	if last, ok := c.uncommitted[key]; ok && last.Offset < offset+1 {
+		delete(c.uncommitted, key)
+	}
+	c.uncommittedMu.Unlock()
+
+	c.Logger.Warn("skipped a record", zap.String("topic", c.Config.Topic), zap.Int32("partition", partition),
+		zap.Int64("offset", offset), zap.String("reason", reason), zap.String("correlation_id", record.CorrelationID()))
+	if c.Audit != nil {
+		now := time.Now().UTC()
+		ranges := models.NewOffsetRanges(c.Config.Consumer, c.Config.Name, []models.Record{record}, models.AuditSkipped, 0, now, now)
+		ranges[0].Reason = reason
+		if err := c.Audit.Record(ctx, ranges); err != nil {
+			c.Logger.Error("failed to record the offset audit trail", zap.Error(err))
+		}
+	}
+	return skipped, nil
+}
+
+// fetchRecord reads the record at offset with a client of its own, outside
+// the group, so the poll loop is left alone
+func (c *Consumer) fetchRecord(ctx context.Context, partition int32, offset int64) (*kgo.Record, error) {
+	client, err := kgo.NewClient(append(c.Config.SASL.Opts(),
+		kgo.SeedBrokers(c.Config.Brokers...),
+		kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{c.Config.Topic: {partition: kgo.NewOffset().At(offset)}}),
+	)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %v", err)
+	}
+	defer client.Close()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, skipFetchTimeout)
+	defer cancel()
+	for {
+		fetches := client.PollRecords(fetchCtx, 1)
+		if err := fetches.Err(); err != nil {
+			return nil, fmt.Errorf("failed to fetch offset %d of partition %d: %v", offset, partition, err)
+		}
+		for _, record := range fetches.Records() {
+			if record.Offset == offset {
+				return record, nil
+			}
+			if record.Offset > offset {
+				return nil, errs.E(errs.NotFound, fmt.Sprintf("partition %d has no record at offset %d", partition, offset))
+			}
+		}
+	}
+}
+
+// dropSkipped leaves out the records of the offsets committed by Skip, a batch
+// fetched before the skip must not commit its partition back
+func (c *Consumer) dropSkipped(records []*kgo.Record) []*kgo.Record {
+	c.uncommittedMu.Lock()
+	defer c.uncommittedMu.Unlock()
+	kept := make([]*kgo.Record, 0, len(records))
+	for _, record := range records {
+		if record.Offset >= c.skipped[partitionKey(record.Topic, record.Partition)] {
+			kept = append(kept, record)
+		}
+	}
+	return kept
+}
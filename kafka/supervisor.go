@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	reporting "tx-stream/reporting"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// SupervisorConfig bounds the restarts, more than MaxRestarts within Window
+// gives up and returns the error
+type SupervisorConfig struct {
+	MaxRestarts    int
+	Window         time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Supervisor restarts a consumer with a new kafka client when its poll loop
+// fails or panics, keeping the rest of the process and its caches alive
+type Supervisor struct {
+	Consumer *Consumer
+	Config   *SupervisorConfig
+	Logger   *zap.Logger
+	Metrics  *metrics.SupervisorMetrics // Optional, counts the restarts
+}
+
+func NewSupervisor(consumer *Consumer, conf *SupervisorConfig, logger *zap.Logger, supervisorMetrics *metrics.SupervisorMetrics) *Supervisor {
+	return &Supervisor{Consumer: consumer, Config: conf, Logger: logger, Metrics: supervisorMetrics}
+}
+
+// Run polls until ctx is done, the consumer drained or the restarts ran out
+func (s *Supervisor) Run(ctx context.Context, consume bool) error {
+	var restarts []time.Time
+	backoff := s.Config.InitialBackoff
+	for {
+		err := s.poll(ctx, consume)
+		if err == nil || ctx.Err() != nil || s.Consumer.draining.Load() {
+			return err
+		}
+
+		now := time.Now()
+		recent := restarts[:0]
+		for _, at := range restarts {
+			if now.Sub(at) < s.Config.Window {
+				recent = append(recent, at)
+			}
+		}
+		restarts = recent
+		if len(restarts) == 0 {
+			backoff = s.Config.InitialBackoff
+		}
+		if len(restarts) >= s.Config.MaxRestarts {
+			return fmt.Errorf("restarted %d times within %s: %v", len(restarts), s.Config.Window, err)
+		}
+
+		s.Logger.Error("poll loop failed, restarting the consumer", zap.Duration("backoff", backoff),
+			zap.Int("restarts", len(restarts)), zap.Error(err))
+		if err := s.restart(ctx, backoff); err != nil {
+			return err
+		}
+		backoff = min(2*backoff, s.Config.MaxBackoff)
+		restarts = append(restarts, now)
+		s.Metrics.Restarted(s.Consumer.Config.Consumer)
+	}
+}
+
+// poll runs the poll loop, turning a panic into an error
+func (s *Supervisor) poll(ctx context.Context, consume bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			reporting.CapturePanic(r)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.Consumer.Poll(ctx, consume)
+}
+
+// restart waits for the backoff and restarts the consumer
+func (s *Supervisor) restart(ctx context.Context, backoff time.Duration) error {
+	s.Consumer.restarting.Store(true)
+	defer s.Consumer.restarting.Store(false)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(backoff):
+	}
+	return s.Consumer.Restart()
+}
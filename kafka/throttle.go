@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	// Go Internal Packages
+	"context"
+	"math"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+
+	// External Packages
+	"golang.org/x/time/rate"
+)
+
+// Throttle caps the records per second the consumers process together, so a
+// struggling downstream is slowed down instead of stopped
+type Throttle struct {
+	Limiter *rate.Limiter
+	Metrics *metrics.ThrottleMetrics // Optional, reports the rate and the time spent waiting
+}
+
+// NewThrottle creates a throttle, zero records per second leaves it open
+func NewThrottle(perSecond float64, throttleMetrics *metrics.ThrottleMetrics) *Throttle {
+	t := &Throttle{Limiter: rate.NewLimiter(rate.Inf, 0), Metrics: throttleMetrics}
+	t.SetRate(perSecond)
+	return t
+}
+
+// SetRate changes the records per second, zero removes the cap
+func (t *Throttle) SetRate(perSecond float64) {
+	if perSecond <= 0 {
+		t.Limiter.SetLimit(rate.Inf)
+		t.Metrics.SetRate(0)
+		return
+	}
+	// A second worth of records may go at once, larger batches wait in chunks
+	t.Limiter.SetBurst(int(math.Max(1, math.Ceil(perSecond))))
+	t.Limiter.SetLimit(rate.Limit(perSecond))
+	t.Metrics.SetRate(perSecond)
+}
+
+// Rate returns the records per second, zero when open
+func (t *Throttle) Rate() float64 {
+	if limit := t.Limiter.Limit(); limit != rate.Inf {
+		return float64(limit)
+	}
+	return 0
+}
+
+// Wait blocks until n more records may be processed
+func (t *Throttle) Wait(ctx context.Context, n int) error {
+	if t == nil || t.Limiter.Limit() == rate.Inf {
+		return nil
+	}
+	start := time.Now()
+	defer func() { t.Metrics.Waited(time.Since(start).Seconds()) }()
+	for n > 0 {
+		chunk := min(n, t.Limiter.Burst())
+		if err := t.Limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
@@ -6,61 +6,503 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	// Local Packages
+	errs "tx-stream/errors"
+	logging "tx-stream/logging"
+	metrics "tx-stream/metrics"
 	models "tx-stream/models"
-	redis "tx-stream/repositories/redis"
+	reporting "tx-stream/reporting"
+	tracing "tx-stream/tracing"
 
 	// External Packages
+	"github.com/google/uuid"
+	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/plugin/kprom"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
 type ConsumerConfig struct {
 	Brokers        []string
-	Name           string
+	SASL           *SASL  // Nil connects without authentication
+	Consumer       string // Name of the consumer in the config
+	Name           string // Consumer group
 	Topic          string
 	RecordsPerPoll int
+	DryRun         bool   // Leaves the offsets uncommitted
+	ClientID       string // Names the instance in the group metadata, kgo's default when empty
 }
 
 type Consumer struct {
-	Client          *kgo.Client
 	Config          *ConsumerConfig
 	Processor       TxProcessor
 	Logger          *zap.Logger
-	DeadLetterQueue *redis.DeadLetterQueue
+	DeadLetterQueue DeadLetterQueue
+	Stages          *metrics.StageMetrics
+	Errors          *metrics.ErrorMetrics
+	Audit           OffsetAuditor             // Optional, records every committed offset range
+	SLO             *metrics.SLOMetrics       // Optional, counts committed records against the freshness objective
+	Heartbeats      *metrics.HeartbeatMetrics // Optional, stamps the time of the last poll and commit
+	LagMonitor      *LagMonitor               // Optional, reports the lag in Status
+	Throttle        *Throttle                 // Optional, caps the records per second, shared by the consumers
+
+	client         atomic.Pointer[kgo.Client] // Replaced by Restart
+	clientOpts     []kgo.Opt
+	restarts       atomic.Int64
+	restarting     atomic.Bool  // Set by the supervisor while it waits to restart, so liveness holds
+	closed         atomic.Bool  // Whether the current client was closed
+	recordsPerPoll atomic.Int64 // Starts at Config.RecordsPerPoll, changed by SetRecordsPerPoll
+	polling        atomic.Bool
+	busySince      atomic.Int64 // Unix nanos the current batch was fetched at, zero while waiting for records
+	inFlight       atomic.Int64 // Records of the batch in processing
+	lastPoll       atomic.Int64 // Unix nanos of the last poll
+	startedAt      atomic.Int64 // Unix nanos the poll loop started at
+	busyNanos      atomic.Int64 // Time spent on finished batches since the poll loop started
+	oldestPending  atomic.Int64 // Unix nanos of the oldest record timestamp of the batch in processing, zero while idle
+	inFlightMu     sync.Mutex
+	inFlightRanges []InFlightRange // Offsets of the batch in processing
+
+	assignedMu sync.Mutex
+	assigned   map[string][]int32
+
+	paused        atomic.Bool
+	standby       atomic.Bool  // Set by SetStandby while the region is not the active one
+	maintenance   atomic.Value // Maintenance mode set by SetMaintenance, off when unset
+	commitMu      sync.Mutex   // Serializes the commits, so a retry never rewinds a later commit
+	uncommittedMu sync.Mutex
+	uncommitted   map[string]*kgo.Record // Last record per partition of the batches whose commit failed
+	skipped       map[string]int64       // Offset per partition committed by Skip, older records are never committed again
+
+	draining  atomic.Bool
+	drainOnce sync.Once
+	stopPoll  atomic.Pointer[context.CancelFunc] // Interrupts a poll waiting for records
+	parked    chan struct{}                      // Closed once the poll loop stopped for the drain
+	released  chan struct{}                      // Closed once the drain is over, the poll loop then returns
+}
+
+// ConsumerStats is a snapshot of a running consumer
+type ConsumerStats struct {
+	Name           string             `json:"name"`
+	Group          string             `json:"group"`
+	Topic          string             `json:"topic"`
+	Polling        bool               `json:"polling"`
+	Paused         bool               `json:"paused"`
+	Standby        bool               `json:"standby,omitempty"`
+	Restarts       int64              `json:"restarts"`
+	Maintenance    string             `json:"maintenance,omitempty"`
+	RecordsPerPoll int64              `json:"records_per_poll"`
+	InFlight       int64              `json:"in_flight"`
+	Buffered       int64              `json:"buffered"` // Fetched from the brokers but not polled yet
+	LastPoll       *time.Time         `json:"last_poll,omitempty"`
+	Utilization    float64            `json:"utilization"` // Share of the time since the poll loop started spent on batches
+	Assigned       map[string][]int32 `json:"assigned_partitions"`
+	InFlightRanges []InFlightRange    `json:"in_flight_offsets,omitempty"`
+}
+
+// InFlightRange is the offsets of a partition in the batch in processing
+type InFlightRange struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	First     int64  `json:"first"`
+	Last      int64  `json:"last"`
+}
+
+// ConsumerStatus is the snapshot of a consumer along with its lag
+type ConsumerStatus struct {
+	ConsumerStats
+	Lag      []PartitionLag `json:"lag,omitempty"`
+	LagError string         `json:"lag_error,omitempty"`
 }
 
 type TxProcessor interface {
 	ProcessRecords(ctx context.Context, records []models.Record) error
 }
 
+// OffsetAuditor keeps a trail of the committed offsets
+type OffsetAuditor interface {
+	Record(ctx context.Context, ranges []models.OffsetRange) error
+}
+
+// DeadLetterQueue receives the records that could not be processed after retries
+type DeadLetterQueue interface {
+	Send(ctx context.Context, records []models.Record, cause error, attempts int) error
+}
+
 // NewTxConsumer creates a new consumer to consume transactions topic
 // (PS: Must call Poll to start consuming the records)
-func NewTxConsumer(conf *ConsumerConfig, logger *zap.Logger, processor TxProcessor, dlQueue *redis.DeadLetterQueue, metrics *kprom.Metrics) (*Consumer, error) {
+func NewTxConsumer(conf *ConsumerConfig, logger *zap.Logger, processor TxProcessor, dlQueue DeadLetterQueue, kafkaMetrics *kprom.Metrics, stages *metrics.StageMetrics, errorMetrics *metrics.ErrorMetrics) (*Consumer, error) {
+	consumer := &Consumer{
+		Config:          conf,
+		Processor:       processor,
+		Logger:          logger,
+		DeadLetterQueue: dlQueue,
+		Stages:          stages,
+		Errors:          errorMetrics,
+		assigned:        make(map[string][]int32),
+		uncommitted:     make(map[string]*kgo.Record),
+		skipped:         make(map[string]int64),
+		parked:          make(chan struct{}),
+		released:        make(chan struct{}),
+	}
+	consumer.recordsPerPoll.Store(int64(conf.RecordsPerPoll))
+
 	opts := []kgo.Opt{
 		kgo.SeedBrokers(conf.Brokers...), // Connects to Kafka brokers
 		kgo.ConsumerGroup(conf.Name),     // Specifies the consumer group
 		kgo.ConsumeTopics(conf.Topic),    // Specifies a single topic to consume
-		kgo.WithHooks(metrics),           // Attaches monitoring hooks
+		kgo.WithHooks(kafkaMetrics),      // Attaches monitoring hooks
 		kgo.DisableAutoCommit(),          // Disables auto-commit
 		kgo.BlockRebalanceOnPoll(),       // Blocks rebalancing until the poll loop is running
+		kgo.OnPartitionsAssigned(consumer.onAssigned),
+		kgo.OnPartitionsRevoked(consumer.onRevoked),
+		kgo.OnPartitionsLost(consumer.onRevoked),
 	}
 
+	if conf.ClientID != "" {
+		opts = append(opts, kgo.ClientID(conf.ClientID))
+	}
+	opts = append(opts, conf.SASL.Opts()...)
+
 	client, err := kgo.NewClient(opts...)
 	if err != nil || client == nil {
 		return nil, err
 	}
+	consumer.client.Store(client)
+	consumer.clientOpts = opts
+	return consumer, nil
+}
+
+// Client returns the kafka client in use, a restart replaces it
+func (c *Consumer) Client() *kgo.Client {
+	return c.client.Load()
+}
+
+// Restart replaces the kafka client, closed once Poll returned, by a new one
+// joining the group again. The records processed but not committed are
+// fetched again by the member that gets their partitions.
+func (c *Consumer) Restart() error {
+	if c.polling.Load() {
+		return errors.New("cannot restart a polling consumer")
+	}
+	client, err := kgo.NewClient(c.clientOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to recreate kafka client: %v", err)
+	}
+	c.assignedMu.Lock()
+	c.assigned = make(map[string][]int32)
+	c.assignedMu.Unlock()
+	c.uncommittedMu.Lock()
+	c.uncommitted = make(map[string]*kgo.Record)
+	c.skipped = make(map[string]int64) // The offsets may have been rewound meanwhile
+	c.uncommittedMu.Unlock()
 
-	return &Consumer{
-		Client:          client,
-		Config:          conf,
-		Processor:       processor,
-		Logger:          logger,
-		DeadLetterQueue: dlQueue,
-	}, nil
+	if c.fetchPaused() {
+		client.PauseFetchTopics(c.Config.Topic)
+	}
+	c.client.Store(client)
+	c.closed.Store(false)
+	c.restarts.Add(1)
+	return nil
+}
+
+// Close commits the processed records whose commit failed and closes the
+// kafka client, the last step of a shutdown once the poll loop returned
+func (c *Consumer) Close(ctx context.Context) error {
+	if c.polling.Load() {
+		return errors.New("cannot close a polling consumer")
+	}
+	var err error
+	if !c.Config.DryRun && !c.closed.Load() && len(c.pendingCommits()) > 0 {
+		c.commitMu.Lock()
+		err = c.commit(ctx, nil)
+		c.commitMu.Unlock()
+		if err != nil {
+			err = fmt.Errorf("failed to commit offsets: %v", err)
+		} else {
+			c.Logger.Info("committed the pending offsets on shutdown")
+		}
+	}
+	c.closeClient()
+	return err
+}
+
+func (c *Consumer) closeClient() {
+	if c.closed.CompareAndSwap(false, true) {
+		c.Client().CloseAllowingRebalance()
+	}
+}
+
+func (c *Consumer) onAssigned(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+	c.assignedMu.Lock()
+	defer c.assignedMu.Unlock()
+	for topic, partitions := range assigned {
+		c.assigned[topic] = append(c.assigned[topic], partitions...)
+		slices.Sort(c.assigned[topic])
+	}
+}
+
+func (c *Consumer) onRevoked(_ context.Context, _ *kgo.Client, revoked map[string][]int32) {
+	c.assignedMu.Lock()
+	defer c.assignedMu.Unlock()
+	for topic, partitions := range revoked {
+		c.assigned[topic] = slices.DeleteFunc(c.assigned[topic], func(partition int32) bool {
+			return slices.Contains(partitions, partition)
+		})
+		if len(c.assigned[topic]) == 0 {
+			delete(c.assigned, topic)
+		}
+	}
+
+	// The new owner of the partitions consumes them from the last commit, and
+	// so does this instance when it gets them back
+	c.uncommittedMu.Lock()
+	defer c.uncommittedMu.Unlock()
+	for topic, partitions := range revoked {
+		for _, partition := range partitions {
+			delete(c.uncommitted, partitionKey(topic, partition))
+			delete(c.skipped, partitionKey(topic, partition))
+		}
+	}
+}
+
+// Stats returns a snapshot of the poll loop and of the assigned partitions
+func (c *Consumer) Stats() ConsumerStats {
+	stats := ConsumerStats{
+		Name:           c.Config.Consumer,
+		Group:          c.Config.Name,
+		Topic:          c.Config.Topic,
+		Polling:        c.polling.Load(),
+		RecordsPerPoll: c.recordsPerPoll.Load(),
+		InFlight:       c.inFlight.Load(),
+		Buffered:       c.Client().BufferedFetchRecords(),
+		Assigned:       make(map[string][]int32),
+	}
+	stats.Paused = c.paused.Load()
+	stats.Standby = c.standby.Load()
+	stats.Restarts = c.restarts.Load()
+	if mode := c.maintenanceMode(); mode != MaintenanceOff {
+		stats.Maintenance = mode
+	}
+	if lastPoll := c.lastPoll.Load(); lastPoll != 0 {
+		at := time.Unix(0, lastPoll)
+		stats.LastPoll = &at
+	}
+	if startedAt := c.startedAt.Load(); startedAt != 0 {
+		busy := time.Duration(c.busyNanos.Load())
+		if since := c.busySince.Load(); since != 0 {
+			busy += time.Since(time.Unix(0, since))
+		}
+		if elapsed := time.Since(time.Unix(0, startedAt)); elapsed > 0 {
+			stats.Utilization = min(busy.Seconds()/elapsed.Seconds(), 1)
+		}
+	}
+
+	c.inFlightMu.Lock()
+	stats.InFlightRanges = slices.Clone(c.inFlightRanges)
+	c.inFlightMu.Unlock()
+
+	c.assignedMu.Lock()
+	defer c.assignedMu.Unlock()
+	for topic, partitions := range c.assigned {
+		stats.Assigned[topic] = slices.Clone(partitions)
+	}
+	return stats
+}
+
+// Status returns the stats along with the lag of every partition
+func (c *Consumer) Status(ctx context.Context) ConsumerStatus {
+	status := ConsumerStatus{ConsumerStats: c.Stats()}
+	if c.LagMonitor != nil {
+		lags, err := c.LagMonitor.Lags(ctx)
+		status.Lag = lags
+		if err != nil {
+			status.LagError = err.Error()
+		}
+	}
+	return status
+}
+
+// Pause stops fetching the topic, the records already buffered are still processed
+func (c *Consumer) Pause() {
+	c.Client().PauseFetchTopics(c.Config.Topic)
+	c.paused.Store(true)
+	c.Logger.Warn("consumption paused")
+}
+
+// Resume fetches the topic again after Pause, unless maintenance or the
+// standby region stops fetching
+func (c *Consumer) Resume() {
+	c.paused.Store(false)
+	if !c.fetchPaused() {
+		c.Client().ResumeFetchTopics(c.Config.Topic)
+	}
+	c.Logger.Info("consumption resumed")
+}
+
+// SetMaintenance follows a maintenance mode: fetch pauses fetching, persist
+// holds the next batch before processing until the mode is off again
+func (c *Consumer) SetMaintenance(mode string) {
+	c.maintenance.Store(mode)
+	c.applyFetch()
+	c.Logger.Warn("maintenance mode changed", zap.String("mode", mode))
+}
+
+// SetStandby follows the role of the region: on standby the consumer stays in
+// its group with the partitions assigned but fetches nothing, so activating
+// it only resumes fetching
+func (c *Consumer) SetStandby(standby bool) {
+	c.standby.Store(standby)
+	c.applyFetch()
+	if standby {
+		c.Logger.Warn("region on standby, fetching paused")
+	} else {
+		c.Logger.Info("region active, fetching resumed")
+	}
+}
+
+// fetchPaused reports whether anything stops fetching the topic
+func (c *Consumer) fetchPaused() bool {
+	return c.paused.Load() || c.standby.Load() || c.draining.Load() || c.maintenanceMode() == MaintenanceFetch
+}
+
+func (c *Consumer) applyFetch() {
+	if c.fetchPaused() {
+		c.Client().PauseFetchTopics(c.Config.Topic)
+	} else {
+		c.Client().ResumeFetchTopics(c.Config.Topic)
+	}
+}
+
+func (c *Consumer) maintenanceMode() string {
+	if mode, ok := c.maintenance.Load().(string); ok {
+		return mode
+	}
+	return MaintenanceOff
+}
+
+// holdForMaintenance waits while persisting is paused, the batch stays
+// uncommitted and kgo keeps the group membership alive in the background. The
+// batch does not count as busy meanwhile, so a hold as long as the outage is
+// not taken for a stalled loop.
+func (c *Consumer) holdForMaintenance(ctx context.Context) error {
+	if c.maintenanceMode() != MaintenancePersist {
+		return nil
+	}
+	c.Logger.Warn("holding the batch during maintenance", zap.Int64("records", c.inFlight.Load()))
+	if since := c.busySince.Swap(0); since != 0 {
+		c.busyNanos.Add(int64(time.Since(time.Unix(0, since))))
+	}
+	defer c.busySince.Store(time.Now().UnixNano())
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for c.maintenanceMode() == MaintenancePersist {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	c.Logger.Info("maintenance over, processing the held batch")
+	return nil
+}
+
+// Commit retries the commit of the processed records whose commit failed and
+// returns the committed offset per partition, nothing to retry commits nothing
+func (c *Consumer) Commit(ctx context.Context) (map[string]map[int32]int64, error) {
+	if c.Config.DryRun {
+		return nil, errs.E(errs.Conflict, "offsets are not committed in dry run")
+	}
+	c.commitMu.Lock()
+	defer c.commitMu.Unlock()
+	committed := make(map[string]map[int32]int64)
+	for _, record := range c.pendingCommits() {
+		if committed[record.Topic] == nil {
+			committed[record.Topic] = make(map[int32]int64)
+		}
+		committed[record.Topic][record.Partition] = record.Offset + 1
+	}
+	if len(committed) == 0 {
+		return committed, nil
+	}
+	if err := c.commit(ctx, nil); err != nil {
+		return nil, fmt.Errorf("failed to commit offsets: %v", err)
+	}
+	c.Logger.Info("committed offsets manually", zap.Any("offsets", committed))
+	return committed, nil
+}
+
+// commit commits the records along with those whose commit failed before,
+// keeping them all for the next commit if it fails again
+// (PS: Must hold commitMu)
+func (c *Consumer) commit(ctx context.Context, records []*kgo.Record) error {
+	records = c.dropSkipped(records)
+	committing := append(records, c.pendingCommits()...)
+	err := c.Client().CommitRecords(ctx, committing...)
+
+	c.uncommittedMu.Lock()
+	defer c.uncommittedMu.Unlock()
+	if err == nil {
+		clear(c.uncommitted)
+		// A commit past a skip point leaves no batch from before the skip
+		for _, record := range committing {
+			key := partitionKey(record.Topic, record.Partition)
+			if skip, ok := c.skipped[key]; ok && record.Offset >= skip {
+				delete(c.skipped, key)
+			}
+		}
+		return nil
+	}
+	for _, record := range records {
+		key := partitionKey(record.Topic, record.Partition)
+		if last, ok := c.uncommitted[key]; !ok || record.Offset > last.Offset {
+			c.uncommitted[key] = record
+		}
+	}
+	return err
+}
+
+func (c *Consumer) pendingCommits() []*kgo.Record {
+	c.uncommittedMu.Lock()
+	defer c.uncommittedMu.Unlock()
+	pending := make([]*kgo.Record, 0, len(c.uncommitted))
+	for _, record := range c.uncommitted {
+		pending = append(pending, record)
+	}
+	return pending
+}
+
+func partitionKey(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
+
+// SetRecordsPerPoll changes the batch size from the next poll on
+func (c *Consumer) SetRecordsPerPoll(n int) {
+	c.recordsPerPoll.Store(int64(n))
+}
+
+// Alive returns nil while the poll loop runs and no batch has been in
+// processing for longer than stallTimeout
+func (c *Consumer) Alive(stallTimeout time.Duration) error {
+	if !c.polling.Load() && !c.restarting.Load() {
+		return errors.New("poll loop is not running")
+	}
+	if since := c.busySince.Load(); since != 0 {
+		if busy := time.Since(time.Unix(0, since)); busy > stallTimeout {
+			return fmt.Errorf("batch in processing for %s", busy.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// Ping checks that a broker is reachable
+func (c *Consumer) Ping(ctx context.Context) error {
+	return c.Client().Ping(ctx)
 }
 
 // Poll polls for records from the Kafka broker.
@@ -68,7 +510,19 @@ func (c *Consumer) Poll(ctx context.Context, consume bool) error {
 	if !consume {
 		return nil
 	}
-	defer c.Client.Close()
+	defer func() {
+		// On shutdown the client stays open for Close to commit what is left
+		if ctx.Err() == nil {
+			c.closeClient()
+		}
+	}()
+	c.polling.Store(true)
+	defer c.polling.Store(false)
+	c.startedAt.Store(time.Now().UnixNano())
+
+	pollCtx, cancelPoll := context.WithCancel(ctx)
+	defer cancelPoll()
+	c.stopPoll.Store(&cancelPoll)
 
 	for {
 		// Check if the context is canceled before polling
@@ -76,9 +530,22 @@ func (c *Consumer) Poll(ctx context.Context, consume bool) error {
 			c.Logger.Warn("polling stopped: context canceled")
 			return ctx.Err() // Exit gracefully
 		}
+		if c.draining.Load() {
+			return c.park(ctx)
+		}
 
 		c.Logger.Info(fmt.Sprintf("%s: polling for records", c.Config.Name))
-		fetches := c.Client.PollRecords(ctx, c.Config.RecordsPerPoll)
+		c.endBatch()
+		fetches := c.Client().PollRecords(pollCtx, int(c.recordsPerPoll.Load()))
+		if c.draining.Load() && len(fetches.Records()) == 0 {
+			return c.park(ctx)
+		}
+		fetchedAt := time.Now()
+		c.busySince.Store(fetchedAt.UnixNano())
+		c.lastPoll.Store(fetchedAt.UnixNano())
+		c.inFlight.Store(int64(len(fetches.Records())))
+		c.oldestPending.Store(oldestTimestamp(fetches.Records()))
+		c.setInFlightRanges(fetches.Records())
 
 		// Handle client shutdown
 		if fetches.IsClientClosed() {
@@ -89,39 +556,262 @@ func (c *Consumer) Poll(ctx context.Context, consume bool) error {
 		if errors.Is(fetches.Err0(), context.Canceled) {
 			return errors.New("context got canceled")
 		}
+		if len(fetches.Records()) > 0 {
+			c.Heartbeats.Polled(c.Config.Consumer, c.Config.Name, c.Config.Topic, fetchedAt)
+		}
 
 		// Preallocate records slice
 		records := make([]models.Record, len(fetches.Records()))
 		for idx, record := range fetches.Records() {
-			records[idx] = models.Record{
-				Key:   record.Key,
-				Value: record.Value,
-				Topic: record.Topic,
+			records[idx] = newRecord(record, c.Config.Consumer)
+		}
+
+		if len(records) > 0 {
+			if err := c.holdForMaintenance(ctx); err != nil {
+				return err
+			}
+			if err := c.Throttle.Wait(ctx, len(records)); err != nil {
+				return err
 			}
 		}
 
+		// A span per fetched batch, linked to the producer traces, with the stages as children
+		batchCtx, batchSpan := tracing.StartBatch(ctx, "kafka", "kafka.batch", records,
+			attribute.String("messaging.consumer.group.name", c.Config.Name),
+			attribute.Int("messaging.batch.message_count", len(records)))
+
+		batchCtx = logging.WithLogger(batchCtx, c.Logger)
+
 		success := false
-		for attempt := 1; attempt <= 2; attempt++ {
-			err := c.Processor.ProcessRecords(ctx, records)
-			if err == nil {
+		attempt := 1
+		var processErr error
+		for ; attempt <= 2; attempt++ {
+			processCtx, processSpan := tracing.Start(batchCtx, "kafka", "process", attribute.Int("attempt", attempt))
+			processStart := time.Now()
+			processErr = c.Processor.ProcessRecords(processCtx, records)
+			c.Stages.ObserveProcess(c.Config.Topic, metrics.Outcome(processErr), time.Since(processStart).Seconds())
+			tracing.End(processSpan, processErr)
+			if processErr == nil {
 				success = true
 				break
 			}
-			c.Logger.Warn("processing failed, retrying...", zap.Int("attempt", attempt), zap.Error(err))
+			c.Logger.Warn("processing failed, retrying...", zap.Int("attempt", attempt),
+				zap.Strings("correlation_ids", models.CorrelationIDs(records)), zap.Error(processErr))
 			jitter := time.Duration(rand.Int63n(int64(time.Second)) * (1 << attempt)) // 1s, 2s-4s, 4s-8s, 8s-16s
 			time.Sleep(jitter)
 		}
 
 		if !success {
-			c.Logger.Info("processing failed after retries, sending to DLQ")
-			if err := c.DeadLetterQueue.Send(ctx, records); err != nil {
+			c.Logger.Info("processing failed after retries, sending to DLQ",
+				zap.Strings("correlation_ids", models.CorrelationIDs(records)))
+			reporting.CaptureRecords(processErr, records)
+			dlqCtx, dlqSpan := tracing.Start(batchCtx, "kafka", "dlq.enqueue")
+			err := c.DeadLetterQueue.Send(dlqCtx, records, processErr, attempt-1)
+			tracing.End(dlqSpan, err)
+			if err != nil {
+				c.Errors.Count(errs.ErrorClass(err), c.Config.Topic, 1)
 				c.Logger.Error("failed to send records to DLQ", zap.Error(err))
 			}
 		}
 
+		outcome := metrics.OutcomeSuccess
+		if !success {
+			outcome = metrics.OutcomeDeadLettered
+		}
+
 		// Commit successfully processed records
-		if err := c.Client.CommitRecords(ctx, fetches.Records()...); err != nil {
+		if c.Config.DryRun {
+			tracing.End(batchSpan, processErr)
+			c.observeEndToEnd(records, outcome)
+			continue
+		}
+		commitCtx, commitSpan := tracing.Start(batchCtx, "kafka", "kafka.commit")
+		c.commitMu.Lock()
+		err := c.commit(commitCtx, fetches.Records())
+		c.commitMu.Unlock()
+		tracing.End(commitSpan, err)
+		tracing.End(batchSpan, processErr)
+		if err != nil {
 			c.Logger.Error("failed to commit processed records", zap.Error(err))
+			outcome = metrics.OutcomeFailure
+		} else {
+			c.Heartbeats.Committed(c.Config.Consumer, c.Config.Name, c.Config.Topic, time.Now())
+			c.audit(ctx, records, success, attempt, fetchedAt)
+			c.observeSLO(records, success)
 		}
+		c.observeEndToEnd(records, outcome)
+	}
+}
+
+// Drain hands the partitions over to the other members before shutdown: it
+// stops fetching, lets the batch in processing commit, leaves the group and
+// waits until the other members own the partitions it had or ctx is done.
+// The poll loop returns once the drain is over.
+func (c *Consumer) Drain(ctx context.Context) error {
+	if !c.polling.Load() {
+		return nil
+	}
+	var drainErr error
+	c.drainOnce.Do(func() {
+		defer close(c.released)
+		c.Logger.Info("draining consumer")
+		c.draining.Store(true)
+		c.Client().PauseFetchTopics(c.Config.Topic)
+		if stopPoll := c.stopPoll.Load(); stopPoll != nil {
+			(*stopPoll)()
+		}
+		select {
+		case <-c.parked:
+		case <-ctx.Done():
+			drainErr = fmt.Errorf("batch still in processing: %v", ctx.Err())
+			return
+		}
+
+		owned := c.Stats().Assigned[c.Config.Topic]
+		c.Client().AllowRebalance()
+		c.Client().LeaveGroup()
+		drainErr = c.awaitHandover(ctx, owned)
+	})
+	return drainErr
+}
+
+// park stops the poll loop until the drain is over
+func (c *Consumer) park(ctx context.Context) error {
+	c.endBatch()
+	close(c.parked)
+	select {
+	case <-c.released:
+	case <-ctx.Done():
+	}
+	c.Logger.Info("consumer drained")
+	return nil
+}
+
+// awaitHandover waits until other members of the group are assigned the partitions
+func (c *Consumer) awaitHandover(ctx context.Context, partitions []int32) error {
+	admin := kadm.NewClient(c.Client())
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		described, err := admin.DescribeGroups(ctx, c.Config.Name)
+		if group, ok := described[c.Config.Name]; err == nil && ok && group.Err == nil {
+			if len(group.Members) == 0 {
+				c.Logger.Warn("no member left in the group to take the partitions over")
+				return nil
+			}
+			assigned := group.AssignedPartitions()
+			if group.State == "Stable" && !slices.ContainsFunc(partitions, func(partition int32) bool {
+				return !assigned.Lookup(c.Config.Topic, partition)
+			}) {
+				c.Logger.Info("partitions taken over", zap.Int32s("partitions", partitions))
+				return nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("partitions not taken over: %v", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// newRecord converts a fetched record, giving it a correlation id unless the producer set one
+func newRecord(record *kgo.Record, consumer string) models.Record {
+	headers := make([]models.RecordHeader, len(record.Headers))
+	for hdx, header := range record.Headers {
+		headers[hdx] = models.RecordHeader{Key: header.Key, Value: header.Value}
+	}
+	converted := models.Record{
+		Key:       record.Key,
+		Value:     record.Value,
+		Headers:   headers,
+		Topic:     record.Topic,
+		Partition: record.Partition,
+		Offset:    record.Offset,
+		Timestamp: record.Timestamp,
+		Consumer:  consumer,
+	}
+	if converted.CorrelationID() == "" {
+		converted.SetHeader(models.HeaderCorrelationID, []byte(uuid.NewString()))
+	}
+	return converted
+}
+
+// observeSLO counts the committed records against the freshness objective
+func (c *Consumer) observeSLO(records []models.Record, processed bool) {
+	now := time.Now()
+	for _, record := range records {
+		c.SLO.ObserveCommitted(record.Topic, now.Sub(record.Timestamp), processed)
+	}
+}
+
+// endBatch adds the time spent on the previous batch, if any, to the busy time
+func (c *Consumer) endBatch() {
+	if since := c.busySince.Swap(0); since != 0 {
+		c.busyNanos.Add(int64(time.Since(time.Unix(0, since))))
+	}
+	c.inFlight.Store(0)
+	c.oldestPending.Store(0)
+	c.setInFlightRanges(nil)
+}
+
+// setInFlightRanges keeps the offset range of each partition of the batch
+func (c *Consumer) setInFlightRanges(records []*kgo.Record) {
+	var ranges []InFlightRange
+	for _, record := range records {
+		idx := slices.IndexFunc(ranges, func(r InFlightRange) bool {
+			return r.Topic == record.Topic && r.Partition == record.Partition
+		})
+		if idx < 0 {
+			ranges = append(ranges, InFlightRange{Topic: record.Topic, Partition: record.Partition, First: record.Offset, Last: record.Offset})
+			continue
+		}
+		ranges[idx].First = min(ranges[idx].First, record.Offset)
+		ranges[idx].Last = max(ranges[idx].Last, record.Offset)
+	}
+	c.inFlightMu.Lock()
+	defer c.inFlightMu.Unlock()
+	c.inFlightRanges = ranges
+}
+
+// OldestPending returns the timestamp of the oldest record fetched but not
+// committed yet, the zero time while no batch is in processing
+func (c *Consumer) OldestPending() time.Time {
+	if oldest := c.oldestPending.Load(); oldest != 0 {
+		return time.Unix(0, oldest)
+	}
+	return time.Time{}
+}
+
+func oldestTimestamp(records []*kgo.Record) int64 {
+	var oldest int64
+	for _, record := range records {
+		if ts := record.Timestamp.UnixNano(); oldest == 0 || ts < oldest {
+			oldest = ts
+		}
+	}
+	return oldest
+}
+
+// audit records the committed offset ranges, a failure is logged and never stops the poll loop
+func (c *Consumer) audit(ctx context.Context, records []models.Record, success bool, attempt int, fetchedAt time.Time) {
+	if c.Audit == nil || len(records) == 0 {
+		return
+	}
+	outcome, attempts := models.AuditProcessed, attempt
+	if !success {
+		outcome, attempts = models.AuditDeadLettered, attempt-1
+	}
+	ranges := models.NewOffsetRanges(c.Config.Consumer, c.Config.Name, records, outcome, attempts, fetchedAt, time.Now().UTC())
+	if err := c.Audit.Record(ctx, ranges); err != nil {
+		c.Logger.Error("failed to record the offset audit trail", zap.Error(err))
+	}
+}
+
+// observeEndToEnd records the latency from the timestamp of each record to now
+func (c *Consumer) observeEndToEnd(records []models.Record, outcome string) {
+	now := time.Now()
+	for _, record := range records {
+		c.Stages.ObserveEndToEnd(record.Topic, outcome, now.Sub(record.Timestamp).Seconds())
 	}
 }
@@ -0,0 +1,119 @@
+package lifecycle
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Phase is a step of the shutdown, the phases run in the order of Phases
+type Phase string
+
+const (
+	StopFetching Phase = "stop_fetching" // Stop polling, draining the partitions when configured
+	DrainWorkers Phase = "drain_workers" // Wait for the batches in processing and the background jobs
+	Flush        Phase = "flush"         // Flush the producers and the buffered batches
+	Commit       Phase = "commit"        // Commit what was processed and close the kafka clients
+	CloseStores  Phase = "close_stores"  // Disconnect from mongo and redis
+	StopServers  Phase = "stop_servers"  // Stop the admin and metrics servers
+	Telemetry    Phase = "telemetry"     // Flush the logs, traces and errors, last so the shutdown is reported
+)
+
+// Phases is the shutdown order
+var Phases = []Phase{StopFetching, DrainWorkers, Flush, Commit, CloseStores, StopServers, Telemetry}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager runs the shutdown hooks of the subsystems phase by phase, each
+// phase bounded by its own timeout. Within a phase the hooks run in reverse
+// order of registration, like defers, and a phase that fails or times out is
+// logged and the next one runs anyway.
+type Manager struct {
+	Logger   *zap.Logger
+	Timeouts map[Phase]time.Duration
+
+	mu    sync.Mutex
+	hooks map[Phase][]hook
+	once  sync.Once
+	err   error
+}
+
+func NewManager(logger *zap.Logger, timeouts map[Phase]time.Duration) *Manager {
+	return &Manager{Logger: logger, Timeouts: timeouts, hooks: make(map[Phase][]hook)}
+}
+
+// OnShutdown registers fn to run in the phase, name identifies it in the logs
+func (m *Manager) OnShutdown(phase Phase, name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[phase] = append(m.hooks[phase], hook{name: name, fn: fn})
+}
+
+// Close registers a close without a context or an error, such as a client's Close
+func (m *Manager) Close(phase Phase, name string, fn func()) {
+	m.OnShutdown(phase, name, func(context.Context) error {
+		fn()
+		return nil
+	})
+}
+
+// Shutdown runs the phases once, later calls return the first outcome
+func (m *Manager) Shutdown() error {
+	m.once.Do(func() {
+		var errs []error
+		for _, phase := range Phases {
+			if err := m.runPhase(phase); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %v", phase, err))
+			}
+		}
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
+
+func (m *Manager) runPhase(phase Phase) error {
+	m.mu.Lock()
+	hooks := append([]hook{}, m.hooks[phase]...)
+	m.mu.Unlock()
+	if len(hooks) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeouts[phase])
+	defer cancel()
+
+	start := time.Now()
+	var errs []error
+	for idx := len(hooks) - 1; idx >= 0; idx-- {
+		if err := m.runHook(ctx, hooks[idx]); err != nil {
+			m.Logger.Warn("shutdown hook failed", zap.String("phase", string(phase)), zap.String("hook", hooks[idx].name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %v", hooks[idx].name, err))
+		}
+	}
+	m.Logger.Info("shutdown phase done", zap.String("phase", string(phase)), zap.Duration("took", time.Since(start)))
+	return errors.Join(errs...)
+}
+
+// runHook gives up on a hook once the phase timed out, a hook ignoring its
+// context is left running so the shutdown still finishes
+func (m *Manager) runHook(ctx context.Context, h hook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- h.fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out: %v", ctx.Err())
+	}
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying the logger, for the calls made on behalf of one record or batch
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by the context, or fallback if there is none
+func FromContext(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// ForRecord returns a logger tagging every line with the correlation id and position of the record
+func ForRecord(logger *zap.Logger, record models.Record) *zap.Logger {
+	return logger.With(
+		zap.String("correlation_id", record.CorrelationID()),
+		zap.String("topic", record.Topic),
+		zap.Int32("partition", record.Partition),
+		zap.Int64("offset", record.Offset),
+	)
+}
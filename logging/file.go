@@ -0,0 +1,33 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"time"
+
+	// External Packages
+	zaplogfmt "github.com/jsternberg/zap-logfmt"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type FileConfig struct {
+	Path       string
+	MaxSizeMB  int           // Size at which the file is rotated
+	MaxAge     time.Duration // Rotated files older than this are removed, rounded up to days, 0 keeps them
+	MaxBackups int           // Rotated files kept, 0 keeps them all
+	Compress   bool          // Gzips the rotated files
+}
+
+// NewFileCore returns a logfmt core writing to a file that is rotated once
+// it grows past its max size. Each write goes straight to the file, so
+// nothing is lost when the process exits without closing it.
+func NewFileCore(conf *FileConfig, encoderConf zapcore.EncoderConfig, level zapcore.LevelEnabler) zapcore.Core {
+	writer := &lumberjack.Logger{
+		Filename:   conf.Path,
+		MaxSize:    conf.MaxSizeMB,
+		MaxAge:     int((conf.MaxAge + 24*time.Hour - 1) / (24 * time.Hour)),
+		MaxBackups: conf.MaxBackups,
+		Compress:   conf.Compress,
+	}
+	return zapcore.NewCore(zaplogfmt.NewEncoder(encoderConf), zapcore.AddSync(writer), level)
+}
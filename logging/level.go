@@ -0,0 +1,107 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"sync"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelController changes the level of a running logger. The base level is the
+// configured one, an override set at runtime replaces it until it expires or
+// is reset, so a temporary debug session does not outlive its purpose.
+type LevelController struct {
+	Level  zap.AtomicLevel
+	Logger *zap.Logger
+
+	mu         sync.Mutex
+	base       zapcore.Level
+	overridden bool
+	expires    time.Time // Zero for an override without expiry
+	generation int       // Tells a stale expiry timer from the current one
+}
+
+// LevelState describes the active level
+type LevelState struct {
+	Level      string     `json:"level"`
+	Base       string     `json:"base"`
+	Overridden bool       `json:"overridden"`
+	Expires    *time.Time `json:"expires,omitempty"`
+}
+
+func NewLevelController(level zap.AtomicLevel, logger *zap.Logger) *LevelController {
+	return &LevelController{Level: level, Logger: logger, base: level.Level()}
+}
+
+// State returns the active level and the override, if any
+func (c *LevelController) State() LevelState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := LevelState{Level: c.Level.Level().String(), Base: c.base.String(), Overridden: c.overridden}
+	if c.overridden && !c.expires.IsZero() {
+		expires := c.expires
+		state.Expires = &expires
+	}
+	return state
+}
+
+// SetBase changes the configured level, an active override stays until it ends
+func (c *LevelController) SetBase(level zapcore.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.base = level
+	if !c.overridden {
+		c.Level.SetLevel(level)
+	}
+}
+
+// Override sets the level, reverting to the base level after ttl unless ttl is zero
+func (c *LevelController) Override(level zapcore.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.overridden = true
+	c.expires = time.Time{}
+	c.Level.SetLevel(level)
+	if ttl > 0 {
+		c.expires = time.Now().Add(ttl)
+		generation := c.generation
+		time.AfterFunc(ttl, func() { c.expire(generation) })
+	}
+	c.Logger.Info("log level overridden", zap.Stringer("level", level), zap.Duration("ttl", ttl))
+}
+
+// Reset drops the override and returns to the base level
+func (c *LevelController) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+// Toggle switches between an override to debug and the base level
+func (c *LevelController) Toggle(ttl time.Duration) {
+	if c.State().Overridden {
+		c.Reset()
+		return
+	}
+	c.Override(zapcore.DebugLevel, ttl)
+}
+
+func (c *LevelController) expire(generation int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overridden && c.generation == generation {
+		c.reset()
+	}
+}
+
+func (c *LevelController) reset() {
+	c.generation++
+	c.overridden = false
+	c.expires = time.Time{}
+	c.Level.SetLevel(c.base)
+	c.Logger.Info("log level reset", zap.Stringer("level", c.base))
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	// External Packages
+	"go.opentelemetry.io/contrib/bridges/otelzap"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.uber.org/zap/zapcore"
+)
+
+type OTLPConfig struct {
+	ServiceName    string
+	Endpoint       string // host:port of the OTLP gRPC collector, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure       bool
+	QueueSize      int // Records held while the collector is slow, the oldest are dropped past it
+	BatchSize      int
+	ExportInterval time.Duration
+}
+
+// NewOTLPCore returns a core shipping the log records to an OTLP collector
+// in batches, along with the func flushing the pending records on shutdown.
+// The queue is bounded so a slow collector costs log records, never
+// blocks the logging goroutine.
+func NewOTLPCore(ctx context.Context, conf *OTLPConfig, level zapcore.LevelEnabler) (zapcore.Core, func(ctx context.Context) error, error) {
+	var opts []otlploggrpc.Option
+	if conf.Endpoint != "" {
+		opts = append(opts, otlploggrpc.WithEndpoint(conf.Endpoint))
+	}
+	if conf.Insecure {
+		opts = append(opts, otlploggrpc.WithInsecure())
+	}
+	exporter, err := otlploggrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create otlp log exporter: %v", err)
+	}
+
+	host, _ := os.Hostname()
+	processor := sdklog.NewBatchProcessor(exporter,
+		sdklog.WithMaxQueueSize(conf.QueueSize),
+		sdklog.WithExportMaxBatchSize(conf.BatchSize),
+		sdklog.WithExportInterval(conf.ExportInterval),
+	)
+	provider := sdklog.NewLoggerProvider(
+		sdklog.WithProcessor(processor),
+		sdklog.WithResource(resource.NewSchemaless(semconv.ServiceName(conf.ServiceName), semconv.HostName(host))),
+	)
+
+	// The bridge takes every level, the application level filters in front of it
+	core, err := zapcore.NewIncreaseLevelCore(otelzap.NewCore("tx-stream", otelzap.WithLoggerProvider(provider)), level)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply the log level: %v", err)
+	}
+	return core, provider.Shutdown, nil
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// PayloadRules select the records whose payload is logged
+type PayloadRules struct {
+	Enabled      bool
+	SampleEvery  int               // Logs 1 in SampleEvery records, 0 logs only the matching ones
+	Match        map[string]string // Top-level payload fields and the values they must all have
+	RedactFields []string          // Top-level payload fields masked before logging
+}
+
+// PayloadSampler logs the redacted payload of a sample of the records, or of
+// the records matching a predicate, to debug data issues without logging every record
+type PayloadSampler struct {
+	rules atomic.Pointer[PayloadRules]
+	seen  atomic.Uint64
+}
+
+func NewPayloadSampler(rules PayloadRules) *PayloadSampler {
+	s := &PayloadSampler{}
+	s.Set(rules)
+	return s
+}
+
+// Set replaces the rules from the next record on
+func (s *PayloadSampler) Set(rules PayloadRules) {
+	s.rules.Store(&rules)
+}
+
+// Log logs the payload of the record when it is sampled or matches, a nil sampler logs nothing
+func (s *PayloadSampler) Log(logger *zap.Logger, record models.Record) {
+	if s == nil {
+		return
+	}
+	rules := s.rules.Load()
+	if !rules.Enabled {
+		return
+	}
+	sampled := rules.SampleEvery > 0 && s.seen.Add(1)%uint64(rules.SampleEvery) == 0
+	if !sampled && len(rules.Match) == 0 {
+		return
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal(record.Value, &payload); err != nil {
+		// Cannot be redacted field by field, so only its size is logged
+		if sampled {
+			logger.Info("sampled payload", zap.String("reason", "sample"), zap.Int("payload_bytes", len(record.Value)), zap.Error(err))
+		}
+		return
+	}
+
+	reason := "sample"
+	if !sampled {
+		if !matches(payload, rules.Match) {
+			return
+		}
+		reason = "match"
+	}
+	for _, field := range rules.RedactFields {
+		if _, ok := payload[field]; ok {
+			payload[field] = RedactedPayloadValue
+		}
+	}
+	redacted, _ := json.Marshal(payload)
+	logger.Info("sampled payload", zap.String("reason", reason), zap.ByteString("payload", redacted))
+}
+
+// RedactedPayloadValue replaces the redacted fields of a logged payload
+const RedactedPayloadValue = "[redacted]"
+
+func matches(payload map[string]any, match map[string]string) bool {
+	for field, want := range match {
+		value, ok := payload[field]
+		if !ok || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
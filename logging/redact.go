@@ -0,0 +1,63 @@
+package logging
+
+import (
+	// Go Internal Packages
+	"errors"
+	"strings"
+
+	// External Packages
+	"go.uber.org/zap/zapcore"
+)
+
+// redactingCore masks secret values in log messages and in string and error
+// fields, so a connection error echoing a URI does not leak its password
+type redactingCore struct {
+	zapcore.Core
+	replacer *strings.Replacer
+}
+
+// NewRedactingCore wraps the core, replacing every occurrence of a secret with mask
+func NewRedactingCore(core zapcore.Core, secrets []string, mask string) zapcore.Core {
+	var pairs []string
+	for _, secret := range secrets {
+		if secret != "" {
+			pairs = append(pairs, secret, mask)
+		}
+	}
+	if len(pairs) == 0 {
+		return core
+	}
+	return &redactingCore{Core: core, replacer: strings.NewReplacer(pairs...)}
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(c.redact(fields)), replacer: c.replacer}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = c.replacer.Replace(entry.Message)
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+func (c *redactingCore) redact(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for idx, field := range fields {
+		switch field.Type {
+		case zapcore.StringType:
+			field.String = c.replacer.Replace(field.String)
+		case zapcore.ErrorType:
+			if err, ok := field.Interface.(error); ok && err != nil {
+				field.Interface = errors.New(c.replacer.Replace(err.Error()))
+			}
+		}
+		redacted[idx] = field
+	}
+	return redacted
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a transaction counted into a window
+const (
+	WindowAdded     = "added"
+	WindowDuplicate = "duplicate" // Redelivered, already counted
+	WindowLate      = "late"      // Every window it belongs to was emitted
+	WindowError     = "error"     // Not counted, redis failed
+)
+
+// AggregationMetrics follow the window aggregates, by window
+type AggregationMetrics struct {
+	Transactions *prometheus.CounterVec
+	Results      *prometheus.CounterVec
+	Watermark    *prometheus.GaugeVec
+}
+
+// NewAggregationMetrics creates the aggregation metrics and registers them with the registerer
+func NewAggregationMetrics(reg prometheus.Registerer, namespace string) *AggregationMetrics {
+	m := &AggregationMetrics{
+		Transactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "aggregation",
+			Name:      "transactions_total",
+			Help:      "Transactions counted into the windows, by outcome.",
+		}, []string{"window", "outcome"}),
+		Results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "aggregation",
+			Name:      "results_total",
+			Help:      "Window results emitted, one per group and window.",
+		}, []string{"window"}),
+		Watermark: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "aggregation",
+			Name:      "watermark_timestamp_seconds",
+			Help:      "End of the last emitted window, as a unix timestamp.",
+		}, []string{"window"}),
+	}
+	reg.MustRegister(m.Transactions, m.Results, m.Watermark)
+	return m
+}
+
+// Counted counts the transactions counted into a window with the outcome
+func (m *AggregationMetrics) Counted(window, outcome string, transactions int) {
+	if m == nil || transactions == 0 {
+		return
+	}
+	m.Transactions.WithLabelValues(window, outcome).Add(float64(transactions))
+}
+
+// Emitted counts the results of a window and moves its watermark
+func (m *AggregationMetrics) Emitted(window string, results int, watermark float64) {
+	if m == nil {
+		return
+	}
+	m.Results.WithLabelValues(window).Add(float64(results))
+	m.Watermark.WithLabelValues(window).Set(watermark)
+}
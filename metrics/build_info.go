@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	// Local Packages
+	version "tx-stream/version"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewBuildInfo registers the build_info gauge, always 1, whose labels tell
+// which build a pod runs
+func NewBuildInfo(reg prometheus.Registerer, namespace string, info version.Info) prometheus.Gauge {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Build of the running binary, always 1.",
+		ConstLabels: prometheus.Labels{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_time": info.BuildTime,
+			"go_version": info.GoVersion,
+		},
+	})
+	gauge.Set(1)
+	reg.MustRegister(gauge)
+	return gauge
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ChaosMetrics count the faults injected for resilience tests, so the
+// failures they cause can be told apart from real ones
+type ChaosMetrics struct {
+	Faults *prometheus.CounterVec
+}
+
+// NewChaosMetrics creates the injected faults counter and registers it with the registerer
+func NewChaosMetrics(reg prometheus.Registerer, namespace string) *ChaosMetrics {
+	m := &ChaosMetrics{
+		Faults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "chaos",
+			Name:      "injected_faults_total",
+			Help:      "Faults injected by the chaos mode, by fault.",
+		}, []string{"fault"}),
+	}
+	reg.MustRegister(m.Faults)
+	return m
+}
+
+// Injected counts an injected fault
+func (m *ChaosMetrics) Injected(fault string) {
+	if m == nil {
+		return
+	}
+	m.Faults.WithLabelValues(fault).Inc()
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of resolving a claim check
+const (
+	ClaimCheckFetched  = "fetched"
+	ClaimCheckCached   = "cached"
+	ClaimCheckRejected = "rejected" // Can never resolve, e.g. a missing object, the record is skipped
+	ClaimCheckFailed   = "failed"   // Out of retries, the batch failed
+)
+
+// ClaimCheckMetrics follow the payloads fetched for the claim-check records
+type ClaimCheckMetrics struct {
+	Resolutions *prometheus.CounterVec
+	Fetches     prometheus.Histogram
+	Bytes       prometheus.Counter
+}
+
+// NewClaimCheckMetrics creates the claim-check metrics and registers them with the registerer
+func NewClaimCheckMetrics(reg prometheus.Registerer, namespace string) *ClaimCheckMetrics {
+	m := &ClaimCheckMetrics{
+		Resolutions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "claim_check",
+			Name:      "resolutions_total",
+			Help:      "Claim-check records resolved to their payloads, by outcome.",
+		}, []string{"outcome"}),
+		Fetches: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "claim_check",
+			Name:      "fetch_duration_seconds",
+			Help:      "Time to fetch a payload from object storage, retries included.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		Bytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "claim_check",
+			Name:      "fetched_bytes_total",
+			Help:      "Bytes of the payloads fetched from object storage.",
+		}),
+	}
+	reg.MustRegister(m.Resolutions, m.Fetches, m.Bytes)
+	return m
+}
+
+// Resolved counts a resolution with the outcome
+func (m *ClaimCheckMetrics) Resolved(outcome string) {
+	if m == nil {
+		return
+	}
+	m.Resolutions.WithLabelValues(outcome).Inc()
+}
+
+// Fetched records a fetch of a payload
+func (m *ClaimCheckMetrics) Fetched(seconds float64, size int) {
+	if m == nil {
+		return
+	}
+	m.Fetches.Observe(seconds)
+	m.Bytes.Add(float64(size))
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Reasons an entry leaves the DLQ
+const (
+	ReasonDeleted      = "deleted"
+	ReasonRequeued     = "requeued"
+	ReasonExpired      = "expired"
+	ReasonEvicted      = "evicted"
+	ReasonAcknowledged = "acknowledged"
+)
+
+type DLQMetrics struct {
+	Depth    prometheus.Gauge
+	Enqueued *prometheus.CounterVec
+	Dequeued *prometheus.CounterVec
+	Dropped  *prometheus.CounterVec
+}
+
+// NewDLQMetrics creates the DLQ metrics and registers them with the registerer
+func NewDLQMetrics(reg prometheus.Registerer, namespace string) *DLQMetrics {
+	m := &DLQMetrics{
+		Depth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "dlq",
+			Name:      "depth",
+			Help:      "Number of entries currently in the dead letter queue.",
+		}),
+		Enqueued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dlq",
+			Name:      "enqueued_total",
+			Help:      "Records sent to the dead letter queue, by failure reason.",
+		}, []string{"reason"}),
+		Dequeued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dlq",
+			Name:      "dequeued_total",
+			Help:      "Entries removed from the dead letter queue, by removal reason.",
+		}, []string{"reason"}),
+		Dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "dlq",
+			Name:      "dropped_total",
+			Help:      "Records dropped because the dead letter queue was full, by failure reason.",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(m.Depth, m.Enqueued, m.Dequeued, m.Dropped)
+	return m
+}
+
+// SetDepth records the current queue depth
+func (m *DLQMetrics) SetDepth(depth int64) {
+	if m == nil {
+		return
+	}
+	m.Depth.Set(float64(depth))
+}
+
+// Enqueue counts records sent to the queue
+func (m *DLQMetrics) Enqueue(reason string, count int) {
+	if m == nil {
+		return
+	}
+	m.Enqueued.WithLabelValues(reason).Add(float64(count))
+}
+
+// Dequeue counts entries removed from the queue
+func (m *DLQMetrics) Dequeue(reason string, count int) {
+	if m == nil {
+		return
+	}
+	m.Dequeued.WithLabelValues(reason).Add(float64(count))
+}
+
+// Drop counts records rejected because the queue was full
+func (m *DLQMetrics) Drop(reason string, count int) {
+	if m == nil {
+		return
+	}
+	m.Dropped.WithLabelValues(reason).Add(float64(count))
+}
@@ -0,0 +1,175 @@
+package metrics
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/zap"
+)
+
+// maxPacketSize keeps a datagram within the MTU of most networks
+const maxPacketSize = 1432
+
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_")
+
+// DogStatsD pushes the gathered metrics to a DogStatsD agent over UDP.
+// Counters are sent as the increase since the previous flush, histograms
+// and summaries as their count and sum, plus the quantiles of summaries.
+type DogStatsD struct {
+	Gatherer prometheus.Gatherer
+	Conn     net.Conn
+	Interval time.Duration
+	Tags     []string // Added to every metric, e.g. env:prod
+	Logger   *zap.Logger
+
+	last     map[string]float64 // Counter values at the previous flush
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDogStatsD creates a sink sending to the agent at address
+// (PS: Must call Start to begin flushing)
+func NewDogStatsD(address string, interval time.Duration, tags []string, gatherer prometheus.Gatherer, logger *zap.Logger) (*DogStatsD, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial dogstatsd agent: %v", err)
+	}
+	return &DogStatsD{
+		Gatherer: gatherer,
+		Conn:     conn,
+		Interval: interval,
+		Tags:     tags,
+		Logger:   logger,
+		last:     make(map[string]float64),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}, nil
+}
+
+// Start flushes every interval until Shutdown, then flushes once more
+func (d *DogStatsD) Start() error {
+	defer close(d.stopped)
+	d.Logger.Info("starting dogstatsd exporter", zap.String("addr", d.Conn.RemoteAddr().String()))
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			d.flush()
+			return nil
+		case <-ticker.C:
+			d.flush()
+		}
+	}
+}
+
+// Shutdown waits for the last flush and closes the connection
+func (d *DogStatsD) Shutdown(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.stop) })
+	select {
+	case <-d.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return d.Conn.Close()
+}
+
+func (d *DogStatsD) flush() {
+	families, err := d.Gatherer.Gather()
+	if err != nil {
+		// Gather still returns what it could collect
+		d.Logger.Warn("failed to gather some metrics", zap.Error(err))
+	}
+
+	var packet bytes.Buffer
+	send := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if _, err := d.Conn.Write(packet.Bytes()); err != nil {
+			d.Logger.Warn("failed to send metrics to dogstatsd", zap.Error(err))
+		}
+		packet.Reset()
+	}
+	write := func(name string, value float64, kind string, tags []string) {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return
+		}
+		line := name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+		if len(tags) > 0 {
+			line += "|#" + strings.Join(tags, ",")
+		}
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacketSize {
+			send()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			tags := d.tags(metric)
+			key := name + "|" + strings.Join(tags, ",")
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				write(name, d.delta(key, metric.GetCounter().GetValue()), "c", tags)
+			case dto.MetricType_GAUGE:
+				write(name, metric.GetGauge().GetValue(), "g", tags)
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				write(name+"_count", d.delta(key+"|count", float64(histogram.GetSampleCount())), "c", tags)
+				write(name+"_sum", d.delta(key+"|sum", histogram.GetSampleSum()), "c", tags)
+			case dto.MetricType_SUMMARY:
+				summary := metric.GetSummary()
+				write(name+"_count", d.delta(key+"|count", float64(summary.GetSampleCount())), "c", tags)
+				write(name+"_sum", d.delta(key+"|sum", summary.GetSampleSum()), "c", tags)
+				for _, quantile := range summary.GetQuantile() {
+					quantileTags := append(append([]string{}, tags...), "quantile:"+strconv.FormatFloat(quantile.GetQuantile(), 'f', -1, 64))
+					write(name, quantile.GetValue(), "g", quantileTags)
+				}
+			default:
+				write(name, metric.GetUntyped().GetValue(), "g", tags)
+			}
+		}
+	}
+	send()
+}
+
+// delta returns the increase of a cumulative value since the previous
+// flush, a value that went down means the counter was reset
+func (d *DogStatsD) delta(key string, value float64) float64 {
+	previous, seen := d.last[key]
+	d.last[key] = value
+	if !seen || value < previous {
+		return value
+	}
+	return value - previous
+}
+
+// tags returns the global tags and the labels of the metric, sorted so the
+// same series always has the same key
+func (d *DogStatsD) tags(metric *dto.Metric) []string {
+	tags := make([]string, 0, len(d.Tags)+len(metric.GetLabel()))
+	tags = append(tags, d.Tags...)
+	for _, label := range metric.GetLabel() {
+		tags = append(tags, tagReplacer.Replace(label.GetName())+":"+tagReplacer.Replace(label.GetValue()))
+	}
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ErrorMetrics struct {
+	Errors *prometheus.CounterVec
+}
+
+// NewErrorMetrics creates the error counter and registers it with the registerer
+func NewErrorMetrics(reg prometheus.Registerer, namespace string) *ErrorMetrics {
+	m := &ErrorMetrics{
+		Errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "errors_total",
+			Help:      "Processing errors, by error class and topic.",
+		}, []string{"class", "topic"}),
+	}
+	reg.MustRegister(m.Errors)
+	return m
+}
+
+// Count counts errors of the class on the topic
+func (m *ErrorMetrics) Count(class, topic string, count int) {
+	if m == nil {
+		return
+	}
+	m.Errors.WithLabelValues(class, topic).Add(float64(count))
+}
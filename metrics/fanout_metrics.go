@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FanoutMetrics follow each fan-out sink on its own, a sink falling behind or
+// dead-lettering shows without the others moving
+type FanoutMetrics struct {
+	Writes       *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	DeadLettered *prometheus.CounterVec
+	QueueDepth   *prometheus.GaugeVec
+}
+
+// NewFanoutMetrics creates the fan-out metrics and registers them with the registerer
+func NewFanoutMetrics(reg prometheus.Registerer, namespace string) *FanoutMetrics {
+	m := &FanoutMetrics{
+		Writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fanout",
+			Name:      "writes_total",
+			Help:      "Batch writes to the fan-out sinks, retries included, by outcome.",
+		}, []string{"sink", "outcome"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "fanout",
+			Name:      "write_duration_seconds",
+			Help:      "Duration of the batch writes to the fan-out sinks.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"sink"}),
+		DeadLettered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fanout",
+			Name:      "dead_lettered_transactions_total",
+			Help:      "Transactions a fan-out sink refused after its retries.",
+		}, []string{"sink"}),
+		QueueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "fanout",
+			Name:      "queued_batches",
+			Help:      "Batches waiting for an async fan-out sink.",
+		}, []string{"sink"}),
+	}
+	reg.MustRegister(m.Writes, m.Duration, m.DeadLettered, m.QueueDepth)
+	return m
+}
+
+// ObserveWrite records the outcome and the duration of a write to a sink
+func (m *FanoutMetrics) ObserveWrite(sink, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.Writes.WithLabelValues(sink, outcome).Inc()
+	m.Duration.WithLabelValues(sink).Observe(seconds)
+}
+
+// DeadLetter counts the transactions a sink refused
+func (m *FanoutMetrics) DeadLetter(sink string, transactions int) {
+	if m == nil {
+		return
+	}
+	m.DeadLettered.WithLabelValues(sink).Add(float64(transactions))
+}
+
+// Queued sets the batches waiting for an async sink
+func (m *FanoutMetrics) Queued(sink string, batches int) {
+	if m == nil {
+		return
+	}
+	m.QueueDepth.WithLabelValues(sink).Set(float64(batches))
+}
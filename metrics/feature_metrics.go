@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type FeatureMetrics struct {
+	Enabled     *prometheus.GaugeVec
+	Rollout     *prometheus.GaugeVec
+	Evaluations *prometheus.CounterVec
+}
+
+// NewFeatureMetrics creates the feature flag metrics and registers them with the registerer
+func NewFeatureMetrics(reg prometheus.Registerer, namespace string) *FeatureMetrics {
+	m := &FeatureMetrics{
+		Enabled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "feature",
+			Name:      "enabled",
+			Help:      "Whether the feature flag is enabled, 1 or 0.",
+		}, []string{"flag"}),
+		Rollout: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "feature",
+			Name:      "rollout_percent",
+			Help:      "Percentage of the unlisted tenants the feature flag is rolled out to.",
+		}, []string{"flag"}),
+		Evaluations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "feature",
+			Name:      "evaluations_total",
+			Help:      "Feature flag evaluations, by flag and result.",
+		}, []string{"flag", "result"}),
+	}
+	reg.MustRegister(m.Enabled, m.Rollout, m.Evaluations)
+	return m
+}
+
+// SetState records the state of a flag
+func (m *FeatureMetrics) SetState(flag string, enabled bool, rollout int) {
+	if m == nil {
+		return
+	}
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	m.Enabled.WithLabelValues(flag).Set(value)
+	m.Rollout.WithLabelValues(flag).Set(float64(rollout))
+}
+
+// Reset forgets the state of flags that no longer exist
+func (m *FeatureMetrics) Reset() {
+	if m == nil {
+		return
+	}
+	m.Enabled.Reset()
+	m.Rollout.Reset()
+}
+
+// Evaluate counts a flag evaluation
+func (m *FeatureMetrics) Evaluate(flag string, enabled bool) {
+	if m == nil {
+		return
+	}
+	result := "off"
+	if enabled {
+		result = "on"
+	}
+	m.Evaluations.WithLabelValues(flag, result).Inc()
+}
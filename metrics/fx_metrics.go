@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of normalizing an amount to the base currency
+const (
+	FXConverted = "converted"
+	FXStale     = "stale"   // Converted with rates past their max age
+	FXSkipped   = "skipped" // No fresh rates, stored without a normalized amount
+	FXUnknown   = "unknown" // The rates have no such currency
+	FXFailed    = "failed"  // No fresh rates, the batch failed
+)
+
+// FXMetrics follow the exchange rates and the conversions made with them
+type FXMetrics struct {
+	Conversions *prometheus.CounterVec
+	Refreshes   *prometheus.CounterVec
+	RatesAge    prometheus.Gauge
+}
+
+// NewFXMetrics creates the fx metrics and registers them with the registerer
+func NewFXMetrics(reg prometheus.Registerer, namespace string) *FXMetrics {
+	m := &FXMetrics{
+		Conversions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fx",
+			Name:      "conversions_total",
+			Help:      "Amounts normalized to the base currency, by currency and outcome.",
+		}, []string{"currency", "outcome"}),
+		Refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fx",
+			Name:      "refreshes_total",
+			Help:      "Refreshes of the exchange rates, by outcome.",
+		}, []string{"outcome"}),
+		RatesAge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "fx",
+			Name:      "rates_age_seconds",
+			Help:      "Age of the exchange rates in use, as of their publication.",
+		}),
+	}
+	reg.MustRegister(m.Conversions, m.Refreshes, m.RatesAge)
+	return m
+}
+
+// Converted counts a conversion of an amount in the currency with the outcome
+func (m *FXMetrics) Converted(currency, outcome string) {
+	if m == nil {
+		return
+	}
+	m.Conversions.WithLabelValues(currency, outcome).Inc()
+}
+
+// Refreshed counts a refresh of the rates and sets the age of the rates in use
+func (m *FXMetrics) Refreshed(outcome string, ratesAge float64) {
+	if m == nil {
+		return
+	}
+	m.Refreshes.WithLabelValues(outcome).Inc()
+	m.RatesAge.Set(ratesAge)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	// Go Internal Packages
+	"time"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HeartbeatMetrics hold the time of the last success of each step, so a
+// consumer that is silently stuck shows as a timestamp that stops moving
+type HeartbeatMetrics struct {
+	LastPoll       *prometheus.GaugeVec
+	LastCommit     *prometheus.GaugeVec
+	LastMongoWrite *prometheus.GaugeVec
+}
+
+// NewHeartbeatMetrics creates the heartbeat gauges and registers them with the registerer
+func NewHeartbeatMetrics(reg prometheus.Registerer, namespace string) *HeartbeatMetrics {
+	m := &HeartbeatMetrics{
+		LastPoll: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "heartbeat",
+			Name:      "last_poll_timestamp_seconds",
+			Help:      "Unix time of the last poll that returned records, it also stops while the topic is idle.",
+		}, []string{"consumer", "group", "topic"}),
+		LastCommit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "heartbeat",
+			Name:      "last_commit_timestamp_seconds",
+			Help:      "Unix time of the last successful offset commit.",
+		}, []string{"consumer", "group", "topic"}),
+		LastMongoWrite: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "heartbeat",
+			Name:      "last_mongo_write_timestamp_seconds",
+			Help:      "Unix time of the last successful write of transactions to mongo.",
+		}, []string{"topic"}),
+	}
+	reg.MustRegister(m.LastPoll, m.LastCommit, m.LastMongoWrite)
+	return m
+}
+
+// Polled records a poll that returned records
+func (m *HeartbeatMetrics) Polled(consumer, group, topic string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.LastPoll.WithLabelValues(consumer, group, topic).Set(float64(at.Unix()))
+}
+
+// Committed records a successful commit
+func (m *HeartbeatMetrics) Committed(consumer, group, topic string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.LastCommit.WithLabelValues(consumer, group, topic).Set(float64(at.Unix()))
+}
+
+// MongoWritten records a successful mongo write
+func (m *HeartbeatMetrics) MongoWritten(topic string, at time.Time) {
+	if m == nil {
+		return
+	}
+	m.LastMongoWrite.WithLabelValues(topic).Set(float64(at.Unix()))
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of looking a record up in the idempotency ledger
+const (
+	LedgerFresh   = "fresh"   // Not applied yet, processed
+	LedgerSkipped = "skipped" // Applied before, left out
+)
+
+// IdempotencyMetrics follow the records looked up in the idempotency ledger
+type IdempotencyMetrics struct {
+	Records *prometheus.CounterVec
+	Lookups *prometheus.HistogramVec
+}
+
+// NewIdempotencyMetrics creates the idempotency metrics and registers them with the registerer
+func NewIdempotencyMetrics(reg prometheus.Registerer, namespace string) *IdempotencyMetrics {
+	m := &IdempotencyMetrics{
+		Records: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "idempotency",
+			Name:      "records_total",
+			Help:      "Records looked up in the idempotency ledger, by topic and outcome.",
+		}, []string{"topic", "outcome"}),
+		Lookups: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "idempotency",
+			Name:      "lookup_duration_seconds",
+			Help:      "Duration of the idempotency ledger lookups of a batch, by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+	reg.MustRegister(m.Records, m.Lookups)
+	return m
+}
+
+// Looked counts the fresh and skipped records of a batch of the topic
+func (m *IdempotencyMetrics) Looked(topic string, fresh, skipped int, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.Lookups.WithLabelValues(outcome).Observe(seconds)
+	if outcome != OutcomeSuccess {
+		return
+	}
+	m.Records.WithLabelValues(topic, LedgerFresh).Add(float64(fresh))
+	m.Records.WithLabelValues(topic, LedgerSkipped).Add(float64(skipped))
+}
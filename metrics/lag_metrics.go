@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	// Go Internal Packages
+	"strconv"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type LagMetrics struct {
+	Lag           *prometheus.GaugeVec
+	RefreshErrors *prometheus.CounterVec
+}
+
+// NewLagMetrics creates the consumer lag metrics and registers them with the registerer
+func NewLagMetrics(reg prometheus.Registerer, namespace string) *LagMetrics {
+	m := &LagMetrics{
+		Lag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "consumer",
+			Name:      "lag",
+			Help:      "Records between the committed offset of the group and the high watermark, by partition.",
+		}, []string{"group", "topic", "partition"}),
+		RefreshErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "consumer",
+			Name:      "lag_refresh_errors_total",
+			Help:      "Failed refreshes of the consumer lag, by group.",
+		}, []string{"group"}),
+	}
+	reg.MustRegister(m.Lag, m.RefreshErrors)
+	return m
+}
+
+// SetLag records the lag of a partition
+func (m *LagMetrics) SetLag(group, topic string, partition int32, lag int64) {
+	if m == nil {
+		return
+	}
+	m.Lag.WithLabelValues(group, topic, strconv.Itoa(int(partition))).Set(float64(lag))
+}
+
+// RefreshFailed counts a failed refresh of the group
+func (m *LagMetrics) RefreshFailed(group string) {
+	if m == nil {
+		return
+	}
+	m.RefreshErrors.WithLabelValues(group).Inc()
+}
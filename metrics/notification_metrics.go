@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a transaction matching an alert rule
+const (
+	AlertRaised     = "raised"
+	AlertDuplicate  = "duplicate" // Already sent within the dedup window
+	AlertEvalFailed = "error"     // Redis failed, velocity rules are skipped
+)
+
+// Outcomes of a notification to a channel
+const (
+	NotificationSent        = "sent"
+	NotificationFailed      = "failed"
+	NotificationRateLimited = "rate_limited"
+	NotificationDropped     = "dropped" // The queue of the channel was full
+)
+
+// NotificationMetrics follow the alert rules, by rule, and their
+// notifications, by channel
+type NotificationMetrics struct {
+	Alerts        *prometheus.CounterVec
+	Notifications *prometheus.CounterVec
+}
+
+// NewNotificationMetrics creates the notification metrics and registers them with the registerer
+func NewNotificationMetrics(reg prometheus.Registerer, namespace string) *NotificationMetrics {
+	m := &NotificationMetrics{
+		Alerts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "notifications",
+			Name:      "alerts_total",
+			Help:      "Transactions matching an alert rule, by outcome.",
+		}, []string{"rule", "outcome"}),
+		Notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "notifications",
+			Name:      "sent_total",
+			Help:      "Notifications of the alerts, by channel and outcome.",
+		}, []string{"channel", "outcome"}),
+	}
+	reg.MustRegister(m.Alerts, m.Notifications)
+	return m
+}
+
+// Alerted counts the matches of a rule with the outcome
+func (m *NotificationMetrics) Alerted(rule, outcome string, matches int) {
+	if m == nil || matches == 0 {
+		return
+	}
+	m.Alerts.WithLabelValues(rule, outcome).Add(float64(matches))
+}
+
+// Notified counts a notification to a channel with the outcome
+func (m *NotificationMetrics) Notified(channel, outcome string) {
+	if m == nil {
+		return
+	}
+	m.Notifications.WithLabelValues(channel, outcome).Inc()
+}
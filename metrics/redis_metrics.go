@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisMetrics struct {
+	Up           prometheus.Gauge
+	PingDuration prometheus.Histogram
+}
+
+// NewRedisMetrics creates the health metrics along with a collector reading the
+// pool stats of the client on every scrape, and registers them with the registerer
+func NewRedisMetrics(reg prometheus.Registerer, namespace string, client redis.UniversalClient) *RedisMetrics {
+	m := &RedisMetrics{
+		Up: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "up",
+			Help:      "Whether the last health check PING to redis succeeded.",
+		}),
+		PingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      "ping_duration_seconds",
+			Help:      "Latency of the health check PING to redis.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}),
+	}
+	reg.MustRegister(m.Up, m.PingDuration, newPoolCollector(namespace, client))
+	return m
+}
+
+// SetUp records the result of a health check
+func (m *RedisMetrics) SetUp(up bool, seconds float64) {
+	if m == nil {
+		return
+	}
+	if up {
+		m.Up.Set(1)
+		m.PingDuration.Observe(seconds)
+		return
+	}
+	m.Up.Set(0)
+}
+
+// poolCollector exports the go-redis connection pool stats
+type poolCollector struct {
+	client     redis.UniversalClient
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newPoolCollector(namespace string, client redis.UniversalClient) *poolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		client:     client,
+		hits:       desc("hits_total", "Times a free connection was found in the pool."),
+		misses:     desc("misses_total", "Times a free connection was not found in the pool."),
+		timeouts:   desc("timeouts_total", "Times waiting for a pool connection timed out."),
+		totalConns: desc("conns", "Connections in the pool."),
+		idleConns:  desc("idle_conns", "Idle connections in the pool."),
+		staleConns: desc("stale_conns_total", "Stale connections removed from the pool."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
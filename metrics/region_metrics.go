@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegionMetrics follow the role of this replica's region
+type RegionMetrics struct {
+	Active        prometheus.Gauge
+	Transitions   *prometheus.CounterVec
+	CheckFailures prometheus.Counter
+}
+
+// NewRegionMetrics creates the region metrics and registers them with the registerer
+func NewRegionMetrics(reg prometheus.Registerer, namespace string) *RegionMetrics {
+	m := &RegionMetrics{
+		Active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "region",
+			Name:      "active",
+			Help:      "Whether the region of this replica is the active one and consumes, 1 or 0.",
+		}),
+		Transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "region",
+			Name:      "transitions_total",
+			Help:      "Switches of this replica between active and standby, by role switched to.",
+		}, []string{"role"}),
+		CheckFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "region",
+			Name:      "check_failures_total",
+			Help:      "Failed reads or heartbeats of the active region record.",
+		}),
+	}
+	reg.MustRegister(m.Active, m.Transitions, m.CheckFailures)
+	return m
+}
+
+// SetRole records the role of the region, counting a transition when changed
+func (m *RegionMetrics) SetRole(role string, changed bool) {
+	if m == nil {
+		return
+	}
+	value := 0.0
+	if role == "active" {
+		value = 1
+	}
+	m.Active.Set(value)
+	if changed {
+		m.Transitions.WithLabelValues(role).Inc()
+	}
+}
+
+// CheckFailed counts a failed check of the active region record
+func (m *RegionMetrics) CheckFailed() {
+	if m == nil {
+		return
+	}
+	m.CheckFailures.Inc()
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RiskMetrics follow the risk rules, the flags they attach by rule and the
+// scores of the evaluated transactions
+type RiskMetrics struct {
+	Flags   *prometheus.CounterVec
+	Scores  prometheus.Histogram
+	Ruleset *prometheus.GaugeVec
+}
+
+// NewRiskMetrics creates the risk metrics and registers them with the registerer
+func NewRiskMetrics(reg prometheus.Registerer, namespace string) *RiskMetrics {
+	m := &RiskMetrics{
+		Flags: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "risk",
+			Name:      "flags_total",
+			Help:      "Transactions flagged by a risk rule, by rule.",
+		}, []string{"rule"}),
+		Scores: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "risk",
+			Name:      "score",
+			Help:      "Risk scores of the evaluated transactions.",
+			Buckets:   []float64{0, 10, 25, 50, 75, 100, 150, 250},
+		}),
+		Ruleset: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "risk",
+			Name:      "rules",
+			Help:      "Risk rules in effect, by version of the ruleset.",
+		}, []string{"ruleset"}),
+	}
+	reg.MustRegister(m.Flags, m.Scores, m.Ruleset)
+	return m
+}
+
+// Evaluated records the score of a transaction and the rules that flagged it
+func (m *RiskMetrics) Evaluated(score float64, flags []string) {
+	if m == nil {
+		return
+	}
+	m.Scores.Observe(score)
+	for _, flag := range flags {
+		m.Flags.WithLabelValues(flag).Inc()
+	}
+}
+
+// SetRuleset records the ruleset in effect, forgetting the previous one
+func (m *RiskMetrics) SetRuleset(version string, rules int) {
+	if m == nil {
+		return
+	}
+	m.Ruleset.Reset()
+	m.Ruleset.WithLabelValues(version).Set(float64(rules))
+}
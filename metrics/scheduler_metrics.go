@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a scheduled run
+const (
+	RunSucceeded = "success"
+	RunFailed    = "failure"
+	RunSkipped   = "skipped" // The previous run was still in flight
+)
+
+// SchedulerMetrics follow the periodic jobs of the scheduler, by job
+type SchedulerMetrics struct {
+	Runs        *prometheus.CounterVec
+	Duration    *prometheus.HistogramVec
+	Running     *prometheus.GaugeVec
+	LastSuccess *prometheus.GaugeVec
+	NextRun     *prometheus.GaugeVec
+}
+
+// NewSchedulerMetrics creates the scheduler metrics and registers them with the registerer
+func NewSchedulerMetrics(reg prometheus.Registerer, namespace string) *SchedulerMetrics {
+	m := &SchedulerMetrics{
+		Runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "runs_total",
+			Help:      "Scheduled runs of the jobs, by outcome.",
+		}, []string{"job", "outcome"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "run_duration_seconds",
+			Help:      "Time a run of the job took.",
+			Buckets:   []float64{0.1, 0.5, 1, 5, 15, 60, 300, 900, 3600},
+		}, []string{"job"}),
+		Running: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "running",
+			Help:      "Whether a run of the job is in flight.",
+		}, []string{"job"}),
+		LastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "last_success_timestamp_seconds",
+			Help:      "End of the last successful run of the job, as a unix timestamp.",
+		}, []string{"job"}),
+		NextRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scheduler",
+			Name:      "next_run_timestamp_seconds",
+			Help:      "Next scheduled run of the job, as a unix timestamp.",
+		}, []string{"job"}),
+	}
+	reg.MustRegister(m.Runs, m.Duration, m.Running, m.LastSuccess, m.NextRun)
+	return m
+}
+
+// Started marks a run of the job in flight
+func (m *SchedulerMetrics) Started(job string) {
+	if m == nil {
+		return
+	}
+	m.Running.WithLabelValues(job).Set(1)
+}
+
+// Finished counts a run of the job that started, with its outcome and duration
+// in seconds, at is when it ended as a unix timestamp
+func (m *SchedulerMetrics) Finished(job, outcome string, seconds, at float64) {
+	if m == nil {
+		return
+	}
+	m.Running.WithLabelValues(job).Set(0)
+	m.Runs.WithLabelValues(job, outcome).Inc()
+	m.Duration.WithLabelValues(job).Observe(seconds)
+	if outcome == RunSucceeded {
+		m.LastSuccess.WithLabelValues(job).Set(at)
+	}
+}
+
+// Skipped counts a run of the job left out because the previous one was in flight
+func (m *SchedulerMetrics) Skipped(job string) {
+	if m == nil {
+		return
+	}
+	m.Runs.WithLabelValues(job, RunSkipped).Inc()
+}
+
+// Scheduled sets the next run of the job, as a unix timestamp
+func (m *SchedulerMetrics) Scheduled(job string, at float64) {
+	if m == nil {
+		return
+	}
+	m.NextRun.WithLabelValues(job).Set(at)
+}
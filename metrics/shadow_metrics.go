@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Divergence reasons, one target stored the batch and the other did not
+const (
+	DivergenceShadowFailed  = "shadow_failed"
+	DivergencePrimaryFailed = "primary_failed"
+)
+
+// ShadowMetrics compare the writes to the primary and to the shadow target
+// of a migration, no divergence over a while means the shadow can take over
+type ShadowMetrics struct {
+	Writes      *prometheus.CounterVec
+	Duration    *prometheus.HistogramVec
+	Divergences *prometheus.CounterVec
+}
+
+// NewShadowMetrics creates the shadow write metrics and registers them with the registerer
+func NewShadowMetrics(reg prometheus.Registerer, namespace string) *ShadowMetrics {
+	m := &ShadowMetrics{
+		Writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "shadow",
+			Name:      "writes_total",
+			Help:      "Batch writes to the primary and the shadow target, by outcome.",
+		}, []string{"target", "outcome"}),
+		Duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "shadow",
+			Name:      "write_duration_seconds",
+			Help:      "Duration of the batch writes to the primary and the shadow target.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"target"}),
+		Divergences: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "shadow",
+			Name:      "divergent_documents_total",
+			Help:      "Documents stored by one target only, by reason.",
+		}, []string{"reason"}),
+	}
+	reg.MustRegister(m.Writes, m.Duration, m.Divergences)
+	return m
+}
+
+// ObserveWrite records the outcome and the duration of a write to a target
+func (m *ShadowMetrics) ObserveWrite(target, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.Writes.WithLabelValues(target, outcome).Inc()
+	m.Duration.WithLabelValues(target).Observe(seconds)
+}
+
+// Diverged counts the documents stored by one target only
+func (m *ShadowMetrics) Diverged(reason string, documents int) {
+	if m == nil {
+		return
+	}
+	m.Divergences.WithLabelValues(reason).Add(float64(documents))
+}
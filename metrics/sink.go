@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	// Go Internal Packages
+	"context"
+)
+
+// Sink exports the registered metrics to a monitoring backend, the
+// prometheus server is scraped while the others push
+type Sink interface {
+	Start() error                       // Exports until Shutdown, an error means the sink failed
+	Shutdown(ctx context.Context) error // Stops the sink, flushing what is left
+}
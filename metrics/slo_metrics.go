@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	// Go Internal Packages
+	"sync"
+	"time"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Results of a record against the freshness objective
+const (
+	SLOGood = "good"
+	SLOBad  = "bad"
+)
+
+// SLOMetrics measure tx-stream against "transactions visible within target":
+// a record is good when it is committed within the target of its timestamp,
+// the burn rate is the bad share over 1 - objective
+type SLOMetrics struct {
+	Events    *prometheus.CounterVec
+	Target    prometheus.Gauge
+	Objective prometheus.Gauge
+	TargetAge time.Duration
+
+	freshness *freshnessCollector
+}
+
+// NewSLOMetrics creates the SLO metrics and registers them with the registerer
+func NewSLOMetrics(reg prometheus.Registerer, namespace string, target time.Duration, objective float64) *SLOMetrics {
+	m := &SLOMetrics{
+		Events: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "slo",
+			Name:      "events_total",
+			Help:      "Records counted against the freshness objective, good when committed within the target.",
+		}, []string{"topic", "result"}),
+		Target: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "slo",
+			Name:      "freshness_target_seconds",
+			Help:      "Time from the record timestamp to its commit a record must stay within.",
+		}),
+		Objective: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "slo",
+			Name:      "objective_ratio",
+			Help:      "Share of the records that must be good, the error budget is the rest.",
+		}),
+		TargetAge: target,
+		freshness: &freshnessCollector{desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "slo", "freshness_seconds"),
+			"Age of the oldest record fetched but not committed yet, 0 while none is in processing.",
+			[]string{"consumer", "topic"}, nil,
+		)},
+	}
+	m.Target.Set(target.Seconds())
+	m.Objective.Set(objective)
+	reg.MustRegister(m.Events, m.Target, m.Objective, m.freshness)
+	return m
+}
+
+// AddFreshness exports the age of the oldest unprocessed record of a
+// consumer, oldest returns the zero time while there is none
+func (m *SLOMetrics) AddFreshness(consumer, topic string, oldest func() time.Time) {
+	if m == nil {
+		return
+	}
+	m.freshness.mu.Lock()
+	defer m.freshness.mu.Unlock()
+	m.freshness.sources = append(m.freshness.sources, freshnessSource{consumer: consumer, topic: topic, oldest: oldest})
+}
+
+// ObserveCommitted counts a committed record, a dead-lettered one is bad however fast
+func (m *SLOMetrics) ObserveCommitted(topic string, latency time.Duration, processed bool) {
+	if m == nil {
+		return
+	}
+	result := SLOGood
+	if !processed || latency > m.TargetAge {
+		result = SLOBad
+	}
+	m.Events.WithLabelValues(topic, result).Inc()
+}
+
+type freshnessSource struct {
+	consumer string
+	topic    string
+	oldest   func() time.Time
+}
+
+// freshnessCollector computes the freshness on every scrape
+type freshnessCollector struct {
+	desc    *prometheus.Desc
+	mu      sync.Mutex
+	sources []freshnessSource
+}
+
+func (c *freshnessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *freshnessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, source := range c.sources {
+		age := 0.0
+		if oldest := source.oldest(); !oldest.IsZero() {
+			age = max(time.Since(oldest).Seconds(), 0)
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, age, source.consumer, source.topic)
+	}
+}
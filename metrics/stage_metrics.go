@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a pipeline stage
+const (
+	OutcomeSuccess      = "success"
+	OutcomeFailure      = "failure"
+	OutcomeDeadLettered = "dead_lettered"
+)
+
+type StageMetrics struct {
+	Decode     *prometheus.HistogramVec
+	Process    *prometheus.HistogramVec
+	MongoWrite *prometheus.HistogramVec
+	EndToEnd   *prometheus.HistogramVec
+}
+
+// NewStageMetrics creates the per-stage latency histograms and registers them with the registerer
+func NewStageMetrics(reg prometheus.Registerer, namespace string) *StageMetrics {
+	histogram := func(name, help string, buckets []float64) *prometheus.HistogramVec {
+		return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "stage",
+			Name:      name,
+			Help:      help,
+			Buckets:   buckets,
+		}, []string{"topic", "outcome"})
+	}
+	m := &StageMetrics{
+		Decode: histogram("decode_duration_seconds", "Time to decode a batch of records.",
+			[]float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1}),
+		Process: histogram("process_duration_seconds", "Time the processor took on a batch, per attempt.",
+			prometheus.DefBuckets),
+		MongoWrite: histogram("mongo_write_duration_seconds", "Time to write a batch of documents to mongo.",
+			prometheus.DefBuckets),
+		EndToEnd: histogram("end_to_end_latency_seconds", "Time from the record timestamp to the commit of its offset.",
+			[]float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900}),
+	}
+	reg.MustRegister(m.Decode, m.Process, m.MongoWrite, m.EndToEnd)
+	return m
+}
+
+// Outcome returns the outcome label of a stage that finished with err
+func Outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+	return OutcomeSuccess
+}
+
+// ObserveDecode records the decode time of a batch
+func (m *StageMetrics) ObserveDecode(topic, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.Decode.WithLabelValues(topic, outcome).Observe(seconds)
+}
+
+// ObserveProcess records the processor time of a batch attempt
+func (m *StageMetrics) ObserveProcess(topic, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.Process.WithLabelValues(topic, outcome).Observe(seconds)
+}
+
+// ObserveMongoWrite records the write time of a batch
+func (m *StageMetrics) ObserveMongoWrite(topic, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.MongoWrite.WithLabelValues(topic, outcome).Observe(seconds)
+}
+
+// ObserveEndToEnd records the latency of a committed record
+func (m *StageMetrics) ObserveEndToEnd(topic, outcome string, seconds float64) {
+	if m == nil {
+		return
+	}
+	m.EndToEnd.WithLabelValues(topic, outcome).Observe(seconds)
+}
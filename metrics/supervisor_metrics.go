@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SupervisorMetrics count the restarts of the consumers after fatal errors
+type SupervisorMetrics struct {
+	Restarts *prometheus.CounterVec
+}
+
+// NewSupervisorMetrics creates the restart counter and registers it with the registerer
+func NewSupervisorMetrics(reg prometheus.Registerer, namespace string) *SupervisorMetrics {
+	m := &SupervisorMetrics{
+		Restarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "consumer",
+			Name:      "restarts_total",
+			Help:      "Restarts of a consumer with a new kafka client after its poll loop failed.",
+		}, []string{"consumer"}),
+	}
+	reg.MustRegister(m.Restarts)
+	return m
+}
+
+// Restarted counts a restart of the consumer
+func (m *SupervisorMetrics) Restarted(consumer string) {
+	if m == nil {
+		return
+	}
+	m.Restarts.WithLabelValues(consumer).Inc()
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TailMetrics follow the live tails of the support API
+type TailMetrics struct {
+	Subscribers prometheus.Gauge
+	Sent        prometheus.Counter
+	Dropped     prometheus.Counter
+}
+
+// NewTailMetrics creates the tail metrics and registers them with the registerer
+func NewTailMetrics(reg prometheus.Registerer, namespace string) *TailMetrics {
+	m := &TailMetrics{
+		Subscribers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "tail",
+			Name:      "subscribers",
+			Help:      "Live tails currently streaming.",
+		}),
+		Sent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "tail",
+			Name:      "events_total",
+			Help:      "Transactions handed to the live tails.",
+		}),
+		Dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "tail",
+			Name:      "dropped_events_total",
+			Help:      "Transactions a live tail was too slow to take.",
+		}),
+	}
+	reg.MustRegister(m.Subscribers, m.Sent, m.Dropped)
+	return m
+}
+
+// Subscribed sets the live tails currently streaming
+func (m *TailMetrics) Subscribed(subscribers int) {
+	if m == nil {
+		return
+	}
+	m.Subscribers.Set(float64(subscribers))
+}
+
+// Delivered counts the transactions handed to a tail and the ones it dropped
+func (m *TailMetrics) Delivered(sent, dropped int) {
+	if m == nil {
+		return
+	}
+	m.Sent.Add(float64(sent))
+	m.Dropped.Add(float64(dropped))
+}
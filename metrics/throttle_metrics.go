@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ThrottleMetrics show the throughput cap and how long batches waited for it
+type ThrottleMetrics struct {
+	Rate        prometheus.Gauge
+	WaitSeconds prometheus.Counter
+}
+
+// NewThrottleMetrics creates the throttle metrics and registers them with the registerer
+func NewThrottleMetrics(reg prometheus.Registerer, namespace string) *ThrottleMetrics {
+	m := &ThrottleMetrics{
+		Rate: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "throttle",
+			Name:      "records_per_second",
+			Help:      "Records per second the consumers may process together, 0 when not throttled.",
+		}),
+		WaitSeconds: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "throttle",
+			Name:      "wait_seconds_total",
+			Help:      "Time the batches waited for the throttle.",
+		}),
+	}
+	reg.MustRegister(m.Rate, m.WaitSeconds)
+	return m
+}
+
+// SetRate records the active cap
+func (m *ThrottleMetrics) SetRate(perSecond float64) {
+	if m == nil {
+		return
+	}
+	m.Rate.Set(perSecond)
+}
+
+// Waited records the time a batch waited
+func (m *ThrottleMetrics) Waited(seconds float64) {
+	if m == nil {
+		return
+	}
+	m.WaitSeconds.Add(seconds)
+}
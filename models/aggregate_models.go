@@ -0,0 +1,41 @@
+package models
+
+import (
+	// Go Internal Packages
+	"time"
+)
+
+// WindowResult is the aggregate of a group of transactions over a closed
+// window. The id is derived from the window, its end and the group, so an
+// emission that is repeated replaces the previous one.
+type WindowResult struct {
+	ID          string            `json:"id" bson:"_id"`
+	Window      string            `json:"window" bson:"window"`
+	Group       map[string]string `json:"group" bson:"group"` // Values of the group_by fields
+	Start       time.Time         `json:"start" bson:"start"`
+	End         time.Time         `json:"end" bson:"end"`
+	Count       int64             `json:"count" bson:"count"`
+	Volume      float64           `json:"volume" bson:"volume"` // Sum of the amounts, in the currency of the group
+	Declined    int64             `json:"declined" bson:"declined"`
+	DeclineRate float64           `json:"decline_rate" bson:"decline_rate"`
+	EmittedAt   time.Time         `json:"emitted_at" bson:"emitted_at"`
+}
+
+// WindowTotals are the running totals of a group within a window bucket
+type WindowTotals struct {
+	Count       int64
+	VolumeCents int64
+	Declined    int64
+}
+
+// WindowAdd counts a transaction into a bucket of a window, Group is the
+// encoded values of its group_by fields
+type WindowAdd struct {
+	Window      string
+	Bucket      time.Time // Start of the bucket
+	Group       string
+	TxID        string
+	VolumeCents int64
+	Declined    bool
+	TTL         time.Duration // Of the bucket, refreshed by every add
+}
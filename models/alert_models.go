@@ -0,0 +1,34 @@
+package models
+
+import (
+	// Go Internal Packages
+	"time"
+)
+
+// Alert is the notification of a transaction matching an alert rule, it is
+// the payload of the webhook channels
+type Alert struct {
+	ID            string    `json:"id"` // The dedup key, rule and value of its dedup_by field
+	Rule          string    `json:"rule"`
+	Severity      string    `json:"severity"`
+	Reason        string    `json:"reason"`
+	TransactionID string    `json:"transaction_id"`
+	UserID        string    `json:"user_id,omitempty"`
+	Amount        float32   `json:"amount"`
+	Currency      string    `json:"currency"`
+	MerchantName  string    `json:"merchant_name,omitempty"`
+	Location      string    `json:"location,omitempty"`
+	Timestamp     string    `json:"timestamp,omitempty"` // Of the transaction
+	Source        string    `json:"source"`              // The application that raised it
+	RaisedAt      time.Time `json:"raised_at"`
+}
+
+// VelocityCheck counts a transaction into the sliding window of a subject,
+// such as a user, under a velocity rule
+type VelocityCheck struct {
+	Rule    string
+	Subject string
+	TxID    string
+	At      time.Time
+	Window  time.Duration
+}
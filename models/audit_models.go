@@ -0,0 +1,64 @@
+package models
+
+import (
+	// Go Internal Packages
+	"time"
+)
+
+// Outcomes of a committed batch
+const (
+	AuditProcessed    = "processed"
+	AuditDeadLettered = "dead_lettered"
+	AuditSkipped      = "skipped" // Abandoned by an operator, see Consumer.Skip
+)
+
+// OffsetRange records that the offsets First to Last of a partition were
+// committed, one document per partition of a batch keeps the trail compact
+type OffsetRange struct {
+	Consumer    string    `json:"consumer" bson:"consumer"`
+	Group       string    `json:"group" bson:"group"`
+	Topic       string    `json:"topic" bson:"topic"`
+	Partition   int32     `json:"partition" bson:"partition"`
+	FirstOffset int64     `json:"first_offset" bson:"first_offset"`
+	LastOffset  int64     `json:"last_offset" bson:"last_offset"`
+	Records     int       `json:"records" bson:"records"`
+	Outcome     string    `json:"outcome" bson:"outcome"`
+	Attempts    int       `json:"attempts" bson:"attempts"`
+	DurationMS  int64     `json:"duration_ms" bson:"duration_ms"` // From fetch to commit
+	CommittedAt time.Time `json:"committed_at" bson:"committed_at"`
+	Reason      string    `json:"reason,omitempty" bson:"reason,omitempty"` // Why the range was skipped
+}
+
+// NewOffsetRanges groups the committed records of a batch into one range per partition
+func NewOffsetRanges(consumer, group string, records []Record, outcome string, attempts int, fetchedAt, committedAt time.Time) []OffsetRange {
+	type position struct {
+		topic     string
+		partition int32
+	}
+	var ranges []OffsetRange
+	index := make(map[position]int)
+	for _, record := range records {
+		key := position{record.Topic, record.Partition}
+		idx, ok := index[key]
+		if !ok {
+			index[key] = len(ranges)
+			ranges = append(ranges, OffsetRange{
+				Consumer:    consumer,
+				Group:       group,
+				Topic:       record.Topic,
+				Partition:   record.Partition,
+				FirstOffset: record.Offset,
+				LastOffset:  record.Offset,
+				Outcome:     outcome,
+				Attempts:    attempts,
+				DurationMS:  committedAt.Sub(fetchedAt).Milliseconds(),
+				CommittedAt: committedAt,
+			})
+			idx = len(ranges) - 1
+		}
+		ranges[idx].FirstOffset = min(ranges[idx].FirstOffset, record.Offset)
+		ranges[idx].LastOffset = max(ranges[idx].LastOffset, record.Offset)
+		ranges[idx].Records++
+	}
+	return ranges
+}
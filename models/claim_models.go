@@ -0,0 +1,16 @@
+package models
+
+// ClaimCheckEnvelope is the value of a record whose payload did not fit the
+// broker limits, the producer parked the payload in object storage:
+//
+//	{"claim_check": {"uri": "s3://bucket/key", "size": 1048576, "sha256": "<hex>"}}
+type ClaimCheckEnvelope struct {
+	ClaimCheck *ClaimCheck `json:"claim_check"`
+}
+
+// ClaimCheck references a payload in object storage
+type ClaimCheck struct {
+	URI    string `json:"uri"`
+	Size   int64  `json:"size,omitempty"`   // Checked when set
+	SHA256 string `json:"sha256,omitempty"` // Hex digest of the payload, checked when set
+}
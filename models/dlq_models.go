@@ -0,0 +1,164 @@
+package models
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+)
+
+// Headers stamped on requeued records, so records that keep failing after
+// replays can be told apart from first-time failures
+const (
+	HeaderReplayCount = "x-dlq-replay-count"
+	HeaderOriginalID  = "x-dlq-original-id"
+)
+
+// DLQEntry is the envelope a dead-lettered record is stored in. The record
+// keeps its original topic, partition, offset, key, headers and payload.
+type DLQEntry struct {
+	ID            string    `json:"id" bson:"_id"`
+	Record        Record    `json:"record" bson:"record"`
+	ErrorClass    string    `json:"error_class" bson:"error_class"`
+	Error         string    `json:"error" bson:"error"`
+	Attempts      int       `json:"attempts" bson:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at" bson:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at" bson:"last_failed_at"`
+}
+
+// DLQFullError reports the records a full DLQ dropped under the
+// drop-new-with-alert policy, the rest of the batch was stored
+type DLQFullError struct {
+	Dropped   int
+	MaxLength int64
+}
+
+func (e *DLQFullError) Error() string {
+	return fmt.Sprintf("dlq is full at %d entries, dropped %d records", e.MaxLength, e.Dropped)
+}
+
+// DLQPage is a single page of dead-lettered entries.
+type DLQPage struct {
+	Entries []DLQEntry `json:"entries"`
+	Offset  int64      `json:"offset"`
+	Limit   int64      `json:"limit"`
+	Total   int64      `json:"total"`
+}
+
+// ReplayCount returns how many times the record was requeued from the DLQ
+func (r Record) ReplayCount() int {
+	value, ok := r.Header(HeaderReplayCount)
+	if !ok {
+		return 0
+	}
+	count, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// DLQEntryID returns the DLQ id of a record, derived from its original position
+func DLQEntryID(record Record) string {
+	return fmt.Sprintf("%s:%d:%d", record.Topic, record.Partition, record.Offset)
+}
+
+// NewDLQEntries wraps the failed records into DLQ envelopes
+func NewDLQEntries(records []Record, cause error, attempts int) []DLQEntry {
+	message := ""
+	if cause != nil {
+		message = cause.Error()
+	}
+	class := errors.Class(cause)
+
+	failedAt := time.Now().UTC()
+	entries := make([]DLQEntry, len(records))
+	for idx, record := range records {
+		entries[idx] = DLQEntry{
+			ID:            DLQEntryID(record),
+			Record:        record,
+			ErrorClass:    class,
+			Error:         message,
+			Attempts:      attempts,
+			FirstFailedAt: failedAt,
+			LastFailedAt:  failedAt,
+		}
+	}
+	return entries
+}
+
+// DLQFilter selects dead-lettered entries, zero fields match everything
+type DLQFilter struct {
+	From       time.Time `json:"from"`        // Last failure at or after
+	To         time.Time `json:"to"`          // Last failure before
+	ErrorClass string    `json:"error_class"` // Exact error class
+	Topic      string    `json:"topic"`       // Exact original topic
+	KeyPattern string    `json:"key_pattern"` // Glob matched against the record key, e.g. "user-42*"
+}
+
+// Match reports whether the entry is selected by the filter
+func (f DLQFilter) Match(entry DLQEntry) bool {
+	if !f.From.IsZero() && entry.LastFailedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.LastFailedAt.Before(f.To) {
+		return false
+	}
+	if f.ErrorClass != "" && entry.ErrorClass != f.ErrorClass {
+		return false
+	}
+	if f.Topic != "" && entry.Record.Topic != f.Topic {
+		return false
+	}
+	if f.KeyPattern != "" {
+		ok, err := path.Match(f.KeyPattern, string(entry.Record.Key))
+		if err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the key pattern is a valid glob and the range is ordered
+func (f DLQFilter) Validate() error {
+	ve := errors.ValidationErrs()
+	if f.KeyPattern != "" {
+		if _, err := path.Match(f.KeyPattern, ""); err != nil {
+			ve.Add("key_pattern", "must be a valid glob pattern")
+		}
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		ve.Add("to", "must be after from")
+	}
+	return ve.Err()
+}
+
+// DLQReplayResult summarises a selective replay
+type DLQReplayResult struct {
+	Matched  int  `json:"matched"`
+	Replayed int  `json:"replayed"`
+	DryRun   bool `json:"dry_run"`
+}
+
+// SinkDeadLetter is a transaction a fan-out sink refused after its retries,
+// kept per sink so the sink can be caught up without touching the others
+type SinkDeadLetter struct {
+	ID          string           `json:"id" bson:"_id"` // sink/transaction_id
+	Sink        string           `json:"sink" bson:"sink"`
+	Transaction MongoTransaction `json:"transaction" bson:"transaction"`
+	Error       string           `json:"error" bson:"error"`
+	Attempts    int              `json:"attempts" bson:"attempts"`
+	FailedAt    time.Time        `json:"failed_at" bson:"failed_at"`
+}
+
+// SinkDeadLetterPage is a single page of sink dead letters
+type SinkDeadLetterPage struct {
+	Entries []SinkDeadLetter `json:"entries"`
+	Offset  int64            `json:"offset"`
+	Limit   int64            `json:"limit"`
+	Total   int64            `json:"total"`
+}
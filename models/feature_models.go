@@ -0,0 +1,29 @@
+package models
+
+import (
+	// Go Internal Packages
+	"hash/fnv"
+	"slices"
+)
+
+// FeatureFlag gates a code path per tenant. A disabled flag is off for everyone,
+// an enabled one is on for the listed tenants and for Rollout percent of the others.
+type FeatureFlag struct {
+	Enabled bool     `json:"enabled"`
+	Tenants []string `json:"tenants,omitempty"`
+	Rollout int      `json:"rollout"` // 0 to 100, 100 turns the flag on for every tenant
+}
+
+// EnabledFor evaluates the flag for a tenant. The rollout bucket of a tenant is
+// stable per flag, so raising the rollout only ever adds tenants.
+func (f FeatureFlag) EnabledFor(name, tenant string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if slices.Contains(f.Tenants, tenant) {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name + ":" + tenant))
+	return int(h.Sum32()%100) < f.Rollout
+}
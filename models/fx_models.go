@@ -0,0 +1,25 @@
+package models
+
+import (
+	// Go Internal Packages
+	"time"
+)
+
+// FXRates are the exchange rates of a base currency, one Base buys
+// Rates[currency] of the currency
+type FXRates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	AsOf      time.Time          `json:"as_of"` // Published by the source, the fetch time when it has none
+	FetchedAt time.Time          `json:"fetched_at"`
+}
+
+// NormalizedAmount is the amount of a transaction in the base currency, the
+// original amount and currency stay on the transaction
+type NormalizedAmount struct {
+	Amount   float64   `json:"amount" bson:"amount"`
+	Currency string    `json:"currency" bson:"currency"`
+	Rate     float64   `json:"rate" bson:"rate"` // Of the transaction currency per base unit
+	RatesAt  time.Time `json:"rates_at" bson:"rates_at"`
+	Stale    bool      `json:"stale,omitempty" bson:"stale,omitempty"` // Converted with rates past their max age
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"time"
+)
+
+// AppliedRecord is an entry of the idempotency ledger, written in the mongo
+// transaction that stored the transaction of the record
+type AppliedRecord struct {
+	ID        string    `json:"id" bson:"_id"` // See AppliedRecordID
+	Consumer  string    `json:"consumer,omitempty" bson:"consumer,omitempty"`
+	Topic     string    `json:"topic" bson:"topic"`
+	Partition int32     `json:"partition" bson:"partition"`
+	Offset    int64     `json:"offset" bson:"offset"`
+	TxID      string    `json:"transaction_id" bson:"transaction_id"`
+	AppliedAt time.Time `json:"applied_at" bson:"applied_at"`
+}
+
+// AppliedRecordID returns the ledger id of a record, derived from its
+// consumer and original position so a retried record keeps its id
+func AppliedRecordID(consumer, topic string, partition int32, offset int64) string {
+	return fmt.Sprintf("%s/%s:%d:%d", consumer, topic, partition, offset)
+}
+
+// NewAppliedRecord returns the ledger entry of a stored transaction, from the
+// record its provenance names
+func NewAppliedRecord(tx MongoTransaction) (AppliedRecord, error) {
+	p := tx.Provenance
+	if p == nil {
+		return AppliedRecord{}, fmt.Errorf("transaction %s has no provenance", tx.TxID)
+	}
+	return AppliedRecord{
+		ID:        AppliedRecordID(p.Consumer, p.Topic, p.Partition, p.Offset),
+		Consumer:  p.Consumer,
+		Topic:     p.Topic,
+		Partition: p.Partition,
+		Offset:    p.Offset,
+		TxID:      tx.TxID,
+		AppliedAt: time.Now().UTC(),
+	}, nil
+}
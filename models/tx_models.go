@@ -1,9 +1,76 @@
 package models
 
+import (
+	// Go Internal Packages
+	"time"
+)
+
+// W3C trace context headers, see https://www.w3.org/TR/trace-context
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// HeaderCorrelationID carries the correlation id of a record, set by the
+// producer or generated on first consumption and kept through DLQ replays
+const HeaderCorrelationID = "x-correlation-id"
+
 type Record struct {
-	Key   []byte
-	Value []byte
-	Topic string
+	Key       []byte         `json:"key"`
+	Value     []byte         `json:"value"`
+	Headers   []RecordHeader `json:"headers,omitempty"`
+	Topic     string         `json:"topic"`
+	Partition int32          `json:"partition"`
+	Offset    int64          `json:"offset"`
+	Timestamp time.Time      `json:"timestamp"`
+	Consumer  string         `json:"consumer,omitempty"` // Name of the consumer that read the record
+}
+
+type RecordHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Header returns the value of the first header with the given key
+func (r Record) Header(key string) ([]byte, bool) {
+	for _, header := range r.Headers {
+		if header.Key == key {
+			return header.Value, true
+		}
+	}
+	return nil, false
+}
+
+// CorrelationID returns the id that ties the log lines of the record together
+func (r Record) CorrelationID() string {
+	value, _ := r.Header(HeaderCorrelationID)
+	return string(value)
+}
+
+// CorrelationIDs returns the correlation ids of the records
+func CorrelationIDs(records []Record) []string {
+	ids := make([]string, len(records))
+	for idx, record := range records {
+		ids[idx] = record.CorrelationID()
+	}
+	return ids
+}
+
+// SetHeader replaces every header with the given key by a single one
+func (r *Record) SetHeader(key string, value []byte) {
+	r.DelHeader(key)
+	r.Headers = append(r.Headers, RecordHeader{Key: key, Value: value})
+}
+
+// DelHeader removes every header with the given key
+func (r *Record) DelHeader(key string) {
+	headers := make([]RecordHeader, 0, len(r.Headers))
+	for _, header := range r.Headers {
+		if header.Key != key {
+			headers = append(headers, header)
+		}
+	}
+	r.Headers = headers
 }
 
 type Transaction struct {
@@ -26,18 +93,63 @@ type Transaction struct {
 }
 
 type MongoTransaction struct {
-	TxID            string  `json:"transaction_id" bson:"_id"`
-	Amount          float32 `json:"amount" bson:"amount"`
-	Currency        string  `json:"currency" bson:"currency"`
-	TransactionType string  `json:"transaction_type" bson:"transaction_type"`
-	Status          string  `json:"status" bson:"status"`
-	Timestamp       string  `json:"timestamp" bson:"timestamp"`
-	PaymentMethod   string  `json:"payment_method" bson:"payment_method"`
+	TxID            string            `json:"transaction_id" bson:"_id"`
+	UserID          string            `json:"user_id,omitempty" bson:"user_id,omitempty"` // Account the transaction belongs to
+	Amount          float32           `json:"amount" bson:"amount"`
+	Currency        string            `json:"currency" bson:"currency"`
+	TransactionType string            `json:"transaction_type" bson:"transaction_type"`
+	Status          string            `json:"status" bson:"status"`
+	Timestamp       string            `json:"timestamp" bson:"timestamp"`
+	PaymentMethod   string            `json:"payment_method" bson:"payment_method"`
+	TraceParent     string            `json:"traceparent,omitempty" bson:"traceparent,omitempty"` // Trace context of the record producer
+	TraceState      string            `json:"tracestate,omitempty" bson:"tracestate,omitempty"`
+	Provenance      *Provenance       `json:"provenance,omitempty" bson:"provenance,omitempty"`
+	Risk            *Risk             `json:"risk,omitempty" bson:"risk,omitempty"`             // Set when the risk rules are enabled
+	Normalized      *NormalizedAmount `json:"normalized,omitempty" bson:"normalized,omitempty"` // Amount in the base currency, set when fx is enabled
+}
+
+// Risk is what the risk rules made of a transaction
+type Risk struct {
+	Score   float64  `json:"score" bson:"score"`     // Sum of the scores of the flags
+	Flags   []string `json:"flags" bson:"flags"`     // Names of the matching rules
+	Ruleset string   `json:"ruleset" bson:"ruleset"` // Version of the rules that evaluated it
+}
+
+// Provenance points a document back to the Kafka record that produced it
+type Provenance struct {
+	Topic       string    `json:"topic" bson:"topic"`
+	Partition   int32     `json:"partition" bson:"partition"`
+	Offset      int64     `json:"offset" bson:"offset"`
+	Consumer    string    `json:"consumer,omitempty" bson:"consumer,omitempty"`
+	ProcessedAt time.Time `json:"processed_at" bson:"processed_at"`
+}
+
+// StampProvenance records the source record of the document and when it was processed
+func (m *MongoTransaction) StampProvenance(record Record, processedAt time.Time) {
+	m.Provenance = &Provenance{
+		Topic:       record.Topic,
+		Partition:   record.Partition,
+		Offset:      record.Offset,
+		Consumer:    record.Consumer,
+		ProcessedAt: processedAt,
+	}
+}
+
+// TraceContext stamps the trace context headers of the record on the document,
+// linking it to the trace that produced it
+func (m *MongoTransaction) TraceContext(record Record) {
+	if value, ok := record.Header(HeaderTraceParent); ok {
+		m.TraceParent = string(value)
+	}
+	if value, ok := record.Header(HeaderTraceState); ok {
+		m.TraceState = string(value)
+	}
 }
 
 func (t *Transaction) Transform() MongoTransaction {
 	return MongoTransaction{
 		TxID:            t.TxID,
+		UserID:          t.UserID,
 		Amount:          t.Amount,
 		Currency:        t.Currency,
 		TransactionType: t.TransactionType,
@@ -46,3 +158,14 @@ func (t *Transaction) Transform() MongoTransaction {
 		PaymentMethod:   t.PaymentMethod,
 	}
 }
+
+// TxFilter narrows a listing of the stored transactions, zero fields match
+// every transaction
+type TxFilter struct {
+	UserID          string
+	Status          string
+	Currency        string
+	TransactionType string
+	ProcessedAfter  time.Time
+	ProcessedBefore time.Time
+}
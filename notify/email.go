@@ -0,0 +1,94 @@
+package notify
+
+import (
+	// Go Internal Packages
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Email sends alerts over SMTP, upgrading to TLS when the server offers it.
+// The first line of the text is the subject.
+type Email struct {
+	Addr     string // host:port of the SMTP server
+	From     string
+	To       []string
+	Username string // Empty sends without authentication
+	Password string
+	Timeout  time.Duration
+}
+
+func NewEmail(addr, from string, to []string, username, password string, timeout time.Duration) *Email {
+	return &Email{Addr: addr, From: from, To: to, Username: username, Password: password, Timeout: timeout}
+}
+
+// Notify sends the text to every recipient
+func (e *Email) Notify(ctx context.Context, text string) error {
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", e.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to reach the smtp server: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	host, _, _ := net.SplitHostPort(e.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to greet the smtp server: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("failed to start tls: %v", err)
+		}
+	}
+	if e.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.Username, e.Password, host)); err != nil {
+			return fmt.Errorf("smtp authentication failed: %v", err)
+		}
+	}
+	if err := client.Mail(e.From); err != nil {
+		return fmt.Errorf("smtp server refused the sender: %v", err)
+	}
+	for _, to := range e.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp server refused recipient %s: %v", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(text)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp server refused the message: %v", err)
+	}
+	return client.Quit()
+}
+
+func (e *Email) message(text string) []byte {
+	subject, _, _ := strings.Cut(text, "\n")
+	subject = strings.ReplaceAll(subject, "\r", "")
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+	return []byte(msg.String())
+}
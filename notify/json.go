@@ -0,0 +1,55 @@
+package notify
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	// Local Packages
+	webhook "tx-stream/repositories/webhook"
+)
+
+// JSONWebhook posts alerts as JSON documents, signed like the webhook sink
+// when a secret is set, for receivers that act on the alert fields
+type JSONWebhook struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func NewJSONWebhook(url, secret string, timeout time.Duration) *JSONWebhook {
+	return &JSONWebhook{URL: url, Secret: secret, Client: &http.Client{Timeout: timeout}}
+}
+
+// Post sends the payload, any non 2xx response is an error
+func (w *JSONWebhook) Post(ctx context.Context, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign(w.Secret, time.Now(), body))
+	}
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
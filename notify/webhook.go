@@ -0,0 +1,48 @@
+package notify
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook posts alerts as a Slack-compatible {"text": "..."} payload, which
+// Slack incoming webhooks and most chat and paging integrations accept.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+// Notify sends the message, any non 2xx response is an error
+func (w *Webhook) Notify(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package observability
+
+import (
+	// Go Internal Packages
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metric types as Grafana and Prometheus know them
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// MetricInfo describes a metric family registered in the binary
+type MetricInfo struct {
+	Name   string
+	Help   string
+	Type   string
+	Labels []string
+}
+
+// Catalog is a registerer recording the metrics registered with it instead of
+// serving them, so dashboards and rules can be generated from the same
+// constructors the service uses
+type Catalog struct {
+	mu      sync.Mutex
+	metrics map[string]MetricInfo
+}
+
+func NewCatalog() *Catalog {
+	return &Catalog{metrics: make(map[string]MetricInfo)}
+}
+
+// A Desc exposes its fields only through String
+var (
+	descPattern  = regexp.MustCompile(`^Desc\{fqName: "([^"]*)", help: "((?:[^"\\]|\\.)*)", constLabels: \{[^}]*\}, variableLabels: (.*)\}$`)
+	labelPattern = regexp.MustCompile(`\{([a-zA-Z_][a-zA-Z0-9_]*) `)
+)
+
+// Register records every metric the collector describes
+func (c *Catalog) Register(collector prometheus.Collector) error {
+	kind := collectorType(collector)
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		collector.Describe(descs)
+		close(descs)
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for desc := range descs {
+		match := descPattern.FindStringSubmatch(desc.String())
+		if match == nil {
+			continue
+		}
+		info := MetricInfo{Name: match[1], Help: strings.ReplaceAll(match[2], `\"`, `"`), Type: kind}
+		for _, label := range labelPattern.FindAllStringSubmatch(match[3], -1) {
+			info.Labels = append(info.Labels, label[1])
+		}
+		if info.Type == "" {
+			info.Type = TypeGauge
+			if strings.HasSuffix(info.Name, "_total") {
+				info.Type = TypeCounter
+			}
+		}
+		c.metrics[info.Name] = info
+	}
+	return nil
+}
+
+// MustRegister records the collectors
+func (c *Catalog) MustRegister(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		_ = c.Register(collector)
+	}
+}
+
+// Unregister is a no-op, the catalog only ever grows
+func (c *Catalog) Unregister(prometheus.Collector) bool {
+	return false
+}
+
+// Metrics returns the recorded metrics sorted by name
+func (c *Catalog) Metrics() []MetricInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics := make([]MetricInfo, 0, len(c.metrics))
+	for _, info := range c.metrics {
+		metrics = append(metrics, info)
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Name < metrics[j].Name })
+	return metrics
+}
+
+// Lookup returns the metric with the name and whether it is registered
+func (c *Catalog) Lookup(name string) (MetricInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, ok := c.metrics[name]
+	return info, ok
+}
+
+// collectorType tells the type of the standard collectors, custom ones and
+// the funcs, which share their interface, are left to the name. A gauge
+// satisfies the counter interface, so it is matched first.
+func collectorType(collector prometheus.Collector) string {
+	switch collector.(type) {
+	case *prometheus.HistogramVec, prometheus.Histogram:
+		return TypeHistogram
+	case *prometheus.GaugeVec, prometheus.Gauge:
+		return TypeGauge
+	case *prometheus.CounterVec, prometheus.Counter:
+		return TypeCounter
+	default:
+		return ""
+	}
+}
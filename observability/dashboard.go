@@ -0,0 +1,119 @@
+package observability
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"strings"
+)
+
+// Dashboard returns a Grafana dashboard with a row per subsystem and a panel
+// per metric, ready to import with a prometheus datasource
+func Dashboard(title, namespace string, catalog *Catalog) map[string]any {
+	var panels []map[string]any
+	id, y := 1, 0
+	row := ""
+	col := 0
+	for _, metric := range catalog.Metrics() {
+		if subsystem := subsystemOf(namespace, metric.Name); subsystem != row {
+			if col != 0 {
+				y += 8
+			}
+			row, col = subsystem, 0
+			panels = append(panels, map[string]any{
+				"id": id, "type": "row", "title": subsystem, "collapsed": false,
+				"gridPos": map[string]int{"h": 1, "w": 24, "x": 0, "y": y},
+			})
+			id++
+			y++
+		}
+		panels = append(panels, map[string]any{
+			"id":          id,
+			"type":        "timeseries",
+			"title":       metric.Name,
+			"description": metric.Help,
+			"datasource":  map[string]string{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":     map[string]int{"h": 8, "w": 12, "x": col * 12, "y": y},
+			"fieldConfig": map[string]any{"defaults": map[string]any{"unit": unitOf(metric)}, "overrides": []any{}},
+			"targets": []map[string]any{{
+				"refId":        "A",
+				"expr":         query(metric),
+				"legendFormat": legendOf(metric),
+			}},
+		})
+		id++
+		if col = (col + 1) % 2; col == 0 {
+			y += 8
+		}
+	}
+
+	return map[string]any{
+		"title":         title,
+		"uid":           strings.ReplaceAll(title, " ", "-"),
+		"schemaVersion": 39,
+		"editable":      true,
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"refresh":       "30s",
+		"tags":          []string{namespace, "generated"},
+		"templating": map[string]any{"list": []map[string]any{{
+			"name": "datasource", "type": "datasource", "query": "prometheus", "label": "Datasource",
+		}}},
+		"panels": panels,
+	}
+}
+
+// query returns the PromQL of the panel: rates for counters, p99 for histograms
+func query(metric MetricInfo) string {
+	by := strings.Join(metric.Labels, ", ")
+	switch metric.Type {
+	case TypeCounter:
+		if by == "" {
+			return fmt.Sprintf("sum(rate(%s[$__rate_interval]))", metric.Name)
+		}
+		return fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", by, metric.Name)
+	case TypeHistogram:
+		return fmt.Sprintf("histogram_quantile(0.99, sum by (%s) (rate(%s_bucket[$__rate_interval])))",
+			strings.Join(append([]string{"le"}, metric.Labels...), ", "), metric.Name)
+	default:
+		if by == "" {
+			return metric.Name
+		}
+		return fmt.Sprintf("max by (%s) (%s)", by, metric.Name)
+	}
+}
+
+func legendOf(metric MetricInfo) string {
+	if len(metric.Labels) == 0 {
+		return metric.Name
+	}
+	parts := make([]string, len(metric.Labels))
+	for idx, label := range metric.Labels {
+		parts[idx] = "{{" + label + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+func unitOf(metric MetricInfo) string {
+	switch {
+	case metric.Type == TypeCounter:
+		return "ops"
+	case strings.HasSuffix(metric.Name, "_seconds"):
+		return "s"
+	case strings.HasSuffix(metric.Name, "_bytes"):
+		return "bytes"
+	case strings.HasSuffix(metric.Name, "_ratio"):
+		return "percentunit"
+	case strings.HasSuffix(metric.Name, "_percent"):
+		return "percent"
+	default:
+		return "short"
+	}
+}
+
+// subsystemOf returns the part of the name after the namespace, e.g. dlq for et_dlq_depth
+func subsystemOf(namespace, name string) string {
+	rest := strings.TrimPrefix(name, namespace+"_")
+	if subsystem, _, ok := strings.Cut(rest, "_"); ok {
+		return subsystem
+	}
+	return rest
+}
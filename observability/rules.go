@@ -0,0 +1,125 @@
+package observability
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"time"
+)
+
+// RuleFile is a prometheus rule file
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// RuleConfig holds the thresholds the rules alert on, taken from the config
+type RuleConfig struct {
+	FreshnessTarget time.Duration
+	Objective       float64
+	DLQDepth        int64
+	Lag             int64
+	StallTimeout    time.Duration // Without a commit for longer while lagging counts as stuck
+}
+
+// Rules returns the alert rules of the metrics in the catalog, a rule whose
+// metric is not registered is left out rather than alerting on nothing
+func Rules(namespace string, catalog *Catalog, conf RuleConfig) RuleFile {
+	name := func(suffix string) (string, bool) {
+		metric := namespace + "_" + suffix
+		_, ok := catalog.Lookup(metric)
+		return metric, ok
+	}
+	var rules []Rule
+	add := func(rule Rule, severity, summary string) {
+		rule.Labels = map[string]string{"severity": severity}
+		rule.Annotations = map[string]string{"summary": summary}
+		rules = append(rules, rule)
+	}
+
+	if metric, ok := name("slo_events_total"); ok {
+		budget := 1 - conf.Objective
+		// Multiwindow burn rates, 14.4 spends 2% of a 30 day budget in an hour
+		for _, burn := range []struct {
+			long, short, severity string
+			rate                  float64
+		}{{"1h", "5m", "critical", 14.4}, {"6h", "30m", "warning", 6}} {
+			add(Rule{
+				Alert: "TxStreamFreshnessBudgetBurn" + burn.long,
+				Expr: fmt.Sprintf("%s > %.6g and %s > %.6g",
+					badRatio(metric, burn.long), burn.rate*budget, badRatio(metric, burn.short), burn.rate*budget),
+			}, burn.severity, fmt.Sprintf("Records of {{ $labels.topic }} miss the %s freshness target %gx faster than the budget allows",
+				conf.FreshnessTarget, burn.rate))
+		}
+	}
+	if metric, ok := name("slo_freshness_seconds"); ok {
+		add(Rule{
+			Alert: "TxStreamRecordsStale",
+			Expr:  fmt.Sprintf("max by (consumer, topic) (%s) > %g", metric, conf.FreshnessTarget.Seconds()),
+			For:   "5m",
+		}, "warning", "Consumer {{ $labels.consumer }} holds records older than the freshness target")
+	}
+	if metric, ok := name("consumer_lag"); ok && conf.Lag > 0 {
+		add(Rule{
+			Alert: "TxStreamConsumerLagHigh",
+			Expr:  fmt.Sprintf("sum by (group, topic) (%s) > %d", metric, conf.Lag),
+			For:   "10m",
+		}, "warning", "Group {{ $labels.group }} lags {{ $value }} records behind on {{ $labels.topic }}")
+	}
+	if metric, ok := name("heartbeat_last_commit_timestamp_seconds"); ok && conf.StallTimeout > 0 {
+		stuck := fmt.Sprintf("time() - max by (group, topic) (%s) > %g", metric, conf.StallTimeout.Seconds())
+		if lag, ok := name("consumer_lag"); ok {
+			// An idle topic stops the heartbeats too, only alert while there is something to consume
+			stuck += fmt.Sprintf(" and on (group, topic) sum by (group, topic) (%s) > 0", lag)
+		}
+		add(Rule{
+			Alert: "TxStreamConsumerStuck",
+			Expr:  stuck,
+			For:   "5m",
+		}, "critical", "Group {{ $labels.group }} has not committed on {{ $labels.topic }} for longer than the stall timeout")
+	}
+	if metric, ok := name("errors_total"); ok {
+		add(Rule{
+			Alert: "TxStreamDatabaseErrors",
+			Expr:  fmt.Sprintf(`sum by (class, topic) (rate(%s{class=~"mongo_.*|redis"}[5m])) > 0`, metric),
+			For:   "5m",
+		}, "critical", "{{ $labels.class }} errors on {{ $labels.topic }}, a database is failing")
+		add(Rule{
+			Alert: "TxStreamDecodeErrors",
+			Expr:  fmt.Sprintf(`sum by (topic) (rate(%s{class=~"decode|validation"}[5m])) > 0`, metric),
+			For:   "15m",
+		}, "warning", "Upstream keeps sending records of {{ $labels.topic }} that cannot be decoded or validated")
+	}
+	if metric, ok := name("dlq_depth"); ok && conf.DLQDepth > 0 {
+		add(Rule{
+			Alert: "TxStreamDLQDepth",
+			Expr:  fmt.Sprintf("%s > %d", metric, conf.DLQDepth),
+			For:   "5m",
+		}, "warning", "The dead letter queue holds {{ $value }} entries")
+	}
+	if metric, ok := name("redis_up"); ok {
+		add(Rule{
+			Alert: "TxStreamRedisDown",
+			Expr:  fmt.Sprintf("%s == 0", metric),
+			For:   "2m",
+		}, "critical", "Redis health checks are failing")
+	}
+
+	return RuleFile{Groups: []RuleGroup{{Name: namespace + ".tx-stream", Rules: rules}}}
+}
+
+func badRatio(metric, window string) string {
+	return fmt.Sprintf(`(sum by (topic) (rate(%s{result="bad"}[%s])) / sum by (topic) (rate(%s[%s])))`,
+		metric, window, metric, window)
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// External Packages
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// IDHeader carries the id of the message, the key to drop its copies by
+const IDHeader = "outbox-id"
+
+// KafkaPublisher publishes the messages to their topics with a franz-go client
+type KafkaPublisher struct {
+	Client *kgo.Client
+}
+
+func NewKafkaPublisher(client *kgo.Client) *KafkaPublisher {
+	return &KafkaPublisher{Client: client}
+}
+
+// Publish produces the messages and waits for every acknowledgement
+func (p *KafkaPublisher) Publish(ctx context.Context, msgs []Message) []error {
+	records := make([]*kgo.Record, len(msgs))
+	for idx, msg := range msgs {
+		headers := make([]kgo.RecordHeader, 0, len(msg.Headers)+1)
+		for key, value := range msg.Headers {
+			headers = append(headers, kgo.RecordHeader{Key: key, Value: []byte(value)})
+		}
+		headers = append(headers, kgo.RecordHeader{Key: IDHeader, Value: []byte(msg.ID)})
+		records[idx] = &kgo.Record{Topic: msg.Topic, Key: msg.Key, Value: msg.Value, Headers: headers}
+	}
+	// The results come in the order of the acknowledgements
+	index := make(map[*kgo.Record]int, len(records))
+	for idx, record := range records {
+		index[record] = idx
+	}
+	errs := make([]error, len(msgs))
+	for _, result := range p.Client.ProduceSync(ctx, records...) {
+		errs[index[result.Record]] = result.Err
+	}
+	return errs
+}
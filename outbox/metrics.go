@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	// External Packages
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of a failed publish
+const (
+	OutcomeRetried = "retried"
+	OutcomeFailed  = "failed" // Out of attempts
+)
+
+// Metrics follow the relay of an outbox. A nil *Metrics records nothing, for
+// the services without prometheus.
+type Metrics struct {
+	Publishes *prometheus.CounterVec
+	Failures  *prometheus.CounterVec
+	Delay     *prometheus.HistogramVec
+	Messages  *prometheus.GaugeVec
+}
+
+// NewMetrics creates the outbox metrics and registers them with the registerer
+func NewMetrics(reg prometheus.Registerer, namespace string) *Metrics {
+	m := &Metrics{
+		Publishes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "outbox",
+			Name:      "published_total",
+			Help:      "Outbox messages published, by topic.",
+		}, []string{"topic"}),
+		Failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "outbox",
+			Name:      "publish_failures_total",
+			Help:      "Failed publishes of outbox messages, by topic and outcome.",
+		}, []string{"topic", "outcome"}),
+		Delay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "outbox",
+			Name:      "publish_delay_seconds",
+			Help:      "Time from the enqueue of a message to its publish.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+		}, []string{"topic"}),
+		Messages: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "outbox",
+			Name:      "messages",
+			Help:      "Messages in the outbox, by state, as of the last cleanup.",
+		}, []string{"state"}),
+	}
+	reg.MustRegister(m.Publishes, m.Failures, m.Delay, m.Messages)
+	return m
+}
+
+// Published counts a published message, delay is the seconds since its enqueue
+func (m *Metrics) Published(topic string, delay float64) {
+	if m == nil {
+		return
+	}
+	m.Publishes.WithLabelValues(topic).Inc()
+	m.Delay.WithLabelValues(topic).Observe(delay)
+}
+
+// Failed counts a failed publish with its outcome
+func (m *Metrics) Failed(topic, outcome string) {
+	if m == nil {
+		return
+	}
+	m.Failures.WithLabelValues(topic, outcome).Inc()
+}
+
+// Backlog sets the number of messages in a state
+func (m *Metrics) Backlog(state string, messages int64) {
+	if m == nil {
+		return
+	}
+	m.Messages.WithLabelValues(state).Set(float64(messages))
+}
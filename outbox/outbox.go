@@ -0,0 +1,210 @@
+// Package outbox implements the transactional outbox pattern on MongoDB.
+// A service writes its messages to the outbox collection in the transaction
+// that changes its documents, and a Relay publishes them afterwards, at least
+// once, so a message goes out if and only if its change was committed. The
+// package depends on nothing else in this repository, so other services can
+// import it as is.
+package outbox
+
+import (
+	// Go Internal Packages
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// States of a message
+const (
+	StatePending   = "pending"
+	StatePublished = "published"
+	StateFailed    = "failed" // Out of attempts, kept for inspection
+)
+
+// Message is a document of the outbox collection. The id is the idempotency
+// key of the message, it goes out in the outbox-id header so consumers can
+// drop the copies the at-least-once delivery produces.
+type Message struct {
+	ID            string            `bson:"_id"`
+	Topic         string            `bson:"topic"`
+	Key           []byte            `bson:"key,omitempty"`
+	Value         []byte            `bson:"value"`
+	Headers       map[string]string `bson:"headers,omitempty"`
+	State         string            `bson:"state"`
+	CreatedAt     time.Time         `bson:"created_at"`
+	NextAttemptAt time.Time         `bson:"next_attempt_at"` // Pushed by a lease while a relay publishes the message
+	Attempts      int               `bson:"attempts"`
+	LastError     string            `bson:"last_error,omitempty"`
+	Claim         string            `bson:"claim,omitempty"` // Of the relay round publishing the message
+	PublishedAt   *time.Time        `bson:"published_at,omitempty"`
+}
+
+// Store is the outbox collection
+type Store struct {
+	Collection *mongo.Collection
+}
+
+func NewStore(collection *mongo.Collection) *Store {
+	return &Store{Collection: collection}
+}
+
+// EnsureIndexes indexes the messages for the relay and the cleanup
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "state", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "state", Value: 1}, {Key: "published_at", Value: 1}}},
+		{Keys: bson.D{{Key: "claim", Value: 1}}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
+
+// Enqueue adds messages to the outbox, pending right away. Called with the
+// session context of a transaction (see WithTransaction) the messages are
+// written atomically with the other writes of the transaction.
+func (s *Store) Enqueue(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	docs := make([]interface{}, len(msgs))
+	for idx, msg := range msgs {
+		msg.State = StatePending
+		if msg.CreatedAt.IsZero() {
+			msg.CreatedAt = now
+		}
+		msg.NextAttemptAt = msg.CreatedAt
+		msg.Attempts, msg.LastError, msg.Claim, msg.PublishedAt = 0, "", "", nil
+		docs[idx] = msg
+	}
+	_, err := s.Collection.InsertMany(ctx, docs)
+	return err
+}
+
+// WithTransaction runs fn in a transaction of the client, retried as the
+// driver retries transient transaction errors, so fn must only write through
+// the context it is given. Called within a transaction fn joins it, so the
+// writes of nested callers commit together. Transactions need a replica set or
+// a sharded cluster.
+func WithTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// Claim leases up to limit messages due now to the caller for the lease
+// duration, oldest due first. Another relay cannot claim them until the
+// lease runs out, when they are due again if they were not settled.
+func (s *Store) Claim(ctx context.Context, limit int, lease time.Duration) (string, []Message, error) {
+	now := time.Now().UTC()
+	due := bson.M{"state": StatePending, "next_attempt_at": bson.M{"$lte": now}}
+	cursor, err := s.Collection.Find(ctx, due, options.Find().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return "", nil, err
+	}
+	var candidates []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return "", nil, err
+	}
+	if len(candidates) == 0 {
+		return "", nil, nil
+	}
+	ids := make([]string, len(candidates))
+	for idx, candidate := range candidates {
+		ids[idx] = candidate.ID
+	}
+
+	// The due condition again, a concurrent relay may have claimed some of them
+	claim := newClaim()
+	due["_id"] = bson.M{"$in": ids}
+	if _, err := s.Collection.UpdateMany(ctx, due, bson.M{"$set": bson.M{"claim": claim, "next_attempt_at": now.Add(lease)}}); err != nil {
+		return "", nil, err
+	}
+	cursor, err = s.Collection.Find(ctx, bson.M{"claim": claim}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return "", nil, err
+	}
+	var msgs []Message
+	if err := cursor.All(ctx, &msgs); err != nil {
+		return "", nil, err
+	}
+	return claim, msgs, nil
+}
+
+// MarkPublished settles the published messages of a claim
+func (s *Store) MarkPublished(ctx context.Context, claim string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := s.Collection.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "claim": claim}, bson.M{
+		"$set":   bson.M{"state": StatePublished, "published_at": time.Now().UTC()},
+		"$unset": bson.M{"claim": ""},
+	})
+	return err
+}
+
+// MarkFailed records a failed attempt of a claimed message, retried at the
+// given time or, when retryAt is zero, failed for good
+func (s *Store) MarkFailed(ctx context.Context, claim string, msg Message, cause error, retryAt time.Time) error {
+	set := bson.M{"last_error": cause.Error()}
+	if retryAt.IsZero() {
+		set["state"] = StateFailed
+	} else {
+		set["next_attempt_at"] = retryAt.UTC()
+	}
+	_, err := s.Collection.UpdateOne(ctx, bson.M{"_id": msg.ID, "claim": claim}, bson.M{
+		"$set":   set,
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"claim": ""},
+	})
+	return err
+}
+
+// Retry makes a failed message pending again with its attempts reset
+func (s *Store) Retry(ctx context.Context, id string) (bool, error) {
+	result, err := s.Collection.UpdateOne(ctx, bson.M{"_id": id, "state": StateFailed}, bson.M{
+		"$set": bson.M{"state": StatePending, "attempts": 0, "next_attempt_at": time.Now().UTC()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Cleanup deletes the messages published before the cutoff
+func (s *Store) Cleanup(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.Collection.DeleteMany(ctx, bson.M{"state": StatePublished, "published_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// Count returns the number of messages in a state
+func (s *Store) Count(ctx context.Context, state string) (int64, error) {
+	return s.Collection.CountDocuments(ctx, bson.M{"state": state})
+}
+
+func newClaim() string {
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
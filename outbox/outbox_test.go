@@ -0,0 +1,40 @@
+package outbox
+
+import (
+	// Go Internal Packages
+	"context"
+	"testing"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestWithTransactionJoins(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("cannot create mongo client: %v", err)
+	}
+	defer func() { _ = client.Disconnect(context.Background()) }()
+	session, err := client.StartSession()
+	if err != nil {
+		t.Fatalf("cannot start mongo session: %v", err)
+	}
+	defer session.EndSession(context.Background())
+	outer := mongo.NewSessionContext(context.Background(), session)
+
+	// Nested calls run in the transaction of the outer one, without a server round trip
+	calls := 0
+	err = WithTransaction(outer, client, func(ctx context.Context) error {
+		return WithTransaction(ctx, client, func(ctx context.Context) error {
+			calls++
+			if mongo.SessionFromContext(ctx) != session {
+				t.Error("nested call does not run in the outer session")
+			}
+			return nil
+		})
+	})
+	if err != nil || calls != 1 {
+		t.Fatalf("WithTransaction() = %v after %d calls, want nil after 1", err, calls)
+	}
+}
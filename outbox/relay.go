@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Publisher sends messages on, the result holds the error of each message,
+// nil for the ones that went out. See KafkaPublisher.
+type Publisher interface {
+	Publish(ctx context.Context, msgs []Message) []error
+}
+
+type RelayConfig struct {
+	BatchSize       int           // Messages claimed per round
+	Interval        time.Duration // Between two rounds once the outbox is drained
+	Lease           time.Duration // A claim not settled within it is published again
+	MaxAttempts     int           // Before a message is failed for good, zero retries forever
+	BaseDelay       time.Duration // Before the first retry, doubled on every further attempt
+	MaxDelay        time.Duration
+	Retention       time.Duration // Published messages are kept this long, zero keeps them
+	CleanupInterval time.Duration // Also refreshes the backlog metrics, zero never cleans up
+}
+
+// Relay publishes the pending messages of the outbox. Each round claims a
+// batch, publishes it and settles every message, a message that fails is
+// retried with backoff after the later ones. A crash before the settlement
+// publishes the batch again once the lease ran out, so delivery is at least
+// once, and in order only as long as nothing is retried. Several relays can
+// share an outbox, they claim separate batches.
+type Relay struct {
+	Store     *Store
+	Publisher Publisher
+	Logger    *zap.Logger
+	Config    RelayConfig
+	Metrics   *Metrics
+}
+
+func NewRelay(store *Store, publisher Publisher, logger *zap.Logger, conf RelayConfig, relayMetrics *Metrics) *Relay {
+	return &Relay{Store: store, Publisher: publisher, Logger: logger, Config: conf, Metrics: relayMetrics}
+}
+
+// Run relays the messages and cleans up the published ones until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	var lastCleanup time.Time
+	for {
+		if r.Config.CleanupInterval > 0 && time.Since(lastCleanup) >= r.Config.CleanupInterval {
+			lastCleanup = time.Now()
+			r.cleanup(ctx)
+		}
+
+		relayed, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.Logger.Error("failed to relay outbox messages, retrying on the next round", zap.Error(err))
+		}
+		if err == nil && relayed == r.Config.BatchSize {
+			continue // More are due, no need to wait
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.Config.Interval):
+		}
+	}
+}
+
+// RelayOnce claims, publishes and settles one batch, it returns the number
+// of messages it claimed
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	claim, msgs, err := r.Store.Claim(ctx, r.Config.BatchSize, r.Config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim messages: %v", err)
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	// The lease bounds the publish, so the claim is settled before it runs out
+	publishCtx, cancel := context.WithTimeout(ctx, r.Config.Lease)
+	errs := r.Publisher.Publish(publishCtx, msgs)
+	cancel()
+
+	now := time.Now()
+	var published []string
+	for idx, msg := range msgs {
+		var cause error
+		if idx < len(errs) {
+			cause = errs[idx]
+		} else {
+			cause = fmt.Errorf("the publisher returned no result")
+		}
+		if cause == nil {
+			published = append(published, msg.ID)
+			r.Metrics.Published(msg.Topic, now.Sub(msg.CreatedAt).Seconds())
+			continue
+		}
+		retryAt := now.Add(r.backoff(msg.Attempts))
+		outcome := OutcomeRetried
+		if r.Config.MaxAttempts > 0 && msg.Attempts+1 >= r.Config.MaxAttempts {
+			retryAt, outcome = time.Time{}, OutcomeFailed
+			r.Logger.Error("outbox message is out of attempts", zap.String("id", msg.ID), zap.String("topic", msg.Topic),
+				zap.Int("attempts", msg.Attempts+1), zap.Error(cause))
+		}
+		r.Metrics.Failed(msg.Topic, outcome)
+		if err := r.Store.MarkFailed(ctx, claim, msg, cause, retryAt); err != nil {
+			return len(msgs), fmt.Errorf("failed to record the failure of %s: %v", msg.ID, err)
+		}
+	}
+	if err := r.Store.MarkPublished(ctx, claim, published); err != nil {
+		return len(msgs), fmt.Errorf("failed to settle published messages, they are published again: %v", err)
+	}
+	return len(msgs), nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.Config.BaseDelay
+	for range attempts {
+		if delay >= r.Config.MaxDelay {
+			break
+		}
+		delay *= 2
+	}
+	return min(delay, r.Config.MaxDelay)
+}
+
+// cleanup deletes the messages past the retention and counts the rest
+func (r *Relay) cleanup(ctx context.Context) {
+	if r.Config.Retention > 0 {
+		deleted, err := r.Store.Cleanup(ctx, time.Now().Add(-r.Config.Retention))
+		switch {
+		case err != nil && ctx.Err() == nil:
+			r.Logger.Error("failed to clean up the outbox", zap.Error(err))
+		case deleted > 0:
+			r.Logger.Info("cleaned up published outbox messages", zap.Int64("deleted", deleted))
+		}
+	}
+	if r.Metrics == nil {
+		return
+	}
+	for _, state := range []string{StatePending, StateFailed} {
+		n, err := r.Store.Count(ctx, state)
+		if err != nil {
+			return
+		}
+		r.Metrics.Backlog(state, n)
+	}
+}
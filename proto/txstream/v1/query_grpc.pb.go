@@ -0,0 +1,207 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: txstream/v1/query.proto
+
+// Read-only queries of the processed transactions, for internal services
+// that should not depend on the mongo schema
+
+package txstreamv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TransactionQueryService_GetTransaction_FullMethodName      = "/txstream.v1.TransactionQueryService/GetTransaction"
+	TransactionQueryService_ListTransactions_FullMethodName    = "/txstream.v1.TransactionQueryService/ListTransactions"
+	TransactionQueryService_GetProcessingStatus_FullMethodName = "/txstream.v1.TransactionQueryService/GetProcessingStatus"
+)
+
+// TransactionQueryServiceClient is the client API for TransactionQueryService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TransactionQueryServiceClient interface {
+	// GetTransaction returns a processed transaction, NOT_FOUND when it is not stored
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error)
+	// ListTransactions pages through the processed transactions in id order
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error)
+	// GetProcessingStatus tells for each id whether it was processed and which sinks refused it
+	GetProcessingStatus(ctx context.Context, in *GetProcessingStatusRequest, opts ...grpc.CallOption) (*GetProcessingStatusResponse, error)
+}
+
+type transactionQueryServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTransactionQueryServiceClient(cc grpc.ClientConnInterface) TransactionQueryServiceClient {
+	return &transactionQueryServiceClient{cc}
+}
+
+func (c *transactionQueryServiceClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTransactionResponse)
+	err := c.cc.Invoke(ctx, TransactionQueryService_GetTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionQueryServiceClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTransactionsResponse)
+	err := c.cc.Invoke(ctx, TransactionQueryService_ListTransactions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *transactionQueryServiceClient) GetProcessingStatus(ctx context.Context, in *GetProcessingStatusRequest, opts ...grpc.CallOption) (*GetProcessingStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProcessingStatusResponse)
+	err := c.cc.Invoke(ctx, TransactionQueryService_GetProcessingStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TransactionQueryServiceServer is the server API for TransactionQueryService service.
+// All implementations must embed UnimplementedTransactionQueryServiceServer
+// for forward compatibility.
+type TransactionQueryServiceServer interface {
+	// GetTransaction returns a processed transaction, NOT_FOUND when it is not stored
+	GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error)
+	// ListTransactions pages through the processed transactions in id order
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	// GetProcessingStatus tells for each id whether it was processed and which sinks refused it
+	GetProcessingStatus(context.Context, *GetProcessingStatusRequest) (*GetProcessingStatusResponse, error)
+	mustEmbedUnimplementedTransactionQueryServiceServer()
+}
+
+// UnimplementedTransactionQueryServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTransactionQueryServiceServer struct{}
+
+func (UnimplementedTransactionQueryServiceServer) GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedTransactionQueryServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (UnimplementedTransactionQueryServiceServer) GetProcessingStatus(context.Context, *GetProcessingStatusRequest) (*GetProcessingStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProcessingStatus not implemented")
+}
+func (UnimplementedTransactionQueryServiceServer) mustEmbedUnimplementedTransactionQueryServiceServer() {
+}
+func (UnimplementedTransactionQueryServiceServer) testEmbeddedByValue() {}
+
+// UnsafeTransactionQueryServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TransactionQueryServiceServer will
+// result in compilation errors.
+type UnsafeTransactionQueryServiceServer interface {
+	mustEmbedUnimplementedTransactionQueryServiceServer()
+}
+
+func RegisterTransactionQueryServiceServer(s grpc.ServiceRegistrar, srv TransactionQueryServiceServer) {
+	// If the following call pancis, it indicates UnimplementedTransactionQueryServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TransactionQueryService_ServiceDesc, srv)
+}
+
+func _TransactionQueryService_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionQueryServiceServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionQueryService_GetTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionQueryServiceServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionQueryService_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionQueryServiceServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionQueryService_ListTransactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionQueryServiceServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TransactionQueryService_GetProcessingStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProcessingStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TransactionQueryServiceServer).GetProcessingStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TransactionQueryService_GetProcessingStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TransactionQueryServiceServer).GetProcessingStatus(ctx, req.(*GetProcessingStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TransactionQueryService_ServiceDesc is the grpc.ServiceDesc for TransactionQueryService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TransactionQueryService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "txstream.v1.TransactionQueryService",
+	HandlerType: (*TransactionQueryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetTransaction",
+			Handler:    _TransactionQueryService_GetTransaction_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _TransactionQueryService_ListTransactions_Handler,
+		},
+		{
+			MethodName: "GetProcessingStatus",
+			Handler:    _TransactionQueryService_GetProcessingStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "txstream/v1/query.proto",
+}
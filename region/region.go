@@ -0,0 +1,234 @@
+package region
+
+import (
+	// Go Internal Packages
+	"context"
+	"sync"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Roles of a region
+const (
+	RoleActive  = "active"
+	RoleStandby = "standby"
+)
+
+// Store is the record of the active region shared by the regions, see redis.RegionStore
+type Store interface {
+	Active(ctx context.Context) (string, error) // Empty while unset
+	Beat(ctx context.Context, region string, ttl time.Duration) error
+	Alive(ctx context.Context, region string) (bool, error)
+	TakeOver(ctx context.Context, from, to string) (bool, error)
+}
+
+// State is the role of this replica's region
+type State struct {
+	Region string    `json:"region"`
+	Active string    `json:"active"` // The active region as last read
+	Role   string    `json:"role"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// Region keeps the replicas of a standby region joined to their consumer
+// groups with fetching paused, so they take over without a cold start once
+// their region becomes the active one. The active region comes from the config
+// or from the Store every Interval. With FailoverAfter set the active region
+// beats in the Store and a standby region takes over once the beats stopped
+// for that long, while an active region that cannot beat steps down to
+// standby an Interval before its last beat expires, so two regions never
+// consume at once.
+type Region struct {
+	Name          string
+	Store         Store // Nil for the active region of the config
+	Logger        *zap.Logger
+	Metrics       *metrics.RegionMetrics
+	Interval      time.Duration
+	FailoverAfter time.Duration // Zero never fails over on its own
+
+	mu         sync.Mutex
+	configured string // Active region of the config, the default while the store is unset
+	state      State
+	watchers   []func(standby bool)
+	lastBeat   time.Time
+}
+
+func New(name, active string, store Store, logger *zap.Logger, regionMetrics *metrics.RegionMetrics,
+	interval, failoverAfter time.Duration) *Region {
+	r := &Region{Name: name, Store: store, Logger: logger, Metrics: regionMetrics, Interval: interval,
+		FailoverAfter: failoverAfter, configured: active, lastBeat: time.Now()}
+	r.state = State{Region: name, Active: active, Role: r.role(active), Reason: "config", Since: time.Now()}
+	r.Metrics.SetRole(r.state.Role, false)
+	return r
+}
+
+// Standby reports whether this replica's region is on standby, a nil region never is
+func (r *Region) Standby() bool {
+	return r != nil && r.State().Role == RoleStandby
+}
+
+// State returns the role of the region
+func (r *Region) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Watch calls fn on every change of the role, and right away on standby
+func (r *Region) Watch(fn func(standby bool)) {
+	r.mu.Lock()
+	r.watchers = append(r.watchers, fn)
+	standby := r.state.Role == RoleStandby
+	r.mu.Unlock()
+	if standby {
+		fn(true)
+	}
+}
+
+// SetConfigured replaces the active region of the config, applied right
+// away without a store and as the default while the store is unset otherwise
+func (r *Region) SetConfigured(active string) {
+	r.mu.Lock()
+	r.configured = active
+	r.mu.Unlock()
+	if r.Store == nil {
+		r.set(active, "config")
+	}
+}
+
+// Run follows the store until the context is canceled, without a store it
+// returns right away
+func (r *Region) Run(ctx context.Context) {
+	if r.Store == nil {
+		return
+	}
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		r.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads the store once and follows it
+func (r *Region) Check(ctx context.Context) {
+	stored, err := r.Store.Active(ctx)
+	if err != nil {
+		r.failed(ctx, "cannot read the active region", err)
+		return
+	}
+	active := stored
+	if active == "" {
+		r.mu.Lock()
+		active = r.configured
+		r.mu.Unlock()
+	}
+
+	if active == r.Name {
+		if r.FailoverAfter > 0 {
+			// The beat lives FailoverAfter from before it was sent at the latest
+			beatAt := time.Now()
+			if err := r.Store.Beat(ctx, r.Name, r.FailoverAfter); err != nil {
+				r.failed(ctx, "cannot beat for the active region", err)
+				return
+			}
+			r.mu.Lock()
+			r.lastBeat = beatAt
+			r.mu.Unlock()
+		}
+		r.set(active, "store")
+		return
+	}
+
+	r.set(active, "store")
+	if r.FailoverAfter <= 0 {
+		return
+	}
+	alive, err := r.Store.Alive(ctx, active)
+	if err != nil {
+		r.failed(ctx, "cannot read the heartbeat of the active region", err)
+		return
+	}
+	if alive {
+		return
+	}
+	taken, err := r.Store.TakeOver(ctx, stored, r.Name)
+	if err != nil {
+		r.failed(ctx, "cannot take over from the active region", err)
+		return
+	}
+	if taken {
+		r.Logger.Warn("active region missed its heartbeats, taking over",
+			zap.String("from", active), zap.Duration("failover_after", r.FailoverAfter))
+		beatAt := time.Now()
+		if err := r.Store.Beat(ctx, r.Name, r.FailoverAfter); err == nil {
+			r.mu.Lock()
+			r.lastBeat = beatAt
+			r.mu.Unlock()
+		}
+		r.set(r.Name, "failover")
+	}
+}
+
+// failed keeps the role, but an active region whose last beat would expire
+// before its next check steps down, an Interval ahead of a standby region
+// taking over
+func (r *Region) failed(ctx context.Context, msg string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	r.Metrics.CheckFailed()
+	r.Logger.Warn(msg+", keeping the role", zap.Error(err))
+	r.mu.Lock()
+	fenced := r.FailoverAfter > 0 && r.state.Role == RoleActive && time.Since(r.lastBeat)+r.Interval >= r.FailoverAfter
+	r.mu.Unlock()
+	if fenced {
+		r.Logger.Error("last heartbeat expires before the next check, stepping down to standby", zap.Error(err))
+		r.set("", "heartbeats lost")
+	}
+}
+
+func (r *Region) set(active, reason string) {
+	role := r.role(active)
+	r.mu.Lock()
+	changed := r.state.Role != role
+	r.state.Active = active
+	r.state.Reason = reason
+	if changed {
+		r.state.Role = role
+		r.state.Since = time.Now()
+		if role == RoleActive {
+			// A fresh activation gets a full FailoverAfter to store its first beat
+			r.lastBeat = time.Now()
+		}
+	}
+	watchers := r.watchers
+	r.mu.Unlock()
+
+	r.Metrics.SetRole(role, changed)
+	if !changed {
+		return
+	}
+	r.Logger.Warn("region role changed", zap.String("region", r.Name), zap.String("active", active),
+		zap.String("role", role), zap.String("reason", reason))
+	for _, watch := range watchers {
+		watch(role == RoleStandby)
+	}
+}
+
+func (r *Region) role(active string) string {
+	if active == r.Name {
+		return RoleActive
+	}
+	return RoleStandby
+}
@@ -0,0 +1,83 @@
+package remote
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// External Packages
+	"github.com/hashicorp/consul/api"
+)
+
+type Consul struct {
+	Client *api.Client
+	Prefix string
+}
+
+// NewConsul creates a consul client, empty endpoints and token fall back
+// to CONSUL_HTTP_ADDR and CONSUL_HTTP_TOKEN
+func NewConsul(conf *Config) (*Consul, error) {
+	cfg := api.DefaultConfig()
+	if len(conf.Endpoints) > 0 {
+		cfg.Address = conf.Endpoints[0]
+	}
+	if conf.Token != "" {
+		cfg.Token = conf.Token
+	}
+	cfg.WaitTime = 5 * time.Minute
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consul client: %v", err)
+	}
+	return &Consul{Client: client, Prefix: conf.Prefix}, nil
+}
+
+// Read returns the config keys below the prefix
+func (c *Consul) Read(ctx context.Context) (map[string]interface{}, error) {
+	values, _, err := c.list(ctx, 0)
+	return values, err
+}
+
+func (c *Consul) list(ctx context.Context, waitIndex uint64) (map[string]interface{}, uint64, error) {
+	opts := (&api.QueryOptions{WaitIndex: waitIndex}).WithContext(ctx)
+	pairs, meta, err := c.Client.KV().List(c.Prefix, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read consul keys: %v", err)
+	}
+	values := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		if key := configKey(c.Prefix, pair.Key); key != "" {
+			values[key] = string(pair.Value)
+		}
+	}
+	return values, meta.LastIndex, nil
+}
+
+// Watch long polls the prefix with blocking queries
+func (c *Consul) Watch(ctx context.Context, onChange func()) error {
+	_, index, err := c.list(ctx, 0)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		_, next, err := c.list(ctx, index)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		// The index only moves on a change, or resets when consul restores a snapshot
+		if next != index {
+			index = next
+			onChange()
+		}
+	}
+	return nil
+}
+
+func (c *Consul) Close() error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package remote
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// External Packages
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type Etcd struct {
+	Client *clientv3.Client
+	Prefix string
+}
+
+func NewEtcd(conf *Config) (*Etcd, error) {
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   conf.Endpoints,
+		Username:    conf.Username,
+		Password:    conf.Password,
+		DialTimeout: conf.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %v", err)
+	}
+	return &Etcd{Client: client, Prefix: conf.Prefix}, nil
+}
+
+// Read returns the config keys below the prefix
+func (e *Etcd) Read(ctx context.Context) (map[string]interface{}, error) {
+	resp, err := e.Client.Get(ctx, e.Prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read etcd keys: %v", err)
+	}
+	values := make(map[string]interface{}, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		if key := configKey(e.Prefix, string(kv.Key)); key != "" {
+			values[key] = string(kv.Value)
+		}
+	}
+	return values, nil
+}
+
+// Watch follows the etcd watch stream of the prefix, resuming when it breaks
+func (e *Etcd) Watch(ctx context.Context, onChange func()) error {
+	for ctx.Err() == nil {
+		for resp := range e.Client.Watch(clientv3.WithRequireLeader(ctx), e.Prefix, clientv3.WithPrefix()) {
+			if resp.Err() != nil {
+				break
+			}
+			if len(resp.Events) > 0 {
+				onChange()
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
+		}
+	}
+	return nil
+}
+
+func (e *Etcd) Close() error {
+	return e.Client.Close()
+}
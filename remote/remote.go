@@ -0,0 +1,51 @@
+package remote
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source is a key value store holding config keys below a prefix, the path
+// <prefix>kafka/brokers holds the value of kafka.brokers
+type Source interface {
+	Read(ctx context.Context) (map[string]interface{}, error)
+	// Watch calls onChange after every change below the prefix until the context is canceled
+	Watch(ctx context.Context, onChange func()) error
+	Close() error
+}
+
+type Config struct {
+	Backend   string // One of consul, etcd
+	Endpoints []string
+	Prefix    string
+	Token     string // Consul ACL token
+	Username  string // Etcd auth
+	Password  string
+	Timeout   time.Duration
+}
+
+// NewSource connects to the backend selected by conf.Backend
+func NewSource(conf *Config) (Source, error) {
+	switch conf.Backend {
+	case "consul":
+		return NewConsul(conf)
+	case "etcd":
+		return NewEtcd(conf)
+	default:
+		return nil, fmt.Errorf("unknown remote config backend %q", conf.Backend)
+	}
+}
+
+// configKey maps a store path to its config key, directories map to no key
+func configKey(prefix, path string) string {
+	if strings.HasSuffix(path, "/") {
+		return ""
+	}
+	return strings.ReplaceAll(strings.Trim(strings.TrimPrefix(path, prefix), "/"), "/", ".")
+}
+
+// retryDelay is the pause before watching again after a failed watch
+const retryDelay = 5 * time.Second
@@ -0,0 +1,106 @@
+package reporting
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/getsentry/sentry-go"
+)
+
+type Config struct {
+	DSN         string
+	Environment string
+	SampleRate  float64
+}
+
+// piiPatterns match the personal data that can end up in error messages,
+// e.g. a card number or an ip address echoed by a failed mongo write
+var piiPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b\d(?:[ -]?\d){11,18}\b`),                       // Card numbers
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), // Emails
+	regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`),                    // IPv4 addresses
+}
+
+// Scrubbed replaces the personal data in the string
+func Scrubbed(value string) string {
+	for _, pattern := range piiPatterns {
+		value = pattern.ReplaceAllString(value, "[scrubbed]")
+	}
+	return value
+}
+
+// Setup installs the global Sentry client and returns the func flushing the
+// pending events on shutdown
+func Setup(conf *Config) (func(timeout time.Duration) bool, error) {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         conf.DSN,
+		Environment: conf.Environment,
+		SampleRate:  conf.SampleRate,
+		BeforeSend:  scrub,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize sentry: %v", err)
+	}
+	return sentry.Flush, nil
+}
+
+// scrub drops the request, user and host data and masks the personal data of the messages
+func scrub(event *sentry.Event, _ *sentry.EventHint) *sentry.Event {
+	event.Request = nil
+	event.User = sentry.User{}
+	event.ServerName = ""
+	event.Message = Scrubbed(event.Message)
+	for idx := range event.Exception {
+		event.Exception[idx].Value = Scrubbed(event.Exception[idx].Value)
+	}
+	for _, crumb := range event.Breadcrumbs {
+		crumb.Message = Scrubbed(crumb.Message)
+	}
+	return event
+}
+
+// Recover reports a panic and panics again, meant to be deferred at the top
+// of a goroutine, a no-op until Setup ran
+func Recover() {
+	if r := recover(); r != nil {
+		sentry.CurrentHub().Recover(r)
+		sentry.Flush(2 * time.Second)
+		panic(r)
+	}
+}
+
+// CapturePanic reports a recovered panic the caller survives, a no-op until Setup ran
+func CapturePanic(r any) {
+	sentry.CurrentHub().Recover(r)
+}
+
+// CaptureRecords reports an error the records could not be processed past,
+// with their positions and correlation ids but never their payloads
+func CaptureRecords(err error, records []models.Record) {
+	if err == nil || len(records) == 0 {
+		return
+	}
+	sentry.WithScope(func(scope *sentry.Scope) {
+		first, last := records[0], records[len(records)-1]
+		scope.SetTags(map[string]string{
+			"topic":       first.Topic,
+			"partition":   strconv.Itoa(int(first.Partition)),
+			"error_class": errors.ErrorClass(err),
+		})
+		scope.SetContext("records", sentry.Context{
+			"count":           len(records),
+			"first_offset":    first.Offset,
+			"last_offset":     last.Offset,
+			"correlation_ids": models.CorrelationIDs(records),
+		})
+		sentry.CaptureException(err)
+	})
+}
@@ -0,0 +1,249 @@
+package clickhouse
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+type Config struct {
+	URL           string // HTTP interface, e.g. http://localhost:8123
+	Database      string
+	Table         string
+	Username      string
+	Password      string
+	BatchSize     int           // Rows buffered before an insert
+	FlushInterval time.Duration // Longest a row waits in the buffer
+	Timeout       time.Duration
+}
+
+// Row is a transaction as stored in the table, flattened with its provenance
+type Row struct {
+	TxID            string    `json:"transaction_id"`
+	Amount          float32   `json:"amount"`
+	Currency        string    `json:"currency"`
+	TransactionType string    `json:"transaction_type"`
+	Status          string    `json:"status"`
+	Timestamp       string    `json:"timestamp"`
+	PaymentMethod   string    `json:"payment_method"`
+	Topic           string    `json:"topic"`
+	Partition       int32     `json:"partition"`
+	Offset          int64     `json:"offset"`
+	ProcessedAt     time.Time `json:"processed_at"`
+}
+
+func newRow(tx models.MongoTransaction) Row {
+	row := Row{
+		TxID:            tx.TxID,
+		Amount:          tx.Amount,
+		Currency:        tx.Currency,
+		TransactionType: tx.TransactionType,
+		Status:          tx.Status,
+		Timestamp:       tx.Timestamp,
+		PaymentMethod:   tx.PaymentMethod,
+		ProcessedAt:     time.Now().UTC(),
+	}
+	if p := tx.Provenance; p != nil {
+		row.Topic, row.Partition, row.Offset, row.ProcessedAt = p.Topic, p.Partition, p.Offset, p.ProcessedAt.UTC()
+	}
+	return row
+}
+
+// TxRepository inserts the transactions into a ClickHouse table for the
+// analytics dashboards. ClickHouse wants few large inserts, so rows are
+// buffered and inserted once BatchSize of them are waiting or the oldest has
+// waited FlushInterval. The table is a ReplacingMergeTree on transaction_id,
+// redeliveries collapse on merge, and a crash loses at most a flush interval
+// of rows, which a replay of the window restores.
+type TxRepository struct {
+	Config *Config
+	Client *http.Client
+	Logger *zap.Logger
+
+	mu     sync.Mutex
+	rows   []Row
+	oldest time.Time
+}
+
+func NewTxRepository(conf *Config, logger *zap.Logger) *TxRepository {
+	return &TxRepository{Config: conf, Client: &http.Client{Timeout: conf.Timeout}, Logger: logger}
+}
+
+// EnsureSchema creates the table unless it exists
+func (r *TxRepository) EnsureSchema(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	transaction_id String,
+	amount Float32,
+	currency LowCardinality(String),
+	transaction_type LowCardinality(String),
+	status LowCardinality(String),
+	timestamp String,
+	payment_method LowCardinality(String),
+	topic LowCardinality(String),
+	"partition" Int32,
+	"offset" Int64,
+	processed_at DateTime64(3, 'UTC')
+) ENGINE = ReplacingMergeTree(processed_at)
+PARTITION BY toYYYYMM(processed_at)
+ORDER BY transaction_id`, r.table())
+	if err := r.exec(ctx, query, nil); err != nil {
+		return fmt.Errorf("failed to create table %s: %v", r.table(), err)
+	}
+	return nil
+}
+
+// Ping checks that the server answers
+func (r *TxRepository) Ping(ctx context.Context) error {
+	return r.exec(ctx, "SELECT 1", nil)
+}
+
+// InsertTransactions buffers a batch, inserting the buffer once it is full
+func (r *TxRepository) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "clickhouse", "clickhouse.insert",
+		attribute.String("db.sql.table", r.table()), attribute.Int("db.rows", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	rows := make([]Row, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot insert a %T", tx)
+		}
+		rows = append(rows, newRow(doc))
+	}
+	return r.add(ctx, rows)
+}
+
+// InsertTransaction buffers a single transaction
+func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "clickhouse", "clickhouse.insert", attribute.String("db.sql.table", r.table()), attribute.Int("db.rows", 1))
+	defer func() { tracing.End(span, err) }()
+	return r.add(ctx, []Row{newRow(tx)})
+}
+
+// add takes the rows of the batch back out when the insert fails, so the
+// redelivered batch is not buffered twice
+func (r *TxRepository) add(ctx context.Context, rows []Row) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before := len(r.rows)
+	if before == 0 {
+		r.oldest = time.Now()
+	}
+	r.rows = append(r.rows, rows...)
+	if len(r.rows) < r.Config.BatchSize {
+		return nil
+	}
+	if err := r.insert(ctx); err != nil {
+		r.rows = r.rows[:before]
+		return err
+	}
+	return nil
+}
+
+// Run inserts the buffer once its oldest row waited the flush interval, the
+// async half of the flush
+func (r *TxRepository) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(r.Config.FlushInterval/4, 100*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		if len(r.rows) > 0 && time.Since(r.oldest) >= r.Config.FlushInterval {
+			if err := r.insert(ctx); err != nil {
+				r.Logger.Error("failed to flush the clickhouse buffer, retrying on the next tick", zap.Int("rows", len(r.rows)), zap.Error(err))
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Flush inserts whatever is buffered
+func (r *TxRepository) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.insert(ctx)
+}
+
+// Close inserts what is still buffered and releases the idle connections
+func (r *TxRepository) Close(ctx context.Context) error {
+	err := r.Flush(ctx)
+	r.Client.CloseIdleConnections()
+	return err
+}
+
+// insert sends the buffer as one JSONEachRow insert, the caller holds mu
+func (r *TxRepository) insert(ctx context.Context) error {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, row := range r.rows {
+		if err := encoder.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %v", row.TxID, err)
+		}
+	}
+	if err := r.exec(ctx, "INSERT INTO "+r.table()+" FORMAT JSONEachRow", body.Bytes()); err != nil {
+		return fmt.Errorf("failed to insert %d rows into %s: %v", len(r.rows), r.table(), err)
+	}
+	r.rows = r.rows[:0]
+	return nil
+}
+
+// exec runs the query, with the insert data as the body when there is some
+func (r *TxRepository) exec(ctx context.Context, query string, data []byte) error {
+	params := url.Values{}
+	params.Set("database", r.Config.Database)
+	params.Set("date_time_input_format", "best_effort") // RFC3339 processed_at
+	body := data
+	if data == nil {
+		body = []byte(query)
+	} else {
+		params.Set("query", query)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(r.Config.URL, "/")+"/?"+params.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if r.Config.Username != "" {
+		req.Header.Set("X-ClickHouse-User", r.Config.Username)
+		req.Header.Set("X-ClickHouse-Key", r.Config.Password)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("clickhouse responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (r *TxRepository) table() string {
+	return r.Config.Database + "." + r.Config.Table
+}
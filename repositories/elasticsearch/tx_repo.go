@@ -0,0 +1,249 @@
+package elasticsearch
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Config struct {
+	Addresses    []string // Tried in order, the next one is used when a node is unreachable
+	Username     string
+	Password     string
+	APIKey       string // Takes precedence over the basic auth credentials
+	Index        string
+	Template     string // Name of the index template matching Index*
+	MaxRetries   int    // Retries of the documents rejected with 429 before failing the batch
+	RetryBackoff time.Duration
+	Timeout      time.Duration
+}
+
+// TxRepository indexes the transactions through the bulk API of
+// Elasticsearch or OpenSearch, one document per transaction id, so support
+// can search them without querying mongo. Redeliveries replace the document.
+type TxRepository struct {
+	Config *Config
+	Client *http.Client
+}
+
+func NewTxRepository(conf *Config) *TxRepository {
+	return &TxRepository{Config: conf, Client: &http.Client{Timeout: conf.Timeout}}
+}
+
+// indexTemplate maps the identifiers as keywords and leaves the other strings
+// to the default dynamic mapping, text with a keyword subfield, for full text search
+const indexTemplate = `{
+  "index_patterns": [%q],
+  "template": {
+    "mappings": {
+      "properties": {
+        "transaction_id": {"type": "keyword"},
+        "amount": {"type": "float"},
+        "currency": {"type": "keyword"},
+        "transaction_type": {"type": "keyword"},
+        "status": {"type": "keyword"},
+        "payment_method": {"type": "keyword"},
+        "traceparent": {"type": "keyword", "index": false},
+        "tracestate": {"type": "keyword", "index": false},
+        "provenance": {
+          "properties": {
+            "topic": {"type": "keyword"},
+            "partition": {"type": "integer"},
+            "offset": {"type": "long"},
+            "consumer": {"type": "keyword"},
+            "processed_at": {"type": "date"}
+          }
+        }
+      }
+    }
+  }
+}`
+
+// EnsureTemplate creates or updates the index template, which applies to
+// the indices created from now on
+func (r *TxRepository) EnsureTemplate(ctx context.Context) error {
+	body := fmt.Sprintf(indexTemplate, r.Config.Index+"*")
+	resp, err := r.do(ctx, http.MethodPut, "/_index_template/"+r.Config.Template, "application/json", []byte(body))
+	if err != nil {
+		return fmt.Errorf("failed to put index template %s: %v", r.Config.Template, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("index template %s refused with status %d: %s", r.Config.Template, resp.StatusCode, detail)
+	}
+	return nil
+}
+
+// Ping checks that a node answers
+func (r *TxRepository) Ping(ctx context.Context) error {
+	resp, err := r.do(ctx, http.MethodGet, "/", "", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("elasticsearch responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// InsertTransactions indexes a batch of transactions
+func (r *TxRepository) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "elasticsearch", "elasticsearch.bulk",
+		attribute.String("db.elasticsearch.index", r.Config.Index), attribute.Int("db.documents", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	docs := make([]models.MongoTransaction, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot index a %T", tx)
+		}
+		docs = append(docs, doc)
+	}
+	return r.bulk(ctx, docs)
+}
+
+// InsertTransaction indexes a single transaction
+func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "elasticsearch", "elasticsearch.bulk",
+		attribute.String("db.elasticsearch.index", r.Config.Index), attribute.Int("db.documents", 1))
+	defer func() { tracing.End(span, err) }()
+	return r.bulk(ctx, []models.MongoTransaction{tx})
+}
+
+// Close releases the idle connections
+func (r *TxRepository) Close(context.Context) error {
+	r.Client.CloseIdleConnections()
+	return nil
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk indexes the documents, resending those rejected with 429 with a
+// growing backoff. Any other rejection fails the batch.
+func (r *TxRepository) bulk(ctx context.Context, docs []models.MongoTransaction) error {
+	pending := docs
+	for attempt := 0; ; attempt++ {
+		if len(pending) == 0 {
+			return nil
+		}
+		if attempt > r.Config.MaxRetries {
+			return fmt.Errorf("%d documents still rejected with 429 after %d retries", len(pending), r.Config.MaxRetries)
+		}
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.Config.RetryBackoff * time.Duration(1<<(attempt-1))):
+			}
+		}
+
+		body, err := bulkBody(r.Config.Index, pending)
+		if err != nil {
+			return err
+		}
+		resp, err := r.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body)
+		if err != nil {
+			return fmt.Errorf("failed to send bulk request: %v", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			continue
+		}
+		var result bulkResponse
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("bulk request refused with status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode bulk response: %v", err)
+		}
+		if !result.Errors {
+			return nil
+		}
+
+		var throttled []models.MongoTransaction
+		for idx, item := range result.Items {
+			for _, outcome := range item {
+				switch {
+				case outcome.Status == http.StatusTooManyRequests && idx < len(pending):
+					throttled = append(throttled, pending[idx])
+				case outcome.Error != nil:
+					return fmt.Errorf("document %s rejected: %s: %s", pending[idx].TxID, outcome.Error.Type, outcome.Error.Reason)
+				}
+			}
+		}
+		pending = throttled
+	}
+}
+
+func bulkBody(index string, docs []models.MongoTransaction) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		action := map[string]map[string]string{"index": {"_index": index, "_id": doc.TxID}}
+		if err := encoder.Encode(action); err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode transaction %s: %v", doc.TxID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// do sends the request to the first address that answers
+func (r *TxRepository) do(ctx context.Context, method, path, contentType string, body []byte) (*http.Response, error) {
+	var lastErr error
+	for _, address := range r.Config.Addresses {
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(address, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		switch {
+		case r.Config.APIKey != "":
+			req.Header.Set("Authorization", "ApiKey "+r.Config.APIKey)
+		case r.Config.Username != "":
+			req.SetBasicAuth(r.Config.Username, r.Config.Password)
+		}
+		resp, err := r.Client.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,190 @@
+package file
+
+import (
+	// Go Internal Packages
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// DeadLetterQueue appends failed records as NDJSON envelopes to a local file.
+// It is meant for development and single-instance deployments, every read
+// scans the whole file.
+type DeadLetterQueue struct {
+	Path   string
+	Logger *zap.Logger
+	mu     sync.Mutex
+}
+
+func NewDeadLetterQueue(path string, logger *zap.Logger) *DeadLetterQueue {
+	return &DeadLetterQueue{Path: path, Logger: logger}
+}
+
+// Send appends all failed records to the file.
+// A record that is already dead-lettered has its attempt count accumulated.
+func (r *DeadLetterQueue) Send(_ context.Context, records []models.Record, cause error, attempts int) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, _, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	entries := models.NewDLQEntries(records, cause, attempts)
+	for idx, entry := range entries {
+		if prev, ok := existing[entry.ID]; ok {
+			entries[idx].Attempts += prev.Attempts
+			entries[idx].FirstFailedAt = prev.FirstFailedAt
+		}
+	}
+	return r.appendEntries(entries)
+}
+
+// Put stores the entries as they are, replacing any entry with the same id
+func (r *DeadLetterQueue) Put(_ context.Context, entries []models.DLQEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.appendEntries(entries)
+}
+
+func (r *DeadLetterQueue) appendEntries(entries []models.DLQEntry) error {
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return f.Sync()
+}
+
+// load reads the file, later lines for the same id replace earlier ones.
+// The returned ids are ordered newest first.
+func (r *DeadLetterQueue) load() (map[string]models.DLQEntry, []string, error) {
+	entries := map[string]models.DLQEntry{}
+	var order []string
+
+	f, err := os.Open(r.Path)
+	if os.IsNotExist(err) {
+		return entries, order, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry models.DLQEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			r.Logger.Warn("skipping malformed dlq line", zap.Error(err))
+			continue
+		}
+		if _, ok := entries[entry.ID]; !ok {
+			order = append(order, entry.ID)
+		}
+		entries[entry.ID] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return entries, order, nil
+}
+
+// List returns a page of entries, newest first
+func (r *DeadLetterQueue) List(_ context.Context, offset, limit int64) (models.DLQPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	page := models.DLQPage{Entries: []models.DLQEntry{}, Offset: offset, Limit: limit}
+	entries, order, err := r.load()
+	if err != nil {
+		return page, err
+	}
+
+	page.Total = int64(len(order))
+	for idx := offset; idx < offset+limit && idx < page.Total; idx++ {
+		page.Entries = append(page.Entries, entries[order[idx]])
+	}
+	return page, nil
+}
+
+// Get returns a single entry by its id
+func (r *DeadLetterQueue) Get(_ context.Context, id string) (models.DLQEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, _, err := r.load()
+	if err != nil {
+		return models.DLQEntry{}, err
+	}
+	entry, ok := entries[id]
+	if !ok {
+		return entry, errors.E(errors.NotFound, "dlq entry not found")
+	}
+	return entry, nil
+}
+
+// Delete removes a single entry by rewriting the file without it
+func (r *DeadLetterQueue) Delete(_ context.Context, id, _ string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, order, err := r.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[id]; !ok {
+		return errors.E(errors.NotFound, "dlq entry not found")
+	}
+
+	tmp := r.Path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	// Rewrite oldest first so the append order is preserved
+	enc := json.NewEncoder(f)
+	for idx := len(order) - 1; idx >= 0; idx-- {
+		if order[idx] == id {
+			continue
+		}
+		if err := enc.Encode(entries[order[idx]]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.Path)
+}
@@ -0,0 +1,90 @@
+package fxrates
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+)
+
+// HTTPSource reads the exchange rates from a JSON api such as
+// openexchangerates or frankfurter, answering
+//
+//	{"base": "USD", "rates": {"EUR": 0.92, ...}, "timestamp": 1700000000}
+//
+// with either a unix timestamp or a "2006-01-02" date. A {base} in the URL is
+// replaced by the base currency.
+type HTTPSource struct {
+	URL          string
+	APIKey       string // Sent in APIKeyHeader when set
+	APIKeyHeader string
+	Client       *http.Client
+}
+
+func NewHTTPSource(url, apiKey, apiKeyHeader string, timeout time.Duration) *HTTPSource {
+	return &HTTPSource{URL: url, APIKey: apiKey, APIKeyHeader: apiKeyHeader, Client: &http.Client{Timeout: timeout}}
+}
+
+type ratesResponse struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	Timestamp int64              `json:"timestamp"`
+	Date      string             `json:"date"`
+}
+
+// Rates fetches the rates of the base currency
+func (s *HTTPSource) Rates(ctx context.Context, base string) (models.FXRates, error) {
+	endpoint := strings.ReplaceAll(s.URL, "{base}", url.PathEscape(base))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return models.FXRates{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set(s.APIKeyHeader, s.APIKey)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return models.FXRates{}, fmt.Errorf("failed to fetch exchange rates: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return models.FXRates{}, fmt.Errorf("exchange rates source returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var decoded ratesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return models.FXRates{}, fmt.Errorf("failed to decode exchange rates: %v", err)
+	}
+	if len(decoded.Rates) == 0 {
+		return models.FXRates{}, fmt.Errorf("exchange rates source returned no rates")
+	}
+
+	now := time.Now().UTC()
+	rates := models.FXRates{Base: strings.ToUpper(decoded.Base), Rates: make(map[string]float64, len(decoded.Rates)),
+		AsOf: now, FetchedAt: now}
+	if rates.Base == "" {
+		rates.Base = strings.ToUpper(base)
+	}
+	for currency, rate := range decoded.Rates {
+		rates.Rates[strings.ToUpper(currency)] = rate
+	}
+	switch {
+	case decoded.Timestamp > 0:
+		rates.AsOf = time.Unix(decoded.Timestamp, 0).UTC()
+	case decoded.Date != "":
+		if date, err := time.Parse(time.DateOnly, decoded.Date); err == nil {
+			rates.AsOf = date
+		}
+	}
+	return rates, nil
+}
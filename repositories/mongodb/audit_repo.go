@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	// Go Internal Packages
+	"context"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type OffsetAudit struct {
+	Client     *mongo.Client
+	Collection string
+}
+
+func NewOffsetAudit(client *mongo.Client, collection string) *OffsetAudit {
+	return &OffsetAudit{Client: client, Collection: collection}
+}
+
+func (r *OffsetAudit) collection() *mongo.Collection {
+	return r.Client.Database("mybase").Collection(r.Collection)
+}
+
+// EnsureIndexes indexes the ranges by partition and offset for lookups and,
+// with a positive retention, expires them after it
+func (r *OffsetAudit) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	indexes := []mongo.IndexModel{{
+		Keys: bson.D{{Key: "topic", Value: 1}, {Key: "partition", Value: 1}, {Key: "first_offset", Value: 1}},
+	}}
+	if retention > 0 {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys:    bson.D{{Key: "committed_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+		})
+	}
+	_, err := r.collection().Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Record inserts the committed offset ranges
+func (r *OffsetAudit) Record(ctx context.Context, ranges []models.OffsetRange) error {
+	if len(ranges) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(ranges))
+	for idx, offsetRange := range ranges {
+		docs[idx] = offsetRange
+	}
+	_, err := r.collection().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
@@ -25,6 +25,7 @@ func Connect(ctx context.Context, uri string) (*mongo.Client, error) {
 	// Ping the MongoDB server to verify the connection.
 	pingErr := client.Ping(ctx, nil)
 	if pingErr != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, pingErr
 	}
 
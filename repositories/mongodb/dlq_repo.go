@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeadLetterQueue struct {
+	Client     *mongo.Client
+	Collection string
+}
+
+func NewDeadLetterQueue(client *mongo.Client, collection string) *DeadLetterQueue {
+	return &DeadLetterQueue{Client: client, Collection: collection}
+}
+
+func (r *DeadLetterQueue) collection() *mongo.Collection {
+	return r.Client.Database("mybase").Collection(r.Collection)
+}
+
+// Send upserts all failed records into the DLQ collection.
+// A record that is already dead-lettered has its attempt count accumulated.
+func (r *DeadLetterQueue) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var writes []mongo.WriteModel
+	for _, entry := range models.NewDLQEntries(records, cause, attempts) {
+		update := bson.M{
+			"$set": bson.M{
+				"record":         entry.Record,
+				"error_class":    entry.ErrorClass,
+				"error":          entry.Error,
+				"last_failed_at": entry.LastFailedAt,
+			},
+			"$inc":         bson.M{"attempts": entry.Attempts},
+			"$setOnInsert": bson.M{"first_failed_at": entry.FirstFailedAt},
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": entry.ID}).
+			SetUpdate(update).
+			SetUpsert(true))
+	}
+
+	_, err := r.collection().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Put stores the entries as they are, replacing any entry with the same id
+func (r *DeadLetterQueue) Put(ctx context.Context, entries []models.DLQEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, len(entries))
+	for idx, entry := range entries {
+		writes[idx] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": entry.ID}).
+			SetReplacement(entry).
+			SetUpsert(true)
+	}
+
+	_, err := r.collection().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// List returns a page of entries, most recently failed first
+func (r *DeadLetterQueue) List(ctx context.Context, offset, limit int64) (models.DLQPage, error) {
+	page := models.DLQPage{Entries: []models.DLQEntry{}, Offset: offset, Limit: limit}
+
+	total, err := r.collection().CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	opts := options.Find().SetSort(bson.M{"last_failed_at": -1}).SetSkip(offset).SetLimit(limit)
+	cursor, err := r.collection().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return page, err
+	}
+	if err := cursor.All(ctx, &page.Entries); err != nil {
+		return page, err
+	}
+	return page, nil
+}
+
+// Get returns a single entry by its id
+func (r *DeadLetterQueue) Get(ctx context.Context, id string) (models.DLQEntry, error) {
+	var entry models.DLQEntry
+	err := r.collection().FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return entry, errors.E(errors.NotFound, "dlq entry not found")
+	}
+	return entry, err
+}
+
+// Delete removes a single entry by its id
+func (r *DeadLetterQueue) Delete(ctx context.Context, id, _ string) error {
+	res, err := r.collection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.E(errors.NotFound, "dlq entry not found")
+	}
+	return nil
+}
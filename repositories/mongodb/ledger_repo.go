@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	// Go Internal Packages
+	"context"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	appliedAtIndex       = "applied_at_1"
+	indexOptionsConflict = 85 // Server error code of an index created again with other options
+)
+
+// AppliedRecords is the idempotency ledger, one document per applied record
+type AppliedRecords struct {
+	Client     *mongo.Client
+	Collection string
+}
+
+func NewAppliedRecords(client *mongo.Client, collection string) *AppliedRecords {
+	return &AppliedRecords{Client: client, Collection: collection}
+}
+
+func (r *AppliedRecords) collection() *mongo.Collection {
+	return r.Client.Database("mybase").Collection(r.Collection)
+}
+
+// EnsureIndexes expires the entries after the retention. A changed retention
+// updates the expiry of the index in place, as creating it again with another
+// expiry conflicts with the existing one.
+func (r *AppliedRecords) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+	expireAfter := int32(retention.Seconds())
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "applied_at", Value: 1}},
+		Options: options.Index().SetName(appliedAtIndex).SetExpireAfterSeconds(expireAfter),
+	})
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) || cmdErr.Code != indexOptionsConflict {
+		return err
+	}
+	return r.Client.Database("mybase").RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: r.Collection},
+		{Key: "index", Value: bson.D{{Key: "name", Value: appliedAtIndex}, {Key: "expireAfterSeconds", Value: expireAfter}}},
+	}).Err()
+}
+
+// Applied returns which of the given ids are in the ledger
+func (r *AppliedRecords) Applied(ctx context.Context, ids []string) (map[string]bool, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	applied := make(map[string]bool, len(ids))
+	for cursor.Next(ctx) {
+		var entry struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		applied[entry.ID] = true
+	}
+	return applied, cursor.Err()
+}
+
+// Add inserts the entries. Called with the session context of a transaction
+// an entry already there, applied by another replica meanwhile, aborts the
+// transaction with a duplicate key error.
+func (r *AppliedRecords) Add(ctx context.Context, entries []models.AppliedRecord) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(entries))
+	for idx, entry := range entries {
+		docs[idx] = entry
+	}
+	_, err := r.collection().InsertMany(ctx, docs)
+	return err
+}
@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SinkDeadLetters keeps the transactions the fan-out sinks refused, one
+// document per sink and transaction
+type SinkDeadLetters struct {
+	Client     *mongo.Client
+	Collection string
+}
+
+func NewSinkDeadLetters(client *mongo.Client, collection string) *SinkDeadLetters {
+	return &SinkDeadLetters{Client: client, Collection: collection}
+}
+
+func (r *SinkDeadLetters) collection() *mongo.Collection {
+	return r.Client.Database("mybase").Collection(r.Collection)
+}
+
+// EnsureIndexes indexes the dead letters by failure time for the listings
+// and by transaction for the status lookups
+func (r *SinkDeadLetters) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "failed_at", Value: -1}}},
+		{Keys: bson.D{{Key: "transaction._id", Value: 1}}},
+	})
+	return err
+}
+
+// Put upserts the transactions refused by the sink, a transaction refused
+// again has its attempts accumulated
+func (r *SinkDeadLetters) Put(ctx context.Context, sink string, txs []models.MongoTransaction, cause error, attempts int) error {
+	if len(txs) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	writes := make([]mongo.WriteModel, len(txs))
+	for idx, tx := range txs {
+		update := bson.M{
+			"$set": bson.M{
+				"sink":        sink,
+				"transaction": tx,
+				"error":       cause.Error(),
+				"failed_at":   now,
+			},
+			"$inc": bson.M{"attempts": attempts},
+		}
+		writes[idx] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": sink + "/" + tx.TxID}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+	_, err := r.collection().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// FailedSinks returns the sinks that refused each of the given transactions,
+// ids no sink refused are left out
+func (r *SinkDeadLetters) FailedSinks(ctx context.Context, ids []string) (map[string][]string, error) {
+	cursor, err := r.collection().Find(ctx, bson.M{"transaction._id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"sink": 1, "transaction._id": 1}).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	failed := make(map[string][]string)
+	for cursor.Next(ctx) {
+		var letter models.SinkDeadLetter
+		if err := cursor.Decode(&letter); err != nil {
+			return nil, fmt.Errorf("failed to decode dead letter: %v", err)
+		}
+		failed[letter.Transaction.TxID] = append(failed[letter.Transaction.TxID], letter.Sink)
+	}
+	return failed, cursor.Err()
+}
+
+// List returns a page of dead letters, most recently failed first
+func (r *SinkDeadLetters) List(ctx context.Context, offset, limit int64) (models.SinkDeadLetterPage, error) {
+	page := models.SinkDeadLetterPage{Entries: []models.SinkDeadLetter{}, Offset: offset, Limit: limit}
+
+	total, err := r.collection().CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+
+	opts := options.Find().SetSort(bson.M{"failed_at": -1}).SetSkip(offset).SetLimit(limit)
+	cursor, err := r.collection().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return page, err
+	}
+	if err := cursor.All(ctx, &page.Entries); err != nil {
+		return page, err
+	}
+	return page, nil
+}
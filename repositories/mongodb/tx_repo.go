@@ -3,27 +3,39 @@ package mongodb
 import (
 	// Go Internal Packages
 	"context"
+	"fmt"
+	"regexp"
+	"time"
 
 	// Local Packages
 	models "tx-stream/models"
+	tracing "tx-stream/tracing"
 
 	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type TxRepository struct {
 	Client     *mongo.Client
+	Database   string
 	Collection string
+	Upsert     bool // Replaces the transactions already stored, replays set it to overwrite what they reprocess
 }
 
 func NewTxRepository(client *mongo.Client) *TxRepository {
-	return &TxRepository{Client: client, Collection: "transactions"}
+	return &TxRepository{Client: client, Database: "mybase", Collection: "transactions"}
 }
 
 // InsertTransaction inserts a single transaction into database
-func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
-	collection := r.Client.Database("mybase").Collection(r.Collection)
-	_, err := collection.InsertOne(ctx, tx)
+func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "mongodb", "mongo.insert", attribute.String("db.collection", r.Collection))
+	defer func() { tracing.End(span, err) }()
+
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	_, err = collection.InsertOne(ctx, tx)
 	if err != nil {
 		return err
 	}
@@ -31,11 +43,162 @@ func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTra
 }
 
 // InsertTransactions inserts a batch of transactions into database
-func (r *TxRepository) InsertTransactions(ctx context.Context, txs []interface{}) error {
-	collection := r.Client.Database("mybase").Collection(r.Collection)
-	_, err := collection.InsertMany(ctx, txs)
+func (r *TxRepository) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "mongodb", "mongo.insert_many",
+		attribute.String("db.collection", r.Collection), attribute.Int("db.documents", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	if r.Upsert {
+		return r.replaceTransactions(ctx, collection, txs)
+	}
+	_, err = collection.InsertMany(ctx, txs)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *TxRepository) replaceTransactions(ctx context.Context, collection *mongo.Collection, txs []interface{}) error {
+	writes := make([]mongo.WriteModel, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot replace a %T by id", tx)
+		}
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": doc.TxID}).SetReplacement(doc).SetUpsert(true))
+	}
+	_, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// ScanTransactions calls fn with the transactions matching the filter in _id
+// order, starting after the after id when set, until fn returns an error
+func (r *TxRepository) ScanTransactions(ctx context.Context, filter bson.M, after string, batchSize int32, fn func(tx models.MongoTransaction) error) error {
+	if after != "" {
+		filter = bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": after}}}}
+	}
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(batchSize))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	for cursor.Next(ctx) {
+		var tx models.MongoTransaction
+		if err := cursor.Decode(&tx); err != nil {
+			return fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// FindTransactions returns the stored transactions among the given ids, by id
+func (r *TxRepository) FindTransactions(ctx context.Context, ids []string) (map[string]models.MongoTransaction, error) {
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	found := make(map[string]models.MongoTransaction, len(ids))
+	for cursor.Next(ctx) {
+		var tx models.MongoTransaction
+		if err := cursor.Decode(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		found[tx.TxID] = tx
+	}
+	return found, cursor.Err()
+}
+
+// ListTransactions returns up to limit transactions matching the filter in
+// _id order, starting after the after id when set
+func (r *TxRepository) ListTransactions(ctx context.Context, filter models.TxFilter, after string, limit int64) ([]models.MongoTransaction, error) {
+	query := bson.M{}
+	if filter.UserID != "" {
+		query["user_id"] = filter.UserID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Currency != "" {
+		query["currency"] = filter.Currency
+	}
+	if filter.TransactionType != "" {
+		query["transaction_type"] = filter.TransactionType
+	}
+	processedAt := bson.M{}
+	if !filter.ProcessedAfter.IsZero() {
+		processedAt["$gte"] = filter.ProcessedAfter
+	}
+	if !filter.ProcessedBefore.IsZero() {
+		processedAt["$lt"] = filter.ProcessedBefore
+	}
+	if len(processedAt) > 0 {
+		query["provenance.processed_at"] = processedAt
+	}
+	if after != "" {
+		query["_id"] = bson.M{"$gt": after}
+	}
+
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	cursor, err := collection.Find(ctx, query, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	txs := make([]models.MongoTransaction, 0, limit)
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %v", err)
+	}
+	return txs, nil
+}
+
+// EnsureIndexes indexes the transactions by account, in the _id order of
+// the listings
+func (r *TxRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	return err
+}
+
+// ProcessedAt returns when each of the given transactions was processed, ids
+// not stored yet are left out
+func (r *TxRepository) ProcessedAt(ctx context.Context, ids []string) (map[string]time.Time, error) {
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"provenance.processed_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(context.Background()) }()
+	processed := make(map[string]time.Time, len(ids))
+	for cursor.Next(ctx) {
+		var tx models.MongoTransaction
+		if err := cursor.Decode(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if tx.Provenance != nil {
+			processed[tx.TxID] = tx.Provenance.ProcessedAt
+		}
+	}
+	return processed, cursor.Err()
+}
+
+// DeleteTransactionsWithPrefix deletes the transactions whose id starts with the prefix
+func (r *TxRepository) DeleteTransactionsWithPrefix(ctx context.Context, prefix string) (int64, error) {
+	collection := r.Client.Database(r.Database).Collection(r.Collection)
+	filter := bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(prefix)}}
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
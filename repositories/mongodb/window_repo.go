@@ -0,0 +1,90 @@
+package mongodb
+
+import (
+	// Go Internal Packages
+	"context"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WindowResults keeps the emitted window aggregates, one document per
+// window, end and group
+type WindowResults struct {
+	Client     *mongo.Client
+	Collection string
+}
+
+func NewWindowResults(client *mongo.Client, collection string) *WindowResults {
+	return &WindowResults{Client: client, Collection: collection}
+}
+
+func (r *WindowResults) collection() *mongo.Collection {
+	return r.Client.Database("mybase").Collection(r.Collection)
+}
+
+// EnsureIndexes indexes the results by window and end for the dashboards
+func (r *WindowResults) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "window", Value: 1}, {Key: "end", Value: -1}},
+	})
+	return err
+}
+
+// Put replaces the results by id, so a repeated emission does not duplicate them
+func (r *WindowResults) Put(ctx context.Context, results []models.WindowResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, len(results))
+	for idx, result := range results {
+		writes[idx] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": result.ID}).
+			SetReplacement(result).
+			SetUpsert(true)
+	}
+	_, err := r.collection().BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Rollup sums the results of a tumbling window ending after from and up to
+// to by group into the into collection, as one document per group with the
+// window fields of a WindowResult. A repeated rollup of the period replaces
+// its documents.
+func (r *WindowResults) Rollup(ctx context.Context, window string, from, to time.Time, into string) error {
+	now := time.Now().UTC()
+	name := bson.M{"$literal": window}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"window": window, "end": bson.M{"$gt": from, "$lte": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$group",
+			"count":    bson.M{"$sum": "$count"},
+			"volume":   bson.M{"$sum": "$volume"},
+			"declined": bson.M{"$sum": "$declined"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":          bson.M{"window": name, "start": from, "group": "$_id"},
+			"window":       name,
+			"group":        "$_id",
+			"start":        from,
+			"end":          to,
+			"count":        1,
+			"volume":       1,
+			"declined":     1,
+			"decline_rate": bson.M{"$divide": bson.A{"$declined", "$count"}},
+			"emitted_at":   now,
+		}}},
+		{{Key: "$merge", Value: bson.M{"into": into, "on": "_id", "whenMatched": "replace", "whenNotMatched": "insert"}}},
+	}
+	cursor, err := r.collection().Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// External Packages
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Connect opens a connection pool to the postgres server and pings it
+func Connect(ctx context.Context, uri string, maxConns int32) (*pgxpool.Pool, error) {
+	poolConf, err := pgxpool.ParseConfig(uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse postgres uri: %v", err)
+	}
+	if maxConns > 0 {
+		poolConf.MaxConns = maxConns
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
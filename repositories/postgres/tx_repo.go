@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TxRepository lands the transactions in a postgres table, one row per
+// transaction id. Writes are upserts, so redeliveries and replays overwrite
+// the row instead of failing on the primary key.
+type TxRepository struct {
+	Pool      *pgxpool.Pool
+	Table     string
+	BatchSize int // Rows per insert statement, a batch of transactions is written in one transaction
+}
+
+func NewTxRepository(pool *pgxpool.Pool, table string, batchSize int) *TxRepository {
+	return &TxRepository{Pool: pool, Table: table, BatchSize: batchSize}
+}
+
+// EnsureSchema creates the table unless it exists
+func (r *TxRepository) EnsureSchema(ctx context.Context) error {
+	_, err := r.Pool.Exec(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	transaction_id   TEXT PRIMARY KEY,
+	amount           REAL NOT NULL,
+	currency         TEXT NOT NULL,
+	transaction_type TEXT NOT NULL,
+	status           TEXT NOT NULL,
+	"timestamp"      TEXT NOT NULL,
+	payment_method   TEXT NOT NULL,
+	source_topic     TEXT,
+	source_partition INTEGER,
+	source_offset    BIGINT,
+	processed_at     TIMESTAMPTZ
+)`, r.table()))
+	if err != nil {
+		return fmt.Errorf("failed to create table %s: %v", r.Table, err)
+	}
+	return nil
+}
+
+// InsertTransactions upserts a batch of transactions
+func (r *TxRepository) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "postgres", "postgres.upsert",
+		attribute.String("db.sql.table", r.Table), attribute.Int("db.rows", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	docs := make([]models.MongoTransaction, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot upsert a %T", tx)
+		}
+		docs = append(docs, doc)
+	}
+	return r.upsert(ctx, docs)
+}
+
+// InsertTransaction upserts a single transaction
+func (r *TxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "postgres", "postgres.upsert", attribute.String("db.sql.table", r.Table), attribute.Int("db.rows", 1))
+	defer func() { tracing.End(span, err) }()
+	return r.upsert(ctx, []models.MongoTransaction{tx})
+}
+
+// Close releases the connections of the pool
+func (r *TxRepository) Close(context.Context) error {
+	r.Pool.Close()
+	return nil
+}
+
+func (r *TxRepository) upsert(ctx context.Context, docs []models.MongoTransaction) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	docs = lastPerID(docs)
+	return pgx.BeginFunc(ctx, r.Pool, func(tx pgx.Tx) error {
+		for start := 0; start < len(docs); start += r.BatchSize {
+			chunk := docs[start:min(start+r.BatchSize, len(docs))]
+			if _, err := tx.Exec(ctx, r.upsertSQL(), upsertArgs(chunk)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// upsertSQL inserts the rows of column arrays, unnest keeps it a single
+// statement whatever the number of rows
+func (r *TxRepository) upsertSQL() string {
+	return fmt.Sprintf(`INSERT INTO %s (transaction_id, amount, currency, transaction_type, status, "timestamp",
+	payment_method, source_topic, source_partition, source_offset, processed_at)
+SELECT * FROM unnest($1::text[], $2::real[], $3::text[], $4::text[], $5::text[], $6::text[],
+	$7::text[], $8::text[], $9::integer[], $10::bigint[], $11::timestamptz[])
+ON CONFLICT (transaction_id) DO UPDATE SET
+	amount = EXCLUDED.amount,
+	currency = EXCLUDED.currency,
+	transaction_type = EXCLUDED.transaction_type,
+	status = EXCLUDED.status,
+	"timestamp" = EXCLUDED."timestamp",
+	payment_method = EXCLUDED.payment_method,
+	source_topic = EXCLUDED.source_topic,
+	source_partition = EXCLUDED.source_partition,
+	source_offset = EXCLUDED.source_offset,
+	processed_at = EXCLUDED.processed_at`, r.table())
+}
+
+func (r *TxRepository) table() string {
+	return pgx.Identifier{r.Table}.Sanitize()
+}
+
+func upsertArgs(docs []models.MongoTransaction) []any {
+	ids := make([]string, len(docs))
+	amounts := make([]float32, len(docs))
+	currencies := make([]string, len(docs))
+	types := make([]string, len(docs))
+	statuses := make([]string, len(docs))
+	timestamps := make([]string, len(docs))
+	methods := make([]string, len(docs))
+	topics := make([]*string, len(docs))
+	partitions := make([]*int32, len(docs))
+	offsets := make([]*int64, len(docs))
+	processedAt := make([]*time.Time, len(docs))
+	for idx, doc := range docs {
+		ids[idx] = doc.TxID
+		amounts[idx] = doc.Amount
+		currencies[idx] = doc.Currency
+		types[idx] = doc.TransactionType
+		statuses[idx] = doc.Status
+		timestamps[idx] = doc.Timestamp
+		methods[idx] = doc.PaymentMethod
+		if provenance := doc.Provenance; provenance != nil {
+			topics[idx] = &provenance.Topic
+			partitions[idx] = &provenance.Partition
+			offsets[idx] = &provenance.Offset
+			processedAt[idx] = &provenance.ProcessedAt
+		}
+	}
+	return []any{ids, amounts, currencies, types, statuses, timestamps, methods, topics, partitions, offsets, processedAt}
+}
+
+// lastPerID keeps the last transaction of each id, an upsert statement
+// cannot update the same row twice
+func lastPerID(docs []models.MongoTransaction) []models.MongoTransaction {
+	last := make(map[string]int, len(docs))
+	for idx, doc := range docs {
+		last[doc.TxID] = idx
+	}
+	if len(last) == len(docs) {
+		return docs
+	}
+	kept := make([]models.MongoTransaction, 0, len(last))
+	for idx, doc := range docs {
+		if last[doc.TxID] == idx {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,93 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"strconv"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+)
+
+// velocityScript adds a transaction to the sorted set of its subject, scored
+// by its time, drops the ones out of the window and counts the rest. Adding
+// a redelivered transaction again does not count it twice.
+var velocityScript = redis.NewScript(`
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return redis.call("ZCOUNT", KEYS[1], "(" .. ARGV[3], ARGV[1])
+`)
+
+// AlertStore keeps the state of the alert rules in redis, the sorted set
+// "{Prefix:velocity:rule:subject}" of every velocity subject and the key
+// "Prefix:sent:id" of every alert sent within its dedup window
+type AlertStore struct {
+	Client redis.UniversalClient
+	Prefix string
+}
+
+func NewAlertStore(client redis.UniversalClient, prefix string) *AlertStore {
+	return &AlertStore{Client: client, Prefix: prefix}
+}
+
+// Velocity counts the transactions into their windows in one round trip and
+// returns the transactions each window holds, the checked one included
+func (s *AlertStore) Velocity(ctx context.Context, checks []models.VelocityCheck) ([]int64, error) {
+	if len(checks) == 0 {
+		return nil, nil
+	}
+	counts, err := s.velocity(ctx, checks)
+	if redis.HasErrorPrefix(err, "NOSCRIPT") {
+		// Replaying the checks adds the same members again
+		if err := velocityScript.Load(ctx, s.Client).Err(); err != nil {
+			return nil, err
+		}
+		counts, err = s.velocity(ctx, checks)
+	}
+	return counts, err
+}
+
+func (s *AlertStore) velocity(ctx context.Context, checks []models.VelocityCheck) ([]int64, error) {
+	pipe := s.Client.Pipeline()
+	cmds := make([]*redis.Cmd, len(checks))
+	for idx, check := range checks {
+		key := "{" + s.Prefix + ":velocity:" + check.Rule + ":" + check.Subject + "}"
+		at := check.At.UnixMilli()
+		cmds[idx] = velocityScript.EvalSha(ctx, pipe, []string{key}, at, check.TxID,
+			strconv.FormatInt(at-check.Window.Milliseconds(), 10), check.Window.Milliseconds())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	counts := make([]int64, len(checks))
+	for idx, cmd := range cmds {
+		counts[idx], _ = cmd.Int64()
+	}
+	return counts, nil
+}
+
+// Dedupe marks the alerts as sent for their windows, the result tells for
+// each id whether it was not sent within its window yet
+func (s *AlertStore) Dedupe(ctx context.Context, ids []string, windows []time.Duration) ([]bool, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	pipe := s.Client.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(ids))
+	for idx, id := range ids {
+		cmds[idx] = pipe.SetNX(ctx, s.Prefix+":sent:"+id, 1, windows[idx])
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	fresh := make([]bool, len(ids))
+	for idx, cmd := range cmds {
+		fresh[idx] = cmd.Val()
+	}
+	return fresh, nil
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// negativeMarker is cached for keys the loader reported as NotFound
+const negativeMarker = "!notfound"
+
+// Cache is a read-through cache of JSON encoded values. Concurrent misses for
+// the same key share a single load, and keys the loader reports as NotFound are
+// cached for NegativeTTL so lookups of unknown ids don't reach the source.
+// Redis failures fall back to the loader, the cache never fails a lookup itself.
+type Cache[T any] struct {
+	Client      redis.UniversalClient
+	Logger      *zap.Logger
+	Prefix      string
+	TTL         time.Duration
+	NegativeTTL time.Duration // Zero disables negative caching
+
+	group singleflight.Group
+}
+
+func NewCache[T any](client redis.UniversalClient, logger *zap.Logger, prefix string, ttl, negativeTTL time.Duration) *Cache[T] {
+	return &Cache[T]{Client: client, Logger: logger, Prefix: prefix, TTL: ttl, NegativeTTL: negativeTTL}
+}
+
+// Get returns the cached value for key, calling load on a miss. A load error of
+// kind NotFound is returned as is and, with negative caching, remembered.
+func (c *Cache[T]) Get(ctx context.Context, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	cacheKey := c.Prefix + ":" + key
+
+	raw, err := c.Client.Get(ctx, cacheKey).Result()
+	switch {
+	case err == nil && raw == negativeMarker:
+		return value, errors.E(errors.NotFound, key+" not found")
+	case err == nil:
+		if err := json.Unmarshal([]byte(raw), &value); err == nil {
+			return value, nil
+		}
+		c.Logger.Warn("dropping undecodable cache entry", zap.String("key", cacheKey))
+	case err != redis.Nil:
+		c.Logger.Warn("cache read failed, loading from source", zap.String("key", cacheKey), zap.Error(err))
+	}
+
+	// Detach the shared load from the first caller's cancellation
+	loadCtx := context.WithoutCancel(ctx)
+	result, err, _ := c.group.Do(cacheKey, func() (interface{}, error) {
+		loaded, err := load(loadCtx)
+		var appErr *errors.Error
+		if err != nil && errors.As(err, &appErr) && appErr.Kind == errors.NotFound {
+			if c.NegativeTTL > 0 {
+				c.set(loadCtx, cacheKey, negativeMarker, c.NegativeTTL)
+			}
+			return loaded, err
+		}
+		if err != nil {
+			return loaded, err
+		}
+
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return loaded, fmt.Errorf("failed to encode cache entry: %v", err)
+		}
+		c.set(loadCtx, cacheKey, string(data), c.TTL)
+		return loaded, nil
+	})
+	if result != nil {
+		value = result.(T)
+	}
+	return value, err
+}
+
+// Invalidate drops the cached value for key, e.g. after the source changed
+func (c *Cache[T]) Invalidate(ctx context.Context, key string) error {
+	return c.Client.Del(ctx, c.Prefix+":"+key).Err()
+}
+
+func (c *Cache[T]) set(ctx context.Context, key, value string, ttl time.Duration) {
+	if err := c.Client.Set(ctx, key, value, ttl).Err(); err != nil {
+		c.Logger.Warn("cache write failed", zap.String("key", key), zap.Error(err))
+	}
+}
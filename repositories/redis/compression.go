@@ -0,0 +1,62 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	// External Packages
+	"github.com/klauspost/compress/zstd"
+)
+
+// Payload codecs, recorded next to each stream entry
+const (
+	CodecNone = "none"
+	CodecGzip = "gzip"
+	CodecZstd = "zstd"
+)
+
+// The zstd coders are safe for concurrent EncodeAll and DecodeAll calls
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+func compressPayload(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case CodecZstd:
+		return zstdEncoder.EncodeAll(data, nil), nil
+	case CodecGzip:
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown payload codec %q", codec)
+	}
+}
+
+func decompressPayload(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case "", CodecNone:
+		return data, nil
+	case CodecZstd:
+		return zstdDecoder.DecodeAll(data, nil)
+	case CodecGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	default:
+		return nil, fmt.Errorf("unknown payload codec %q", codec)
+	}
+}
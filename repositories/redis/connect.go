@@ -3,22 +3,70 @@ package redis
 import (
 	// Go Internal Packages
 	"context"
+	"crypto/tls"
+	"fmt"
 
 	// External Packages
 	"github.com/redis/go-redis/v9"
 )
 
+// Connection modes supported by Connect
+const (
+	ModeStandalone = "standalone"
+	ModeCluster    = "cluster"
+	ModeSentinel   = "sentinel"
+)
+
+type ConnectConfig struct {
+	Mode             string
+	Addrs            []string // A single address for standalone, seed addresses for cluster, sentinel addresses for sentinel
+	Username         string   // ACL user, empty authenticates as the default user
+	Password         string
+	DB               int // Ignored in cluster mode
+	TLS              *tls.Config
+	MasterName       string // Sentinel only
+	SentinelPassword string // Sentinel only
+}
+
 // Connect connects to the redis db and returns the client.
-func Connect(ctx context.Context, uri, password string) (*redis.Client, error) {
-	// Configure the Redis client
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     uri,      // Redis server address
-		Password: password, // Redis password
-		DB:       0,        // Default DB
-	})
+// In cluster mode the returned client routes commands to the owning node and
+// in sentinel mode it follows the current primary across failovers.
+func Connect(ctx context.Context, conf *ConnectConfig) (redis.UniversalClient, error) {
+	var rdb redis.UniversalClient
+	switch conf.Mode {
+	case ModeStandalone, "":
+		// Configure the Redis client
+		rdb = redis.NewClient(&redis.Options{
+			Addr:      conf.Addrs[0], // Redis server address
+			Username:  conf.Username, // Redis ACL user
+			Password:  conf.Password, // Redis password
+			DB:        conf.DB,       // Selected DB
+			TLSConfig: conf.TLS,      // Nil for plaintext
+		})
+	case ModeCluster:
+		rdb = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     conf.Addrs,    // Seed addresses, the rest of the cluster is discovered
+			Username:  conf.Username, // Redis ACL user
+			Password:  conf.Password, // Redis password
+			TLSConfig: conf.TLS,      // Nil for plaintext
+		})
+	case ModeSentinel:
+		rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.MasterName,       // Name of the monitored primary
+			SentinelAddrs:    conf.Addrs,            // Sentinel addresses
+			SentinelPassword: conf.SentinelPassword, // Sentinel password
+			Username:         conf.Username,         // Redis ACL user
+			Password:         conf.Password,         // Redis password
+			DB:               conf.DB,               // Selected DB
+			TLSConfig:        conf.TLS,              // Nil for plaintext
+		})
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", conf.Mode)
+	}
 
 	_, pingErr := rdb.Ping(ctx).Result()
 	if pingErr != nil {
+		_ = rdb.Close()
 		return nil, pingErr
 	}
 	return rdb, nil
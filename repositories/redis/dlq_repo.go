@@ -4,45 +4,628 @@ import (
 	// Go Internal Packages
 	"context"
 	"encoding/json"
-	"go.uber.org/zap"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	// Local Packages
+	errors "tx-stream/errors"
+	metrics "tx-stream/metrics"
 	models "tx-stream/models"
 
 	// External Packages
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Overflow policies applied once the DLQ reaches its max length
+const (
+	OverflowDropOldest = "drop-oldest"
+	OverflowDropNew    = "drop-new-with-alert"
 )
 
+type DLQConfig struct {
+	Name            string
+	EntryTTL        time.Duration // Zero keeps entries forever
+	MaxLength       int64         // Zero leaves the queue unbounded
+	OverflowPolicy  string
+	TrimInterval    time.Duration // Of Run, zero leaves the trimming to the writes
+	Codec           string        // Compresses entries of at least CompressMinSize bytes, none disables
+	CompressMinSize int
+}
+
+// writeScript replaces the previous stream entry of a record, if any, and
+// points the record index at the new one. Compressed entries carry their codec.
+var writeScript = redis.NewScript(`
+if ARGV[3] ~= "" then
+	redis.call("XDEL", KEYS[1], ARGV[3])
+end
+local id
+if ARGV[4] ~= "" then
+	id = redis.call("XADD", KEYS[1], "*", "entry", ARGV[2], "codec", ARGV[4])
+else
+	id = redis.call("XADD", KEYS[1], "*", "entry", ARGV[2])
+end
+redis.call("HSET", KEYS[2], ARGV[1], id)
+return id
+`)
+
+// DeadLetterQueue keeps failed records in the Redis stream "{Name}:stream".
+// Entry ids are stream ids, so entries are ordered by failure time and can be
+// range queried, and replay workers share the stream through consumer groups.
+// The hash "{Name}:index" maps a record (topic:partition:offset) to its stream
+// id so repeated failures of the same record replace a single entry.
+// The hash tag keeps all keys in the same slot when running on a cluster.
 type DeadLetterQueue struct {
-	Client   *redis.Client
-	Logger   *zap.Logger
-	ListName string
+	Client  redis.UniversalClient
+	Logger  *zap.Logger
+	Config  *DLQConfig
+	Metrics *metrics.DLQMetrics // Optional
 }
 
-func NewDeadLetterQueue(client *redis.Client, logger *zap.Logger) *DeadLetterQueue {
-	return &DeadLetterQueue{Client: client, Logger: logger, ListName: "failed-transactions"}
+func NewDeadLetterQueue(client redis.UniversalClient, logger *zap.Logger, conf *DLQConfig, dlqMetrics *metrics.DLQMetrics) *DeadLetterQueue {
+	return &DeadLetterQueue{Client: client, Logger: logger, Config: conf, Metrics: dlqMetrics}
 }
 
-// Send pushes all failed records into the Redis list "failed-transactions"
-func (r *DeadLetterQueue) Send(ctx context.Context, records []models.Record) error {
+func (r *DeadLetterQueue) streamKey() string {
+	return "{" + r.Config.Name + "}:stream"
+}
+
+func (r *DeadLetterQueue) indexKey() string {
+	return "{" + r.Config.Name + "}:index"
+}
+
+// Send appends all failed records to the stream.
+// A record that is already dead-lettered has its attempt count accumulated.
+// Entries expire after EntryTTL and the stream is capped at MaxLength, by the
+// writes and by Run in between.
+// The batch is written in a single pipeline, entries whose commands fail are
+// logged and reported in the returned error while the rest are kept. Records
+// a full queue drops are reported as a *models.DLQFullError.
+func (r *DeadLetterQueue) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
 	if len(records) == 0 {
 		return nil
 	}
+	return r.write(ctx, models.NewDLQEntries(records, cause, attempts), true)
+}
+
+// Put stores the entries as they are, replacing any entry for the same record.
+// The entries are given new stream ids.
+func (r *DeadLetterQueue) Put(ctx context.Context, entries []models.DLQEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.write(ctx, entries, false)
+}
+
+// write stores the entries, accumulating attempts onto existing entries if asked
+func (r *DeadLetterQueue) write(ctx context.Context, entries []models.DLQEntry, accumulate bool) error {
+	recordIDs := make([]string, len(entries))
+	for idx, entry := range entries {
+		recordIDs[idx] = models.DLQEntryID(entry.Record)
+	}
+
+	// Look up the already dead-lettered entries in two round trips
+	prevIDs, err := r.Client.HMGet(ctx, r.indexKey(), recordIDs...).Result()
+	if err != nil {
+		return err
+	}
+	prevs := make([]*redis.XMessageSliceCmd, len(entries))
+	pipe := r.Client.Pipeline()
+	for idx, prevID := range prevIDs {
+		if id, ok := prevID.(string); ok {
+			prevs[idx] = pipe.XRange(ctx, r.streamKey(), id, id)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
 
-	var transactions []interface{}
-	for _, record := range records {
-		transaction, err := json.Marshal(record)
+	// Number of new entries that still fit when dropping new records on overflow
+	free := int64(len(entries))
+	if r.Config.MaxLength > 0 && r.Config.OverflowPolicy == OverflowDropNew {
+		length, err := r.Client.XLen(ctx, r.streamKey()).Result()
+		if err != nil {
+			return err
+		}
+		free = r.Config.MaxLength - length
+	}
+
+	var batch []pendingWrite
+	dropped := 0
+
+	for idx, entry := range entries {
+		prevID := ""
+		if prevs[idx] != nil && len(prevs[idx].Val()) > 0 {
+			msg := prevs[idx].Val()[0]
+			prevID = msg.ID
+			if accumulate {
+				if prev, err := decodeEntry(msg); err == nil {
+					entry.Attempts += prev.Attempts
+					entry.FirstFailedAt = prev.FirstFailedAt
+				}
+			}
+		}
+		exists := prevID != ""
+
+		if !exists {
+			if free <= 0 {
+				r.Logger.Error("dlq is full, dropping record", zap.String("record", recordIDs[idx]),
+					zap.Int64("max_length", r.Config.MaxLength), zap.String("error", entry.Error))
+				r.Metrics.Drop(entry.ErrorClass, 1)
+				dropped++
+				continue
+			}
+			free--
+		}
+
+		// The stream id becomes the entry id, it is not part of the payload
+		entry.ID = ""
+		data, err := json.Marshal(entry)
 		if err != nil {
 			r.Logger.Error("failed to marshal transaction", zap.Error(err))
 			continue
 		}
-		transactions = append(transactions, transaction)
+
+		codec := ""
+		if c := r.Config.Codec; c != "" && c != CodecNone && len(data) >= r.Config.CompressMinSize {
+			compressed, err := compressPayload(c, data)
+			if err != nil {
+				r.Logger.Error("failed to compress dlq entry", zap.String("record", recordIDs[idx]), zap.Error(err))
+				continue
+			}
+			data, codec = compressed, c
+		}
+
+		batch = append(batch, pendingWrite{entry: entry, exists: exists, args: []interface{}{recordIDs[idx], data, prevID, codec}})
+	}
+	if len(batch) > 0 {
+		r.exec(ctx, batch)
+	}
+
+	var failed int
+	var firstErr error
+	for _, p := range batch {
+		if err := p.cmd.Err(); err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			r.Logger.Error("failed to dead-letter record", zap.String("record", models.DLQEntryID(p.entry.Record)), zap.Error(err))
+			continue
+		}
+		if !p.exists {
+			r.Metrics.Enqueue(p.entry.ErrorClass, 1)
+		}
+	}
+
+	// The entries are stored, a failed trim is left to the next one
+	if err := r.trim(ctx); err != nil {
+		r.Logger.Warn("failed to trim the dlq", zap.Error(err))
+	}
+	r.refreshDepth(ctx)
+
+	var errs []error
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf("failed to dead-letter %d of %d records: %v", failed, len(batch), firstErr))
+	}
+	if dropped > 0 {
+		errs = append(errs, &models.DLQFullError{Dropped: dropped, MaxLength: r.Config.MaxLength})
+	}
+	return errors.Join(errs...)
+}
+
+type pendingWrite struct {
+	entry  models.DLQEntry
+	exists bool
+	args   []interface{}
+	cmd    *redis.Cmd
+}
+
+// exec runs the write script of every entry in a pipeline. A pipeline cannot
+// fall back from EVALSHA to EVAL, so when redis does not know the script, a
+// fresh or restarted one, it is loaded and the writes missing it run again.
+// The result of each write is left in its cmd.
+func (r *DeadLetterQueue) exec(ctx context.Context, batch []pendingWrite) {
+	keys := []string{r.streamKey(), r.indexKey()}
+	pipe := r.Client.Pipeline()
+	for idx := range batch {
+		batch[idx].cmd = writeScript.EvalSha(ctx, pipe, keys, batch[idx].args...)
+	}
+	if _, err := pipe.Exec(ctx); err == nil {
+		return
+	}
+
+	var missing []int
+	for idx, p := range batch {
+		if redis.HasErrorPrefix(p.cmd.Err(), "NOSCRIPT") {
+			missing = append(missing, idx)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	if err := writeScript.Load(ctx, r.Client).Err(); err != nil {
+		r.Logger.Error("failed to load the dlq write script", zap.Error(err))
+		return
+	}
+	pipe = r.Client.Pipeline()
+	for _, idx := range missing {
+		batch[idx].cmd = writeScript.EvalSha(ctx, pipe, keys, batch[idx].args...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.Logger.Warn("dlq writes failed after loading the write script", zap.Int("writes", len(missing)), zap.Error(err))
+	}
+}
+
+func decodeEntry(msg redis.XMessage) (models.DLQEntry, error) {
+	var entry models.DLQEntry
+	data, ok := msg.Values["entry"].(string)
+	if !ok {
+		return entry, fmt.Errorf("stream entry %s has no payload", msg.ID)
+	}
+	codec, _ := msg.Values["codec"].(string)
+	payload, err := decompressPayload(codec, []byte(data))
+	if err != nil {
+		return entry, fmt.Errorf("failed to decompress stream entry %s: %v", msg.ID, err)
+	}
+	if err := json.Unmarshal(payload, &entry); err != nil {
+		return entry, err
+	}
+	entry.ID = msg.ID
+	return entry, nil
+}
+
+func (r *DeadLetterQueue) decodeEntries(msgs []redis.XMessage) []models.DLQEntry {
+	entries := make([]models.DLQEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		entry, err := decodeEntry(msg)
+		if err != nil {
+			r.Logger.Error("failed to unmarshal dlq entry", zap.String("id", msg.ID), zap.Error(err))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// refreshDepth updates the depth gauge from the stream length
+func (r *DeadLetterQueue) refreshDepth(ctx context.Context) {
+	if r.Metrics == nil {
+		return
+	}
+	depth, err := r.Client.XLen(ctx, r.streamKey()).Result()
+	if err != nil {
+		r.Logger.Warn("failed to read dlq depth", zap.Error(err))
+		return
+	}
+	r.Metrics.SetDepth(depth)
+}
+
+// Run trims the queue every TrimInterval until the context is canceled, so
+// entries expire while nothing is written
+func (r *DeadLetterQueue) Run(ctx context.Context) {
+	if r.Config.TrimInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(r.Config.TrimInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				expired, err := r.expire(ctx)
+				if err != nil {
+					r.Logger.Warn("failed to expire dlq entries", zap.Error(err))
+					break
+				}
+				if expired < trimBatchSize {
+					break
+				}
+			}
+			if err := r.evict(ctx); err != nil {
+				r.Logger.Warn("failed to evict dlq entries", zap.Error(err))
+			}
+			r.refreshDepth(ctx)
+		}
 	}
+}
+
+// trimBatchSize is the number of expired entries removed per round trip
+const trimBatchSize = 1000
 
-	err := r.Client.LPush(ctx, r.ListName, transactions...).Err()
+// expire removes up to trimBatchSize entries older than EntryTTL, returns how many
+func (r *DeadLetterQueue) expire(ctx context.Context) (int, error) {
+	if r.Config.EntryTTL <= 0 {
+		return 0, nil
+	}
+	// Stream ids start with their insertion time in milliseconds
+	cutoff := time.Now().Add(-r.Config.EntryTTL).UnixMilli()
+	msgs, err := r.Client.XRangeN(ctx, r.streamKey(), "-", "("+strconv.FormatInt(cutoff, 10), trimBatchSize).Result()
 	if err != nil {
+		return 0, err
+	}
+	if err := r.remove(ctx, msgs); err != nil {
+		return 0, err
+	}
+	r.Metrics.Dequeue(metrics.ReasonExpired, len(msgs))
+	return len(msgs), nil
+}
+
+// trim removes a batch of entries older than EntryTTL and the entries evict removes
+func (r *DeadLetterQueue) trim(ctx context.Context) error {
+	if _, err := r.expire(ctx); err != nil {
 		return err
 	}
+	return r.evict(ctx)
+}
+
+// evict removes the oldest entries beyond MaxLength, when dropping the oldest on overflow
+func (r *DeadLetterQueue) evict(ctx context.Context) error {
+	if r.Config.MaxLength <= 0 || r.Config.OverflowPolicy != OverflowDropOldest {
+		return nil
+	}
 
+	length, err := r.Client.XLen(ctx, r.streamKey()).Result()
+	if err != nil {
+		return err
+	}
+	excess := length - r.Config.MaxLength
+	if excess <= 0 {
+		return nil
+	}
+
+	msgs, err := r.Client.XRangeN(ctx, r.streamKey(), "-", "+", excess).Result()
+	if err != nil {
+		return err
+	}
+	if err := r.remove(ctx, msgs); err != nil {
+		return err
+	}
+
+	r.Logger.Warn("dlq is full, evicted oldest entries", zap.Int("evicted", len(msgs)),
+		zap.Int64("max_length", r.Config.MaxLength))
+	r.Metrics.Dequeue(metrics.ReasonEvicted, len(msgs))
 	return nil
 }
+
+// remove deletes the stream entries along with their index fields
+func (r *DeadLetterQueue) remove(ctx context.Context, msgs []redis.XMessage) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(msgs))
+	pipe := r.Client.TxPipeline()
+	for idx, msg := range msgs {
+		ids[idx] = msg.ID
+		if entry, err := decodeEntry(msg); err == nil {
+			pipe.HDel(ctx, r.indexKey(), models.DLQEntryID(entry.Record))
+		}
+	}
+	pipe.XDel(ctx, r.streamKey(), ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List returns a page of entries, newest first
+func (r *DeadLetterQueue) List(ctx context.Context, offset, limit int64) (models.DLQPage, error) {
+	page := models.DLQPage{Entries: []models.DLQEntry{}, Offset: offset, Limit: limit}
+
+	total, err := r.Client.XLen(ctx, r.streamKey()).Result()
+	if err != nil {
+		return page, err
+	}
+	page.Total = total
+	r.Metrics.SetDepth(total)
+
+	msgs, err := r.Client.XRevRangeN(ctx, r.streamKey(), "+", "-", offset+limit).Result()
+	if err != nil || int64(len(msgs)) <= offset {
+		return page, err
+	}
+
+	page.Entries = r.decodeEntries(msgs[offset:])
+	return page, nil
+}
+
+// Scan returns the entries of up to count stream entries after the entry id
+// after, oldest first, from the start of the stream when after is empty. next
+// is the id to scan after for the following entries, empty at the end.
+func (r *DeadLetterQueue) Scan(ctx context.Context, after string, count int64) (entries []models.DLQEntry, next string, err error) {
+	start := "-"
+	if after != "" {
+		start = "(" + after
+	}
+	msgs, err := r.Client.XRangeN(ctx, r.streamKey(), start, "+", count).Result()
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(msgs)) == count {
+		next = msgs[len(msgs)-1].ID
+	}
+	return r.decodeEntries(msgs), next, nil
+}
+
+func (r *DeadLetterQueue) getMessage(ctx context.Context, id string) (redis.XMessage, error) {
+	msgs, err := r.Client.XRange(ctx, r.streamKey(), id, id).Result()
+	if err != nil && strings.Contains(err.Error(), "Invalid stream ID") {
+		return redis.XMessage{}, errors.E(errors.NotFound, "dlq entry not found")
+	}
+	if err != nil {
+		return redis.XMessage{}, err
+	}
+	if len(msgs) == 0 {
+		return redis.XMessage{}, errors.E(errors.NotFound, "dlq entry not found")
+	}
+	return msgs[0], nil
+}
+
+// Get returns a single entry by its id
+func (r *DeadLetterQueue) Get(ctx context.Context, id string) (models.DLQEntry, error) {
+	msg, err := r.getMessage(ctx, id)
+	if err != nil {
+		return models.DLQEntry{}, err
+	}
+
+	entry, err := decodeEntry(msg)
+	if err != nil {
+		return entry, errors.E(errors.Internal, "failed to unmarshal dlq entry", err)
+	}
+	return entry, nil
+}
+
+// Delete removes a single entry by its id, reason labels the removal in metrics
+func (r *DeadLetterQueue) Delete(ctx context.Context, id, reason string) error {
+	msg, err := r.getMessage(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := r.remove(ctx, []redis.XMessage{msg}); err != nil {
+		return err
+	}
+	r.Metrics.Dequeue(reason, 1)
+	r.refreshDepth(ctx)
+	return nil
+}
+
+// DeleteBatch removes the entries in a single round trip, reason labels the removal in metrics
+func (r *DeadLetterQueue) DeleteBatch(ctx context.Context, entries []models.DLQEntry, reason string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(entries))
+	pipe := r.Client.TxPipeline()
+	for idx, entry := range entries {
+		ids[idx] = entry.ID
+		pipe.HDel(ctx, r.indexKey(), models.DLQEntryID(entry.Record))
+	}
+	pipe.XDel(ctx, r.streamKey(), ids...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	r.Metrics.Dequeue(reason, len(entries))
+	r.refreshDepth(ctx)
+	return nil
+}
+
+// EnsureGroup creates the replay consumer group, reading from the start of the stream
+func (r *DeadLetterQueue) EnsureGroup(ctx context.Context, group string) error {
+	err := r.Client.XGroupCreateMkStream(ctx, r.streamKey(), group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// Claim hands up to count entries to the consumer of the group. Entries left
+// pending by a consumer for longer than minIdle are reclaimed first, so an
+// entry is only lost once it has been acknowledged.
+func (r *DeadLetterQueue) Claim(ctx context.Context, group, consumer string, count int64, minIdle time.Duration) ([]models.DLQEntry, error) {
+	claimed, _, err := r.Client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   r.streamKey(),
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) > 0 {
+		return r.decodeEntries(claimed), nil
+	}
+
+	streams, err := r.Client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{r.streamKey(), ">"},
+		Count:    count,
+		Block:    -1, // Do not block, an empty result means the stream is drained
+	}).Result()
+	if errors.Is(err, redis.Nil) || len(streams) == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.decodeEntries(streams[0].Messages), nil
+}
+
+// Ack acknowledges replayed entries for the group and removes them from the DLQ
+func (r *DeadLetterQueue) Ack(ctx context.Context, group string, entries []models.DLQEntry, reason string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(entries))
+	pipe := r.Client.TxPipeline()
+	for idx, entry := range entries {
+		ids[idx] = entry.ID
+		pipe.HDel(ctx, r.indexKey(), models.DLQEntryID(entry.Record))
+	}
+	pipe.XAck(ctx, r.streamKey(), group, ids...)
+	pipe.XDel(ctx, r.streamKey(), ids...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	r.Metrics.Dequeue(reason, len(entries))
+	r.refreshDepth(ctx)
+	return nil
+}
+
+// MigrateFromList moves entries from the list based layout used before the
+// stream (list Name with ids, hash "{Name}:entries" with entries) into the stream
+func (r *DeadLetterQueue) MigrateFromList(ctx context.Context, batchSize int64) (int, error) {
+	listKey, entriesKey := r.Config.Name, "{"+r.Config.Name+"}:entries"
+
+	migrated := 0
+	for {
+		// Oldest ids are at the tail of the list
+		ids, err := r.Client.LRange(ctx, listKey, -batchSize, -1).Result()
+		if err != nil || len(ids) == 0 {
+			return migrated, err
+		}
+
+		values, err := r.Client.HMGet(ctx, entriesKey, ids...).Result()
+		if err != nil {
+			return migrated, err
+		}
+
+		var entries []models.DLQEntry
+		for idx := len(values) - 1; idx >= 0; idx-- {
+			data, ok := values[idx].(string)
+			if !ok {
+				continue
+			}
+			var entry models.DLQEntry
+			if err := json.Unmarshal([]byte(data), &entry); err != nil {
+				r.Logger.Warn("skipping malformed legacy dlq entry", zap.String("id", ids[idx]), zap.Error(err))
+				continue
+			}
+			entries = append(entries, entry)
+		}
+
+		if len(entries) > 0 {
+			if err := r.write(ctx, entries, false); err != nil {
+				return migrated, err
+			}
+		}
+
+		pipe := r.Client.TxPipeline()
+		pipe.LTrim(ctx, listKey, 0, int64(-len(ids)-1))
+		pipe.HDel(ctx, entriesKey, ids...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return migrated, err
+		}
+		migrated += len(entries)
+	}
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+)
+
+// FeatureFlagRepo keeps feature flags in a hash of flag name to JSON encoded flag
+type FeatureFlagRepo struct {
+	Client redis.UniversalClient
+	Key    string
+}
+
+func NewFeatureFlagRepo(client redis.UniversalClient, key string) *FeatureFlagRepo {
+	return &FeatureFlagRepo{Client: client, Key: key}
+}
+
+// List returns every stored flag
+func (r *FeatureFlagRepo) List(ctx context.Context) (map[string]models.FeatureFlag, error) {
+	values, err := r.Client.HGetAll(ctx, r.Key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feature flags: %v", err)
+	}
+	flags := make(map[string]models.FeatureFlag, len(values))
+	for name, value := range values {
+		var flag models.FeatureFlag
+		if err := json.Unmarshal([]byte(value), &flag); err != nil {
+			return nil, fmt.Errorf("failed to decode feature flag %s: %v", name, err)
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// Set stores a flag, replacing any flag with the same name
+func (r *FeatureFlagRepo) Set(ctx context.Context, name string, flag models.FeatureFlag) error {
+	value, err := json.Marshal(flag)
+	if err != nil {
+		return fmt.Errorf("failed to encode feature flag: %v", err)
+	}
+	if err := r.Client.HSet(ctx, r.Key, name, value).Err(); err != nil {
+		return fmt.Errorf("failed to store feature flag: %v", err)
+	}
+	return nil
+}
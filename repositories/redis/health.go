@@ -0,0 +1,76 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"sync"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	metrics "tx-stream/metrics"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// HealthChecker pings redis periodically and remembers the outcome, so
+// readiness can report redis trouble separately from kafka trouble
+type HealthChecker struct {
+	Client   redis.UniversalClient
+	Logger   *zap.Logger
+	Metrics  *metrics.RedisMetrics // Optional
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu      sync.RWMutex
+	lastErr error
+}
+
+func NewHealthChecker(client redis.UniversalClient, logger *zap.Logger, redisMetrics *metrics.RedisMetrics, interval, timeout time.Duration) *HealthChecker {
+	return &HealthChecker{Client: client, Logger: logger, Metrics: redisMetrics, Interval: interval, Timeout: timeout}
+}
+
+// Run checks the connection every Interval until the context is canceled
+func (h *HealthChecker) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthChecker) check(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := h.Client.Ping(pingCtx).Err()
+	h.Metrics.SetUp(err == nil, time.Since(start).Seconds())
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil && h.lastErr == nil {
+		h.Logger.Warn("redis health check failed", zap.Error(err))
+	} else if err == nil && h.lastErr != nil {
+		h.Logger.Info("redis health check recovered")
+	}
+	h.lastErr = err
+}
+
+// Ready returns the error of the last health check, nil while redis is healthy
+func (h *HealthChecker) Ready() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.lastErr != nil {
+		return errors.E(errors.Internal, "redis is unhealthy", h.lastErr)
+	}
+	return nil
+}
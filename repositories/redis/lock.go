@@ -0,0 +1,170 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// acquireScript sets the lock if it is free and hands out the next fencing token
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// renewScript extends the lock only while it is still held by the caller
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock only while it is still held by the caller
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Locker hands out leases on named locks shared by all replicas
+type Locker struct {
+	Client redis.UniversalClient
+	Logger *zap.Logger
+	Owner  string // Identifies this replica in the lock value, e.g. the hostname
+}
+
+func NewLocker(client redis.UniversalClient, logger *zap.Logger, owner string) *Locker {
+	return &Locker{Client: client, Logger: logger, Owner: owner}
+}
+
+// Lease is a held lock that is renewed in the background until released or lost.
+// Token is a fencing token that increases with every acquisition of the lock, so
+// writes guarded by the lock can reject a holder whose lease silently expired.
+type Lease struct {
+	Name  string
+	Token int64
+
+	locker  *Locker
+	key     string
+	value   string
+	ttl     time.Duration
+	stop    chan struct{}
+	lost    chan struct{}
+	stopped sync.Once
+	done    sync.WaitGroup
+}
+
+func lockKeys(name string) (string, string) {
+	// Both keys share a hash tag so the scripts work on a cluster
+	return "{lock:" + name + "}", "{lock:" + name + "}:fence"
+}
+
+// Acquire takes the named lock for ttl, returns a Conflict error if another holder has it.
+// The lease renews itself every ttl/3 until Release is called or renewal fails.
+func (l *Locker) Acquire(ctx context.Context, name string, ttl time.Duration) (*Lease, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	value := l.Owner + ":" + hex.EncodeToString(nonce)
+
+	key, fenceKey := lockKeys(name)
+	token, err := acquireScript.Run(ctx, l.Client, []string{key, fenceKey}, value, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, err
+	}
+	if token == 0 {
+		return nil, errors.E(errors.Conflict, "lock "+name+" is held by another owner")
+	}
+
+	lease := &Lease{
+		Name:   name,
+		Token:  token,
+		locker: l,
+		key:    key,
+		value:  value,
+		ttl:    ttl,
+		stop:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	lease.done.Add(1)
+	go lease.renew()
+	return lease, nil
+}
+
+// renew extends the lease until it is released, closing lost if it cannot
+func (lease *Lease) renew() {
+	defer lease.done.Done()
+
+	ticker := time.NewTicker(lease.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), lease.ttl/3)
+			ok, err := renewScript.Run(ctx, lease.locker.Client, []string{lease.key}, lease.value, lease.ttl.Milliseconds()).Int64()
+			cancel()
+			if err != nil || ok == 0 {
+				lease.locker.Logger.Warn("lost lock lease", zap.String("lock", lease.Name),
+					zap.Int64("token", lease.Token), zap.Error(err))
+				close(lease.lost)
+				return
+			}
+		}
+	}
+}
+
+// Lost is closed when the lease could not be renewed and may be held by someone else
+func (lease *Lease) Lost() <-chan struct{} {
+	return lease.lost
+}
+
+// Release stops renewal and frees the lock if it is still held
+func (lease *Lease) Release(ctx context.Context) error {
+	lease.stopped.Do(func() { close(lease.stop) })
+	lease.done.Wait()
+	return releaseScript.Run(ctx, lease.locker.Client, []string{lease.key}, lease.value).Err()
+}
+
+// WithLock runs fn while holding the named lock. The context passed to fn is
+// canceled if the lease is lost, so fn must stop its work when it is done.
+func (l *Locker) WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context, token int64) error) error {
+	lease, err := l.Acquire(ctx, name, ttl)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := lease.Release(context.Background()); err != nil {
+			l.Logger.Warn("failed to release lock", zap.String("lock", name), zap.Error(err))
+		}
+	}()
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lease.Lost():
+			cancel()
+		case <-fnCtx.Done():
+		}
+	}()
+
+	return fn(fnCtx, lease.Token)
+}
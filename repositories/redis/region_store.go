@@ -0,0 +1,71 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+)
+
+// takeOverScript names a new active region only if the record still names
+// the old one, so the standby regions never flip it twice
+var takeOverScript = redis.NewScript(`
+if (redis.call("GET", KEYS[1]) or "") ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2])
+return 1
+`)
+
+// RegionStore keeps the name of the active region in "Key:active", set by
+// hand for a planned failover, and the heartbeat of each region in
+// "Key:heartbeat:region", expiring when the region stops beating
+type RegionStore struct {
+	Client redis.UniversalClient
+	Key    string
+}
+
+func NewRegionStore(client redis.UniversalClient, key string) *RegionStore {
+	return &RegionStore{Client: client, Key: key}
+}
+
+// Active returns the active region, empty while the record is unset
+func (s *RegionStore) Active(ctx context.Context) (string, error) {
+	active, err := s.Client.Get(ctx, s.Key+":active").Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read the active region: %v", err)
+	}
+	return active, nil
+}
+
+// Beat marks the region alive for ttl
+func (s *RegionStore) Beat(ctx context.Context, region string, ttl time.Duration) error {
+	if err := s.Client.Set(ctx, s.Key+":heartbeat:"+region, time.Now().UTC().Format(time.RFC3339), ttl).Err(); err != nil {
+		return fmt.Errorf("failed to beat for region %s: %v", region, err)
+	}
+	return nil
+}
+
+// Alive reports whether the region beat within the ttl of its last beat
+func (s *RegionStore) Alive(ctx context.Context, region string) (bool, error) {
+	n, err := s.Client.Exists(ctx, s.Key+":heartbeat:"+region).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to read the heartbeat of region %s: %v", region, err)
+	}
+	return n > 0, nil
+}
+
+// TakeOver makes to the active region if from, empty for an unset record, still is
+func (s *RegionStore) TakeOver(ctx context.Context, from, to string) (bool, error) {
+	taken, err := takeOverScript.Run(ctx, s.Client, []string{s.Key + ":active"}, from, to).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to take over from region %s: %v", from, err)
+	}
+	return taken == 1, nil
+}
@@ -0,0 +1,178 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type RetryConfig struct {
+	Name        string
+	BaseDelay   time.Duration // Delay before the first retry, doubled for every further retry
+	MaxDelay    time.Duration
+	MaxAttempts int           // Retries before a record is handed to the permanent DLQ
+	Lease       time.Duration // Due records are hidden this long while a worker processes them
+}
+
+// claimScript hands out due records and pushes their score past the lease, so
+// records of a worker that crashed mid-retry become due again
+var claimScript = redis.NewScript(`
+local ids = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+if #ids == 0 then
+	return {}
+end
+for _, id in ipairs(ids) do
+	redis.call("ZADD", KEYS[1], ARGV[3], id)
+end
+return redis.call("HMGET", KEYS[2], unpack(ids))
+`)
+
+// retryEntry is a failed record waiting for its next attempt
+type retryEntry struct {
+	models.DLQEntry
+	Retries int       `json:"retries"`
+	DueAt   time.Time `json:"due_at"`
+}
+
+// RetryQueue delays failed records in the sorted set "{Name}:schedule", scored
+// by the next attempt time in milliseconds, with the entries themselves in the
+// hash "{Name}:entries" keyed by record (topic:partition:offset).
+type RetryQueue struct {
+	Client redis.UniversalClient
+	Logger *zap.Logger
+	Config *RetryConfig
+}
+
+func NewRetryQueue(client redis.UniversalClient, logger *zap.Logger, conf *RetryConfig) *RetryQueue {
+	return &RetryQueue{Client: client, Logger: logger, Config: conf}
+}
+
+func (r *RetryQueue) scheduleKey() string {
+	return "{" + r.Config.Name + "}:schedule"
+}
+
+func (r *RetryQueue) entriesKey() string {
+	return "{" + r.Config.Name + "}:entries"
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (r *RetryQueue) backoff(retry int) time.Duration {
+	delay := r.Config.BaseDelay
+	for idx := 1; idx < retry && delay < r.Config.MaxDelay; idx++ {
+		delay *= 2
+	}
+	return min(delay, r.Config.MaxDelay)
+}
+
+// Schedule delays the records with exponential backoff. Records that used up
+// MaxAttempts retries are returned for the permanent DLQ, they stay due again
+// after MaxDelay until Done removes them once the DLQ took them.
+func (r *RetryQueue) Schedule(ctx context.Context, records []models.Record, cause error, attempts int) ([]models.DLQEntry, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	entries := models.NewDLQEntries(records, cause, attempts)
+
+	ids := make([]string, len(entries))
+	for idx, entry := range entries {
+		ids[idx] = entry.ID
+	}
+	prevs, err := r.Client.HMGet(ctx, r.entriesKey(), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var exhausted []models.DLQEntry
+	now := time.Now()
+	pipe := r.Client.TxPipeline()
+	for idx, entry := range entries {
+		retry := retryEntry{DLQEntry: entry}
+		if data, ok := prevs[idx].(string); ok {
+			var prev retryEntry
+			if err := json.Unmarshal([]byte(data), &prev); err == nil {
+				retry.Retries = prev.Retries
+				retry.Attempts += prev.Attempts
+				retry.FirstFailedAt = prev.FirstFailedAt
+			}
+		}
+
+		if retry.Retries >= r.Config.MaxAttempts {
+			exhausted = append(exhausted, retry.DLQEntry)
+			retry.DueAt = now.Add(r.Config.MaxDelay)
+		} else {
+			retry.Retries++
+			retry.DueAt = now.Add(r.backoff(retry.Retries))
+		}
+		data, err := json.Marshal(retry)
+		if err != nil {
+			r.Logger.Error("failed to marshal retry entry", zap.String("record", entry.ID), zap.Error(err))
+			continue
+		}
+		pipe.HSet(ctx, r.entriesKey(), entry.ID, data)
+		pipe.ZAdd(ctx, r.scheduleKey(), redis.Z{Score: float64(retry.DueAt.UnixMilli()), Member: entry.ID})
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to schedule retries: %v", err)
+	}
+	return exhausted, nil
+}
+
+// Due claims up to count records whose next attempt is due
+func (r *RetryQueue) Due(ctx context.Context, count int64) ([]models.Record, error) {
+	now := time.Now()
+	values, err := claimScript.Run(ctx, r.Client, []string{r.scheduleKey(), r.entriesKey()},
+		now.UnixMilli(), count, now.Add(r.Config.Lease).UnixMilli()).Slice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	records := make([]models.Record, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var retry retryEntry
+		if err := json.Unmarshal([]byte(data), &retry); err != nil {
+			r.Logger.Error("failed to unmarshal retry entry", zap.Error(err))
+			continue
+		}
+		records = append(records, retry.Record)
+	}
+	return records, nil
+}
+
+// Done removes records that were processed successfully
+func (r *RetryQueue) Done(ctx context.Context, records []models.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	ids := make([]string, len(records))
+	members := make([]interface{}, len(records))
+	for idx, record := range records {
+		ids[idx] = models.DLQEntryID(record)
+		members[idx] = ids[idx]
+	}
+
+	pipe := r.Client.TxPipeline()
+	pipe.ZRem(ctx, r.scheduleKey(), members...)
+	pipe.HDel(ctx, r.entriesKey(), ids...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Pending returns the number of records waiting for a retry
+func (r *RetryQueue) Pending(ctx context.Context) (int64, error) {
+	return r.Client.ZCard(ctx, r.scheduleKey()).Result()
+}
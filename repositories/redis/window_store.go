@@ -0,0 +1,170 @@
+package redis
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/redis/go-redis/v9"
+)
+
+// addScript counts a transaction into a bucket unless the bucket has seen
+// it, so a redelivered batch is not counted twice
+var addScript = redis.NewScript(`
+if redis.call("SADD", KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call("HINCRBY", KEYS[2], "c:" .. ARGV[2], 1)
+redis.call("HINCRBY", KEYS[2], "v:" .. ARGV[2], ARGV[3])
+if ARGV[4] == "1" then
+	redis.call("HINCRBY", KEYS[2], "d:" .. ARGV[2], 1)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+redis.call("PEXPIRE", KEYS[2], ARGV[5])
+return 1
+`)
+
+// advanceScript moves the watermark only from the value the caller read
+var advanceScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if (current or "") ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2])
+return 1
+`)
+
+// WindowStore keeps the window buckets in redis. A bucket is the hash
+// "{Prefix:window:start}:totals", with a count, volume and declined field
+// per group, and the set "{Prefix:window:start}:seen" of the transactions it
+// counted. The watermark "{Prefix:window}:watermark" is the end of the last
+// emitted window, in unix milliseconds.
+type WindowStore struct {
+	Client redis.UniversalClient
+	Prefix string
+}
+
+func NewWindowStore(client redis.UniversalClient, prefix string) *WindowStore {
+	return &WindowStore{Client: client, Prefix: prefix}
+}
+
+func (s *WindowStore) bucketKeys(window string, start time.Time) (string, string) {
+	// Both keys share a hash tag so the script works on a cluster
+	tag := "{" + s.Prefix + ":" + window + ":" + strconv.FormatInt(start.UnixMilli(), 10) + "}"
+	return tag + ":seen", tag + ":totals"
+}
+
+func (s *WindowStore) watermarkKey(window string) string {
+	return "{" + s.Prefix + ":" + window + "}:watermark"
+}
+
+// Add counts the transactions into their buckets in one round trip, the
+// result tells for each add whether it was new rather than a redelivery
+func (s *WindowStore) Add(ctx context.Context, adds []models.WindowAdd) ([]bool, error) {
+	if len(adds) == 0 {
+		return nil, nil
+	}
+	added, err := s.add(ctx, adds)
+	if redis.HasErrorPrefix(err, "NOSCRIPT") {
+		// The seen sets make the replay of the batch harmless
+		if err := addScript.Load(ctx, s.Client).Err(); err != nil {
+			return nil, err
+		}
+		added, err = s.add(ctx, adds)
+	}
+	return added, err
+}
+
+func (s *WindowStore) add(ctx context.Context, adds []models.WindowAdd) ([]bool, error) {
+	pipe := s.Client.Pipeline()
+	cmds := make([]*redis.Cmd, len(adds))
+	for idx, add := range adds {
+		seen, totals := s.bucketKeys(add.Window, add.Bucket)
+		declined := "0"
+		if add.Declined {
+			declined = "1"
+		}
+		cmds[idx] = addScript.EvalSha(ctx, pipe, []string{seen, totals}, add.TxID, add.Group, add.VolumeCents, declined, add.TTL.Milliseconds())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	added := make([]bool, len(adds))
+	for idx, cmd := range cmds {
+		added[idx] = cmd.Val() == int64(1)
+	}
+	return added, nil
+}
+
+// Totals sums the buckets of a window by group, buckets that expired or
+// never had a transaction count as empty
+func (s *WindowStore) Totals(ctx context.Context, window string, buckets []time.Time) (map[string]models.WindowTotals, error) {
+	pipe := s.Client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(buckets))
+	for idx, bucket := range buckets {
+		_, totals := s.bucketKeys(window, bucket)
+		cmds[idx] = pipe.HGetAll(ctx, totals)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]models.WindowTotals)
+	for _, cmd := range cmds {
+		for field, value := range cmd.Val() {
+			kind, group, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid window total %s of %s: %v", field, window, err)
+			}
+			totals := sums[group]
+			switch kind {
+			case "c":
+				totals.Count += n
+			case "v":
+				totals.VolumeCents += n
+			case "d":
+				totals.Declined += n
+			}
+			sums[group] = totals
+		}
+	}
+	return sums, nil
+}
+
+// Watermark returns the end of the last emitted window, zero before the first
+func (s *WindowStore) Watermark(ctx context.Context, window string) (time.Time, error) {
+	value, err := s.Client.Get(ctx, s.watermarkKey(window)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid watermark of %s: %v", window, err)
+	}
+	return time.UnixMilli(ms).UTC(), nil
+}
+
+// AdvanceWatermark moves the watermark from what was read to the given end,
+// false means another replica moved it first
+func (s *WindowStore) AdvanceWatermark(ctx context.Context, window string, from, to time.Time) (bool, error) {
+	var current string
+	if !from.IsZero() {
+		current = strconv.FormatInt(from.UnixMilli(), 10)
+	}
+	n, err := advanceScript.Run(ctx, s.Client, []string{s.watermarkKey(window)}, current, to.UnixMilli()).Int64()
+	return n == 1, err
+}
@@ -0,0 +1,28 @@
+package s3
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// External Packages
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Connect creates an S3 client using the default AWS credential chain.
+// A non-empty endpoint targets S3-compatible stores such as MinIO.
+func Connect(ctx context.Context, region, endpoint string) (*s3.Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return client, nil
+}
@@ -0,0 +1,59 @@
+package s3
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DeadLetterQueue writes every failed batch as one NDJSON object to a bucket.
+// It is write-only, objects are inspected and replayed with regular S3 tooling.
+type DeadLetterQueue struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+func NewDeadLetterQueue(client *s3.Client, bucket, prefix string) *DeadLetterQueue {
+	return &DeadLetterQueue{Client: client, Bucket: bucket, Prefix: prefix}
+}
+
+// Send uploads all failed records under prefix/dt=YYYY-MM-DD/
+func (r *DeadLetterQueue) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	entries := models.NewDLQEntries(records, cause, attempts)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+
+	first := entries[0]
+	key := path.Join(r.Prefix, "dt="+first.LastFailedAt.Format(time.DateOnly),
+		fmt.Sprintf("%s-%d-%d-%d.ndjson", first.Record.Topic, first.Record.Partition,
+			first.Record.Offset, first.LastFailedAt.UnixNano()))
+
+	_, err := r.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(r.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(buf.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	return err
+}
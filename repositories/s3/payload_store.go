@@ -0,0 +1,52 @@
+package s3
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"io"
+
+	// Local Packages
+	errors "tx-stream/errors"
+
+	// External Packages
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// PayloadStore reads the payloads the producers parked in a bucket for the
+// claim checks of their records
+type PayloadStore struct {
+	Client *s3.Client
+}
+
+func NewPayloadStore(client *s3.Client) *PayloadStore {
+	return &PayloadStore{Client: client}
+}
+
+// Get reads an object of at most maxBytes. A missing object is a NotFound
+// error and a larger one an Invalid error, neither is worth retrying.
+func (s *PayloadStore) Get(ctx context.Context, bucket, key string, maxBytes int64) ([]byte, error) {
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, errors.E(errors.NotFound, fmt.Sprintf("s3://%s/%s not found", bucket, key))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get s3://%s/%s: %v", bucket, key, err)
+	}
+	defer out.Body.Close()
+	if size := aws.ToInt64(out.ContentLength); size > maxBytes {
+		return nil, errors.E(errors.Invalid, fmt.Sprintf("s3://%s/%s is %d bytes, over %d", bucket, key, size, maxBytes))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read s3://%s/%s: %v", bucket, key, err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, errors.E(errors.Invalid, fmt.Sprintf("s3://%s/%s is over %d bytes", bucket, key, maxBytes))
+	}
+	return data, nil
+}
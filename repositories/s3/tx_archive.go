@@ -0,0 +1,287 @@
+package s3
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Archive formats
+const (
+	FormatParquet = "parquet"
+	FormatNDJSON  = "ndjson"
+)
+
+type ArchiveConfig struct {
+	Bucket        string
+	Prefix        string
+	Format        string // parquet or ndjson, ndjson files are gzipped
+	Partition     string // hour or day, the granularity of the dt=/hour= directories
+	MaxRecords    int    // A file is uploaded once it holds this many transactions
+	MaxBytes      int    // or this many bytes of uncompressed NDJSON
+	FlushInterval time.Duration
+}
+
+// ArchivedTransaction is a row of the archive, the transaction flattened with
+// the record it came from
+type ArchivedTransaction struct {
+	TxID            string    `json:"transaction_id" parquet:"transaction_id"`
+	Amount          float32   `json:"amount" parquet:"amount"`
+	Currency        string    `json:"currency" parquet:"currency,dict"`
+	TransactionType string    `json:"transaction_type" parquet:"transaction_type,dict"`
+	Status          string    `json:"status" parquet:"status,dict"`
+	Timestamp       string    `json:"timestamp" parquet:"timestamp"`
+	PaymentMethod   string    `json:"payment_method" parquet:"payment_method,dict"`
+	Topic           string    `json:"topic" parquet:"topic,dict"`
+	Partition       int32     `json:"partition" parquet:"partition"`
+	Offset          int64     `json:"offset" parquet:"offset"`
+	Consumer        string    `json:"consumer,omitempty" parquet:"consumer,dict"`
+	ProcessedAt     time.Time `json:"processed_at" parquet:"processed_at,timestamp(millisecond)"`
+}
+
+func newArchivedTransaction(tx models.MongoTransaction) ArchivedTransaction {
+	row := ArchivedTransaction{
+		TxID:            tx.TxID,
+		Amount:          tx.Amount,
+		Currency:        tx.Currency,
+		TransactionType: tx.TransactionType,
+		Status:          tx.Status,
+		Timestamp:       tx.Timestamp,
+		PaymentMethod:   tx.PaymentMethod,
+		ProcessedAt:     time.Now().UTC(),
+	}
+	if p := tx.Provenance; p != nil {
+		row.Topic, row.Partition, row.Offset, row.Consumer = p.Topic, p.Partition, p.Offset, p.Consumer
+		row.ProcessedAt = p.ProcessedAt.UTC()
+	}
+	return row
+}
+
+type archiveFile struct {
+	rows   []ArchivedTransaction
+	bytes  int
+	opened time.Time
+}
+
+// TxArchive buffers the transactions into files per hive partition of their
+// processing time, prefix/dt=YYYY-MM-DD/hour=HH/, and uploads a file once it
+// is full or has been open for the flush interval. The bucket is a raw
+// archive for Athena or Spark: delivery is at least once, so queries dedupe
+// on transaction_id, and a crash loses what was buffered but not uploaded,
+// at most a flush interval of records. GCS is written through its S3
+// interoperability endpoint.
+type TxArchive struct {
+	Client *s3.Client
+	Config *ArchiveConfig
+	Logger *zap.Logger
+
+	instance string // Keeps the file names of instances sharing a bucket apart
+	mu       sync.Mutex
+	files    map[string]*archiveFile // By partition directory
+	seq      int
+}
+
+func NewTxArchive(client *s3.Client, conf *ArchiveConfig, logger *zap.Logger) *TxArchive {
+	id := make([]byte, 4)
+	_, _ = rand.Read(id)
+	return &TxArchive{Client: client, Config: conf, Logger: logger, instance: hex.EncodeToString(id), files: make(map[string]*archiveFile)}
+}
+
+// InsertTransactions buffers a batch, uploading the files it fills. When an
+// upload fails the rows of the batch are taken back out of that file so the
+// redelivered batch is not archived twice.
+func (a *TxArchive) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "s3", "s3.archive",
+		attribute.String("aws.s3.bucket", a.Config.Bucket), attribute.Int("db.documents", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	rows := make([]ArchivedTransaction, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot archive a %T", tx)
+		}
+		rows = append(rows, newArchivedTransaction(doc))
+	}
+	return a.add(ctx, rows)
+}
+
+// InsertTransaction buffers a single transaction
+func (a *TxArchive) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "s3", "s3.archive",
+		attribute.String("aws.s3.bucket", a.Config.Bucket), attribute.Int("db.documents", 1))
+	defer func() { tracing.End(span, err) }()
+	return a.add(ctx, []ArchivedTransaction{newArchivedTransaction(tx)})
+}
+
+func (a *TxArchive) add(ctx context.Context, rows []ArchivedTransaction) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	before := make(map[string]int)
+	for _, row := range rows {
+		dir := a.partitionDir(row.ProcessedAt)
+		file := a.files[dir]
+		if file == nil {
+			file = &archiveFile{opened: time.Now()}
+			a.files[dir] = file
+		}
+		if _, ok := before[dir]; !ok {
+			before[dir] = len(file.rows)
+		}
+		line, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %v", row.TxID, err)
+		}
+		file.rows = append(file.rows, row)
+		file.bytes += len(line) + 1
+	}
+
+	var errs []error
+	for dir := range before {
+		file := a.files[dir]
+		if len(file.rows) < a.Config.MaxRecords && file.bytes < a.Config.MaxBytes {
+			continue
+		}
+		if err := a.upload(ctx, dir, file); err != nil {
+			file.rows = file.rows[:before[dir]]
+			file.bytes = ndjsonSize(file.rows)
+			errs = append(errs, err)
+			continue
+		}
+		delete(a.files, dir)
+	}
+	return errors.Join(errs...)
+}
+
+// Run uploads the files open for longer than the flush interval, so a quiet
+// stream still reaches the bucket
+func (a *TxArchive) Run(ctx context.Context) {
+	ticker := time.NewTicker(max(a.Config.FlushInterval/4, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := a.flush(ctx, time.Now().Add(-a.Config.FlushInterval)); err != nil {
+			a.Logger.Error("failed to upload the archive files, retrying on the next tick", zap.Error(err))
+		}
+	}
+}
+
+// Flush uploads every buffered file, run at shutdown before the last commit
+func (a *TxArchive) Flush(ctx context.Context) error {
+	return a.flush(ctx, time.Now())
+}
+
+// Close uploads what is still buffered
+func (a *TxArchive) Close(ctx context.Context) error {
+	return a.Flush(ctx)
+}
+
+// flush uploads the files opened before the cutoff, a failed file stays
+// buffered for the next attempt
+func (a *TxArchive) flush(ctx context.Context, cutoff time.Time) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for dir, file := range a.files {
+		if file.opened.After(cutoff) {
+			continue
+		}
+		if err := a.upload(ctx, dir, file); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(a.files, dir)
+	}
+	return errors.Join(errs...)
+}
+
+func (a *TxArchive) upload(ctx context.Context, dir string, file *archiveFile) error {
+	if len(file.rows) == 0 {
+		return nil
+	}
+	body, contentType, ext, err := a.encode(file.rows)
+	if err != nil {
+		return err
+	}
+	a.seq++
+	key := path.Join(dir, fmt.Sprintf("part-%s-%d-%05d.%s", a.instance, file.opened.UnixMilli(), a.seq, ext))
+	_, err = a.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(a.Config.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %v", key, err)
+	}
+	a.Logger.Info("archived transactions", zap.String("key", key), zap.Int("records", len(file.rows)), zap.Int("bytes", len(body)))
+	return nil
+}
+
+func (a *TxArchive) encode(rows []ArchivedTransaction) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if a.Config.Format == FormatParquet {
+		writer := parquet.NewGenericWriter[ArchivedTransaction](&buf, parquet.Compression(&parquet.Snappy))
+		if _, err := writer.Write(rows); err != nil {
+			return nil, "", "", fmt.Errorf("failed to write parquet rows: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, "", "", fmt.Errorf("failed to write parquet footer: %v", err)
+		}
+		return buf.Bytes(), "application/vnd.apache.parquet", "parquet", nil
+	}
+	gz := gzip.NewWriter(&buf)
+	encoder := json.NewEncoder(gz)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), "application/x-ndjson", "ndjson.gz", nil
+}
+
+// partitionDir is the hive partition of a processing time
+func (a *TxArchive) partitionDir(at time.Time) string {
+	at = at.UTC()
+	dir := path.Join(a.Config.Prefix, "dt="+at.Format(time.DateOnly))
+	if a.Config.Partition == "hour" {
+		dir = path.Join(dir, fmt.Sprintf("hour=%02d", at.Hour()))
+	}
+	return dir
+}
+
+func ndjsonSize(rows []ArchivedTransaction) int {
+	size := 0
+	for _, row := range rows {
+		line, _ := json.Marshal(row)
+		size += len(line) + 1
+	}
+	return size
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+	tracing "tx-stream/tracing"
+
+	// External Packages
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+)
+
+// Headers of every delivery
+const (
+	SignatureHeader = "X-Signature"   // t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+	DeliveryHeader  = "X-Delivery-Id" // Same for the retries and redeliveries of a payload, for dedupe
+)
+
+type Endpoint struct {
+	Name       string
+	URL        string
+	Secret     string // HMAC key, unsigned when empty
+	MaxRetries int
+	Backoff    time.Duration // Doubles per retry up to MaxBackoff
+	MaxBackoff time.Duration
+}
+
+// Payload is the body posted to the endpoints
+type Payload struct {
+	Transactions []models.MongoTransaction `json:"transactions"`
+}
+
+// DeadLetter is a delivery an endpoint did not accept, appended to the dead
+// letter file to be replayed by hand
+type DeadLetter struct {
+	Endpoint   string          `json:"endpoint"`
+	DeliveryID string          `json:"delivery_id"`
+	Attempts   int             `json:"attempts"`
+	Error      string          `json:"error"`
+	FailedAt   time.Time       `json:"failed_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// TxSink posts the transactions to partner endpoints, BatchSize transactions
+// per request. The endpoints are delivered to concurrently and each retries
+// on its own, a delivery an endpoint still refuses is dead-lettered so one
+// partner being down never holds back the stream or the other partners.
+// Delivery is at least once, receivers dedupe on the X-Delivery-Id header.
+type TxSink struct {
+	Endpoints      []Endpoint
+	BatchSize      int
+	DeadLetterPath string
+	Client         *http.Client
+	Logger         *zap.Logger
+
+	deadLetterMu sync.Mutex
+}
+
+func NewTxSink(endpoints []Endpoint, batchSize int, deadLetterPath string, timeout time.Duration, logger *zap.Logger) *TxSink {
+	return &TxSink{
+		Endpoints:      endpoints,
+		BatchSize:      batchSize,
+		DeadLetterPath: deadLetterPath,
+		Client:         &http.Client{Timeout: timeout},
+		Logger:         logger,
+	}
+}
+
+// InsertTransactions delivers a batch to every endpoint
+func (s *TxSink) InsertTransactions(ctx context.Context, txs []interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook", "webhook.deliver", attribute.Int("db.documents", len(txs)))
+	defer func() { tracing.End(span, err) }()
+
+	docs := make([]models.MongoTransaction, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot deliver a %T", tx)
+		}
+		docs = append(docs, doc)
+	}
+	return s.deliver(ctx, docs)
+}
+
+// InsertTransaction delivers a single transaction to every endpoint
+func (s *TxSink) InsertTransaction(ctx context.Context, tx models.MongoTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, "webhook", "webhook.deliver", attribute.Int("db.documents", 1))
+	defer func() { tracing.End(span, err) }()
+	return s.deliver(ctx, []models.MongoTransaction{tx})
+}
+
+// Close releases the idle connections
+func (s *TxSink) Close(context.Context) error {
+	s.Client.CloseIdleConnections()
+	return nil
+}
+
+// deliver fails only when a refused delivery cannot be dead-lettered, or on
+// cancellation, so the batch is retried instead of lost
+func (s *TxSink) deliver(ctx context.Context, docs []models.MongoTransaction) error {
+	var payloads [][]byte
+	for start := 0; start < len(docs); start += s.BatchSize {
+		body, err := json.Marshal(Payload{Transactions: docs[start:min(start+s.BatchSize, len(docs))]})
+		if err != nil {
+			return fmt.Errorf("failed to encode the payload: %v", err)
+		}
+		payloads = append(payloads, body)
+	}
+
+	errs := make([]error, len(s.Endpoints))
+	var wg sync.WaitGroup
+	for idx, endpoint := range s.Endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, body := range payloads {
+				if err := s.deliverTo(ctx, endpoint, body); err != nil {
+					errs[idx] = err
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *TxSink) deliverTo(ctx context.Context, endpoint Endpoint, body []byte) error {
+	sum := sha256.Sum256(body)
+	deliveryID := hex.EncodeToString(sum[:16])
+	backoff := endpoint.Backoff
+	var err error
+	attempt := 0
+	for ; attempt <= endpoint.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, endpoint.MaxBackoff)
+		}
+		var retryable bool
+		if retryable, err = s.post(ctx, endpoint, deliveryID, body); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable {
+			attempt++
+			break
+		}
+		s.Logger.Warn("webhook delivery failed, retrying", zap.String("endpoint", endpoint.Name),
+			zap.String("delivery_id", deliveryID), zap.Int("attempt", attempt+1), zap.Error(err))
+	}
+
+	s.Logger.Error("webhook delivery refused, dead-lettering it", zap.String("endpoint", endpoint.Name),
+		zap.String("delivery_id", deliveryID), zap.Int("attempts", attempt), zap.Error(err))
+	return s.deadLetter(DeadLetter{
+		Endpoint:   endpoint.Name,
+		DeliveryID: deliveryID,
+		Attempts:   attempt,
+		Error:      err.Error(),
+		FailedAt:   time.Now().UTC(),
+		Payload:    body,
+	})
+}
+
+// post sends one delivery, the bool tells whether a retry may succeed:
+// network errors, 429 and 5xx are retried, other refusals are final
+func (s *TxSink) post(ctx context.Context, endpoint Endpoint, deliveryID string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, deliveryID)
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, time.Now(), body))
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to post webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the signature header of a body sent at t, receivers recompute
+// it with the shared secret and reject stale timestamps against replays
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *TxSink) deadLetter(letter DeadLetter) error {
+	s.deadLetterMu.Lock()
+	defer s.deadLetterMu.Unlock()
+	f, err := os.OpenFile(s.DeadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the webhook dead letter file: %v", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(letter); err != nil {
+		return fmt.Errorf("failed to dead-letter delivery %s: %v", letter.DeliveryID, err)
+	}
+	return nil
+}
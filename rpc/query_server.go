@@ -0,0 +1,157 @@
+package rpc
+
+//go:generate sh -c "cd ../proto && buf generate"
+
+import (
+	// Go Internal Packages
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+	txstreamv1 "tx-stream/proto/txstream/v1"
+	txsvc "tx-stream/services/transactions"
+
+	// External Packages
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// QueryServer serves the TransactionQueryService of proto/txstream/v1
+type QueryServer struct {
+	txstreamv1.UnimplementedTransactionQueryServiceServer
+	Queries *txsvc.QueryService
+	Logger  *zap.Logger
+}
+
+func NewQueryServer(queries *txsvc.QueryService, logger *zap.Logger) *QueryServer {
+	return &QueryServer{Queries: queries, Logger: logger}
+}
+
+func (s *QueryServer) GetTransaction(ctx context.Context, req *txstreamv1.GetTransactionRequest) (*txstreamv1.GetTransactionResponse, error) {
+	tx, err := s.Queries.Get(ctx, req.GetTransactionId())
+	if err != nil {
+		return nil, s.status(err)
+	}
+	return &txstreamv1.GetTransactionResponse{Transaction: toTransaction(tx)}, nil
+}
+
+func (s *QueryServer) ListTransactions(ctx context.Context, req *txstreamv1.ListTransactionsRequest) (*txstreamv1.ListTransactionsResponse, error) {
+	filter := models.TxFilter{
+		UserID:          req.GetUserId(),
+		Status:          req.GetStatus(),
+		Currency:        req.GetCurrency(),
+		TransactionType: req.GetTransactionType(),
+	}
+	if req.ProcessedAfter != nil {
+		filter.ProcessedAfter = req.ProcessedAfter.AsTime()
+	}
+	if req.ProcessedBefore != nil {
+		filter.ProcessedBefore = req.ProcessedBefore.AsTime()
+	}
+	page, err := s.Queries.List(ctx, filter, int(req.GetPageSize()), req.GetPageToken())
+	if err != nil {
+		return nil, s.status(err)
+	}
+	resp := &txstreamv1.ListTransactionsResponse{NextPageToken: page.NextPageToken}
+	for _, tx := range page.Transactions {
+		resp.Transactions = append(resp.Transactions, toTransaction(tx))
+	}
+	return resp, nil
+}
+
+func (s *QueryServer) GetProcessingStatus(ctx context.Context, req *txstreamv1.GetProcessingStatusRequest) (*txstreamv1.GetProcessingStatusResponse, error) {
+	statuses, err := s.Queries.Status(ctx, req.GetTransactionIds())
+	if err != nil {
+		return nil, s.status(err)
+	}
+	resp := &txstreamv1.GetProcessingStatusResponse{}
+	for _, st := range statuses {
+		state := txstreamv1.ProcessingStatus_STATE_NOT_FOUND
+		if st.Processed {
+			state = txstreamv1.ProcessingStatus_STATE_PROCESSED
+		}
+		resp.Statuses = append(resp.Statuses, &txstreamv1.ProcessingStatus{
+			TransactionId:     st.TxID,
+			State:             state,
+			Provenance:        toProvenance(st.Provenance),
+			DeadLetteredSinks: st.DeadLetteredSinks,
+		})
+	}
+	return resp, nil
+}
+
+// status maps an application error to its grpc status, internal errors are
+// logged and hidden from the caller
+func (s *QueryServer) status(err error) error {
+	var appErr *errors.Error
+	if !errors.As(err, &appErr) {
+		s.Logger.Error("query failed", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+	switch appErr.Kind {
+	case errors.Invalid:
+		message := appErr.Message
+		var validationErrs errors.ValidationErrors
+		if errors.As(appErr.WrappedErr, &validationErrs) {
+			message = validationErrs.Error()
+		} else if appErr.WrappedErr != nil {
+			message += ": " + appErr.WrappedErr.Error()
+		}
+		return status.Error(codes.InvalidArgument, message)
+	case errors.NotFound:
+		return status.Error(codes.NotFound, appErr.Message)
+	default:
+		s.Logger.Error("query failed", zap.Error(err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func toTransaction(tx models.MongoTransaction) *txstreamv1.Transaction {
+	return &txstreamv1.Transaction{
+		TransactionId:   tx.TxID,
+		UserId:          tx.UserID,
+		Amount:          tx.Amount,
+		Currency:        tx.Currency,
+		TransactionType: tx.TransactionType,
+		Status:          tx.Status,
+		Timestamp:       tx.Timestamp,
+		PaymentMethod:   tx.PaymentMethod,
+		Provenance:      toProvenance(tx.Provenance),
+	}
+}
+
+func toProvenance(p *models.Provenance) *txstreamv1.Provenance {
+	if p == nil {
+		return nil
+	}
+	return &txstreamv1.Provenance{
+		Topic:       p.Topic,
+		Partition:   p.Partition,
+		Offset:      p.Offset,
+		Consumer:    p.Consumer,
+		ProcessedAt: timestamppb.New(p.ProcessedAt),
+	}
+}
+
+// RequireToken rejects the calls without the bearer token in their
+// authorization metadata
+func RequireToken(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var given string
+		if values := md.Get("authorization"); len(values) > 0 {
+			given, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,183 @@
+package scheduler
+
+import (
+	// Go Internal Packages
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the first run strictly after a time, zero when there is none
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// Descriptors standing for a cron expression
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+// Parse reads a cron expression of five fields, minute hour day-of-month
+// month day-of-week, each a *, a value, a range or a list of them with an
+// optional /step. It also takes the @hourly style descriptors and
+// "@every <duration>", which runs on the multiples of the duration.
+func Parse(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %v", err)
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("@every must be at least a second")
+		}
+		return everySchedule(every), nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, minute hour day-of-month month day-of-week, got %d", len(fields))
+	}
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute: %v", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour: %v", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month: %v", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month: %v", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week: %v", err)
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is sunday as well
+	}
+	s.domAny, s.dowAny = fields[2] == "*", fields[4] == "*"
+	s.loc = loc
+	if loc == nil {
+		s.loc = time.UTC
+	}
+	if s.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("%q never runs", spec)
+	}
+	return s, nil
+}
+
+// parseField returns the bit set of the values a field matches
+func parseField(field string, low, high int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepText)
+			}
+		}
+		start, end := low, high
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = strconv.Atoi(first); err != nil {
+				return 0, fmt.Errorf("invalid value %q", first)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(last); err != nil {
+					return 0, fmt.Errorf("invalid value %q", last)
+				}
+			} else if hasStep {
+				end = high // 5/15 runs from 5 to the end
+			}
+		}
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("%q is out of %d-%d", item, low, high)
+		}
+		for value := start; value <= end; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+	loc                           *time.Location
+}
+
+func (s cronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	// Leap days repeat every 4 years, a schedule not matching by then never will
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = nextHour(t, s.loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			// Jumps to the next minute of the set within the hour, if any
+			rest := s.minute >> uint(t.Minute())
+			if rest == 0 {
+				t = nextHour(t, s.loc)
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// nextHour is the start of the next hour, by the clock of the location
+func nextHour(t time.Time, loc *time.Location) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+	if !next.After(t) {
+		// The clock went back, the hour repeats
+		next = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+	}
+	return next
+}
+
+// matchesDay follows cron, when both day fields are restricted either one matches
+func (s cronSchedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+type everySchedule time.Duration
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Truncate(time.Duration(s)).Add(time.Duration(s))
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Job is a periodic task, Run gets a context canceled after Timeout or when
+// the scheduler stops
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Timeout  time.Duration // Zero runs the job without a deadline
+	Run      func(ctx context.Context) error
+}
+
+type job struct {
+	Job
+	running atomic.Bool
+}
+
+// Scheduler runs periodic jobs on their schedules. A run that is due while
+// the previous run of the job is still in flight is skipped, not queued, so
+// a slow job never piles up. It is meant to run as a singleton job, so the
+// jobs run on the leader only.
+type Scheduler struct {
+	Logger  *zap.Logger
+	Metrics *metrics.SchedulerMetrics
+	Now     func() time.Time
+
+	jobs []*job
+}
+
+func NewScheduler(logger *zap.Logger, schedulerMetrics *metrics.SchedulerMetrics) *Scheduler {
+	return &Scheduler{Logger: logger, Metrics: schedulerMetrics, Now: time.Now}
+}
+
+// Add registers a job
+// (PS: Must be called before Run)
+func (s *Scheduler) Add(j Job) {
+	s.jobs = append(s.jobs, &job{Job: j})
+}
+
+// Jobs returns the names of the registered jobs
+func (s *Scheduler) Jobs() []string {
+	names := make([]string, len(s.jobs))
+	for idx, j := range s.jobs {
+		names[idx] = j.Name
+	}
+	return names
+}
+
+// Run runs the jobs on their schedules until ctx is done, then waits for the
+// runs in flight, which see ctx canceled, to return
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, j, &wg)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job, wg *sync.WaitGroup) {
+	for {
+		next := j.Schedule.Next(s.Now())
+		if next.IsZero() {
+			s.Logger.Warn("scheduled job has no next run, stopping it", zap.String("job", j.Name))
+			return
+		}
+		s.Metrics.Scheduled(j.Name, float64(next.Unix()))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if !j.running.CompareAndSwap(false, true) {
+			s.Metrics.Skipped(j.Name)
+			s.Logger.Warn("skipping scheduled run, the previous run is still in flight", zap.String("job", j.Name))
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer j.running.Store(false)
+			s.run(ctx, j)
+		}()
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job) {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+	s.Metrics.Started(j.Name)
+	start := s.Now()
+	err := s.call(ctx, j)
+	end := s.Now()
+	elapsed := end.Sub(start)
+	if err != nil {
+		s.Metrics.Finished(j.Name, metrics.RunFailed, elapsed.Seconds(), float64(end.Unix()))
+		s.Logger.Error("scheduled job failed", zap.String("job", j.Name), zap.Duration("duration", elapsed), zap.Error(err))
+		return
+	}
+	s.Metrics.Finished(j.Name, metrics.RunSucceeded, elapsed.Seconds(), float64(end.Unix()))
+	s.Logger.Info("scheduled job finished", zap.String("job", j.Name), zap.Duration("duration", elapsed))
+}
+
+// call turns a panic of the job into an error, so one job cannot take the
+// others down with it
+func (s *Scheduler) call(ctx context.Context, j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.Run(ctx)
+}
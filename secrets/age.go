@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	// External Packages
+	"filippo.io/age"
+)
+
+// AgePrefix marks a config value holding base64 encoded age ciphertext, as
+// produced by `age -r <recipient> | base64 -w0`
+const AgePrefix = "enc:"
+
+// IsAgeValue reports whether a config value is age encrypted
+func IsAgeValue(value string) bool {
+	return strings.HasPrefix(value, AgePrefix)
+}
+
+// AgeDecrypter decrypts enc: config values with the identities of an age key file
+type AgeDecrypter struct {
+	Identities []age.Identity
+}
+
+// NewAgeDecrypter reads the identities of keyFile. An empty keyFile falls back to
+// the SOPS_AGE_KEY_FILE and SOPS_AGE_KEY variables shared with the sops binary.
+func NewAgeDecrypter(keyFile string) (*AgeDecrypter, error) {
+	if keyFile == "" {
+		keyFile = os.Getenv("SOPS_AGE_KEY_FILE")
+	}
+
+	var keys []byte
+	switch {
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read age key file: %v", err)
+		}
+		keys = data
+	case os.Getenv("SOPS_AGE_KEY") != "":
+		keys = []byte(os.Getenv("SOPS_AGE_KEY"))
+	default:
+		return nil, fmt.Errorf("no age key, set encryption.age_key_file or SOPS_AGE_KEY_FILE")
+	}
+
+	identities, err := age.ParseIdentities(bytes.NewReader(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age keys: %v", err)
+	}
+	return &AgeDecrypter{Identities: identities}, nil
+}
+
+// Decrypt returns the plaintext of an enc: value
+func (d *AgeDecrypter) Decrypt(value string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, AgePrefix))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode age value: %v", err)
+	}
+	reader, err := age.Decrypt(bytes.NewReader(ciphertext), d.Identities...)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age value: %v", err)
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt age value: %v", err)
+	}
+	return string(plaintext), nil
+}
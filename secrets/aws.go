@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// External Packages
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Reference prefixes of config values resolved from AWS
+const (
+	SecretsManagerPrefix = "aws-sm://"
+	ParameterStorePrefix = "aws-ssm://"
+)
+
+// IsAWSRef reports whether the config value refers to an AWS secret or parameter
+func IsAWSRef(value string) bool {
+	return strings.HasPrefix(value, SecretsManagerPrefix) || strings.HasPrefix(value, ParameterStorePrefix)
+}
+
+// AWSResolver resolves config values of the forms
+//
+//	aws-sm://payments/mongo-uri          the secret string
+//	aws-sm://payments/db#password        a field of a JSON secret
+//	aws-ssm:///payments/redis-password   a parameter, decrypted if secure
+//
+// using the default credential chain, so IAM roles work without extra config
+type AWSResolver struct {
+	SecretsManager *secretsmanager.Client
+	ParameterStore *ssm.Client
+}
+
+// NewAWSResolver creates the clients, an empty region uses AWS_REGION or the profile region
+func NewAWSResolver(ctx context.Context, region string) (*AWSResolver, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %v", err)
+	}
+	return &AWSResolver{
+		SecretsManager: secretsmanager.NewFromConfig(cfg),
+		ParameterStore: ssm.NewFromConfig(cfg),
+	}, nil
+}
+
+// secretField returns the field of a JSON secret, or the whole secret without a field
+func secretField(id, value, field string) (string, error) {
+	if field == "" {
+		return value, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %v", id, err)
+	}
+	fieldValue, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %s", id, field)
+	}
+	return fmt.Sprint(fieldValue), nil
+}
+
+// Resolve returns the value the reference points to
+func (r *AWSResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, SecretsManagerPrefix):
+		id, field, _ := strings.Cut(strings.TrimPrefix(ref, SecretsManagerPrefix), "#")
+		out, err := r.SecretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(id)})
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %s: %v", id, err)
+		}
+		return secretField(id, aws.ToString(out.SecretString), field)
+
+	case strings.HasPrefix(ref, ParameterStorePrefix):
+		name := strings.TrimPrefix(ref, ParameterStorePrefix)
+		out, err := r.ParameterStore.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(name), WithDecryption: aws.Bool(true)})
+		if err != nil {
+			return "", fmt.Errorf("failed to get parameter %s: %v", name, err)
+		}
+		return aws.ToString(out.Parameter.Value), nil
+
+	default:
+		return "", fmt.Errorf("%q is not an aws reference", ref)
+	}
+}
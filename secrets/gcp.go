@@ -0,0 +1,84 @@
+package secrets
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"strings"
+
+	// External Packages
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+)
+
+// GCPSecretManagerPrefix prefixes config values resolved from GCP Secret Manager
+const GCPSecretManagerPrefix = "gcp-sm://"
+
+// IsGCPRef reports whether the config value refers to a GCP secret
+func IsGCPRef(value string) bool {
+	return strings.HasPrefix(value, GCPSecretManagerPrefix)
+}
+
+// GCPResolver resolves config values of the forms
+//
+//	gcp-sm://mongo-uri                      the latest version in the default project
+//	gcp-sm://payments-prod/mongo-uri/3      a version of a secret in a project
+//	gcp-sm://payments-prod/db#password      a field of a JSON secret
+//
+// using application default credentials, so GKE workload identity works
+// without extra config
+type GCPResolver struct {
+	Client  *secretmanager.Client
+	Project string // Used by references without a project
+}
+
+func NewGCPResolver(ctx context.Context, project string) (*GCPResolver, error) {
+	client, err := secretmanager.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcp secret manager client: %v", err)
+	}
+	return &GCPResolver{Client: client, Project: project}, nil
+}
+
+// Resolve returns the value the reference points to
+func (r *GCPResolver) Resolve(ctx context.Context, ref string) (string, error) {
+	if !IsGCPRef(ref) {
+		return "", fmt.Errorf("%q is not a gcp reference", ref)
+	}
+	path, field, _ := strings.Cut(strings.TrimPrefix(ref, GCPSecretManagerPrefix), "#")
+	name, err := r.versionName(path)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := r.Client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+	if err != nil {
+		return "", fmt.Errorf("failed to access secret %s: %v", name, err)
+	}
+	return secretField(name, string(out.GetPayload().GetData()), field)
+}
+
+// versionName expands [project/]secret[/version] to the secret version resource name
+func (r *GCPResolver) versionName(path string) (string, error) {
+	parts := strings.Split(path, "/")
+	project, version := r.Project, "latest"
+	var secret string
+	switch len(parts) {
+	case 1:
+		secret = parts[0]
+	case 2:
+		project, secret = parts[0], parts[1]
+	case 3:
+		project, secret, version = parts[0], parts[1], parts[2]
+	default:
+		return "", fmt.Errorf("gcp reference %q must be [project/]secret[/version]", path)
+	}
+	if project == "" || secret == "" {
+		return "", fmt.Errorf("gcp reference %q needs a project, set gcp.project or write project/secret", path)
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", project, secret, version), nil
+}
+
+func (r *GCPResolver) Close() error {
+	return r.Client.Close()
+}
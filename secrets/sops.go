@@ -0,0 +1,30 @@
+package secrets
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+)
+
+// sopsMetadata matches the top level sops block every encrypted file carries
+var sopsMetadata = regexp.MustCompile(`(?m)^sops:\s*$`)
+
+// IsSOPSFile reports whether the yaml content was encrypted by sops
+func IsSOPSFile(data []byte) bool {
+	return sopsMetadata.Match(data)
+}
+
+// DecryptSOPSFile decrypts a sops encrypted yaml file with the sops binary,
+// which finds its keys (age, KMS, PGP) the same way it does on the command line
+func DecryptSOPSFile(path string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", path)
+	cmd.Stderr = &stderr
+	plaintext, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt sops file %s: %v: %s", path, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return plaintext, nil
+}
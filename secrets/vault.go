@@ -0,0 +1,167 @@
+package secrets
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	// External Packages
+	vault "github.com/hashicorp/vault/api"
+	"go.uber.org/zap"
+)
+
+// Vault auth methods supported by NewVault
+const (
+	AuthToken      = "token"
+	AuthAppRole    = "approle"
+	AuthKubernetes = "kubernetes"
+)
+
+type VaultConfig struct {
+	Address      string // Empty uses VAULT_ADDR
+	AuthMethod   string
+	AuthMount    string // Defaults to the auth method name
+	Token        string // Token auth, empty uses VAULT_TOKEN
+	RoleID       string // AppRole auth
+	SecretIDFile string // AppRole auth
+	Role         string // Kubernetes auth
+	JWTFile      string // Kubernetes auth, the service account token
+	Mount        string // KV v2 mount, e.g. secret
+	Path         string // Secret path within the mount, e.g. tx-stream
+}
+
+// Vault reads config overrides from a KV v2 secret whose keys are config keys,
+// for instance "mongo.uri", "redis.password" or "kafka.sasl.password"
+type Vault struct {
+	Client *vault.Client
+	Config *VaultConfig
+
+	auth *vault.Secret // Nil for token auth
+}
+
+// NewVault connects and authenticates to vault
+func NewVault(ctx context.Context, conf *VaultConfig) (*Vault, error) {
+	vaultConf := vault.DefaultConfig()
+	if conf.Address != "" {
+		vaultConf.Address = conf.Address
+	}
+	client, err := vault.NewClient(vaultConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault client: %v", err)
+	}
+
+	v := &Vault{Client: client, Config: conf}
+	if err := v.login(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *Vault) login(ctx context.Context) error {
+	mount := v.Config.AuthMount
+	if mount == "" {
+		mount = v.Config.AuthMethod
+	}
+
+	var data map[string]interface{}
+	switch v.Config.AuthMethod {
+	case AuthToken, "":
+		if v.Config.Token != "" {
+			v.Client.SetToken(v.Config.Token)
+		}
+		return nil
+	case AuthAppRole:
+		secretID, err := os.ReadFile(v.Config.SecretIDFile)
+		if err != nil {
+			return fmt.Errorf("failed to read approle secret id: %v", err)
+		}
+		data = map[string]interface{}{"role_id": v.Config.RoleID, "secret_id": strings.TrimSpace(string(secretID))}
+	case AuthKubernetes:
+		jwt, err := os.ReadFile(v.Config.JWTFile)
+		if err != nil {
+			return fmt.Errorf("failed to read service account token: %v", err)
+		}
+		data = map[string]interface{}{"role": v.Config.Role, "jwt": strings.TrimSpace(string(jwt))}
+	default:
+		return fmt.Errorf("unknown vault auth method %q", v.Config.AuthMethod)
+	}
+
+	secret, err := v.Client.Logical().WriteWithContext(ctx, "auth/"+mount+"/login", data)
+	if err != nil {
+		return fmt.Errorf("failed to login to vault: %v", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("vault login returned no token")
+	}
+	v.Client.SetToken(secret.Auth.ClientToken)
+	v.auth = secret
+	return nil
+}
+
+// Read returns the secret as a map of config keys to values
+func (v *Vault) Read(ctx context.Context) (map[string]interface{}, error) {
+	secret, err := v.Client.KVv2(v.Config.Mount).Get(ctx, v.Config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault secret %s/%s: %v", v.Config.Mount, v.Config.Path, err)
+	}
+	return secret.Data, nil
+}
+
+// KeepAlive renews the login token until the context is canceled, logging in
+// again once it can no longer be renewed. Tokens given directly are renewed
+// while they are renewable.
+func (v *Vault) KeepAlive(ctx context.Context, logger *zap.Logger) {
+	for ctx.Err() == nil {
+		auth := v.auth
+		if auth == nil {
+			self, err := v.Client.Auth().Token().LookupSelfWithContext(ctx)
+			if err != nil {
+				logger.Warn("cannot look up vault token, not renewing it", zap.Error(err))
+				return
+			}
+			if renewable, _ := self.TokenIsRenewable(); !renewable {
+				return
+			}
+			// LookupSelf reports the token under data, the watcher expects it under auth
+			ttl, _ := self.TokenTTL()
+			auth = &vault.Secret{Auth: &vault.SecretAuth{ClientToken: v.Client.Token(), Renewable: true, LeaseDuration: int(ttl.Seconds())}}
+		}
+
+		err := v.renew(ctx, logger, auth)
+		if ctx.Err() != nil {
+			return
+		}
+		if v.auth == nil {
+			logger.Warn("vault token can no longer be renewed", zap.Error(err))
+			return
+		}
+		logger.Info("vault token expired, logging in again", zap.Error(err))
+		if err := v.login(ctx); err != nil {
+			logger.Error("vault login failed", zap.Error(err))
+			return
+		}
+	}
+}
+
+// renew keeps the token alive until it can no longer be renewed or the context is canceled
+func (v *Vault) renew(ctx context.Context, logger *zap.Logger, auth *vault.Secret) error {
+	watcher, err := v.Client.NewLifetimeWatcher(&vault.LifetimeWatcherInput{Secret: auth})
+	if err != nil {
+		return err
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-watcher.DoneCh():
+			return err
+		case renewal := <-watcher.RenewCh():
+			logger.Debug("renewed vault token", zap.Time("at", renewal.RenewedAt))
+		}
+	}
+}
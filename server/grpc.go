@@ -0,0 +1,56 @@
+package server
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	// External Packages
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+type GRPCServer struct {
+	GRPC   *grpc.Server
+	Addr   string
+	Logger *zap.Logger
+}
+
+// NewGRPCServer creates a grpc server listening on the given port, the
+// services are registered on GRPC before Start
+func NewGRPCServer(port int, logger *zap.Logger, opts ...grpc.ServerOption) *GRPCServer {
+	return &GRPCServer{GRPC: grpc.NewServer(opts...), Addr: fmt.Sprintf(":%d", port), Logger: logger}
+}
+
+// Start listens and serves until the server is shut down
+func (s *GRPCServer) Start() error {
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	s.Logger.Info("starting grpc server", zap.String("addr", s.Addr))
+	err = s.GRPC.Serve(listener)
+	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting calls and waits for the running ones, those still
+// running at the deadline are canceled
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.GRPC.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.GRPC.Stop()
+		return ctx.Err()
+	}
+}
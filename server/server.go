@@ -0,0 +1,45 @@
+package server
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+type Server struct {
+	HTTP   *http.Server
+	Logger *zap.Logger
+}
+
+// NewServer creates a new http server listening on the given port
+func NewServer(port int, handler http.Handler, logger *zap.Logger) *Server {
+	return &Server{
+		HTTP: &http.Server{
+			Addr:              fmt.Sprintf(":%d", port),
+			Handler:           handler,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		Logger: logger,
+	}
+}
+
+// Start listens and serves until the server is shut down
+func (s *Server) Start() error {
+	s.Logger.Info("starting http server", zap.String("addr", s.HTTP.Addr))
+	err := s.HTTP.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.HTTP.Shutdown(ctx)
+}
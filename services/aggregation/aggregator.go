@@ -0,0 +1,298 @@
+package aggregation
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Windows emitted per window and round, so a long downtime is caught up a
+// little at a time instead of stalling the other windows
+const maxCatchUp = 100
+
+// Window is a tumbling window of Size, or with a Slide a sliding window of
+// Size emitted every Slide
+type Window struct {
+	Name  string
+	Size  time.Duration
+	Slide time.Duration
+}
+
+// bucket is the granularity the window is counted at, it is emitted at every
+// bucket boundary
+func (w Window) bucket() time.Duration {
+	if w.Slide == 0 {
+		return w.Size
+	}
+	return w.Slide
+}
+
+type Config struct {
+	Windows          []Window
+	GroupBy          []string // JSON names of the string fields of models.Transaction
+	DeclinedStatuses []string
+	Grace            time.Duration // A window is emitted this long after its end, for the batches still in flight
+	Retention        time.Duration // Buckets outlive their windows this long, the downtime the emitter can catch up
+	Interval         time.Duration // Between two emission rounds
+}
+
+// Store keeps the window buckets and the watermark of every window, see
+// redis.WindowStore
+type Store interface {
+	Add(ctx context.Context, adds []models.WindowAdd) ([]bool, error)
+	Totals(ctx context.Context, window string, buckets []time.Time) (map[string]models.WindowTotals, error)
+	Watermark(ctx context.Context, window string) (time.Time, error)
+	AdvanceWatermark(ctx context.Context, window string, from, to time.Time) (bool, error)
+}
+
+// ResultSink receives the results of the closed windows
+type ResultSink interface {
+	Put(ctx context.Context, results []models.WindowResult) error
+}
+
+// Aggregator counts the stored transactions into windows by group (e.g. per
+// merchant and currency) and emits the count, volume and decline rate of each
+// group once a window closes. The totals live in the store, so every replica
+// counts into the same windows, and a transaction is counted once per window
+// however often it is redelivered. Transactions are windowed by their record
+// timestamp. A failed count is logged and not retried, it never fails the
+// batch the transactions were stored with.
+type Aggregator struct {
+	Config  Config
+	Store   Store
+	Sink    ResultSink
+	Logger  *zap.Logger
+	Metrics *metrics.AggregationMetrics
+
+	groupFields []int // Indexes of the GroupBy fields in models.Transaction
+	declined    map[string]bool
+}
+
+func NewAggregator(conf Config, store Store, sink ResultSink, logger *zap.Logger, aggregationMetrics *metrics.AggregationMetrics) (*Aggregator, error) {
+	fields, err := GroupFields(conf.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	declined := make(map[string]bool, len(conf.DeclinedStatuses))
+	for _, status := range conf.DeclinedStatuses {
+		declined[status] = true
+	}
+	return &Aggregator{
+		Config:      conf,
+		Store:       store,
+		Sink:        sink,
+		Logger:      logger,
+		Metrics:     aggregationMetrics,
+		groupFields: fields,
+		declined:    declined,
+	}, nil
+}
+
+// GroupFields returns the indexes of the named string fields of
+// models.Transaction, by JSON name
+func GroupFields(names []string) ([]int, error) {
+	txType := reflect.TypeOf(models.Transaction{})
+	indexes := make([]int, len(names))
+	for idx, name := range names {
+		found := false
+		for fdx := range txType.NumField() {
+			field := txType.Field(fdx)
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if tag == name && field.Type.Kind() == reflect.String {
+				indexes[idx], found = fdx, true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s is not a text field of the transactions", name)
+		}
+	}
+	return indexes, nil
+}
+
+// group encodes the values of the group_by fields of a transaction
+func (a *Aggregator) group(tx models.Transaction) string {
+	value := reflect.ValueOf(tx)
+	values := make([]string, len(a.groupFields))
+	for idx, field := range a.groupFields {
+		values[idx] = value.Field(field).String()
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+// Observe counts the stored transactions into every window, records[i]
+// being the record of txs[i]. A nil aggregator does nothing.
+func (a *Aggregator) Observe(ctx context.Context, records []models.Record, txs []models.Transaction) {
+	if a == nil || len(txs) == 0 {
+		return
+	}
+	now := time.Now()
+	var adds []models.WindowAdd
+	late := make(map[string]int)
+	for idx, tx := range txs {
+		at := records[idx].Timestamp
+		if at.IsZero() {
+			at = now
+		}
+		group := a.group(tx)
+		for _, window := range a.Config.Windows {
+			bucket := at.Truncate(window.bucket())
+			// The last window holding the bucket ends Size after it
+			if !bucket.Add(window.Size).After(now.Add(-a.Config.Grace)) {
+				late[window.Name]++
+				continue
+			}
+			adds = append(adds, models.WindowAdd{
+				Window:      window.Name,
+				Bucket:      bucket,
+				Group:       group,
+				TxID:        tx.TxID,
+				VolumeCents: int64(math.Round(float64(tx.Amount) * 100)),
+				Declined:    a.declined[tx.Status],
+				TTL:         window.Size + a.Config.Grace + a.Config.Retention,
+			})
+		}
+	}
+	for window, n := range late {
+		a.Metrics.Counted(window, metrics.WindowLate, n)
+	}
+
+	added, err := a.Store.Add(ctx, adds)
+	if err != nil {
+		for _, add := range adds {
+			a.Metrics.Counted(add.Window, metrics.WindowError, 1)
+		}
+		a.Logger.Error("failed to count transactions into the windows, the aggregates miss them",
+			zap.Int("transactions", len(txs)), zap.Error(err))
+		return
+	}
+	for idx, add := range adds {
+		if added[idx] {
+			a.Metrics.Counted(add.Window, metrics.WindowAdded, 1)
+		} else {
+			a.Metrics.Counted(add.Window, metrics.WindowDuplicate, 1)
+		}
+	}
+}
+
+// Run emits the windows that closed every interval until ctx is done, on
+// one replica only when the election is on
+func (a *Aggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.Config.Interval)
+	defer ticker.Stop()
+	for {
+		for _, window := range a.Config.Windows {
+			if err := a.emit(ctx, window); err != nil && ctx.Err() == nil {
+				a.Logger.Error("failed to emit window aggregates, retrying on the next round", zap.String("window", window.Name), zap.Error(err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// emit sends the results of the closed windows after the watermark and moves
+// the watermark past them. Another replica moving the watermark first ends
+// the round, a crash between the two repeats the window with the same ids.
+func (a *Aggregator) emit(ctx context.Context, window Window) error {
+	step := window.bucket()
+	closed := time.Now().Add(-a.Config.Grace).Truncate(step)
+	mark, err := a.Store.Watermark(ctx, window.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read the watermark: %v", err)
+	}
+	if mark.IsZero() {
+		// A new window starts with the next one to close
+		_, err := a.Store.AdvanceWatermark(ctx, window.Name, mark, closed)
+		return err
+	}
+	if floor := closed.Add(-a.Config.Retention).Truncate(step); mark.Before(floor) {
+		a.Logger.Warn("window buckets expired before they were emitted, skipping them",
+			zap.String("window", window.Name), zap.Time("from", mark), zap.Time("to", floor))
+		if ok, err := a.Store.AdvanceWatermark(ctx, window.Name, mark, floor); err != nil || !ok {
+			return err
+		}
+		mark = floor
+	}
+
+	for n := 0; n < maxCatchUp && mark.Before(closed); n++ {
+		end := mark.Add(step)
+		results, err := a.results(ctx, window, end)
+		if err != nil {
+			return err
+		}
+		if err := a.Sink.Put(ctx, results); err != nil {
+			return fmt.Errorf("failed to emit the window ending %s: %v", end.Format(time.RFC3339), err)
+		}
+		ok, err := a.Store.AdvanceWatermark(ctx, window.Name, mark, end)
+		if err != nil || !ok {
+			return err
+		}
+		a.Metrics.Emitted(window.Name, len(results), float64(end.Unix()))
+		mark = end
+	}
+	return nil
+}
+
+// results sums the buckets of the window ending at end, by group
+func (a *Aggregator) results(ctx context.Context, window Window, end time.Time) ([]models.WindowResult, error) {
+	start := end.Add(-window.Size)
+	var buckets []time.Time
+	for bucket := start; bucket.Before(end); bucket = bucket.Add(window.bucket()) {
+		buckets = append(buckets, bucket)
+	}
+	totals, err := a.Store.Totals(ctx, window.Name, buckets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum the window ending %s: %v", end.Format(time.RFC3339), err)
+	}
+
+	now := time.Now().UTC()
+	results := make([]models.WindowResult, 0, len(totals))
+	for group, total := range totals {
+		if total.Count == 0 {
+			continue
+		}
+		var values []string
+		if err := json.Unmarshal([]byte(group), &values); err != nil || len(values) != len(a.Config.GroupBy) {
+			continue // Counted under another group_by, the window is cut over
+		}
+		groupMap := make(map[string]string, len(values))
+		escaped := make([]string, len(values))
+		for idx, value := range values {
+			groupMap[a.Config.GroupBy[idx]] = value
+			escaped[idx] = url.PathEscape(value)
+		}
+		results = append(results, models.WindowResult{
+			ID:          fmt.Sprintf("%s/%d/%s", window.Name, end.UnixMilli(), strings.Join(escaped, "/")),
+			Window:      window.Name,
+			Group:       groupMap,
+			Start:       start.UTC(),
+			End:         end.UTC(),
+			Count:       total.Count,
+			Volume:      float64(total.VolumeCents) / 100,
+			Declined:    total.Declined,
+			DeclineRate: float64(total.Declined) / float64(total.Count),
+			EmittedAt:   now,
+		})
+	}
+	slices.SortFunc(results, func(a, b models.WindowResult) int { return strings.Compare(a.ID, b.ID) })
+	return results, nil
+}
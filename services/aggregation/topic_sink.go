@@ -0,0 +1,41 @@
+package aggregation
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+
+	// Local Packages
+	models "tx-stream/models"
+)
+
+type Producer interface {
+	Produce(ctx context.Context, records ...models.Record) error
+}
+
+// TopicSink publishes the window results to a topic, keyed by result id so
+// a compacted topic keeps the last emission of each
+type TopicSink struct {
+	Producer Producer
+	Topic    string
+}
+
+func NewTopicSink(producer Producer, topic string) *TopicSink {
+	return &TopicSink{Producer: producer, Topic: topic}
+}
+
+func (s *TopicSink) Put(ctx context.Context, results []models.WindowResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	records := make([]models.Record, len(results))
+	for idx, result := range results {
+		value, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode window result %s: %v", result.ID, err)
+		}
+		records[idx] = models.Record{Topic: s.Topic, Key: []byte(result.ID), Value: value}
+	}
+	return s.Producer.Produce(ctx, records...)
+}
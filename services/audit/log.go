@@ -0,0 +1,46 @@
+package audit
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// LogAuditor writes the committed offset ranges as log lines, for setups
+// shipping logs to a store that outlives the pods anyway
+type LogAuditor struct {
+	Logger *zap.Logger
+}
+
+func NewLogAuditor(logger *zap.Logger) *LogAuditor {
+	return &LogAuditor{Logger: logger.Named("offset_audit")}
+}
+
+// Record logs one line per committed range
+func (a *LogAuditor) Record(_ context.Context, ranges []models.OffsetRange) error {
+	for _, offsetRange := range ranges {
+		logger := a.Logger
+		if offsetRange.Reason != "" {
+			logger = logger.With(zap.String("reason", offsetRange.Reason))
+		}
+		logger.Info("offsets committed",
+			zap.String("consumer", offsetRange.Consumer),
+			zap.String("group", offsetRange.Group),
+			zap.String("topic", offsetRange.Topic),
+			zap.Int32("partition", offsetRange.Partition),
+			zap.Int64("first_offset", offsetRange.FirstOffset),
+			zap.Int64("last_offset", offsetRange.LastOffset),
+			zap.Int("records", offsetRange.Records),
+			zap.String("outcome", offsetRange.Outcome),
+			zap.Int("attempts", offsetRange.Attempts),
+			zap.Int64("duration_ms", offsetRange.DurationMS),
+			zap.Time("committed_at", offsetRange.CommittedAt),
+		)
+	}
+	return nil
+}
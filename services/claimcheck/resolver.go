@@ -0,0 +1,274 @@
+package claimcheck
+
+import (
+	// Go Internal Packages
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	logging "tx-stream/logging"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxEnvelopeBytes bounds the records looked at for an envelope, an envelope
+// is a few hundred bytes and the regular payloads are never decoded twice
+const maxEnvelopeBytes = 4096
+
+// Store reads a payload from object storage, see s3.PayloadStore. A NotFound
+// or Invalid error is never retried.
+type Store interface {
+	Get(ctx context.Context, bucket, key string, maxBytes int64) ([]byte, error)
+}
+
+// Resolver replaces the claim-check envelopes of the records, see
+// models.ClaimCheckEnvelope, by the payloads they reference before the
+// records are decoded. The payloads are fetched concurrently with retries and
+// kept in a cache of CacheBytes, so the retries of a batch fetch them once.
+type Resolver struct {
+	Store       Store
+	Logger      *zap.Logger
+	Metrics     *metrics.ClaimCheckMetrics
+	Buckets     []string // The only buckets referenced payloads are read from
+	MaxBytes    int64
+	Timeout     time.Duration // Of a fetch attempt
+	Attempts    int
+	Backoff     time.Duration // Doubles per attempt
+	Concurrency int
+
+	group singleflight.Group
+	cache *payloadCache
+}
+
+func NewResolver(store Store, logger *zap.Logger, claimMetrics *metrics.ClaimCheckMetrics, buckets []string, maxBytes int64,
+	timeout time.Duration, attempts int, backoff time.Duration, concurrency int, cacheBytes int64) *Resolver {
+	return &Resolver{
+		Store:       store,
+		Logger:      logger,
+		Metrics:     claimMetrics,
+		Buckets:     buckets,
+		MaxBytes:    maxBytes,
+		Timeout:     timeout,
+		Attempts:    max(attempts, 1),
+		Backoff:     backoff,
+		Concurrency: max(concurrency, 1),
+		cache:       newPayloadCache(cacheBytes),
+	}
+}
+
+// Resolve returns the records with the payloads in place of the envelopes.
+// Records whose envelope can never resolve, a missing object or a digest
+// mismatch, are logged and left out, rejected counts them. A payload still
+// failing after the retries fails the whole batch. A nil resolver returns the
+// records as they are.
+func (r *Resolver) Resolve(ctx context.Context, records []models.Record) (resolved []models.Record, rejected int, err error) {
+	if r == nil {
+		return records, 0, nil
+	}
+	var checks []int
+	for idx, record := range records {
+		if len(record.Value) <= maxEnvelopeBytes && bytes.Contains(record.Value, []byte(`"claim_check"`)) {
+			checks = append(checks, idx)
+		}
+	}
+	if len(checks) == 0 {
+		return records, 0, nil
+	}
+
+	resolved = slices.Clone(records)
+	keep := make([]bool, len(records))
+	for idx := range keep {
+		keep[idx] = true
+	}
+	var mu sync.Mutex
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(r.Concurrency)
+	batchLogger := logging.FromContext(ctx, r.Logger)
+	for _, idx := range checks {
+		group.Go(func() error {
+			var envelope models.ClaimCheckEnvelope
+			if json.Unmarshal(records[idx].Value, &envelope) != nil || envelope.ClaimCheck == nil {
+				return nil // Not an envelope after all
+			}
+			payload, err := r.fetch(groupCtx, *envelope.ClaimCheck)
+			if isPermanent(err) {
+				logging.ForRecord(batchLogger, records[idx]).Error("claim check cannot resolve, skipping the record",
+					zap.String("uri", envelope.ClaimCheck.URI), zap.Error(err))
+				r.Metrics.Resolved(metrics.ClaimCheckRejected)
+				mu.Lock()
+				keep[idx] = false
+				rejected++
+				mu.Unlock()
+				return nil
+			}
+			if err != nil {
+				r.Metrics.Resolved(metrics.ClaimCheckFailed)
+				return fmt.Errorf("failed to resolve claim check %s: %v", envelope.ClaimCheck.URI, err)
+			}
+			resolved[idx].Value = payload
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, 0, err
+	}
+	if rejected > 0 {
+		kept := resolved[:0]
+		for idx, record := range resolved {
+			if keep[idx] {
+				kept = append(kept, record)
+			}
+		}
+		resolved = kept
+	}
+	return resolved, rejected, nil
+}
+
+// fetch returns the payload of a claim check, from the cache when it holds it
+func (r *Resolver) fetch(ctx context.Context, check models.ClaimCheck) ([]byte, error) {
+	bucket, key, err := r.parse(check.URI)
+	if err != nil {
+		return nil, err
+	}
+	if payload, ok := r.cache.get(check.URI); ok {
+		r.Metrics.Resolved(metrics.ClaimCheckCached)
+		return payload, nil
+	}
+
+	// Envelopes of one batch often share a payload
+	result, err, _ := r.group.Do(check.URI, func() (interface{}, error) {
+		start := time.Now()
+		payload, err := r.get(ctx, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		r.Metrics.Fetched(time.Since(start).Seconds(), len(payload))
+		if err := verify(check, payload); err != nil {
+			return nil, err
+		}
+		r.cache.put(check.URI, payload)
+		return payload, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.Metrics.Resolved(metrics.ClaimCheckFetched)
+	return result.([]byte), nil
+}
+
+// get reads the payload, retrying with backoff until it is found or rejected
+func (r *Resolver) get(ctx context.Context, bucket, key string) ([]byte, error) {
+	backoff := r.Backoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+		payload, err := r.Store.Get(attemptCtx, bucket, key, r.MaxBytes)
+		cancel()
+		if err == nil || isPermanent(err) || attempt >= r.Attempts {
+			return payload, err
+		}
+		r.Logger.Warn("claim check fetch failed, retrying", zap.String("bucket", bucket), zap.String("key", key),
+			zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// parse splits an s3://bucket/key uri, the bucket must be an allowed one
+func (r *Resolver) parse(uri string) (string, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "s3" || u.Host == "" || strings.TrimPrefix(u.Path, "/") == "" {
+		return "", "", errors.E(errors.Invalid, fmt.Sprintf("claim check uri %q is not s3://bucket/key", uri))
+	}
+	if !slices.Contains(r.Buckets, u.Host) {
+		return "", "", errors.E(errors.Invalid, fmt.Sprintf("claim check bucket %s is not allowed", u.Host))
+	}
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// verify checks the payload against the size and digest of the envelope
+func verify(check models.ClaimCheck, payload []byte) error {
+	if check.Size > 0 && int64(len(payload)) != check.Size {
+		return errors.E(errors.Invalid, fmt.Sprintf("payload of %s is %d bytes, the envelope says %d", check.URI, len(payload), check.Size))
+	}
+	if check.SHA256 != "" {
+		sum := sha256.Sum256(payload)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), check.SHA256) {
+			return errors.E(errors.Invalid, fmt.Sprintf("payload of %s does not match its sha256", check.URI))
+		}
+	}
+	return nil
+}
+
+func isPermanent(err error) bool {
+	var appErr *errors.Error
+	return errors.As(err, &appErr) && (appErr.Kind == errors.NotFound || appErr.Kind == errors.Invalid)
+}
+
+// payloadCache keeps the most recently used payloads up to a total size
+type payloadCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // Of *cacheEntry, most recent first
+	entries  map[string]*list.Element
+}
+
+type cacheEntry struct {
+	uri     string
+	payload []byte
+}
+
+func newPayloadCache(maxBytes int64) *payloadCache {
+	return &payloadCache{maxBytes: maxBytes, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *payloadCache) get(uri string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[uri]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).payload, true
+}
+
+func (c *payloadCache) put(uri string, payload []byte) {
+	if int64(len(payload)) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[uri]; ok {
+		return
+	}
+	c.entries[uri] = c.order.PushFront(&cacheEntry{uri: uri, payload: payload})
+	c.size += int64(len(payload))
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.uri)
+		c.size -= int64(len(entry.payload))
+	}
+}
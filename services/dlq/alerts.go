@@ -0,0 +1,142 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, text string) error
+}
+
+// DepthFunc reports the current number of entries in the DLQ
+type DepthFunc func(ctx context.Context) (int64, error)
+
+// AlertingSender forwards failed records to the wrapped Sender and notifies when
+// the first dead letter after a quiet period arrives, when the wrapped Sender
+// starts failing or a full DLQ starts dropping records after a quiet period,
+// or when the DLQ depth crosses Threshold.
+// The depth alert fires again only after the depth drops back below the
+// threshold. The alerts are posted in the background, so a slow notifier never
+// holds up the poll loop, and their failures are logged, never returned.
+type AlertingSender struct {
+	Logger      *zap.Logger
+	Sender      Sender
+	Notifier    Notifier
+	Depth       DepthFunc // Nil when the backend cannot report its depth
+	Threshold   int64     // Zero disables the depth alert
+	QuietPeriod time.Duration
+	Source      string // Names the application in the alert text
+
+	mu              sync.Mutex
+	lastFailure     time.Time
+	lastSendFailure time.Time
+	lastOverflow    time.Time
+	above           bool
+	inFlight        sync.WaitGroup
+}
+
+func NewAlertingSender(logger *zap.Logger, sender Sender, notifier Notifier, depth DepthFunc, threshold int64, quietPeriod time.Duration, source string) *AlertingSender {
+	return &AlertingSender{
+		Logger:      logger,
+		Sender:      sender,
+		Notifier:    notifier,
+		Depth:       depth,
+		Threshold:   threshold,
+		QuietPeriod: quietPeriod,
+		Source:      source,
+	}
+}
+
+// Send dead-letters the records and then evaluates the alert conditions
+func (a *AlertingSender) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	err := a.Sender.Send(ctx, records, cause, attempts)
+	if len(records) == 0 {
+		return err
+	}
+
+	now := time.Now()
+	if err != nil {
+		var full *models.DLQFullError
+		overflow := errors.As(err, &full)
+		a.mu.Lock()
+		last := &a.lastSendFailure
+		if overflow {
+			last = &a.lastOverflow
+		}
+		quiet := last.IsZero() || now.Sub(*last) >= a.QuietPeriod
+		*last = now
+		a.mu.Unlock()
+		switch {
+		case quiet && overflow:
+			a.notify(ctx, fmt.Sprintf("[%s] dlq is full at %d entries, dropped %d records of topic %s, error class %s: %v",
+				a.Source, full.MaxLength, full.Dropped, records[0].Topic, errors.Class(cause), cause))
+		case quiet:
+			a.notify(ctx, fmt.Sprintf("[%s] failed to dead-letter %d records of topic %s, error class %s: %v",
+				a.Source, len(records), records[0].Topic, errors.Class(cause), err))
+		}
+		return err
+	}
+
+	a.mu.Lock()
+	quiet := a.lastFailure.IsZero() || now.Sub(a.lastFailure) >= a.QuietPeriod
+	a.lastFailure = now
+	threshold := a.Threshold
+	a.mu.Unlock()
+	if quiet {
+		a.notify(ctx, fmt.Sprintf("[%s] %d records dead-lettered after %s without failures, topic %s, error class %s: %v",
+			a.Source, len(records), a.QuietPeriod, records[0].Topic, errors.Class(cause), cause))
+	}
+
+	if a.Depth == nil || threshold <= 0 {
+		return nil
+	}
+	depth, err := a.Depth(ctx)
+	if err != nil {
+		a.Logger.Warn("failed to read dlq depth for alerting", zap.Error(err))
+		return nil
+	}
+	a.mu.Lock()
+	crossed := depth >= threshold && !a.above
+	a.above = depth >= threshold
+	a.mu.Unlock()
+	if crossed {
+		a.notify(ctx, fmt.Sprintf("[%s] dlq depth is %d, crossed the threshold of %d", a.Source, depth, threshold))
+	}
+	return nil
+}
+
+// SetThreshold changes the depth threshold of a running sender
+func (a *AlertingSender) SetThreshold(threshold int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.Threshold = threshold
+}
+
+// Wait returns once the alerts in flight are posted
+func (a *AlertingSender) Wait() {
+	a.inFlight.Wait()
+}
+
+// notify posts the alert in the background, past the cancellation of the
+// batch that raised it
+func (a *AlertingSender) notify(ctx context.Context, text string) {
+	ctx = context.WithoutCancel(ctx)
+	a.inFlight.Add(1)
+	go func() {
+		defer a.inFlight.Done()
+		if err := a.Notifier.Notify(ctx, text); err != nil {
+			a.Logger.Warn("failed to send dlq alert", zap.Error(err))
+		}
+	}()
+}
@@ -0,0 +1,197 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+type DeadLetterQueue interface {
+	Sender
+	Put(ctx context.Context, entries []models.DLQEntry) error
+	List(ctx context.Context, offset, limit int64) (models.DLQPage, error)
+	Get(ctx context.Context, id string) (models.DLQEntry, error)
+	Delete(ctx context.Context, id, reason string) error
+}
+
+type Producer interface {
+	Produce(ctx context.Context, records ...models.Record) error
+}
+
+type DLQService struct {
+	Logger     *zap.Logger
+	Queue      DeadLetterQueue
+	Quarantine DeadLetterQueue // Nil when quarantine is disabled
+	Producer   Producer
+}
+
+func NewDLQService(logger *zap.Logger, queue, quarantine DeadLetterQueue, producer Producer) *DLQService {
+	return &DLQService{Logger: logger, Queue: queue, Quarantine: quarantine, Producer: producer}
+}
+
+// List returns a page of dead-lettered entries, newest first
+func (s *DLQService) List(ctx context.Context, offset, limit int64) (models.DLQPage, error) {
+	return s.Queue.List(ctx, offset, limit)
+}
+
+// Show returns a single dead-lettered entry
+func (s *DLQService) Show(ctx context.Context, id string) (models.DLQEntry, error) {
+	return s.Queue.Get(ctx, id)
+}
+
+// Delete drops a dead-lettered entry without reprocessing it
+func (s *DLQService) Delete(ctx context.Context, id string) error {
+	return s.Queue.Delete(ctx, id, metrics.ReasonDeleted)
+}
+
+// Requeue publishes the entry back to its original topic and removes it from the DLQ.
+// The record is stamped with its replay count so a repeated failure is quarantined.
+func (s *DLQService) Requeue(ctx context.Context, id string) error {
+	entry, err := s.Queue.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Producer.Produce(ctx, ReplayRecord(entry)); err != nil {
+		return fmt.Errorf("failed to requeue dlq entry: %v", err)
+	}
+
+	if err := s.Queue.Delete(ctx, id, metrics.ReasonRequeued); err != nil {
+		return fmt.Errorf("failed to delete requeued dlq entry: %v", err)
+	}
+
+	s.Logger.Info("requeued dlq entry", zap.String("id", id), zap.String("topic", entry.Record.Topic))
+	return nil
+}
+
+// ReplayMatching requeues up to limit entries selected by the filter, oldest
+// first when the queue is a Scanner and newest first otherwise. With dryRun
+// the matches are only counted.
+func (s *DLQService) ReplayMatching(ctx context.Context, filter models.DLQFilter, limit int, dryRun bool) (models.DLQReplayResult, error) {
+	result := models.DLQReplayResult{DryRun: dryRun}
+	if err := filter.Validate(); err != nil {
+		return result, errors.ValidationFailedErr(err)
+	}
+
+	// Collect the matches first, deleting while paging would shift the offsets
+	var matches []models.DLQEntry
+	seen := make(map[string]bool)
+	collect := func(entries []models.DLQEntry) {
+		for _, entry := range entries {
+			// An entry can show up on two pages when others arrive meanwhile
+			if filter.Match(entry) && len(matches) < limit && !seen[entry.ID] {
+				seen[entry.ID] = true
+				matches = append(matches, entry)
+			}
+		}
+	}
+	const pageSize = 500
+	if scanner, ok := s.Queue.(Scanner); ok {
+		for after := ""; len(matches) < limit; {
+			entries, next, err := scanner.Scan(ctx, after, pageSize)
+			if err != nil {
+				return result, err
+			}
+			collect(entries)
+			if next == "" {
+				break
+			}
+			after = next
+		}
+	} else {
+		for offset := int64(0); len(matches) < limit; offset += pageSize {
+			page, err := s.Queue.List(ctx, offset, pageSize)
+			if err != nil {
+				return result, err
+			}
+			collect(page.Entries)
+			if offset+pageSize >= page.Total {
+				break
+			}
+		}
+	}
+	result.Matched = len(matches)
+	if dryRun || len(matches) == 0 {
+		return result, nil
+	}
+
+	for _, entry := range matches {
+		if err := s.Producer.Produce(ctx, ReplayRecord(entry)); err != nil {
+			return result, fmt.Errorf("failed to requeue dlq entry %s: %v", entry.ID, err)
+		}
+		if err := s.Queue.Delete(ctx, entry.ID, metrics.ReasonRequeued); err != nil {
+			return result, fmt.Errorf("failed to delete requeued dlq entry %s: %v", entry.ID, err)
+		}
+		result.Replayed++
+	}
+
+	s.Logger.Info("replayed matching dlq entries", zap.Int("matched", result.Matched), zap.Int("replayed", result.Replayed))
+	return result, nil
+}
+
+func (s *DLQService) quarantine() (DeadLetterQueue, error) {
+	if s.Quarantine == nil {
+		return nil, errors.E(errors.NotFound, "quarantine is disabled")
+	}
+	return s.Quarantine, nil
+}
+
+// ListQuarantine returns a page of quarantined entries, newest first
+func (s *DLQService) ListQuarantine(ctx context.Context, offset, limit int64) (models.DLQPage, error) {
+	quarantine, err := s.quarantine()
+	if err != nil {
+		return models.DLQPage{}, err
+	}
+	return quarantine.List(ctx, offset, limit)
+}
+
+// ShowQuarantine returns a single quarantined entry
+func (s *DLQService) ShowQuarantine(ctx context.Context, id string) (models.DLQEntry, error) {
+	quarantine, err := s.quarantine()
+	if err != nil {
+		return models.DLQEntry{}, err
+	}
+	return quarantine.Get(ctx, id)
+}
+
+// DeleteQuarantine drops a quarantined entry for good
+func (s *DLQService) DeleteQuarantine(ctx context.Context, id string) error {
+	quarantine, err := s.quarantine()
+	if err != nil {
+		return err
+	}
+	return quarantine.Delete(ctx, id, metrics.ReasonDeleted)
+}
+
+// AckQuarantine acknowledges a quarantined entry and moves it back to the DLQ
+// with its replay count reset, from where it can be requeued again
+func (s *DLQService) AckQuarantine(ctx context.Context, id string) error {
+	quarantine, err := s.quarantine()
+	if err != nil {
+		return err
+	}
+
+	entry, err := quarantine.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	entry.Record.DelHeader(models.HeaderReplayCount)
+
+	if err := s.Queue.Put(ctx, []models.DLQEntry{entry}); err != nil {
+		return fmt.Errorf("failed to move quarantined entry to dlq: %v", err)
+	}
+	if err := quarantine.Delete(ctx, id, metrics.ReasonAcknowledged); err != nil {
+		return fmt.Errorf("failed to delete acknowledged quarantine entry: %v", err)
+	}
+
+	s.Logger.Info("acknowledged quarantined entry", zap.String("id", id))
+	return nil
+}
@@ -0,0 +1,37 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// DryRunSender logs the records it would dead-letter instead of sending them
+type DryRunSender struct {
+	Logger  *zap.Logger
+	Summary FailureTally // Optional, counts the records that would be dead-lettered
+}
+
+// FailureTally counts failed records by reason
+type FailureTally interface {
+	WouldFail(reason string, n int)
+}
+
+func NewDryRunSender(logger *zap.Logger) *DryRunSender {
+	return &DryRunSender{Logger: logger}
+}
+
+func (s *DryRunSender) Send(_ context.Context, records []models.Record, cause error, attempts int) error {
+	s.Logger.Info("dry run, skipping dead letter", zap.Int("records", len(records)),
+		zap.String("error_class", errors.Class(cause)), zap.Int("attempts", attempts), zap.Error(cause))
+	if s.Summary != nil {
+		s.Summary.WouldFail(errors.ErrorClass(cause), len(records))
+	}
+	return nil
+}
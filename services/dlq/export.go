@@ -0,0 +1,116 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	// Local Packages
+	models "tx-stream/models"
+)
+
+// exportBatchSize is the number of entries read or written per backend call
+const exportBatchSize = 500
+
+// Scanner pages the entries oldest first by entry id, see
+// redis.DeadLetterQueue.Scan. Queues without it are exported page by page.
+type Scanner interface {
+	Scan(ctx context.Context, after string, count int64) (entries []models.DLQEntry, next string, err error)
+}
+
+// Export writes every dead-lettered entry to w as NDJSON, oldest first, so an
+// import restores them in their original order
+func (s *DLQService) Export(ctx context.Context, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	if scanner, ok := s.Queue.(Scanner); ok {
+		return exportScan(ctx, scanner, enc)
+	}
+
+	first, err := s.Queue.List(ctx, 0, 1)
+	if err != nil {
+		return 0, err
+	}
+	exported := 0
+	// Pages are newest first, so walk them from the end and reverse each one
+	for end := first.Total; end > 0; end -= exportBatchSize {
+		offset := max(end-exportBatchSize, 0)
+		page, err := s.Queue.List(ctx, offset, end-offset)
+		if err != nil {
+			return exported, err
+		}
+		for idx := len(page.Entries) - 1; idx >= 0; idx-- {
+			if err := enc.Encode(page.Entries[idx]); err != nil {
+				return exported, fmt.Errorf("failed to write dlq entry: %v", err)
+			}
+			exported++
+		}
+	}
+	return exported, nil
+}
+
+// exportScan writes the entries following the last one written, every batch
+// costs the same however deep into the queue it is
+func exportScan(ctx context.Context, scanner Scanner, enc *json.Encoder) (int, error) {
+	exported, after := 0, ""
+	for {
+		entries, next, err := scanner.Scan(ctx, after, exportBatchSize)
+		if err != nil {
+			return exported, err
+		}
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return exported, fmt.Errorf("failed to write dlq entry: %v", err)
+			}
+			exported++
+		}
+		if next == "" {
+			return exported, nil
+		}
+		after = next
+	}
+}
+
+// Import stores the NDJSON entries read from r in the DLQ, replacing entries of
+// the same record. Entries keep their failure details and attempts.
+func (s *DLQService) Import(ctx context.Context, r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024) // Payloads can be large
+
+	imported, line := 0, 0
+	batch := make([]models.DLQEntry, 0, exportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.Queue.Put(ctx, batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry models.DLQEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return imported, fmt.Errorf("failed to parse line %d: %v", line, err)
+		}
+		batch = append(batch, entry)
+		if len(batch) == exportBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return imported, fmt.Errorf("failed to read import: %v", err)
+	}
+	return imported, flush()
+}
@@ -0,0 +1,50 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+type Sender interface {
+	Send(ctx context.Context, records []models.Record, cause error, attempts int) error
+}
+
+// QuarantineRouter sends failed records to the DLQ, except records that already
+// failed after MaxReplays requeues, which go to quarantine instead so they stop
+// bouncing between the topic and the DLQ.
+type QuarantineRouter struct {
+	Logger     *zap.Logger
+	DLQ        Sender
+	Quarantine Sender
+	MaxReplays int
+}
+
+func NewQuarantineRouter(logger *zap.Logger, dlq, quarantine Sender, maxReplays int) *QuarantineRouter {
+	return &QuarantineRouter{Logger: logger, DLQ: dlq, Quarantine: quarantine, MaxReplays: maxReplays}
+}
+
+// Send splits the failed records between the DLQ and quarantine
+func (q *QuarantineRouter) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	var failed, quarantined []models.Record
+	for _, record := range records {
+		if record.ReplayCount() >= q.MaxReplays {
+			quarantined = append(quarantined, record)
+			continue
+		}
+		failed = append(failed, record)
+	}
+
+	if len(quarantined) > 0 {
+		q.Logger.Warn("records failed again after replay, quarantining", zap.Int("count", len(quarantined)))
+		if err := q.Quarantine.Send(ctx, quarantined, cause, attempts); err != nil {
+			return err
+		}
+	}
+	return q.DLQ.Send(ctx, failed, cause, attempts)
+}
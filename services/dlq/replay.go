@@ -0,0 +1,89 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// ReplayQueue is a DLQ that replay workers can share through a consumer group
+type ReplayQueue interface {
+	EnsureGroup(ctx context.Context, group string) error
+	Claim(ctx context.Context, group, consumer string, count int64, minIdle time.Duration) ([]models.DLQEntry, error)
+	Ack(ctx context.Context, group string, entries []models.DLQEntry, reason string) error
+}
+
+type ReplayConfig struct {
+	Group     string
+	Consumer  string
+	BatchSize int64
+	MinIdle   time.Duration // Pending entries idle for longer are reclaimed from crashed workers
+}
+
+// Replayer publishes dead-lettered records back to their topics. Several
+// replayers in the same group split the DLQ between them, and an entry is only
+// removed once it has been published, so a crashed replayer loses nothing.
+type Replayer struct {
+	Logger   *zap.Logger
+	Queue    ReplayQueue
+	Producer Producer
+	Config   *ReplayConfig
+}
+
+func NewReplayer(logger *zap.Logger, queue ReplayQueue, producer Producer, conf *ReplayConfig) *Replayer {
+	return &Replayer{Logger: logger, Queue: queue, Producer: producer, Config: conf}
+}
+
+// Drain replays entries until the DLQ has nothing left for this consumer
+func (r *Replayer) Drain(ctx context.Context) (int, error) {
+	if err := r.Queue.EnsureGroup(ctx, r.Config.Group); err != nil {
+		return 0, fmt.Errorf("failed to create replay group: %v", err)
+	}
+
+	replayed := 0
+	for ctx.Err() == nil {
+		entries, err := r.Queue.Claim(ctx, r.Config.Group, r.Config.Consumer, r.Config.BatchSize, r.Config.MinIdle)
+		if err != nil {
+			return replayed, fmt.Errorf("failed to claim dlq entries: %v", err)
+		}
+		if len(entries) == 0 {
+			return replayed, nil
+		}
+
+		records := make([]models.Record, len(entries))
+		for idx, entry := range entries {
+			records[idx] = ReplayRecord(entry)
+		}
+		if err := r.Producer.Produce(ctx, records...); err != nil {
+			// Entries stay pending and are reclaimed once idle for MinIdle
+			return replayed, fmt.Errorf("failed to replay dlq entries: %v", err)
+		}
+
+		if err := r.Queue.Ack(ctx, r.Config.Group, entries, metrics.ReasonRequeued); err != nil {
+			return replayed, fmt.Errorf("failed to acknowledge replayed entries: %v", err)
+		}
+		replayed += len(entries)
+		r.Logger.Info("replayed dlq entries", zap.Int("count", len(entries)), zap.Int("total", replayed))
+	}
+	return replayed, ctx.Err()
+}
+
+// ReplayRecord returns the record of the entry stamped with its replay count,
+// so a repeated failure after replay is quarantined
+func ReplayRecord(entry models.DLQEntry) models.Record {
+	record := entry.Record
+	record.SetHeader(models.HeaderReplayCount, []byte(strconv.Itoa(record.ReplayCount()+1)))
+	if _, ok := record.Header(models.HeaderOriginalID); !ok {
+		record.SetHeader(models.HeaderOriginalID, []byte(entry.ID))
+	}
+	return record
+}
@@ -0,0 +1,141 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// RetryQueue delays failed records until their next attempt is due
+type RetryQueue interface {
+	Schedule(ctx context.Context, records []models.Record, cause error, attempts int) ([]models.DLQEntry, error)
+	Due(ctx context.Context, count int64) ([]models.Record, error)
+	Done(ctx context.Context, records []models.Record) error
+}
+
+type Processor interface {
+	ProcessRecords(ctx context.Context, records []models.Record) error
+}
+
+// RetryScheduler retries failed records later instead of dead-lettering them at
+// once. Send schedules the records, Run processes them again once due, and
+// records that exhausted their retries are sent on to the permanent DLQ.
+// A record is retried by the processor of the consumer that read it, so it
+// lands in the sinks of that consumer.
+type RetryScheduler struct {
+	Logger     *zap.Logger
+	Queue      RetryQueue
+	DLQ        Sender
+	Processor  Processor            // Retries the records of the consumers missing from Processors
+	Processors map[string]Processor // Optional, by consumer name, set before Run
+	BatchSize  int64
+	Interval   time.Duration
+	Paused     func() bool // Optional, skips the retries while true, e.g. during maintenance
+}
+
+func NewRetryScheduler(logger *zap.Logger, queue RetryQueue, dlq Sender, processor Processor, batchSize int64, interval time.Duration) *RetryScheduler {
+	return &RetryScheduler{Logger: logger, Queue: queue, DLQ: dlq, Processor: processor, BatchSize: batchSize, Interval: interval}
+}
+
+// Send schedules the records for a retry, or dead-letters them once exhausted.
+// An exhausted record leaves the retry queue once the DLQ took it, so one the
+// DLQ refused is handed to it again on its next failure.
+func (s *RetryScheduler) Send(ctx context.Context, records []models.Record, cause error, attempts int) error {
+	exhausted, err := s.Queue.Schedule(ctx, records, cause, attempts)
+	if err != nil {
+		// Never lose a failed record because the retry queue is unavailable
+		s.Logger.Error("failed to schedule retries, dead-lettering instead", zap.Error(err))
+		return s.DLQ.Send(ctx, records, cause, attempts)
+	}
+
+	var sent []models.Record
+	var errs []error
+	for _, entry := range exhausted {
+		s.Logger.Warn("record exhausted its retries, dead-lettering", zap.String("record", entry.ID), zap.Int("attempts", entry.Attempts))
+		if err := s.DLQ.Send(ctx, []models.Record{entry.Record}, cause, entry.Attempts); err != nil {
+			s.Logger.Error("failed to dead-letter exhausted record, keeping it for a retry", zap.String("record", entry.ID), zap.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		sent = append(sent, entry.Record)
+	}
+	if err := s.Queue.Done(ctx, sent); err != nil {
+		errs = append(errs, fmt.Errorf("failed to remove dead-lettered retries: %v", err))
+	}
+	return errors.Join(errs...)
+}
+
+// Run processes due records every Interval until the context is canceled
+func (s *RetryScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.Paused != nil && s.Paused() {
+				continue
+			}
+			for s.retryDue(ctx) {
+			}
+		}
+	}
+}
+
+// retryDue processes one batch of due records, reports whether a full batch was claimed
+func (s *RetryScheduler) retryDue(ctx context.Context) bool {
+	records, err := s.Queue.Due(ctx, s.BatchSize)
+	if err != nil {
+		s.Logger.Error("failed to claim due retries", zap.Error(err))
+		return false
+	}
+	if len(records) == 0 {
+		return false
+	}
+
+	// One batch per consumer, in the order they were claimed
+	var consumers []string
+	batches := make(map[string][]models.Record)
+	for _, record := range records {
+		if _, ok := batches[record.Consumer]; !ok {
+			consumers = append(consumers, record.Consumer)
+		}
+		batches[record.Consumer] = append(batches[record.Consumer], record)
+	}
+	retried := true
+	for _, consumer := range consumers {
+		retried = s.retry(ctx, consumer, batches[consumer]) && retried
+	}
+	return retried && int64(len(records)) == s.BatchSize
+}
+
+// retry processes the due records of a consumer, reports false once it rescheduled them
+func (s *RetryScheduler) retry(ctx context.Context, consumer string, records []models.Record) bool {
+	processor, ok := s.Processors[consumer]
+	if !ok {
+		processor = s.Processor
+	}
+	if err := processor.ProcessRecords(ctx, records); err != nil {
+		s.Logger.Warn("retry failed, rescheduling", zap.String("consumer", consumer), zap.Int("count", len(records)), zap.Error(err))
+		if err := s.Send(ctx, records, err, 1); err != nil {
+			s.Logger.Error("failed to reschedule retries", zap.Error(err))
+		}
+		return false
+	}
+
+	if err := s.Queue.Done(ctx, records); err != nil {
+		s.Logger.Error("failed to remove retried records", zap.Error(err))
+	}
+	s.Logger.Info("retried records processed", zap.String("consumer", consumer), zap.Int("count", len(records)))
+	return true
+}
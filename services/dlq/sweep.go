@@ -0,0 +1,102 @@
+package dlq
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// sweepBatchSize is the number of entries read per backend call of a sweep
+const sweepBatchSize = 500
+
+// BatchDeleter removes several entries in one backend call, see
+// redis.DeadLetterQueue.DeleteBatch
+type BatchDeleter interface {
+	DeleteBatch(ctx context.Context, entries []models.DLQEntry, reason string) error
+}
+
+// Sweep deletes the entries that last failed before the cutoff, at most limit
+// of them when limit is positive, and returns how many it deleted
+func (s *DLQService) Sweep(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	scanner, scans := s.Queue.(Scanner)
+	batchDeleter, batches := s.Queue.(BatchDeleter)
+	if scans && batches {
+		return s.sweepScan(ctx, scanner, batchDeleter, cutoff, limit)
+	}
+
+	deleted := 0
+	for limit <= 0 || deleted < limit {
+		first, err := s.Queue.List(ctx, 0, 1)
+		if err != nil {
+			return deleted, err
+		}
+		// Pages are newest first, so the expired entries are at the end
+		offset := max(first.Total-sweepBatchSize, 0)
+		page, err := s.Queue.List(ctx, offset, first.Total-offset)
+		if err != nil {
+			return deleted, err
+		}
+		swept := 0
+		for idx := len(page.Entries) - 1; idx >= 0; idx-- {
+			entry := page.Entries[idx]
+			if !entry.LastFailedAt.Before(cutoff) || (limit > 0 && deleted == limit) {
+				break
+			}
+			if err := s.Queue.Delete(ctx, entry.ID, metrics.ReasonExpired); err != nil {
+				return deleted, fmt.Errorf("failed to delete expired dlq entry %s: %v", entry.ID, err)
+			}
+			deleted++
+			swept++
+		}
+		// A page that was not swept whole ends at the first entry to keep
+		if swept < len(page.Entries) || swept == 0 {
+			break
+		}
+	}
+
+	s.logSweep(deleted, cutoff)
+	return deleted, nil
+}
+
+// sweepScan deletes the expired entries from the start of the queue, a batch
+// per round trip, every batch costs the same however deep the queue is
+func (s *DLQService) sweepScan(ctx context.Context, scanner Scanner, batchDeleter BatchDeleter, cutoff time.Time, limit int) (int, error) {
+	deleted, after := 0, ""
+	for limit <= 0 || deleted < limit {
+		entries, next, err := scanner.Scan(ctx, after, sweepBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		expired := 0
+		for expired < len(entries) && entries[expired].LastFailedAt.Before(cutoff) && (limit <= 0 || deleted+expired < limit) {
+			expired++
+		}
+		if expired == 0 {
+			break
+		}
+		if err := batchDeleter.DeleteBatch(ctx, entries[:expired], metrics.ReasonExpired); err != nil {
+			return deleted, fmt.Errorf("failed to delete expired dlq entries: %v", err)
+		}
+		deleted += expired
+		if expired < len(entries) || next == "" {
+			break
+		}
+		after = next
+	}
+	s.logSweep(deleted, cutoff)
+	return deleted, nil
+}
+
+func (s *DLQService) logSweep(deleted int, cutoff time.Time) {
+	if deleted > 0 {
+		s.Logger.Info("swept expired dlq entries", zap.Int("deleted", deleted), zap.Time("cutoff", cutoff))
+	}
+}
@@ -0,0 +1,90 @@
+package features
+
+import (
+	// Go Internal Packages
+	"context"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Store is a shared source of feature flags, such as redis
+type Store interface {
+	List(ctx context.Context) (map[string]models.FeatureFlag, error)
+}
+
+// Flags evaluates feature flags at runtime. The flags come from Set, for flags
+// in the config, or are refreshed from the Store every Interval by Run.
+// Unknown flags are off.
+type Flags struct {
+	Logger   *zap.Logger
+	Store    Store // Nil for flags from the config
+	Metrics  *metrics.FeatureMetrics
+	Interval time.Duration
+
+	flags atomic.Pointer[map[string]models.FeatureFlag]
+}
+
+func NewFlags(logger *zap.Logger, store Store, featureMetrics *metrics.FeatureMetrics, interval time.Duration) *Flags {
+	f := &Flags{Logger: logger, Store: store, Metrics: featureMetrics, Interval: interval}
+	f.Set(nil)
+	return f
+}
+
+// Set replaces every flag
+func (f *Flags) Set(flags map[string]models.FeatureFlag) {
+	flags = maps.Clone(flags)
+	if flags == nil {
+		flags = make(map[string]models.FeatureFlag)
+	}
+	f.flags.Store(&flags)
+
+	f.Metrics.Reset()
+	for name, flag := range flags {
+		f.Metrics.SetState(name, flag.Enabled, flag.Rollout)
+	}
+}
+
+// Enabled evaluates the flag for the tenant
+func (f *Flags) Enabled(name, tenant string) bool {
+	flag, ok := (*f.flags.Load())[name]
+	enabled := ok && flag.EnabledFor(name, tenant)
+	f.Metrics.Evaluate(name, enabled)
+	return enabled
+}
+
+// List returns the current flags
+func (f *Flags) List() map[string]models.FeatureFlag {
+	return maps.Clone(*f.flags.Load())
+}
+
+// Run refreshes the flags from the store until the context is canceled. A failed
+// refresh keeps the previous flags.
+func (f *Flags) Run(ctx context.Context) {
+	if f.Store == nil {
+		return
+	}
+	ticker := time.NewTicker(f.Interval)
+	defer ticker.Stop()
+
+	for {
+		flags, err := f.Store.List(ctx)
+		if err != nil {
+			f.Logger.Warn("cannot refresh feature flags, keeping the previous ones", zap.Error(err))
+		} else {
+			f.Set(flags)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
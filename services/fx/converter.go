@@ -0,0 +1,151 @@
+package fx
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// What to do with a transaction when the rates are older than the max age,
+// or were never fetched
+const (
+	StaleUse  = "use"  // Convert with the stale rates and mark the amount stale, skip without rates
+	StaleSkip = "skip" // Store the transaction without a normalized amount
+	StaleFail = "fail" // Fail the batch so it is retried once the rates are back
+)
+
+// Provider returns the exchange rates of a base currency
+type Provider interface {
+	Rates(ctx context.Context, base string) (models.FXRates, error)
+}
+
+// ProviderFunc adapts a function to a Provider, e.g. to read the rates through a cache
+type ProviderFunc func(ctx context.Context, base string) (models.FXRates, error)
+
+func (f ProviderFunc) Rates(ctx context.Context, base string) (models.FXRates, error) {
+	return f(ctx, base)
+}
+
+// Converter normalizes the amounts of the transactions to the Base currency.
+// The rates are refreshed from the Provider every Interval in the background,
+// so a conversion never waits on the provider, and a failed refresh keeps the
+// previous rates until they are older than MaxAge and OnStale applies.
+type Converter struct {
+	Provider Provider
+	Logger   *zap.Logger
+	Metrics  *metrics.FXMetrics
+	Base     string
+	Interval time.Duration
+	MaxAge   time.Duration
+	OnStale  string
+
+	rates atomic.Pointer[models.FXRates]
+}
+
+func NewConverter(provider Provider, logger *zap.Logger, fxMetrics *metrics.FXMetrics, base string,
+	interval, maxAge time.Duration, onStale string) *Converter {
+	return &Converter{Provider: provider, Logger: logger, Metrics: fxMetrics, Base: strings.ToUpper(base),
+		Interval: interval, MaxAge: maxAge, OnStale: onStale}
+}
+
+// Refresh fetches the rates, a failure keeps the previous ones
+func (c *Converter) Refresh(ctx context.Context) error {
+	rates, err := c.Provider.Rates(ctx, c.Base)
+	if err == nil && !strings.EqualFold(rates.Base, c.Base) {
+		err = fmt.Errorf("provider returned rates of %s instead of %s", rates.Base, c.Base)
+	}
+	if err != nil {
+		var age float64
+		if current := c.rates.Load(); current != nil {
+			age = time.Since(current.AsOf).Seconds()
+		}
+		c.Metrics.Refreshed(metrics.OutcomeFailure, age)
+		return err
+	}
+	c.rates.Store(&rates)
+	c.Metrics.Refreshed(metrics.OutcomeSuccess, time.Since(rates.AsOf).Seconds())
+	return nil
+}
+
+// Run refreshes the rates every Interval until the context is canceled
+func (c *Converter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+			c.Logger.Warn("cannot refresh exchange rates, keeping the previous ones", zap.Error(err))
+		}
+	}
+}
+
+// Normalize converts an amount to the base currency. It returns nil for an
+// unknown currency, and without fresh rates follows OnStale. A nil converter
+// returns nil.
+func (c *Converter) Normalize(amount float32, currency string) (*models.NormalizedAmount, error) {
+	if c == nil {
+		return nil, nil
+	}
+	currency = strings.ToUpper(currency)
+	rates := c.rates.Load()
+	stale := rates == nil || time.Since(rates.AsOf) > c.MaxAge
+	if stale && (rates == nil || c.OnStale != StaleUse) {
+		if c.OnStale == StaleFail {
+			c.Metrics.Converted(c.label(rates, currency), metrics.FXFailed)
+			return nil, fmt.Errorf("no exchange rates of %s within %s", c.Base, c.MaxAge)
+		}
+		c.Metrics.Converted(c.label(rates, currency), metrics.FXSkipped)
+		return nil, nil
+	}
+
+	rate := 1.0
+	if currency != c.Base {
+		var ok bool
+		if rate, ok = rates.Rates[currency]; !ok || rate <= 0 {
+			c.Metrics.Converted(c.label(rates, currency), metrics.FXUnknown)
+			return nil, nil
+		}
+	}
+	outcome := metrics.FXConverted
+	if stale {
+		outcome = metrics.FXStale
+	}
+	c.Metrics.Converted(currency, outcome)
+	return &models.NormalizedAmount{
+		// Rounded to the cent of the base currency
+		Amount:   math.Round(float64(amount)/rate*100) / 100,
+		Currency: c.Base,
+		Rate:     rate,
+		RatesAt:  rates.AsOf,
+		Stale:    stale,
+	}, nil
+}
+
+// label keeps the currencies in the metrics to the ones of the rates, the
+// currencies of the transactions are input
+func (c *Converter) label(rates *models.FXRates, currency string) string {
+	if currency == c.Base {
+		return currency
+	}
+	if rates != nil {
+		if _, ok := rates.Rates[currency]; ok {
+			return currency
+		}
+	}
+	return "other"
+}
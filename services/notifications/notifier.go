@@ -0,0 +1,323 @@
+package notifications
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+	aggregation "tx-stream/services/aggregation"
+
+	// External Packages
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// Rule types
+const (
+	RuleAmount   = "amount"   // At least MinAmount, in the currency of the transaction
+	RuleVelocity = "velocity" // At least Count transactions of the same By value within Window
+	RuleCountry  = "country"  // Located in one of Countries
+)
+
+type Rule struct {
+	Name        string
+	Type        string
+	Severity    string
+	MinAmount   float64
+	Currencies  []string // Amount rules only, empty matches every currency
+	Count       int
+	Window      time.Duration
+	By          string // JSON name of the transaction field a velocity is counted by, e.g. user_id
+	Countries   []string
+	DedupBy     string        // JSON name of the transaction field an alert is sent once per value of
+	DedupWindow time.Duration // Within which an alert is sent once
+	Channels    []string      // Names of the channels to notify, empty notifies every channel
+}
+
+// Channel sends the alerts to one destination, at most Limit per second
+// with bursts of Burst when Limit is set
+type Channel struct {
+	Name  string
+	Send  func(ctx context.Context, alert models.Alert) error
+	Limit rate.Limit
+	Burst int
+
+	limiter *rate.Limiter
+	queue   chan models.Alert
+}
+
+// Store keeps the velocity windows and the sent alerts, see redis.AlertStore
+type Store interface {
+	Velocity(ctx context.Context, checks []models.VelocityCheck) ([]int64, error)
+	Dedupe(ctx context.Context, ids []string, windows []time.Duration) ([]bool, error)
+}
+
+type rule struct {
+	Rule
+	byField    int // Index of By in models.Transaction
+	dedupField int
+	channels   []*Channel
+}
+
+// Notifier raises alerts for the stored transactions matching the alert
+// rules and sends them to the channels of the rules. An alert is sent once
+// per rule and dedup_by value within the dedup window across the replicas,
+// and each channel is rate limited on its own. The alerts are sent in the
+// background, a full channel queue drops them. A failed evaluation is
+// logged and never fails the batch, a failed dedupe sends the alerts anyway.
+type Notifier struct {
+	Store   Store
+	Logger  *zap.Logger
+	Metrics *metrics.NotificationMetrics
+	Source  string        // Names the application in the alerts
+	Timeout time.Duration // Of a notification
+
+	rules    []*rule
+	channels []*Channel
+}
+
+func NewNotifier(rules []Rule, channels []*Channel, store Store, logger *zap.Logger, notificationMetrics *metrics.NotificationMetrics,
+	source string, queueSize int, timeout time.Duration) (*Notifier, error) {
+	byName := make(map[string]*Channel, len(channels))
+	for _, channel := range channels {
+		channel.limiter = rate.NewLimiter(rate.Inf, 0)
+		if channel.Limit > 0 {
+			channel.limiter = rate.NewLimiter(channel.Limit, max(channel.Burst, 1))
+		}
+		channel.queue = make(chan models.Alert, queueSize)
+		byName[channel.Name] = channel
+	}
+
+	n := &Notifier{Store: store, Logger: logger, Metrics: notificationMetrics, Source: source, Timeout: timeout, channels: channels}
+	for _, r := range rules {
+		compiled := &rule{Rule: r, byField: -1}
+		fields, err := aggregation.GroupFields([]string{r.DedupBy})
+		if err != nil {
+			return nil, fmt.Errorf("invalid dedup_by of rule %s: %v", r.Name, err)
+		}
+		compiled.dedupField = fields[0]
+		if r.Type == RuleVelocity {
+			if fields, err = aggregation.GroupFields([]string{r.By}); err != nil {
+				return nil, fmt.Errorf("invalid by of rule %s: %v", r.Name, err)
+			}
+			compiled.byField = fields[0]
+		}
+		compiled.channels = channels
+		if len(r.Channels) > 0 {
+			compiled.channels = nil
+			for _, name := range r.Channels {
+				channel, ok := byName[name]
+				if !ok {
+					return nil, fmt.Errorf("rule %s notifies the unknown channel %s", r.Name, name)
+				}
+				compiled.channels = append(compiled.channels, channel)
+			}
+		}
+		n.rules = append(n.rules, compiled)
+	}
+	return n, nil
+}
+
+// match is a transaction matching a rule
+type match struct {
+	rule  *rule
+	tx    models.Transaction
+	alert models.Alert
+}
+
+// Observe evaluates the rules against the stored transactions, records[i]
+// being the record of txs[i], and queues the alerts. A nil notifier does nothing.
+func (n *Notifier) Observe(ctx context.Context, records []models.Record, txs []models.Transaction) {
+	if n == nil || len(txs) == 0 {
+		return
+	}
+	now := time.Now()
+	var matches []match
+	var checks []models.VelocityCheck
+	var checked []match
+	for idx, tx := range txs {
+		for _, r := range n.rules {
+			switch r.Type {
+			case RuleAmount:
+				if float64(tx.Amount) >= r.MinAmount && (len(r.Currencies) == 0 || slices.Contains(r.Currencies, tx.Currency)) {
+					matches = append(matches, match{rule: r, tx: tx, alert: models.Alert{
+						Reason: fmt.Sprintf("amount %.2f %s is at least %.2f", tx.Amount, tx.Currency, r.MinAmount)}})
+				}
+			case RuleCountry:
+				if country, ok := blockedCountry(tx.Location, r.Countries); ok {
+					matches = append(matches, match{rule: r, tx: tx, alert: models.Alert{
+						Reason: fmt.Sprintf("located in the blocked country %s", country)}})
+				}
+			case RuleVelocity:
+				subject := fieldValue(tx, r.byField)
+				if subject == "" {
+					continue
+				}
+				at := records[idx].Timestamp
+				if at.IsZero() {
+					at = now
+				}
+				checks = append(checks, models.VelocityCheck{Rule: r.Name, Subject: subject, TxID: tx.TxID, At: at, Window: r.Window})
+				checked = append(checked, match{rule: r, tx: tx})
+			}
+		}
+	}
+
+	if len(checks) > 0 {
+		counts, err := n.Store.Velocity(ctx, checks)
+		if err != nil {
+			for _, m := range checked {
+				n.Metrics.Alerted(m.rule.Name, metrics.AlertEvalFailed, 1)
+			}
+			n.Logger.Error("failed to count the velocity rules, skipping them for the batch", zap.Error(err))
+		}
+		for idx, count := range counts {
+			if m := checked[idx]; count >= int64(m.rule.Count) {
+				m.alert.Reason = fmt.Sprintf("%d transactions of %s %s within %s", count, m.rule.By, fieldValue(m.tx, m.rule.byField), m.rule.Window)
+				matches = append(matches, m)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	n.raise(ctx, matches, now)
+}
+
+// raise dedupes the matches and queues the alerts of the fresh ones
+func (n *Notifier) raise(ctx context.Context, matches []match, now time.Time) {
+	ids := make([]string, len(matches))
+	windows := make([]time.Duration, len(matches))
+	for idx, m := range matches {
+		ids[idx] = m.rule.Name + ":" + fieldValue(m.tx, m.rule.dedupField)
+		windows[idx] = m.rule.DedupWindow
+	}
+	fresh, err := n.Store.Dedupe(ctx, ids, windows)
+	if err != nil {
+		n.Logger.Warn("failed to dedupe alerts, sending them all", zap.Error(err))
+	}
+
+	seen := make(map[string]bool)
+	for idx, m := range matches {
+		// Matches of one batch share no dedupe round trip, so they are deduped here too
+		if (err == nil && !fresh[idx]) || seen[ids[idx]] {
+			n.Metrics.Alerted(m.rule.Name, metrics.AlertDuplicate, 1)
+			continue
+		}
+		seen[ids[idx]] = true
+		n.Metrics.Alerted(m.rule.Name, metrics.AlertRaised, 1)
+		alert := m.alert
+		alert.ID = ids[idx]
+		alert.Rule = m.rule.Name
+		alert.Severity = m.rule.Severity
+		alert.TransactionID = m.tx.TxID
+		alert.UserID = m.tx.UserID
+		alert.Amount = m.tx.Amount
+		alert.Currency = m.tx.Currency
+		alert.MerchantName = m.tx.MerchantName
+		alert.Location = m.tx.Location
+		alert.Timestamp = m.tx.Timestamp
+		alert.Source = n.Source
+		alert.RaisedAt = now.UTC()
+		for _, channel := range m.rule.channels {
+			select {
+			case channel.queue <- alert:
+			default:
+				n.Metrics.Notified(channel.Name, metrics.NotificationDropped)
+				n.Logger.Warn("alert queue is full, dropping the notification", zap.String("channel", channel.Name), zap.String("alert", alert.ID))
+			}
+		}
+	}
+}
+
+// Run sends the queued alerts of every channel until ctx is done
+func (n *Notifier) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for _, channel := range n.channels {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			n.deliver(ctx, channel)
+		}()
+	}
+	for range n.channels {
+		<-done
+	}
+}
+
+func (n *Notifier) deliver(ctx context.Context, channel *Channel) {
+	for {
+		var alert models.Alert
+		select {
+		case <-ctx.Done():
+			return
+		case alert = <-channel.queue:
+		}
+		if !channel.limiter.Allow() {
+			n.Metrics.Notified(channel.Name, metrics.NotificationRateLimited)
+			n.Logger.Warn("notification rate limited", zap.String("channel", channel.Name), zap.String("alert", alert.ID))
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, n.Timeout)
+		err := channel.Send(sendCtx, alert)
+		cancel()
+		if err != nil {
+			n.Metrics.Notified(channel.Name, metrics.NotificationFailed)
+			n.Logger.Error("failed to send notification", zap.String("channel", channel.Name), zap.String("alert", alert.ID), zap.Error(err))
+			continue
+		}
+		n.Metrics.Notified(channel.Name, metrics.NotificationSent)
+	}
+}
+
+// Text renders an alert for the chat and email channels, the first line is the email subject
+func Text(alert models.Alert) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "[%s] %s alert %s on transaction %s\n", alert.Source, alert.Severity, alert.Rule, alert.TransactionID)
+	fmt.Fprintf(&text, "%s\n", alert.Reason)
+	fmt.Fprintf(&text, "amount %.2f %s", alert.Amount, alert.Currency)
+	if alert.UserID != "" {
+		fmt.Fprintf(&text, ", user %s", alert.UserID)
+	}
+	if alert.MerchantName != "" {
+		fmt.Fprintf(&text, ", merchant %s", alert.MerchantName)
+	}
+	if alert.Location != "" {
+		fmt.Fprintf(&text, ", location %s", alert.Location)
+	}
+	if alert.Timestamp != "" {
+		fmt.Fprintf(&text, ", at %s", alert.Timestamp)
+	}
+	return text.String()
+}
+
+func fieldValue(tx models.Transaction, field int) string {
+	return reflect.ValueOf(tx).Field(field).String()
+}
+
+// blockedCountry matches the location, or its part after the last comma as
+// in "Lyon, France", against the countries regardless of case
+func blockedCountry(location string, countries []string) (string, bool) {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return "", false
+	}
+	candidates := []string{location}
+	if idx := strings.LastIndex(location, ","); idx >= 0 {
+		candidates = append(candidates, strings.TrimSpace(location[idx+1:]))
+	}
+	for _, country := range countries {
+		for _, candidate := range candidates {
+			if strings.EqualFold(candidate, country) {
+				return country, true
+			}
+		}
+	}
+	return "", false
+}
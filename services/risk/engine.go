@@ -0,0 +1,253 @@
+package risk
+
+import (
+	// Go Internal Packages
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+)
+
+// Operators of a condition. Text fields take every operator but the
+// comparisons, number fields take eq, ne, in, not_in and the comparisons.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpContains = "contains"
+	OpPrefix   = "prefix"
+	OpSuffix   = "suffix"
+	OpRegex    = "regex"
+)
+
+// Condition compares a field of the transactions with a value
+type Condition struct {
+	Field  string   `json:"field"` // JSON name of the transaction field, e.g. amount
+	Op     string   `json:"op"`
+	Value  string   `json:"value,omitempty"`  // Every operator but in and not_in
+	Values []string `json:"values,omitempty"` // in and not_in
+}
+
+// Rule flags the transactions meeting every condition with its name and adds
+// its score to theirs
+type Rule struct {
+	Name  string      `json:"name"`
+	Score float64     `json:"score"`
+	When  []Condition `json:"when"`
+}
+
+type condition struct {
+	field int // Index in models.Transaction
+	match func(field reflect.Value) bool
+}
+
+type rule struct {
+	name       string
+	score      float64
+	conditions []condition
+}
+
+type ruleset struct {
+	version string
+	rules   []rule
+}
+
+// Engine evaluates the risk rules against the transactions. The rules are
+// replaced as a whole by Set, so a config reload changes them without a
+// restart, and every result names the version of the rules that made it.
+type Engine struct {
+	Metrics *metrics.RiskMetrics
+
+	current atomic.Pointer[ruleset]
+}
+
+func NewEngine(riskMetrics *metrics.RiskMetrics) *Engine {
+	e := &Engine{Metrics: riskMetrics}
+	e.current.Store(&ruleset{version: Version(nil)})
+	return e
+}
+
+// Set replaces the rules, invalid rules keep the previous ones
+func (e *Engine) Set(rules []Rule) error {
+	compiled := make([]rule, len(rules))
+	for idx, r := range rules {
+		compiled[idx] = rule{name: r.Name, score: r.Score, conditions: make([]condition, len(r.When))}
+		for cdx, c := range r.When {
+			cond, err := compile(c)
+			if err != nil {
+				return fmt.Errorf("invalid condition %d of risk rule %s: %v", cdx, r.Name, err)
+			}
+			compiled[idx].conditions[cdx] = cond
+		}
+	}
+	set := &ruleset{version: Version(rules), rules: compiled}
+	e.current.Store(set)
+	e.Metrics.SetRuleset(set.version, len(compiled))
+	return nil
+}
+
+// Version returns the version of the rules in effect
+func (e *Engine) Version() string {
+	return e.current.Load().version
+}
+
+// Version identifies a ruleset by its content
+func Version(rules []Rule) string {
+	encoded, _ := json.Marshal(rules)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Evaluate returns the flags and the score of a transaction. A nil engine
+// returns nil, leaving the document without a risk.
+func (e *Engine) Evaluate(tx models.Transaction) *models.Risk {
+	if e == nil {
+		return nil
+	}
+	set := e.current.Load()
+	value := reflect.ValueOf(tx)
+	risk := &models.Risk{Flags: []string{}, Ruleset: set.version}
+	for _, r := range set.rules {
+		if r.matches(value) {
+			risk.Flags = append(risk.Flags, r.name)
+			risk.Score += r.score
+		}
+	}
+	e.Metrics.Evaluated(risk.Score, risk.Flags)
+	return risk
+}
+
+func (r rule) matches(tx reflect.Value) bool {
+	for _, c := range r.conditions {
+		if !c.match(tx.Field(c.field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate reports whether the condition compiles
+func Validate(c Condition) error {
+	_, err := compile(c)
+	return err
+}
+
+func compile(c Condition) (condition, error) {
+	txType := reflect.TypeOf(models.Transaction{})
+	for idx := range txType.NumField() {
+		field := txType.Field(idx)
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != c.Field {
+			continue
+		}
+		var match func(reflect.Value) bool
+		var err error
+		switch field.Type.Kind() {
+		case reflect.String:
+			match, err = compileText(c)
+		case reflect.Float32, reflect.Float64:
+			match, err = compileNumber(c)
+		default:
+			err = fmt.Errorf("%s is not a text or number field", c.Field)
+		}
+		if err != nil {
+			return condition{}, err
+		}
+		return condition{field: idx, match: match}, nil
+	}
+	return condition{}, fmt.Errorf("%s is not a field of the transactions", c.Field)
+}
+
+func compileText(c Condition) (func(reflect.Value) bool, error) {
+	value := c.Value
+	switch c.Op {
+	case OpEq:
+		return func(field reflect.Value) bool { return field.String() == value }, nil
+	case OpNe:
+		return func(field reflect.Value) bool { return field.String() != value }, nil
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			return nil, fmt.Errorf("%s needs values", c.Op)
+		}
+		values, in := slices.Clone(c.Values), c.Op == OpIn
+		return func(field reflect.Value) bool { return slices.Contains(values, field.String()) == in }, nil
+	case OpContains:
+		return func(field reflect.Value) bool { return strings.Contains(field.String(), value) }, nil
+	case OpPrefix:
+		return func(field reflect.Value) bool { return strings.HasPrefix(field.String(), value) }, nil
+	case OpSuffix:
+		return func(field reflect.Value) bool { return strings.HasSuffix(field.String(), value) }, nil
+	case OpRegex:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %v", err)
+		}
+		return func(field reflect.Value) bool { return re.MatchString(field.String()) }, nil
+	case OpGt, OpGte, OpLt, OpLte:
+		return nil, fmt.Errorf("%s only compares number fields, %s is text", c.Op, c.Field)
+	default:
+		return nil, fmt.Errorf("unknown operator %q", c.Op)
+	}
+}
+
+func compileNumber(c Condition) (func(reflect.Value) bool, error) {
+	switch c.Op {
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			return nil, fmt.Errorf("%s needs values", c.Op)
+		}
+		values := make([]float64, len(c.Values))
+		for idx, raw := range c.Values {
+			value, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s is a number field, %q is not a number", c.Field, raw)
+			}
+			values[idx] = value
+		}
+		in := c.Op == OpIn
+		return func(field reflect.Value) bool { return slices.Contains(values, number(field)) == in }, nil
+	case OpContains, OpPrefix, OpSuffix, OpRegex:
+		return nil, fmt.Errorf("%s only applies to text fields, %s is a number", c.Op, c.Field)
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+	default:
+		return nil, fmt.Errorf("unknown operator %q", c.Op)
+	}
+
+	value, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s is a number field, %q is not a number", c.Field, c.Value)
+	}
+	compare := map[string]func(float64) bool{
+		OpEq:  func(n float64) bool { return n == value },
+		OpNe:  func(n float64) bool { return n != value },
+		OpGt:  func(n float64) bool { return n > value },
+		OpGte: func(n float64) bool { return n >= value },
+		OpLt:  func(n float64) bool { return n < value },
+		OpLte: func(n float64) bool { return n <= value },
+	}[c.Op]
+	return func(field reflect.Value) bool { return compare(number(field)) }, nil
+}
+
+// number reads a float32 field at its printed precision, so amount eq 19.99
+// matches an amount of 19.99 rather than 19.9899997711
+func number(field reflect.Value) float64 {
+	if field.Kind() == reflect.Float32 {
+		n, _ := strconv.ParseFloat(strconv.FormatFloat(field.Float(), 'g', -1, 32), 64)
+		return n
+	}
+	return field.Float()
+}
@@ -0,0 +1,67 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+)
+
+// Archiver copies the stored transactions processed within a period to a
+// sink, the s3 archive of the scheduled archive job
+type Archiver struct {
+	Source    TxSource
+	Sink      Sink
+	Logger    *zap.Logger
+	BatchSize int
+}
+
+func NewArchiver(source TxSource, sink Sink, logger *zap.Logger, batchSize int) *Archiver {
+	return &Archiver{Source: source, Sink: sink, Logger: logger, BatchSize: batchSize}
+}
+
+// Run writes the transactions processed at or after from and before to, and
+// flushes the sink. The transactions stored before provenance was stamped
+// have no processing time and are left out.
+func (a *Archiver) Run(ctx context.Context, from, to time.Time) (int64, error) {
+	filter := bson.M{"provenance.processed_at": bson.M{"$gte": from, "$lt": to}}
+	var archived int64
+	batch := make([]interface{}, 0, a.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := a.Sink.InsertTransactions(ctx, batch); err != nil {
+			return fmt.Errorf("failed to archive transactions: %v", err)
+		}
+		archived += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	err := a.Source.ScanTransactions(ctx, filter, "", int32(a.BatchSize), func(tx models.MongoTransaction) error {
+		batch = append(batch, tx)
+		if len(batch) == a.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return archived, err
+	}
+	if err := a.Sink.Close(ctx); err != nil {
+		return archived, fmt.Errorf("failed to flush the archive: %v", err)
+	}
+	a.Logger.Info("archived transactions", zap.Int64("transactions", archived), zap.Time("from", from), zap.Time("to", to))
+	return archived, nil
+}
@@ -0,0 +1,102 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/bson"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// TxSource reads stored transactions in _id order, see mongodb.TxRepository
+type TxSource interface {
+	ScanTransactions(ctx context.Context, filter bson.M, after string, batchSize int32, fn func(tx models.MongoTransaction) error) error
+}
+
+// Publisher publishes records to their topics
+type Publisher interface {
+	Produce(ctx context.Context, records ...models.Record) error
+}
+
+// BackfillResult is how far a backfill got, LastID resumes it with --after
+type BackfillResult struct {
+	Published int64
+	LastID    string
+}
+
+// Backfiller publishes stored transactions to a topic at a bounded rate, to
+// seed new downstream consumers with the history
+type Backfiller struct {
+	Source    TxSource
+	Publisher Publisher
+	Logger    *zap.Logger
+	Topic     string
+	BatchSize int
+	Limit     int64         // Stops after this many transactions, zero for all of them
+	Limiter   *rate.Limiter // Transactions per second
+}
+
+func NewBackfiller(source TxSource, publisher Publisher, logger *zap.Logger, topic string, batchSize int, perSecond float64) *Backfiller {
+	return &Backfiller{
+		Source:    source,
+		Publisher: publisher,
+		Logger:    logger,
+		Topic:     topic,
+		BatchSize: batchSize,
+		Limiter:   rate.NewLimiter(rate.Limit(perSecond), batchSize),
+	}
+}
+
+// errLimitReached ends the scan once the limit is published
+var errLimitReached = fmt.Errorf("limit reached")
+
+// Run publishes the transactions matching the filter, keyed by transaction
+// id so they land on the partition a live record of the same id would
+func (b *Backfiller) Run(ctx context.Context, filter bson.M, after string) (BackfillResult, error) {
+	result := BackfillResult{LastID: after}
+	batch := make([]models.Record, 0, b.BatchSize)
+	var batchLastID string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := b.Limiter.WaitN(ctx, len(batch)); err != nil {
+			return err
+		}
+		if err := b.Publisher.Produce(ctx, batch...); err != nil {
+			return fmt.Errorf("failed to publish transactions: %v", err)
+		}
+		result.Published += int64(len(batch))
+		result.LastID = batchLastID
+		b.Logger.Info("backfilled transactions", zap.Int64("published", result.Published), zap.String("last_id", result.LastID))
+		batch = batch[:0]
+		return nil
+	}
+
+	err := b.Source.ScanTransactions(ctx, filter, after, int32(b.BatchSize), func(tx models.MongoTransaction) error {
+		if b.Limit > 0 && result.Published+int64(len(batch)) >= b.Limit {
+			return errLimitReached
+		}
+		value, err := json.Marshal(tx)
+		if err != nil {
+			return fmt.Errorf("failed to encode transaction %s: %v", tx.TxID, err)
+		}
+		batch = append(batch, models.Record{Key: []byte(tx.TxID), Value: value, Topic: b.Topic})
+		batchLastID = tx.TxID
+		if len(batch) == b.BatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil && err != errLimitReached {
+		return result, err
+	}
+	return result, flush()
+}
@@ -0,0 +1,276 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// ProcessedLookup tells when stored transactions were processed, see mongodb.TxRepository
+type ProcessedLookup interface {
+	ProcessedAt(ctx context.Context, ids []string) (map[string]time.Time, error)
+}
+
+type BenchConfig struct {
+	Topic        string
+	Rate         float64       // Transactions published per second
+	Duration     time.Duration // How long to publish for
+	MinSize      int           // Encoded size of the transactions, drawn uniformly between MinSize and MaxSize bytes
+	MaxSize      int
+	BatchSize    int
+	Wait         time.Duration // How long to wait for the stragglers to be stored once publishing stops
+	PollInterval time.Duration
+}
+
+// BenchLatency are the end-to-end latency percentiles, from publishing a
+// transaction to the consumer processing it
+type BenchLatency struct {
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+	Max time.Duration `json:"max"`
+}
+
+type BenchReport struct {
+	RunID          string        `json:"run_id"`
+	Prefix         string        `json:"prefix"` // Transaction ids of the run start with it
+	Produced       int64         `json:"produced"`
+	ProducedBytes  int64         `json:"produced_bytes"`
+	ProduceErrors  int64         `json:"produce_errors"`
+	Stored         int64         `json:"stored"`
+	Missing        int64         `json:"missing"` // Published but not stored within the wait
+	ProduceElapsed time.Duration `json:"produce_elapsed"`
+	StoreElapsed   time.Duration `json:"store_elapsed"` // From the first publish to the last transaction stored
+	ProducedPerSec float64       `json:"produced_per_sec"`
+	StoredPerSec   float64       `json:"stored_per_sec"`
+	Latency        BenchLatency  `json:"latency"`
+}
+
+// Bench publishes synthetic transactions at a target rate and watches them
+// land in the store, measuring the throughput and end-to-end latency of the
+// pipeline. Latencies compare the clock of the bench with the processed_at
+// stamped by the consumer, so the hosts should be time-synced.
+type Bench struct {
+	Publisher Publisher
+	Store     ProcessedLookup
+	Logger    *zap.Logger
+	Config    BenchConfig
+
+	mu        sync.Mutex
+	pending   map[string]time.Time // Published ids not seen in the store yet, by publish time
+	latencies []time.Duration
+	lastSeen  time.Time
+}
+
+func NewBench(publisher Publisher, store ProcessedLookup, logger *zap.Logger, config BenchConfig) *Bench {
+	return &Bench{Publisher: publisher, Store: store, Logger: logger, Config: config}
+}
+
+// benchLookupChunk bounds the ids looked up per query
+const benchLookupChunk = 1000
+
+// Run publishes for the configured duration, then waits for the published
+// transactions to be stored. On cancellation the report covers what was
+// measured so far.
+func (b *Bench) Run(ctx context.Context) (BenchReport, error) {
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	report := BenchReport{RunID: runID, Prefix: "bench-" + runID + "-"}
+	b.pending = make(map[string]time.Time)
+	b.latencies = nil
+
+	start := time.Now()
+	produced := make(chan error, 1)
+	go func() { produced <- b.produce(ctx, &report) }()
+
+	ticker := time.NewTicker(b.Config.PollInterval)
+	defer ticker.Stop()
+	var produceErr error
+	producing := true
+	var deadline <-chan time.Time
+	for producing || b.pendingCount() > 0 {
+		select {
+		case produceErr = <-produced:
+			producing = false
+			report.ProduceElapsed = time.Since(start)
+			deadline = time.After(b.Config.Wait)
+			b.Logger.Info("bench published, waiting for the stragglers",
+				zap.Int64("produced", report.Produced), zap.Int("pending", b.pendingCount()))
+		case <-deadline:
+			b.finish(&report, start)
+			return report, produceErr
+		case <-ctx.Done():
+			if producing {
+				produceErr = <-produced
+				report.ProduceElapsed = time.Since(start)
+			}
+			b.finish(&report, start)
+			return report, ctx.Err()
+		case <-ticker.C:
+			if err := b.poll(ctx); err != nil && ctx.Err() == nil {
+				b.Logger.Warn("cannot look up bench transactions", zap.Error(err))
+			}
+		}
+	}
+	b.finish(&report, start)
+	return report, produceErr
+}
+
+func (b *Bench) produce(ctx context.Context, report *BenchReport) error {
+	limiter := rate.NewLimiter(rate.Limit(b.Config.Rate), b.Config.BatchSize)
+	end := time.Now().Add(b.Config.Duration)
+	var seq int64
+	for time.Now().Before(end) {
+		if err := limiter.WaitN(ctx, b.Config.BatchSize); err != nil {
+			return nil
+		}
+		batch := make([]models.Record, b.Config.BatchSize)
+		for idx := range batch {
+			seq++
+			tx := b.transaction(report.Prefix + strconv.FormatInt(seq, 10))
+			value, err := json.Marshal(tx)
+			if err != nil {
+				return fmt.Errorf("failed to encode transaction: %v", err)
+			}
+			batch[idx] = models.Record{Key: []byte(tx.TxID), Value: value, Topic: b.Config.Topic}
+			report.ProducedBytes += int64(len(value))
+		}
+		publishedAt := time.Now()
+		if err := b.Publisher.Produce(ctx, batch...); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			report.ProduceErrors += int64(len(batch))
+			b.Logger.Warn("cannot publish bench transactions", zap.Error(err))
+			continue
+		}
+		report.Produced += int64(len(batch))
+		b.mu.Lock()
+		for _, record := range batch {
+			b.pending[string(record.Key)] = publishedAt
+		}
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+var (
+	benchCurrencies = []string{"USD", "EUR", "GBP", "INR"}
+	benchTypes      = []string{"purchase", "refund", "transfer"}
+	benchMethods    = []string{"credit_card", "debit_card", "upi", "bank_transfer"}
+)
+
+// transaction returns a synthetic transaction padded to a size drawn from the
+// configured distribution
+func (b *Bench) transaction(id string) models.Transaction {
+	tx := models.Transaction{
+		TxID:            id,
+		UserID:          "bench-user-" + strconv.Itoa(rand.IntN(1000)),
+		Amount:          float32(math.Round(rand.Float64()*100000) / 100),
+		Currency:        benchCurrencies[rand.IntN(len(benchCurrencies))],
+		TransactionType: benchTypes[rand.IntN(len(benchTypes))],
+		Status:          "completed",
+		Timestamp:       time.Now().UTC().Format(time.RFC3339),
+		PaymentMethod:   benchMethods[rand.IntN(len(benchMethods))],
+		CardNumber:      "4111********1111",
+		BankName:        "Bench Bank",
+		Location:        "Bench",
+		Category:        "bench",
+		InvoiceNumber:   "INV-" + id,
+		IPAddress:       "192.0.2.1",
+	}
+	size := b.Config.MinSize
+	if b.Config.MaxSize > b.Config.MinSize {
+		size += rand.IntN(b.Config.MaxSize - b.Config.MinSize + 1)
+	}
+	if encoded, err := json.Marshal(tx); err == nil && len(encoded) < size {
+		tx.MerchantName = strings.Repeat("x", size-len(encoded))
+	}
+	return tx
+}
+
+// poll looks up the pending transactions and records the latency of the stored ones
+func (b *Bench) poll(ctx context.Context) error {
+	b.mu.Lock()
+	ids := make([]string, 0, len(b.pending))
+	for id := range b.pending {
+		ids = append(ids, id)
+	}
+	b.mu.Unlock()
+
+	for chunk := range slices.Chunk(ids, benchLookupChunk) {
+		processed, err := b.Store.ProcessedAt(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		b.mu.Lock()
+		for id, processedAt := range processed {
+			publishedAt, ok := b.pending[id]
+			if !ok {
+				continue
+			}
+			delete(b.pending, id)
+			b.latencies = append(b.latencies, max(processedAt.Sub(publishedAt), 0))
+			if processedAt.After(b.lastSeen) {
+				b.lastSeen = processedAt
+			}
+		}
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+func (b *Bench) pendingCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+func (b *Bench) finish(report *BenchReport, start time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	report.Stored = int64(len(b.latencies))
+	report.Missing = int64(len(b.pending))
+	if report.ProduceElapsed > 0 {
+		report.ProducedPerSec = float64(report.Produced) / report.ProduceElapsed.Seconds()
+	}
+	if !b.lastSeen.IsZero() {
+		report.StoreElapsed = b.lastSeen.Sub(start)
+		if report.StoreElapsed > 0 {
+			report.StoredPerSec = float64(report.Stored) / report.StoreElapsed.Seconds()
+		}
+	}
+	latencies := slices.Clone(b.latencies)
+	slices.Sort(latencies)
+	report.Latency = BenchLatency{
+		P50: percentile(latencies, 0.50),
+		P90: percentile(latencies, 0.90),
+		P99: percentile(latencies, 0.99),
+	}
+	if len(latencies) > 0 {
+		report.Latency.Max = latencies[len(latencies)-1]
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(idx, 0)]
+}
@@ -0,0 +1,96 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// DryRunTxRepository logs the transactions it would insert instead of writing them
+type DryRunTxRepository struct {
+	Logger  *zap.Logger
+	Summary *DryRunSummary // Optional, counts the transactions that would be written
+}
+
+func NewDryRunTxRepository(logger *zap.Logger) *DryRunTxRepository {
+	return &DryRunTxRepository{Logger: logger}
+}
+
+func (r *DryRunTxRepository) InsertTransactions(_ context.Context, txs []interface{}) error {
+	r.Logger.Info("dry run, skipping insert", zap.Int("transactions", len(txs)))
+	r.Summary.WouldWrite(len(txs))
+	return nil
+}
+
+func (r *DryRunTxRepository) InsertTransaction(_ context.Context, tx models.MongoTransaction) error {
+	r.Logger.Info("dry run, skipping insert", zap.String("transaction_id", tx.TxID))
+	r.Summary.WouldWrite(1)
+	return nil
+}
+
+// DryRunReport is what a dry run would have done
+type DryRunReport struct {
+	Records   int64            `json:"records"`     // Records fetched and processed
+	Written   int64            `json:"would_write"` // Transactions that would be inserted
+	Failures  map[string]int64 `json:"would_fail"`  // Records that would fail, by error class
+	Failed    int64            `json:"failed"`
+	StartedAt time.Time        `json:"started_at"`
+}
+
+// DryRunSummary tallies the outcomes of a dry run, for the report on exit
+type DryRunSummary struct {
+	mu     sync.Mutex
+	report DryRunReport
+}
+
+func NewDryRunSummary() *DryRunSummary {
+	return &DryRunSummary{report: DryRunReport{Failures: make(map[string]int64), StartedAt: time.Now()}}
+}
+
+// Processed counts the records of a processed batch
+func (s *DryRunSummary) Processed(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.Records += int64(n)
+}
+
+// WouldWrite counts the transactions the repository was asked to insert
+func (s *DryRunSummary) WouldWrite(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.Written += int64(n)
+}
+
+// WouldFail counts records that would be skipped or dead-lettered
+func (s *DryRunSummary) WouldFail(reason string, n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report.Failures[reason] += int64(n)
+	s.report.Failed += int64(n)
+}
+
+// Report returns a copy of the tallies so far
+func (s *DryRunSummary) Report() DryRunReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := s.report
+	report.Failures = maps.Clone(s.report.Failures)
+	return report
+}
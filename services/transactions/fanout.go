@@ -0,0 +1,215 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// Commit policies of a fan-out sink
+const (
+	CommitWait  = "wait"  // The offsets are committed once the sink stored the batch or dead-lettered it
+	CommitAsync = "async" // The offsets are committed right away, the sink catches up in the background
+)
+
+// SinkDeadLetters keeps the transactions a fan-out sink refused
+type SinkDeadLetters interface {
+	Put(ctx context.Context, sink string, txs []models.MongoTransaction, cause error, attempts int) error
+}
+
+type FanoutConfig struct {
+	MaxRetries int // Per sink, before its share of the batch is dead-lettered
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	QueueSize  int // Batches an async sink holds, a batch arriving at a full queue is dead-lettered
+}
+
+type fanoutSink struct {
+	name   string
+	repo   TxRepository
+	commit string
+	queue  chan []models.MongoTransaction // CommitAsync only
+}
+
+// FanoutTxRepository writes every batch to the primary repository and to
+// each fan-out sink concurrently. Only the primary's error fails the batch,
+// every sink retries on its own and then dead-letters what it still refuses,
+// so a sink being down delays neither the primary nor the other sinks. A
+// batch the primary refused reaches the sinks again when it is retried, so
+// the sinks replace existing documents.
+type FanoutTxRepository struct {
+	Primary     TxRepository
+	DeadLetters SinkDeadLetters
+	Logger      *zap.Logger
+	Metrics     *metrics.FanoutMetrics
+	Config      FanoutConfig
+
+	sinks   []*fanoutSink
+	mu      sync.RWMutex // Guards the async queues against a write after Close
+	closed  bool
+	workers sync.WaitGroup
+	stop    context.CancelFunc
+	ctx     context.Context
+}
+
+func NewFanoutTxRepository(primary TxRepository, deadLetters SinkDeadLetters, logger *zap.Logger, fanoutMetrics *metrics.FanoutMetrics, conf FanoutConfig) *FanoutTxRepository {
+	ctx, stop := context.WithCancel(context.Background())
+	return &FanoutTxRepository{Primary: primary, DeadLetters: deadLetters, Logger: logger, Metrics: fanoutMetrics, Config: conf, ctx: ctx, stop: stop}
+}
+
+// AddSink fans the batches out to repo, an async sink gets a worker of its own
+func (r *FanoutTxRepository) AddSink(name string, repo TxRepository, commit string) {
+	sink := &fanoutSink{name: name, repo: repo, commit: commit}
+	if commit == CommitAsync {
+		sink.queue = make(chan []models.MongoTransaction, r.Config.QueueSize)
+		r.workers.Add(1)
+		go r.work(sink)
+	}
+	r.sinks = append(r.sinks, sink)
+}
+
+func (r *FanoutTxRepository) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	docs := make([]models.MongoTransaction, 0, len(txs))
+	for _, tx := range txs {
+		if doc, ok := tx.(models.MongoTransaction); ok {
+			docs = append(docs, doc)
+		}
+	}
+	return r.write(ctx, docs, func(ctx context.Context) error { return r.Primary.InsertTransactions(ctx, txs) })
+}
+
+func (r *FanoutTxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	return r.write(ctx, []models.MongoTransaction{tx}, func(ctx context.Context) error { return r.Primary.InsertTransaction(ctx, tx) })
+}
+
+func (r *FanoutTxRepository) write(ctx context.Context, docs []models.MongoTransaction, primary func(ctx context.Context) error) error {
+	errs := make([]error, len(r.sinks)+1)
+	var wg sync.WaitGroup
+	for idx, sink := range r.sinks {
+		if sink.commit == CommitAsync {
+			r.enqueue(ctx, sink, docs)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[idx+1] = r.deliver(ctx, sink, docs)
+		}()
+	}
+	errs[0] = primary(ctx)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (r *FanoutTxRepository) enqueue(ctx context.Context, sink *fanoutSink, docs []models.MongoTransaction) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cause := fmt.Errorf("sink is closed")
+	if !r.closed {
+		select {
+		case sink.queue <- docs:
+			r.Metrics.Queued(sink.name, len(sink.queue))
+			return
+		default:
+			cause = fmt.Errorf("queue of %d batches is full", cap(sink.queue))
+		}
+	}
+	if err := r.deadLetter(ctx, sink, docs, cause, 0); err != nil {
+		r.Logger.Error("async sink lost a batch", zap.String("sink", sink.name), zap.Int("transactions", len(docs)), zap.Error(err))
+	}
+}
+
+// work delivers the batches queued for an async sink until Close
+func (r *FanoutTxRepository) work(sink *fanoutSink) {
+	defer r.workers.Done()
+	for docs := range sink.queue {
+		r.Metrics.Queued(sink.name, len(sink.queue))
+		if err := r.deliver(r.ctx, sink, docs); err != nil {
+			r.Logger.Error("async sink lost a batch", zap.String("sink", sink.name), zap.Int("transactions", len(docs)), zap.Error(err))
+		}
+	}
+}
+
+// deliver retries the write to the sink and dead-letters the batch once the
+// retries are spent, an error means the batch was neither stored nor kept
+func (r *FanoutTxRepository) deliver(ctx context.Context, sink *fanoutSink, docs []models.MongoTransaction) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	txs := make([]interface{}, len(docs))
+	for idx, doc := range docs {
+		txs[idx] = doc
+	}
+	backoff := r.Config.Backoff
+	var err error
+	attempt := 0
+	for ; attempt <= r.Config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, r.Config.MaxBackoff)
+		}
+		start := time.Now()
+		err = sink.repo.InsertTransactions(ctx, txs)
+		r.Metrics.ObserveWrite(sink.name, metrics.Outcome(err), time.Since(start).Seconds())
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.Logger.Warn("fan-out sink write failed", zap.String("sink", sink.name), zap.Int("attempt", attempt+1), zap.Error(err))
+	}
+	return r.deadLetter(ctx, sink, docs, err, attempt)
+}
+
+func (r *FanoutTxRepository) deadLetter(ctx context.Context, sink *fanoutSink, docs []models.MongoTransaction, cause error, attempts int) error {
+	r.Metrics.DeadLetter(sink.name, len(docs))
+	r.Logger.Error("fan-out sink refused transactions, dead-lettering them", zap.String("sink", sink.name),
+		zap.Int("transactions", len(docs)), zap.Int("attempts", attempts), zap.Error(cause))
+	if err := r.DeadLetters.Put(ctx, sink.name, docs, cause, attempts); err != nil {
+		return fmt.Errorf("failed to dead-letter the transactions of sink %s: %v", sink.name, err)
+	}
+	return nil
+}
+
+// Close waits for the async sinks to deliver their queues, what is left at
+// the deadline is given up and logged
+func (r *FanoutTxRepository) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		for _, sink := range r.sinks {
+			if sink.queue != nil {
+				close(sink.queue)
+			}
+		}
+	}
+	r.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		r.stop()
+		return nil
+	case <-ctx.Done():
+		r.stop()
+		return fmt.Errorf("async sinks did not drain: %v", ctx.Err())
+	}
+}
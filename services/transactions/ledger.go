@@ -0,0 +1,122 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+	outbox "tx-stream/outbox"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LedgerStore keeps the records whose transactions were stored, see
+// mongodb.AppliedRecords. Add must write through the context it is given, so
+// it joins the transaction of the writes.
+type LedgerStore interface {
+	Applied(ctx context.Context, ids []string) (map[string]bool, error)
+	Add(ctx context.Context, entries []models.AppliedRecord) error
+}
+
+// Ledger leaves out the records found in the idempotency ledger, so a record
+// redelivered after a rebalance or a crash, or retried after its batch
+// partly went through, is never applied twice. The entries are only written
+// by LedgerTxRepository, in the transaction of the writes. The hooks that run
+// after a write, the tails, aggregates and alerts, are thus skipped for the
+// redelivered records too, but they are lost when a crash comes between the
+// commit and them.
+type Ledger struct {
+	Store   LedgerStore
+	Metrics *metrics.IdempotencyMetrics
+}
+
+func NewLedger(store LedgerStore, idempotencyMetrics *metrics.IdempotencyMetrics) *Ledger {
+	return &Ledger{Store: store, Metrics: idempotencyMetrics}
+}
+
+// Filter returns the records not applied yet, skipped counts the others. A nil
+// ledger returns the records as they are.
+func (l *Ledger) Filter(ctx context.Context, records []models.Record) (fresh []models.Record, skipped int, err error) {
+	if l == nil || len(records) == 0 {
+		return records, 0, nil
+	}
+	ids := make([]string, len(records))
+	for idx, record := range records {
+		ids[idx] = models.AppliedRecordID(record.Consumer, record.Topic, record.Partition, record.Offset)
+	}
+	start := time.Now()
+	applied, err := l.Store.Applied(ctx, ids)
+	topic := records[0].Topic
+	if err != nil {
+		l.Metrics.Looked(topic, 0, 0, metrics.OutcomeFailure, time.Since(start).Seconds())
+		return nil, 0, err
+	}
+	if len(applied) == 0 {
+		l.Metrics.Looked(topic, len(records), 0, metrics.OutcomeSuccess, time.Since(start).Seconds())
+		return records, 0, nil
+	}
+	fresh = make([]models.Record, 0, len(records)-len(applied))
+	for idx, record := range records {
+		if !applied[ids[idx]] {
+			fresh = append(fresh, record)
+		}
+	}
+	skipped = len(records) - len(fresh)
+	l.Metrics.Looked(topic, len(fresh), skipped, metrics.OutcomeSuccess, time.Since(start).Seconds())
+	return fresh, skipped, nil
+}
+
+// LedgerTxRepository stores the transactions together with the ledger entries
+// of their records, in a single mongo transaction, so a record is in the
+// ledger once its transaction is stored and never otherwise. A replica that
+// applies a record another one applied meanwhile fails on the duplicate entry
+// and its batch is retried without it. The wrapped repository must write
+// through Client, an outbox repository joins the transaction.
+type LedgerTxRepository struct {
+	Client *mongo.Client
+	Repo   TxRepository
+	Store  LedgerStore
+}
+
+func NewLedgerTxRepository(client *mongo.Client, repo TxRepository, store LedgerStore) *LedgerTxRepository {
+	return &LedgerTxRepository{Client: client, Repo: repo, Store: store}
+}
+
+func (r *LedgerTxRepository) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	entries := make([]models.AppliedRecord, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot record a %T in the ledger", tx)
+		}
+		entry, err := models.NewAppliedRecord(doc)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	return outbox.WithTransaction(ctx, r.Client, func(ctx context.Context) error {
+		if err := r.Repo.InsertTransactions(ctx, txs); err != nil {
+			return err
+		}
+		return r.Store.Add(ctx, entries)
+	})
+}
+
+func (r *LedgerTxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	entry, err := models.NewAppliedRecord(tx)
+	if err != nil {
+		return err
+	}
+	return outbox.WithTransaction(ctx, r.Client, func(ctx context.Context) error {
+		if err := r.Repo.InsertTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return r.Store.Add(ctx, []models.AppliedRecord{entry})
+	})
+}
@@ -0,0 +1,343 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// fakeLedger keeps the applied ids in memory, like mongodb.AppliedRecords the
+// entries already there fail Add as a duplicate
+type fakeLedger struct {
+	applied   map[string]bool
+	lookupErr error
+	added     [][]models.AppliedRecord
+	sessions  []mongo.Session
+}
+
+func newFakeLedger(ids ...string) *fakeLedger {
+	l := &fakeLedger{applied: make(map[string]bool)}
+	for _, id := range ids {
+		l.applied[id] = true
+	}
+	return l
+}
+
+func (l *fakeLedger) Applied(_ context.Context, ids []string) (map[string]bool, error) {
+	if l.lookupErr != nil {
+		return nil, l.lookupErr
+	}
+	found := make(map[string]bool)
+	for _, id := range ids {
+		if l.applied[id] {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+func (l *fakeLedger) Add(ctx context.Context, entries []models.AppliedRecord) error {
+	l.sessions = append(l.sessions, mongo.SessionFromContext(ctx))
+	for _, entry := range entries {
+		if l.applied[entry.ID] {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key " + entry.ID}}}
+		}
+	}
+	for _, entry := range entries {
+		l.applied[entry.ID] = true
+	}
+	l.added = append(l.added, entries)
+	return nil
+}
+
+// fakeTxRepo records the stored transaction ids and the session they were written in
+type fakeTxRepo struct {
+	err      error
+	stored   []string
+	sessions []mongo.Session
+}
+
+func (r *fakeTxRepo) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	r.sessions = append(r.sessions, mongo.SessionFromContext(ctx))
+	if r.err != nil {
+		return r.err
+	}
+	for _, tx := range txs {
+		r.stored = append(r.stored, tx.(models.MongoTransaction).TxID)
+	}
+	return nil
+}
+
+func (r *fakeTxRepo) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	return r.InsertTransactions(ctx, []interface{}{tx})
+}
+
+func ledgerRecord(offset int64) models.Record {
+	return models.Record{
+		Topic:     "transactions",
+		Partition: 2,
+		Offset:    offset,
+		Consumer:  "main",
+		Value:     []byte(`{"transaction_id":"tx-` + strconv.FormatInt(offset, 10) + `","amount":10}`),
+	}
+}
+
+func ledgerID(offset int64) string {
+	return models.AppliedRecordID("main", "transactions", 2, offset)
+}
+
+func ledgerDoc(offset int64) models.MongoTransaction {
+	doc := models.MongoTransaction{TxID: "tx-" + strconv.FormatInt(offset, 10)}
+	doc.StampProvenance(ledgerRecord(offset), time.Now())
+	return doc
+}
+
+// sessionContext carries a session like the context of a transaction, so the
+// writes join it without a server
+func sessionContext(t *testing.T) (context.Context, *mongo.Client) {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("cannot create mongo client: %v", err)
+	}
+	session, err := client.StartSession()
+	if err != nil {
+		t.Fatalf("cannot start mongo session: %v", err)
+	}
+	t.Cleanup(func() {
+		session.EndSession(context.Background())
+		_ = client.Disconnect(context.Background())
+	})
+	return mongo.NewSessionContext(context.Background(), session), client
+}
+
+func TestLedgerFilter(t *testing.T) {
+	lookupErr := errors.New("mongo unavailable")
+	tests := []struct {
+		name        string
+		ledger      *Ledger
+		records     []models.Record
+		wantOffsets []int64
+		wantSkipped int
+		wantErr     error
+	}{
+		{
+			name:        "nil ledger keeps every record",
+			records:     []models.Record{ledgerRecord(1), ledgerRecord(2)},
+			wantOffsets: []int64{1, 2},
+		},
+		{
+			name:   "no record applied",
+			ledger: NewLedger(newFakeLedger(), nil),
+			records: []models.Record{
+				ledgerRecord(1), ledgerRecord(2),
+			},
+			wantOffsets: []int64{1, 2},
+		},
+		{
+			name:        "applied records are left out",
+			ledger:      NewLedger(newFakeLedger(ledgerID(1), ledgerID(3)), nil),
+			records:     []models.Record{ledgerRecord(1), ledgerRecord(2), ledgerRecord(3), ledgerRecord(4)},
+			wantOffsets: []int64{2, 4},
+			wantSkipped: 2,
+		},
+		{
+			name:        "every record applied",
+			ledger:      NewLedger(newFakeLedger(ledgerID(1), ledgerID(2)), nil),
+			records:     []models.Record{ledgerRecord(1), ledgerRecord(2)},
+			wantOffsets: []int64{},
+			wantSkipped: 2,
+		},
+		{
+			name:        "another consumer's entry does not apply",
+			ledger:      NewLedger(newFakeLedger(models.AppliedRecordID("shadow", "transactions", 2, 1)), nil),
+			records:     []models.Record{ledgerRecord(1)},
+			wantOffsets: []int64{1},
+		},
+		{
+			name:        "the lookup fails",
+			ledger:      NewLedger(&fakeLedger{lookupErr: lookupErr}, nil),
+			records:     []models.Record{ledgerRecord(1)},
+			wantOffsets: []int64{},
+			wantErr:     lookupErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fresh, skipped, err := tt.ledger.Filter(context.Background(), tt.records)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Filter() error = %v, want %v", err, tt.wantErr)
+			}
+			offsets := []int64{}
+			for _, record := range fresh {
+				offsets = append(offsets, record.Offset)
+			}
+			if !slices.Equal(offsets, tt.wantOffsets) || skipped != tt.wantSkipped {
+				t.Errorf("Filter() = %v, %d skipped, want %v, %d skipped", offsets, skipped, tt.wantOffsets, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestLedgerTxRepository(t *testing.T) {
+	repoErr := errors.New("write failed")
+	tests := []struct {
+		name       string
+		applied    []string
+		repoErr    error
+		txs        []interface{}
+		wantStored []string
+		wantAdded  []string
+		wantErr    bool
+	}{
+		{
+			name:       "stores the transactions and their entries",
+			txs:        []interface{}{ledgerDoc(1), ledgerDoc(2)},
+			wantStored: []string{"tx-1", "tx-2"},
+			wantAdded:  []string{ledgerID(1), ledgerID(2)},
+		},
+		{
+			name:    "a failed write adds no entry",
+			repoErr: repoErr,
+			txs:     []interface{}{ledgerDoc(1)},
+			wantErr: true,
+		},
+		{
+			name:       "an entry applied meanwhile fails the batch",
+			applied:    []string{ledgerID(2)},
+			txs:        []interface{}{ledgerDoc(1), ledgerDoc(2)},
+			wantStored: []string{"tx-1", "tx-2"}, // Rolled back with the transaction
+			wantErr:    true,
+		},
+		{
+			name:    "a transaction without provenance is refused before writing",
+			txs:     []interface{}{models.MongoTransaction{TxID: "tx-x"}},
+			wantErr: true,
+		},
+		{
+			name:    "a foreign document is refused before writing",
+			txs:     []interface{}{"tx-y"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, client := sessionContext(t)
+			store := newFakeLedger(tt.applied...)
+			inner := &fakeTxRepo{err: tt.repoErr}
+			repo := NewLedgerTxRepository(client, inner, store)
+
+			err := repo.InsertTransactions(ctx, tt.txs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InsertTransactions() error = %v, want an error %t", err, tt.wantErr)
+			}
+			if !slices.Equal(inner.stored, tt.wantStored) {
+				t.Errorf("stored %v, want %v", inner.stored, tt.wantStored)
+			}
+			var added []string
+			for _, entries := range store.added {
+				for _, entry := range entries {
+					added = append(added, entry.ID)
+				}
+			}
+			if !slices.Equal(added, tt.wantAdded) {
+				t.Errorf("added %v, want %v", added, tt.wantAdded)
+			}
+			// Both writes join the transaction of the context
+			session := mongo.SessionFromContext(ctx)
+			for _, written := range slices.Concat(inner.sessions, store.sessions) {
+				if written != session {
+					t.Errorf("write outside the transaction of the context")
+				}
+			}
+		})
+	}
+}
+
+// TestLedgerRedelivery processes a batch again after another replica applied
+// one of its records, as after a rebalance: the duplicate entry fails the
+// batch and the retry leaves the applied record out
+func TestLedgerRedelivery(t *testing.T) {
+	ctx, client := sessionContext(t)
+	// The other replica applies the first record between the lookup and the write
+	store := &racingLedger{fakeLedger: newFakeLedger(), applyOnLookup: ledgerID(1)}
+	inner := &fakeTxRepo{}
+	processor := NewTxProcessor(zap.NewNop(), NewLedgerTxRepository(client, inner, store), nil, nil)
+	processor.Ledger = NewLedger(store, nil)
+	records := []models.Record{ledgerRecord(1), ledgerRecord(2)}
+
+	if err := processor.ProcessRecords(ctx, records); err == nil {
+		t.Fatal("ProcessRecords() applied a record applied meanwhile")
+	}
+	inner.stored = nil // Rolled back with the transaction
+
+	if err := processor.ProcessRecords(ctx, records); err != nil {
+		t.Fatalf("retried ProcessRecords() error = %v", err)
+	}
+	if !slices.Equal(inner.stored, []string{"tx-2"}) {
+		t.Errorf("retry stored %v, want only tx-2", inner.stored)
+	}
+	if err := processor.ProcessRecords(ctx, records); err != nil {
+		t.Fatalf("redelivered ProcessRecords() error = %v", err)
+	}
+	if !slices.Equal(inner.stored, []string{"tx-2"}) {
+		t.Errorf("redelivery stored %v, want nothing more", inner.stored)
+	}
+}
+
+// racingLedger applies a record of another replica right after the first lookup
+type racingLedger struct {
+	*fakeLedger
+	applyOnLookup string
+}
+
+func (l *racingLedger) Applied(ctx context.Context, ids []string) (map[string]bool, error) {
+	applied, err := l.fakeLedger.Applied(ctx, ids)
+	if l.applyOnLookup != "" {
+		l.fakeLedger.applied[l.applyOnLookup] = true
+		l.applyOnLookup = ""
+	}
+	return applied, err
+}
+
+// TestLedgerFanout redelivers a batch to a consumer fanning out from the
+// ledger repository: the processor leaves the applied records out, so the
+// duplicate entries never fail the batch and the sinks get each record once
+func TestLedgerFanout(t *testing.T) {
+	ctx, client := sessionContext(t)
+	store := newFakeLedger()
+	inner := &fakeTxRepo{}
+	sink := &fakeTxRepo{}
+	fanout := NewFanoutTxRepository(NewLedgerTxRepository(client, inner, store), nil, zap.NewNop(), nil, FanoutConfig{})
+	fanout.AddSink("s3", sink, CommitWait)
+	defer fanout.Close(context.Background())
+	processor := NewTxProcessor(zap.NewNop(), fanout, nil, nil)
+	processor.Ledger = NewLedger(store, nil)
+	records := []models.Record{ledgerRecord(1), ledgerRecord(2)}
+
+	if err := processor.ProcessRecords(ctx, records); err != nil {
+		t.Fatalf("ProcessRecords() error = %v", err)
+	}
+	if err := processor.ProcessRecords(ctx, append(records, ledgerRecord(3))); err != nil {
+		t.Fatalf("redelivered ProcessRecords() error = %v", err)
+	}
+	want := []string{"tx-1", "tx-2", "tx-3"}
+	if !slices.Equal(inner.stored, want) {
+		t.Errorf("primary stored %v, want %v", inner.stored, want)
+	}
+	if !slices.Equal(sink.stored, want) {
+		t.Errorf("sink stored %v, want %v", sink.stored, want)
+	}
+}
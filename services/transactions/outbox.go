@@ -0,0 +1,83 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+
+	// Local Packages
+	models "tx-stream/models"
+	outbox "tx-stream/outbox"
+
+	// External Packages
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StoredEventType is the event-type header of the events of the stored transactions
+const StoredEventType = "transaction.stored"
+
+// OutboxTxRepository stores the transactions together with one stored event
+// each in the outbox, in a single mongo transaction, so the event of a
+// transaction goes out once it is stored and never otherwise. The wrapped
+// repository must write through Client for its writes to join the transaction.
+type OutboxTxRepository struct {
+	Client *mongo.Client
+	Repo   TxRepository
+	Outbox *outbox.Store
+	Topic  string
+}
+
+func NewOutboxTxRepository(client *mongo.Client, repo TxRepository, store *outbox.Store, topic string) *OutboxTxRepository {
+	return &OutboxTxRepository{Client: client, Repo: repo, Outbox: store, Topic: topic}
+}
+
+func (r *OutboxTxRepository) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	msgs := make([]outbox.Message, 0, len(txs))
+	for _, tx := range txs {
+		doc, ok := tx.(models.MongoTransaction)
+		if !ok {
+			return fmt.Errorf("cannot publish a %T", tx)
+		}
+		msg, err := r.storedEvent(doc)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return outbox.WithTransaction(ctx, r.Client, func(ctx context.Context) error {
+		if err := r.Repo.InsertTransactions(ctx, txs); err != nil {
+			return err
+		}
+		return r.Outbox.Enqueue(ctx, msgs...)
+	})
+}
+
+func (r *OutboxTxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	msg, err := r.storedEvent(tx)
+	if err != nil {
+		return err
+	}
+	return outbox.WithTransaction(ctx, r.Client, func(ctx context.Context) error {
+		if err := r.Repo.InsertTransaction(ctx, tx); err != nil {
+			return err
+		}
+		return r.Outbox.Enqueue(ctx, msg)
+	})
+}
+
+// storedEvent is keyed by transaction id like the source records, its id
+// is derived from the transaction so consumers can drop the copies
+func (r *OutboxTxRepository) storedEvent(tx models.MongoTransaction) (outbox.Message, error) {
+	value, err := json.Marshal(tx)
+	if err != nil {
+		return outbox.Message{}, fmt.Errorf("failed to encode transaction %s: %v", tx.TxID, err)
+	}
+	return outbox.Message{
+		ID:      StoredEventType + ":" + tx.TxID,
+		Topic:   r.Topic,
+		Key:     []byte(tx.TxID),
+		Value:   value,
+		Headers: map[string]string{"event-type": StoredEventType},
+	}, nil
+}
@@ -0,0 +1,129 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	models "tx-stream/models"
+)
+
+// TxQueryStore reads the processed transactions
+type TxQueryStore interface {
+	FindTransactions(ctx context.Context, ids []string) (map[string]models.MongoTransaction, error)
+	ListTransactions(ctx context.Context, filter models.TxFilter, after string, limit int64) ([]models.MongoTransaction, error)
+}
+
+// SinkFailures tells which fan-out sinks refused a transaction
+type SinkFailures interface {
+	FailedSinks(ctx context.Context, ids []string) (map[string][]string, error)
+}
+
+// TxStatus is how far a transaction went through the pipeline
+type TxStatus struct {
+	TxID              string             `json:"transaction_id"`
+	Processed         bool               `json:"processed"`
+	Provenance        *models.Provenance `json:"provenance,omitempty"`
+	DeadLetteredSinks []string           `json:"dead_lettered_sinks,omitempty"`
+}
+
+// TxPage is a page of a listing, NextPageToken is empty on the last page
+type TxPage struct {
+	Transactions  []models.MongoTransaction `json:"transactions"`
+	NextPageToken string                    `json:"next_page_token,omitempty"`
+}
+
+// DefaultPageSize is the page size of the listings that don't set one
+const DefaultPageSize = 100
+
+// QueryService answers the read-only queries of the APIs, so they don't
+// depend on the repository behind them. Failures is optional.
+type QueryService struct {
+	Store       TxQueryStore
+	Failures    SinkFailures
+	MaxPageSize int // Also caps the ids of a status query
+}
+
+func NewQueryService(store TxQueryStore, failures SinkFailures, maxPageSize int) *QueryService {
+	return &QueryService{Store: store, Failures: failures, MaxPageSize: maxPageSize}
+}
+
+// Get returns a processed transaction
+func (s *QueryService) Get(ctx context.Context, id string) (models.MongoTransaction, error) {
+	if id == "" {
+		return models.MongoTransaction{}, errors.EmptyParamErr("transaction_id")
+	}
+	found, err := s.Store.FindTransactions(ctx, []string{id})
+	if err != nil {
+		return models.MongoTransaction{}, fmt.Errorf("failed to find transaction: %v", err)
+	}
+	tx, ok := found[id]
+	if !ok {
+		return models.MongoTransaction{}, errors.E(errors.NotFound, fmt.Sprintf("transaction %s not found", id))
+	}
+	return tx, nil
+}
+
+// List pages through the transactions matching the filter in id order, the
+// page token is opaque to the callers
+func (s *QueryService) List(ctx context.Context, filter models.TxFilter, pageSize int, pageToken string) (TxPage, error) {
+	if pageSize < 0 {
+		return TxPage{}, errors.E(errors.Invalid, "page size cannot be negative")
+	}
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	pageSize = min(pageSize, s.MaxPageSize)
+	after, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return TxPage{}, errors.E(errors.Invalid, "invalid page token")
+	}
+	if !filter.ProcessedAfter.IsZero() && !filter.ProcessedBefore.IsZero() && !filter.ProcessedBefore.After(filter.ProcessedAfter) {
+		return TxPage{}, errors.E(errors.Invalid, "processed before must be after processed after")
+	}
+
+	// One more than the page tells whether another page follows
+	txs, err := s.Store.ListTransactions(ctx, filter, string(after), int64(pageSize)+1)
+	if err != nil {
+		return TxPage{}, fmt.Errorf("failed to list transactions: %v", err)
+	}
+	page := TxPage{Transactions: txs}
+	if len(txs) > pageSize {
+		page.Transactions = txs[:pageSize]
+		page.NextPageToken = base64.RawURLEncoding.EncodeToString([]byte(txs[pageSize-1].TxID))
+	}
+	return page, nil
+}
+
+// Status returns the status of each id, in the order given
+func (s *QueryService) Status(ctx context.Context, ids []string) ([]TxStatus, error) {
+	if len(ids) == 0 {
+		return nil, errors.EmptyParamErr("transaction_ids")
+	}
+	if len(ids) > s.MaxPageSize {
+		return nil, errors.E(errors.Invalid, fmt.Sprintf("at most %d transaction ids per query", s.MaxPageSize))
+	}
+	found, err := s.Store.FindTransactions(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transactions: %v", err)
+	}
+	var failed map[string][]string
+	if s.Failures != nil {
+		if failed, err = s.Failures.FailedSinks(ctx, ids); err != nil {
+			return nil, fmt.Errorf("failed to look up the sink dead letters: %v", err)
+		}
+	}
+	statuses := make([]TxStatus, len(ids))
+	for idx, id := range ids {
+		status := TxStatus{TxID: id, DeadLetteredSinks: failed[id]}
+		if tx, ok := found[id]; ok {
+			status.Processed = true
+			status.Provenance = tx.Provenance
+		}
+		statuses[idx] = status
+	}
+	return statuses, nil
+}
@@ -0,0 +1,157 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	// Local Packages
+	models "tx-stream/models"
+)
+
+// TxLookup reads stored transactions by id, see mongodb.TxRepository
+type TxLookup interface {
+	FindTransactions(ctx context.Context, ids []string) (map[string]models.MongoTransaction, error)
+}
+
+// ReconcileFinding is a transaction whose stored document disagrees with
+// the topic, pointing at the first record that carried it
+type ReconcileFinding struct {
+	TxID      string `json:"transaction_id,omitempty"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Records   int    `json:"records"` // Records of the range carrying the transaction id
+}
+
+type ReconcileReport struct {
+	Records     int64              `json:"records"`
+	Expected    int64              `json:"expected"` // Distinct transaction ids of the range
+	Missing     []ReconcileFinding `json:"missing"`
+	Duplicates  []ReconcileFinding `json:"duplicates"`  // Ids carried by several records, only one document is kept
+	Mismatched  []ReconcileFinding `json:"mismatched"`  // Stored documents matching none of their records
+	Undecodable []ReconcileFinding `json:"undecodable"` // Records the processor dead-letters
+}
+
+type reconcileState struct {
+	first   models.Record
+	records int
+	stored  bool
+	matched bool
+}
+
+// Reconciler compares the records of a topic range against the stored
+// transactions. It is fed by a kafka.Replayer in place of the processor, so
+// it reads the range without a consumer group and writes nothing.
+type Reconciler struct {
+	Store TxLookup
+
+	mu          sync.Mutex
+	records     int64
+	states      map[string]*reconcileState
+	undecodable []ReconcileFinding
+}
+
+func NewReconciler(store TxLookup) *Reconciler {
+	return &Reconciler{Store: store, states: make(map[string]*reconcileState)}
+}
+
+// ProcessRecords decodes the records the way TxProcessor does and checks the
+// transactions not matched yet against the store. Nothing is recorded until
+// the lookup succeeds, so the replayer can retry a failed batch.
+func (r *Reconciler) ProcessRecords(ctx context.Context, records []models.Record) error {
+	type decoded struct {
+		record models.Record
+		doc    models.MongoTransaction
+	}
+	var txs []decoded
+	var undecodable []ReconcileFinding
+	for _, record := range records {
+		var tx models.Transaction
+		if err := json.Unmarshal(record.Value, &tx); err != nil {
+			undecodable = append(undecodable, ReconcileFinding{Partition: record.Partition, Offset: record.Offset, Records: 1})
+			continue
+		}
+		txs = append(txs, decoded{record: record, doc: tx.Transform()})
+	}
+
+	var ids []string
+	r.mu.Lock()
+	for _, tx := range txs {
+		if state, ok := r.states[tx.doc.TxID]; !ok || !state.matched {
+			ids = append(ids, tx.doc.TxID)
+		}
+	}
+	r.mu.Unlock()
+	var stored map[string]models.MongoTransaction
+	if len(ids) > 0 {
+		var err error
+		if stored, err = r.Store.FindTransactions(ctx, ids); err != nil {
+			return fmt.Errorf("failed to look up transactions: %v", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records += int64(len(records))
+	r.undecodable = append(r.undecodable, undecodable...)
+	for _, tx := range txs {
+		state, ok := r.states[tx.doc.TxID]
+		if !ok {
+			state = &reconcileState{first: tx.record}
+			r.states[tx.doc.TxID] = state
+		}
+		state.records++
+		if doc, ok := stored[tx.doc.TxID]; ok {
+			state.stored = true
+			state.matched = state.matched || sameTransaction(doc, tx.doc)
+		}
+	}
+	return nil
+}
+
+// Report sorts the findings by partition and offset
+func (r *Reconciler) Report() ReconcileReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report := ReconcileReport{
+		Records:     r.records,
+		Expected:    int64(len(r.states)),
+		Undecodable: append([]ReconcileFinding{}, r.undecodable...),
+	}
+	for id, state := range r.states {
+		finding := ReconcileFinding{TxID: id, Partition: state.first.Partition, Offset: state.first.Offset, Records: state.records}
+		switch {
+		case !state.stored:
+			report.Missing = append(report.Missing, finding)
+		case !state.matched:
+			report.Mismatched = append(report.Mismatched, finding)
+		}
+		if state.records > 1 {
+			report.Duplicates = append(report.Duplicates, finding)
+		}
+	}
+	for _, findings := range [][]ReconcileFinding{report.Missing, report.Duplicates, report.Mismatched, report.Undecodable} {
+		sort.Slice(findings, func(i, j int) bool {
+			if findings[i].Partition != findings[j].Partition {
+				return findings[i].Partition < findings[j].Partition
+			}
+			return findings[i].Offset < findings[j].Offset
+		})
+	}
+	return report
+}
+
+// sameTransaction compares the fields a record sets, leaving out the trace
+// context and provenance that differ between deliveries
+func sameTransaction(a, b models.MongoTransaction) bool {
+	return a.TxID == b.TxID &&
+		a.Amount == b.Amount &&
+		a.Currency == b.Currency &&
+		a.TransactionType == b.TransactionType &&
+		a.Status == b.Status &&
+		a.Timestamp == b.Timestamp &&
+		a.PaymentMethod == b.PaymentMethod
+}
@@ -0,0 +1,64 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+	"time"
+
+	// Local Packages
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+
+	// External Packages
+	"go.uber.org/zap"
+)
+
+// ShadowTxRepository writes every transaction to the primary and to a shadow
+// target during a storage migration. The primary stays authoritative: its
+// error is returned, a failed shadow write is only logged and counted. The
+// shadow should replace existing documents, as retries of a batch the
+// primary refused write it to the shadow again.
+type ShadowTxRepository struct {
+	Primary TxRepository
+	Shadow  TxRepository
+	Logger  *zap.Logger
+	Metrics *metrics.ShadowMetrics
+	Timeout time.Duration // Bounds the shadow write, so a slow shadow cannot stall the pipeline
+}
+
+func NewShadowTxRepository(primary, shadow TxRepository, logger *zap.Logger, shadowMetrics *metrics.ShadowMetrics, timeout time.Duration) *ShadowTxRepository {
+	return &ShadowTxRepository{Primary: primary, Shadow: shadow, Logger: logger, Metrics: shadowMetrics, Timeout: timeout}
+}
+
+func (r *ShadowTxRepository) InsertTransactions(ctx context.Context, txs []interface{}) error {
+	return r.write(ctx, len(txs),
+		func(ctx context.Context) error { return r.Primary.InsertTransactions(ctx, txs) },
+		func(ctx context.Context) error { return r.Shadow.InsertTransactions(ctx, txs) })
+}
+
+func (r *ShadowTxRepository) InsertTransaction(ctx context.Context, tx models.MongoTransaction) error {
+	return r.write(ctx, 1,
+		func(ctx context.Context) error { return r.Primary.InsertTransaction(ctx, tx) },
+		func(ctx context.Context) error { return r.Shadow.InsertTransaction(ctx, tx) })
+}
+
+func (r *ShadowTxRepository) write(ctx context.Context, documents int, primary, shadow func(ctx context.Context) error) error {
+	start := time.Now()
+	primaryErr := primary(ctx)
+	r.Metrics.ObserveWrite("primary", metrics.Outcome(primaryErr), time.Since(start).Seconds())
+
+	shadowCtx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	start = time.Now()
+	shadowErr := shadow(shadowCtx)
+	r.Metrics.ObserveWrite("shadow", metrics.Outcome(shadowErr), time.Since(start).Seconds())
+
+	switch {
+	case primaryErr == nil && shadowErr != nil:
+		r.Metrics.Diverged(metrics.DivergenceShadowFailed, documents)
+		r.Logger.Warn("shadow write failed", zap.Int("documents", documents), zap.Error(shadowErr))
+	case primaryErr != nil && shadowErr == nil:
+		r.Metrics.Diverged(metrics.DivergencePrimaryFailed, documents)
+	}
+	return primaryErr
+}
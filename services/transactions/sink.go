@@ -0,0 +1,23 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"context"
+)
+
+// Sink is a destination besides the mongo collection that consumers can land
+// their transactions in, it is written to like the primary repository and
+// owns its connections
+type Sink interface {
+	TxRepository
+	Close(ctx context.Context) error
+}
+
+// BufferedSink holds the transactions back to write them in larger pieces,
+// Run writes out what has waited long enough and Flush everything buffered
+// before the last offsets are committed
+type BufferedSink interface {
+	Sink
+	Run(ctx context.Context)
+	Flush(ctx context.Context) error
+}
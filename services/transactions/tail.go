@@ -0,0 +1,184 @@
+package transactions
+
+import (
+	// Go Internal Packages
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Local Packages
+	errors "tx-stream/errors"
+	logging "tx-stream/logging"
+	metrics "tx-stream/metrics"
+	models "tx-stream/models"
+)
+
+// TailEvent is a processed transaction as streamed to a live tail, with the
+// redact fields of its payload masked
+type TailEvent struct {
+	Topic       string          `json:"topic"`
+	Partition   int32           `json:"partition"`
+	Offset      int64           `json:"offset"`
+	ProcessedAt time.Time       `json:"processed_at"`
+	Transaction json.RawMessage `json:"transaction"`
+}
+
+// TailSubscription receives the events matching its filter until it is
+// unsubscribed or the hub closes, then Events is closed
+type TailSubscription struct {
+	filter  map[string]string
+	events  chan TailEvent
+	dropped atomic.Uint64
+}
+
+// Events is closed when the subscription ends
+func (s *TailSubscription) Events() <-chan TailEvent {
+	return s.events
+}
+
+// Dropped returns how many matching events the subscriber was too slow for
+func (s *TailSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// TailHub hands the processed transactions to the live tails of the support
+// API. Publishing never blocks the pipeline: a subscriber whose buffer is
+// full misses the event and has it counted as dropped. With no subscriber
+// the payloads are not even decoded.
+type TailHub struct {
+	RedactFields   []string // Top-level payload fields masked before streaming
+	BufferSize     int
+	MaxSubscribers int
+	Metrics        *metrics.TailMetrics
+
+	mu     sync.RWMutex
+	subs   map[*TailSubscription]struct{}
+	count  atomic.Int32
+	closed bool
+}
+
+func NewTailHub(redactFields []string, bufferSize, maxSubscribers int, tailMetrics *metrics.TailMetrics) *TailHub {
+	return &TailHub{
+		RedactFields:   redactFields,
+		BufferSize:     bufferSize,
+		MaxSubscribers: maxSubscribers,
+		Metrics:        tailMetrics,
+		subs:           make(map[*TailSubscription]struct{}),
+	}
+}
+
+// Subscribe starts a tail of the transactions whose top-level payload fields
+// have all the values of the filter, an empty filter is refused so nobody
+// tails the whole stream by accident, and the redacted fields cannot be
+// filtered on
+func (h *TailHub) Subscribe(filter map[string]string) (*TailSubscription, error) {
+	if len(filter) == 0 {
+		return nil, errors.E(errors.Invalid, "a tail needs at least one filter field")
+	}
+	for _, field := range h.RedactFields {
+		if _, ok := filter[field]; ok {
+			return nil, errors.E(errors.Invalid, fmt.Sprintf("cannot filter on redacted field %s", field))
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, errors.E(errors.Internal, "tails are closed")
+	}
+	if len(h.subs) >= h.MaxSubscribers {
+		return nil, errors.E(errors.Conflict, fmt.Sprintf("at most %d tails at once", h.MaxSubscribers))
+	}
+	sub := &TailSubscription{filter: filter, events: make(chan TailEvent, h.BufferSize)}
+	h.subs[sub] = struct{}{}
+	h.count.Store(int32(len(h.subs)))
+	h.Metrics.Subscribed(len(h.subs))
+	return sub, nil
+}
+
+// Unsubscribe ends a tail, ending it twice is a no-op
+func (h *TailHub) Unsubscribe(sub *TailSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+	h.count.Store(int32(len(h.subs)))
+	h.Metrics.Subscribed(len(h.subs))
+}
+
+// Close ends every tail, so the API server does not wait for the streams
+// on shutdown
+func (h *TailHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		delete(h.subs, sub)
+		close(sub.events)
+	}
+	h.count.Store(0)
+	h.Metrics.Subscribed(0)
+}
+
+// Publish hands the stored records to the matching tails, a nil hub does nothing
+func (h *TailHub) Publish(records []models.Record, processedAt time.Time) {
+	if h == nil || h.count.Load() == 0 {
+		return
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	sent, dropped := 0, 0
+	for _, record := range records {
+		var payload map[string]any
+		if err := json.Unmarshal(record.Value, &payload); err != nil {
+			continue
+		}
+		var event *TailEvent
+		for sub := range h.subs {
+			if !tailMatches(payload, sub.filter) {
+				continue
+			}
+			if event == nil {
+				event = h.event(record, payload, processedAt)
+			}
+			select {
+			case sub.events <- *event:
+				sent++
+			default:
+				sub.dropped.Add(1)
+				dropped++
+			}
+		}
+	}
+	h.Metrics.Delivered(sent, dropped)
+}
+
+func (h *TailHub) event(record models.Record, payload map[string]any, processedAt time.Time) *TailEvent {
+	for _, field := range h.RedactFields {
+		if _, ok := payload[field]; ok {
+			payload[field] = logging.RedactedPayloadValue
+		}
+	}
+	redacted, _ := json.Marshal(payload)
+	return &TailEvent{
+		Topic:       record.Topic,
+		Partition:   record.Partition,
+		Offset:      record.Offset,
+		ProcessedAt: processedAt,
+		Transaction: redacted,
+	}
+}
+
+func tailMatches(payload map[string]any, filter map[string]string) bool {
+	for field, want := range filter {
+		value, ok := payload[field]
+		if !ok || fmt.Sprint(value) != want {
+			return false
+		}
+	}
+	return true
+}
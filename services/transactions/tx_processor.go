@@ -5,9 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	// Local Packages
+	errors "tx-stream/errors"
+	logging "tx-stream/logging"
+	metrics "tx-stream/metrics"
 	models "tx-stream/models"
+	aggregation "tx-stream/services/aggregation"
+	claimcheck "tx-stream/services/claimcheck"
+	fx "tx-stream/services/fx"
+	notifications "tx-stream/services/notifications"
+	risk "tx-stream/services/risk"
+	tracing "tx-stream/tracing"
 
 	// External Packages
 	"go.uber.org/zap"
@@ -19,46 +29,162 @@ type TxRepository interface {
 }
 
 type TxProcessor struct {
-	Logger *zap.Logger
-	TxRepo TxRepository
+	Logger     *zap.Logger
+	TxRepo     TxRepository
+	Metrics    *metrics.StageMetrics
+	Errors     *metrics.ErrorMetrics
+	Payloads   *logging.PayloadSampler   // Optional, logs a sample of the payloads
+	Heartbeats *metrics.HeartbeatMetrics // Optional, stamps the time of the last mongo write
+	Summary    *DryRunSummary            // Optional, tallies the outcomes of a dry run
+	Tail       *TailHub                  // Optional, streams the stored transactions to the live tails
+	Aggregates *aggregation.Aggregator   // Optional, counts the stored transactions into the windows
+	Alerts     *notifications.Notifier   // Optional, alerts on the stored transactions matching the alert rules
+	Risk       *risk.Engine              // Optional, scores the transactions with the risk rules before they are stored
+	FX         *fx.Converter             // Optional, normalizes the amounts to the base currency
+	Claims     *claimcheck.Resolver      // Optional, fetches the payloads of the claim-check records before decoding
+	Ledger     *Ledger                   // Optional, skips the records already applied, see LedgerTxRepository
 }
 
-func NewTxProcessor(logger *zap.Logger, txRepo TxRepository) *TxProcessor {
-	return &TxProcessor{TxRepo: txRepo, Logger: logger}
+func NewTxProcessor(logger *zap.Logger, txRepo TxRepository, metrics *metrics.StageMetrics, errs *metrics.ErrorMetrics) *TxProcessor {
+	return &TxProcessor{TxRepo: txRepo, Logger: logger, Metrics: metrics, Errors: errs}
 }
 
 func (p *TxProcessor) ProcessRecords(ctx context.Context, records []models.Record) error {
 	var txs []interface{}
+	var decoded []models.Record
+	var decodedTxs []models.Transaction
+	if len(records) == 0 {
+		return nil
+	}
+	topic := records[0].Topic
+	p.Summary.Processed(len(records))
+
+	records, skipped, err := p.Ledger.Filter(ctx, records)
+	if err != nil {
+		p.Errors.Count(errors.ErrorClass(err), topic, 1)
+		return fmt.Errorf("failed to look up applied records: %v", err)
+	}
+	if skipped > 0 {
+		logging.FromContext(ctx, p.Logger).Info("skipping records applied before", zap.Int("count", skipped))
+		if len(records) == 0 {
+			return nil
+		}
+	}
+
+	records, rejected, err := p.Claims.Resolve(ctx, records)
+	if err != nil {
+		p.Errors.Count(errors.ClassClaimCheck, topic, 1)
+		return err
+	}
+	if rejected > 0 {
+		p.Errors.Count(errors.ClassClaimCheck, topic, rejected)
+		p.Summary.WouldFail(errors.ClassClaimCheck, rejected)
+	}
 
+	_, decodeSpan := tracing.Start(ctx, "transactions", "decode")
+	decodeStart := time.Now()
+	decodeOutcome := metrics.OutcomeSuccess
+	batchLogger := logging.FromContext(ctx, p.Logger)
+	processedAt := time.Now().UTC()
 	for _, record := range records {
+		recordLogger := logging.ForRecord(batchLogger, record)
+		p.Payloads.Log(recordLogger, record)
 		var tx models.Transaction
 		err := json.Unmarshal(record.Value, &tx)
 		if err != nil {
-			p.Logger.Error("failed to unmarshal transaction", zap.Error(err))
+			recordLogger.Error("failed to unmarshal transaction", zap.Error(err))
+			p.Errors.Count(errors.ClassDecode, topic, 1)
+			p.Summary.WouldFail(errors.ClassDecode, 1)
+			decodeOutcome = metrics.OutcomeFailure
 			continue
 		}
-		txs = append(txs, tx.Transform())
+		recordLogger.Debug("decoded transaction", zap.String("transaction_id", tx.TxID))
+		doc := tx.Transform()
+		doc.TraceContext(record)
+		doc.StampProvenance(record, processedAt)
+		doc.Risk = p.Risk.Evaluate(tx)
+		if doc.Normalized, err = p.FX.Normalize(tx.Amount, tx.Currency); err != nil {
+			p.Errors.Count(errors.ClassFXRates, topic, 1)
+			tracing.End(decodeSpan, err)
+			return fmt.Errorf("failed to normalize amount of transaction %s: %v", tx.TxID, err)
+		}
+		txs = append(txs, doc)
+		decoded = append(decoded, record)
+		decodedTxs = append(decodedTxs, tx)
 	}
+	p.Metrics.ObserveDecode(topic, decodeOutcome, time.Since(decodeStart).Seconds())
+	tracing.End(decodeSpan, nil)
 
-	err := p.TxRepo.InsertTransactions(ctx, txs)
+	writeStart := time.Now()
+	err = p.TxRepo.InsertTransactions(ctx, txs)
+	p.Metrics.ObserveMongoWrite(topic, metrics.Outcome(err), time.Since(writeStart).Seconds())
 	if err != nil {
+		p.Errors.Count(errors.ErrorClass(err), topic, 1)
 		return fmt.Errorf("failed to insert transactions: %v", err)
 	}
+	p.Heartbeats.MongoWritten(topic, time.Now())
+	p.Tail.Publish(decoded, processedAt)
+	p.Aggregates.Observe(ctx, decoded, decodedTxs)
+	p.Alerts.Observe(ctx, decoded, decodedTxs)
 	return nil
 }
 
 func (p *TxProcessor) ProcessRecord(ctx context.Context, record models.Record) error {
 	var tx models.Transaction
+	recordLogger := logging.ForRecord(logging.FromContext(ctx, p.Logger), record)
+	ctx = logging.WithLogger(ctx, recordLogger)
+	p.Summary.Processed(1)
 
-	err := json.Unmarshal(record.Value, &tx)
+	fresh, _, err := p.Ledger.Filter(ctx, []models.Record{record})
 	if err != nil {
-		p.Logger.Error("failed to unmarshal transaction", zap.Error(err))
+		p.Errors.Count(errors.ErrorClass(err), record.Topic, 1)
+		return fmt.Errorf("failed to look up applied record: %v", err)
+	}
+	if len(fresh) == 0 {
+		recordLogger.Info("skipping record applied before")
+		return nil
+	}
+
+	resolved, rejected, err := p.Claims.Resolve(ctx, fresh)
+	if err != nil {
+		p.Errors.Count(errors.ClassClaimCheck, record.Topic, 1)
+		return err
+	}
+	if rejected > 0 {
+		p.Errors.Count(errors.ClassClaimCheck, record.Topic, 1)
+		p.Summary.WouldFail(errors.ClassClaimCheck, 1)
 		return nil
 	}
+	record = resolved[0]
+	p.Payloads.Log(recordLogger, record)
 
-	err = p.TxRepo.InsertTransaction(ctx, tx.Transform())
+	err = json.Unmarshal(record.Value, &tx)
+	if err != nil {
+		recordLogger.Error("failed to unmarshal transaction", zap.Error(err))
+		p.Errors.Count(errors.ClassDecode, record.Topic, 1)
+		p.Summary.WouldFail(errors.ClassDecode, 1)
+		return nil
+	}
+
+	doc := tx.Transform()
+	doc.TraceContext(record)
+	processedAt := time.Now().UTC()
+	doc.StampProvenance(record, processedAt)
+	doc.Risk = p.Risk.Evaluate(tx)
+	if doc.Normalized, err = p.FX.Normalize(tx.Amount, tx.Currency); err != nil {
+		p.Errors.Count(errors.ClassFXRates, record.Topic, 1)
+		return fmt.Errorf("failed to normalize amount of transaction %s: %v", tx.TxID, err)
+	}
+	writeStart := time.Now()
+	err = p.TxRepo.InsertTransaction(ctx, doc)
+	p.Metrics.ObserveMongoWrite(record.Topic, metrics.Outcome(err), time.Since(writeStart).Seconds())
 	if err != nil {
+		p.Errors.Count(errors.ErrorClass(err), record.Topic, 1)
 		return fmt.Errorf("failed to insert transaction: %v", err)
 	}
+	p.Heartbeats.MongoWritten(record.Topic, time.Now())
+	p.Tail.Publish([]models.Record{record}, processedAt)
+	p.Aggregates.Observe(ctx, []models.Record{record}, []models.Transaction{tx})
+	p.Alerts.Observe(ctx, []models.Record{record}, []models.Transaction{tx})
 	return nil
 }
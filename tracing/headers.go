@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	// Go Internal Packages
+	"context"
+
+	// Local Packages
+	models "tx-stream/models"
+
+	// External Packages
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordCarrier reads and writes the W3C trace context in record headers
+type RecordCarrier struct {
+	Record *models.Record
+}
+
+func (c RecordCarrier) Get(key string) string {
+	value, _ := c.Record.Header(key)
+	return string(value)
+}
+
+func (c RecordCarrier) Set(key, value string) {
+	c.Record.SetHeader(key, []byte(value))
+}
+
+func (c RecordCarrier) Keys() []string {
+	keys := make([]string, len(c.Record.Headers))
+	for idx, header := range c.Record.Headers {
+		keys[idx] = header.Key
+	}
+	return keys
+}
+
+// Extract returns the context carrying the trace context of the record producer
+func Extract(ctx context.Context, record models.Record) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, RecordCarrier{Record: &record})
+}
+
+// Inject writes the trace context of ctx into the record headers, replacing
+// the producer's, so consumers of the record continue the trace of ctx
+func Inject(ctx context.Context, record *models.Record) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		otel.GetTextMapPropagator().Inject(ctx, RecordCarrier{Record: record})
+	}
+}
+
+// StartBatch starts a span for a batch of records, linked to the trace of each
+// record producer since a batch belongs to many traces
+func StartBatch(ctx context.Context, component, name string, records []models.Record, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	var links []trace.Link
+	for _, record := range records {
+		if spanCtx := trace.SpanContextFromContext(Extract(ctx, record)); spanCtx.IsValid() {
+			links = append(links, trace.Link{SpanContext: spanCtx})
+		}
+	}
+	return otel.Tracer("tx-stream/"+component).Start(ctx, name, trace.WithAttributes(attrs...), trace.WithLinks(links...))
+}
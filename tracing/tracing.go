@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	// Go Internal Packages
+	"context"
+	"fmt"
+
+	// External Packages
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Config struct {
+	ServiceName string
+	Endpoint    string // host:port of the OTLP gRPC collector, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool
+	SampleRatio float64 // Of the traces started here, traces continued from a parent follow its decision
+}
+
+// Setup installs the global tracer provider exporting spans over OTLP and
+// returns the func flushing the pending spans on shutdown
+func Setup(ctx context.Context, conf *Config) (func(ctx context.Context) error, error) {
+	var opts []otlptracegrpc.Option
+	if conf.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(conf.Endpoint))
+	}
+	if conf.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(conf.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the named component, a no-op until Setup ran
+func Start(ctx context.Context, component, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer("tx-stream/"+component).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
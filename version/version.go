@@ -0,0 +1,70 @@
+package version
+
+import (
+	// Go Internal Packages
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X tx-stream/version.Version=v1.4.0 -X tx-stream/version.Commit=$(git rev-parse HEAD) -X tx-stream/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/tx-stream
+//
+// Without ldflags the commit and the build time fall back to the vcs stamp of the go toolchain
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is the build of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a tree with uncommitted changes
+}
+
+// Get returns the build info, unknown values are empty
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// String is the one line version printed by --version
+func (i Info) String() string {
+	commit := i.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if i.Modified {
+		commit += "-dirty"
+	}
+	line := i.Version + " (commit " + commit
+	if i.BuildTime != "" {
+		line += ", built " + i.BuildTime
+	}
+	return line + ", " + i.GoVersion + ")"
+}